@@ -0,0 +1,105 @@
+// Команда simulate прогоняет дискретно-событийную модель цеха на снимке
+// рабочего пространства или сгенерированном сценарии и печатает KPI.
+//
+//	go run ./cmd/simulate -snapshot ws.json -days 14 -replan event -duration-mode p80
+//	go run ./cmd/simulate -devices 6 -operators 3 -backlog 40 -orders 8 -mtbf 120h -json
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"recsys-backend/internal/service"
+	"recsys-backend/internal/simulation"
+)
+
+func main() {
+	var (
+		snapshot    = flag.String("snapshot", "", "JSON-снимок из GET /api/workspaces/{id}/snapshot; пусто — сгенерировать сценарий")
+		devices     = flag.Int("devices", 5, "оборудование в сгенерированном сценарии")
+		deviceTypes = flag.Int("device-types", 2, "типов оборудования в сгенерированном сценарии")
+		operators   = flag.Int("operators", 3, "операторов в сгенерированном сценарии")
+		backlog     = flag.Int("backlog", 30, "заданий в очереди на старте сгенерированного сценария")
+		days        = flag.Int("days", 14, "горизонт моделирования, дней")
+		seed        = flag.Uint64("seed", 1, "зерно генератора случайных чисел")
+		spread      = flag.Float64("spread", 0.2, "коэффициент вариации фактической длительности без истории")
+		failRate    = flag.Float64("fail", 0.05, "вероятность брака задания")
+		mtbf        = flag.Duration("mtbf", 0, "средняя наработка оборудования на отказ (0 — без поломок)")
+		mttr        = flag.Duration("mttr", 4*time.Hour, "среднее время ремонта")
+		orders      = flag.Float64("orders", 5, "новых заказов в сутки")
+		mode        = flag.String("duration-mode", service.DurationModeNominal, "оценка длительности планировщиком: nominal | p80")
+		replan      = flag.String("replan", simulation.ReplanOnEvent, "политика пересчёта плана: event | daily")
+		asJSON      = flag.Bool("json", false, "вывести результат в JSON")
+	)
+	flag.Parse()
+
+	var (
+		sc  simulation.Scenario
+		err error
+	)
+	if *snapshot != "" {
+		sc, err = simulation.LoadScenario(*snapshot)
+		if err != nil {
+			log.Fatal(err)
+		}
+	} else {
+		rng := rand.New(rand.NewPCG(*seed, *seed>>1))
+		sc = simulation.GenerateScenario(simulation.GenerateConfig{
+			Start:       service.AlignToWorkday(time.Now().Truncate(time.Hour)),
+			Devices:     *devices,
+			DeviceTypes: *deviceTypes,
+			Operators:   *operators,
+			Backlog:     *backlog,
+		}, rng)
+	}
+
+	res, err := simulation.Run(sc, simulation.Config{
+		Horizon:        time.Duration(*days) * 24 * time.Hour,
+		Seed:           *seed,
+		DurationSpread: *spread,
+		FailureRate:    *failRate,
+		MTBF:           *mtbf,
+		MTTR:           *mttr,
+		OrdersPerDay:   *orders,
+		DurationMode:   *mode,
+		Replan:         *replan,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	if *asJSON {
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(res); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+	printResult(res)
+}
+
+func printResult(res simulation.Result) {
+	fmt.Printf("Период:            %s — %s\n", res.Start.Format(time.DateTime), res.End.Format(time.DateTime))
+	fmt.Printf("Политика:          replan=%s, duration_mode=%s\n", res.Replan, res.DurationMode)
+	fmt.Printf("Завершено:         %d (не завершено %d)\n", res.Completed, res.Unfinished)
+	fmt.Printf("В срок:            %.1f%% (просрочено %d, среднее опоздание %.0f мин)\n", res.OnTimeRate*100, res.LateTasks, res.MeanLatenessMin)
+	fmt.Printf("Штраф за опоздания: %.2f\n", res.LatePenalty)
+	fmt.Printf("Среднее время потока: %.0f мин\n", res.AvgFlowTimeMin)
+	fmt.Printf("Последнее завершение: через %.0f мин\n", res.MakespanMin)
+	fmt.Printf("Загрузка:          %.1f%%\n", res.Utilization*100)
+	fmt.Printf("События:           заказов %d, брак %d, поломок %d, пересчётов плана %d\n", res.Arrivals, res.Failures, res.Breakdowns, res.Replans)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "\nID\tОборудование\tЗанято, мин\tПростой, мин\tПоломок\tЗагрузка")
+	for _, d := range res.Devices {
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%d\t%d\t%.1f%%\n", d.DeviceID, d.Name, d.BusyMin, d.DownMin, d.Breakdowns, d.Utilization*100)
+	}
+	tw.Flush()
+}
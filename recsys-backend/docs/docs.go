@@ -15,59 +15,27 @@ const docTemplate = `{
     "host": "{{.Host}}",
     "basePath": "{{.BasePath}}",
     "paths": {
-        "/api/device-states": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "device_states"
-                ],
-                "summary": "Список состояний оборудования",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.DeviceState"
-                            }
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
-                }
-            },
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/characteristic-values/{valueId}": {
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_states"
+                    "characteristics"
                 ],
-                "summary": "Создать состояние оборудования",
+                "summary": "Удалить значение характеристики",
                 "parameters": [
                     {
-                        "description": "Device state payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.NameRequest"
-                        }
+                        "type": "integer",
+                        "description": "Value ID",
+                        "name": "valueId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -90,7 +58,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/device-states/{stateId}": {
+        "/api/characteristics/{characteristicId}": {
             "put": {
                 "consumes": [
                     "application/json"
@@ -99,24 +67,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "device_states"
+                    "characteristics"
                 ],
-                "summary": "Обновить состояние оборудования",
+                "summary": "Обновить характеристику оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "State ID",
-                        "name": "stateId",
+                        "description": "Characteristic ID",
+                        "name": "characteristicId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Device state payload",
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Characteristic payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.NameRequest"
+                            "$ref": "#/definitions/httpapi.CharacteristicRequest"
                         }
                     }
                 ],
@@ -145,18 +120,19 @@ const docTemplate = `{
                 }
             },
             "delete": {
+                "description": "Вместе с характеристикой удаляются её значения и требования заданий к ней.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_states"
+                    "characteristics"
                 ],
-                "summary": "Удалить состояние оборудования",
+                "summary": "Удалить характеристику оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "State ID",
-                        "name": "stateId",
+                        "description": "Characteristic ID",
+                        "name": "characteristicId",
                         "in": "path",
                         "required": true
                     }
@@ -186,20 +162,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/device-task-types/{deviceTaskTypeId}": {
+        "/api/customer-orders/{orderId}": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_task_types"
+                    "customer_orders"
                 ],
-                "summary": "Получить тип задачи оборудования",
+                "summary": "Получить заказ клиента с заданиями",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Device task type ID",
-                        "name": "deviceTaskTypeId",
+                        "description": "Customer order ID",
+                        "name": "orderId",
                         "in": "path",
                         "required": true
                     }
@@ -208,7 +184,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/storage.DeviceTaskType"
+                            "$ref": "#/definitions/httpapi.CustomerOrderDTO"
                         }
                     },
                     "400": {
@@ -218,6 +194,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -235,14 +218,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "device_task_types"
+                    "customer_orders"
                 ],
-                "summary": "Обновить тип задачи оборудования",
+                "summary": "Обновить заказ клиента",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Device task type ID",
-                        "name": "deviceTaskTypeId",
+                        "description": "Customer order ID",
+                        "name": "orderId",
                         "in": "path",
                         "required": true
                     },
@@ -254,12 +237,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Device task type payload",
+                        "description": "Order payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceTaskTypeRequest"
+                            "$ref": "#/definitions/httpapi.CustomerOrderRequest"
                         }
                     }
                 ],
@@ -288,18 +271,19 @@ const docTemplate = `{
                 }
             },
             "delete": {
+                "description": "Задания заказа остаются, но без заказа.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_task_types"
+                    "customer_orders"
                 ],
-                "summary": "Удалить тип задачи оборудования",
+                "summary": "Удалить заказ клиента",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Device task type ID",
-                        "name": "deviceTaskTypeId",
+                        "description": "Customer order ID",
+                        "name": "orderId",
                         "in": "path",
                         "required": true
                     }
@@ -329,20 +313,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/device-tasks/{deviceTaskId}": {
-            "get": {
+        "/api/device-downtime/{downtimeId}": {
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_tasks"
+                    "devices"
                 ],
-                "summary": "Получить задачу оборудования",
+                "summary": "Удалить простой оборудования (например, ремонт закончился раньше)",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Device task ID",
-                        "name": "deviceTaskId",
+                        "description": "Downtime ID",
+                        "name": "downtimeId",
                         "in": "path",
                         "required": true
                     }
@@ -351,7 +335,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceTaskDTO"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -369,8 +354,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/device-pools/{poolId}": {
             "put": {
+                "description": "Состав пула заменяется целиком. Задания на выбывшем оборудовании пересчёт плана переносит на оборудование пула.",
                 "consumes": [
                     "application/json"
                 ],
@@ -378,14 +366,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "device_tasks"
+                    "device_pools"
                 ],
-                "summary": "Обновить задачу оборудования",
+                "summary": "Обновить пул оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Device task ID",
-                        "name": "deviceTaskId",
+                        "description": "Pool ID",
+                        "name": "poolId",
                         "in": "path",
                         "required": true
                     },
@@ -397,12 +385,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "Device task payload",
+                        "description": "Pool payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceTaskRequest"
+                            "$ref": "#/definitions/httpapi.DevicePoolRequest"
                         }
                     }
                 ],
@@ -431,18 +419,19 @@ const docTemplate = `{
                 }
             },
             "delete": {
+                "description": "Задания на пул остаются на выбранном для них оборудовании.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_tasks"
+                    "device_pools"
                 ],
-                "summary": "Удалить задачу оборудования",
+                "summary": "Удалить пул оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Device task ID",
-                        "name": "deviceTaskId",
+                        "description": "Pool ID",
+                        "name": "poolId",
                         "in": "path",
                         "required": true
                     }
@@ -472,56 +461,23 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/device-types/{deviceTypeId}": {
-            "put": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/device-states": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_types"
-                ],
-                "summary": "Обновить тип оборудования",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Device type ID",
-                        "name": "deviceTypeId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "Device type payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceTypeRequest"
-                        }
-                    }
+                    "device_states"
                 ],
+                "summary": "Список состояний оборудования",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.DeviceState"
+                            }
                         }
                     },
                     "500": {
@@ -533,26 +489,31 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_types"
+                    "device_states"
                 ],
-                "summary": "Удалить тип оборудования",
+                "summary": "Создать состояние оборудования",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Device type ID",
-                        "name": "deviceTypeId",
-                        "in": "path",
-                        "required": true
+                        "description": "Device state payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.NameRequest"
+                        }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -575,7 +536,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/devices/{deviceId}": {
+        "/api/device-states/{stateId}": {
             "put": {
                 "consumes": [
                     "application/json"
@@ -584,31 +545,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "device_states"
                 ],
-                "summary": "Обновить оборудование",
+                "summary": "Обновить состояние оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Device ID",
-                        "name": "deviceId",
+                        "description": "State ID",
+                        "name": "stateId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "Device payload",
+                        "description": "Device state payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceRequest"
+                            "$ref": "#/definitions/httpapi.NameRequest"
                         }
                     }
                 ],
@@ -641,14 +595,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "device_states"
                 ],
-                "summary": "Удалить оборудование",
+                "summary": "Удалить состояние оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Device ID",
-                        "name": "deviceId",
+                        "description": "State ID",
+                        "name": "stateId",
                         "in": "path",
                         "required": true
                     }
@@ -678,49 +632,29 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/equipment-characteristics/{characteristicId}": {
-            "put": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/device-task-types/{deviceTaskTypeId}": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "equipment_characteristics"
+                    "device_task_types"
                 ],
-                "summary": "Обновить характеристику оборудования",
+                "summary": "Получить тип задачи оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Characteristic ID",
-                        "name": "characteristicId",
+                        "description": "Device task type ID",
+                        "name": "deviceTaskTypeId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "Characteristic payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.EquipmentCharacteristicRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/httpapi.DeviceTaskTypeDTO"
                         }
                     },
                     "400": {
@@ -739,21 +673,40 @@ const docTemplate = `{
                     }
                 }
             },
-            "delete": {
-                "produces": [
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "equipment_characteristics"
+                    "device_task_types"
                 ],
-                "summary": "Удалить характеристику оборудования",
+                "summary": "Обновить тип задачи оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Characteristic ID",
-                        "name": "characteristicId",
+                        "description": "Device task type ID",
+                        "name": "deviceTaskTypeId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Device task type payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.DeviceTaskTypeRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -779,43 +732,22 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/operator-competencies/{competencyId}": {
-            "put": {
-                "consumes": [
-                    "application/json"
-                ],
+            },
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operator_competencies"
+                    "device_task_types"
                 ],
-                "summary": "Обновить компетенцию оператора",
+                "summary": "Удалить тип задачи оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Competency ID",
-                        "name": "competencyId",
+                        "description": "Device task type ID",
+                        "name": "deviceTaskTypeId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "Competency payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.OperatorCompetencyRequest"
-                        }
                     }
                 ],
                 "responses": {
@@ -841,20 +773,22 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/device-tasks/{deviceTaskId}": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operator_competencies"
+                    "device_tasks"
                 ],
-                "summary": "Удалить компетенцию оператора",
+                "summary": "Получить задачу оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Competency ID",
-                        "name": "competencyId",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
                         "in": "path",
                         "required": true
                     }
@@ -863,8 +797,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/httpapi.DeviceTaskDTO"
                         }
                     },
                     "400": {
@@ -882,10 +815,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/operator-devices/{operatorDeviceId}": {
+            },
             "put": {
+                "description": "Поручения оператору на наладку и снятие в той же транзакции пересчитываются под новый слот, оператора и статус задания; изменения — в operator_tasks.",
                 "consumes": [
                     "application/json"
                 ],
@@ -893,24 +825,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "operator_devices"
+                    "device_tasks"
                 ],
-                "summary": "Обновить связку оператор-оборудование",
+                "summary": "Обновить задачу оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Operator device ID",
-                        "name": "operatorDeviceId",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Operator device payload",
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Device task payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.OperatorDeviceRequest"
+                            "$ref": "#/definitions/httpapi.DeviceTaskRequest"
                         }
                     }
                 ],
@@ -929,6 +868,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -943,14 +896,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "operator_devices"
+                    "device_tasks"
                 ],
-                "summary": "Удалить связку оператор-оборудование",
+                "summary": "Удалить задачу оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Operator device ID",
-                        "name": "operatorDeviceId",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
                         "in": "path",
                         "required": true
                     }
@@ -980,49 +933,30 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/operators/{operatorId}": {
-            "put": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/device-tasks/{deviceTaskId}/cost": {
+            "get": {
+                "description": "Плановая себестоимость — по слоту плана (у незапланированного задания — по наладке, печати и снятию), фактическая — по выполнению завершённого задания. Статьи: оборудование и оператор по часовым ставкам, материал по стоимости единицы на складе, энергия по тарифам. Время, ставки и энергия прогона делятся между его заданиями.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operators"
+                    "device_tasks"
                 ],
-                "summary": "Обновить оператора",
+                "summary": "Себестоимость задания",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Operator ID",
-                        "name": "operatorId",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspace_id",
-                        "in": "query",
-                        "required": true
-                    },
-                    {
-                        "description": "Operator payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.OperatorRequest"
-                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/service.TaskCost"
                         }
                     },
                     "400": {
@@ -1032,6 +966,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1040,20 +981,23 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/device-tasks/{deviceTaskId}/eligible-devices": {
+            "get": {
+                "description": "Всё оборудование workspace задания: сначала удовлетворяющее всем требованиям задания, затем остальное с перечнем невыполненных требований.",
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operators"
+                    "device_tasks"
                 ],
-                "summary": "Удалить оператора",
+                "summary": "Оборудование, подходящее заданию по характеристикам",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Operator ID",
-                        "name": "operatorId",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
                         "in": "path",
                         "required": true
                     }
@@ -1062,8 +1006,10 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.DeviceEligibility"
+                            }
                         }
                     },
                     "400": {
@@ -1073,6 +1019,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1083,8 +1036,9 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/plans/recompute": {
+        "/api/device-tasks/{deviceTaskId}/move": {
             "post": {
+                "description": "Ручной перенос ожидающего задания (вместе с его прогоном) на новый старт, при необходимости на другое оборудование (из пула, целевого типа или того же типа) и к другому оператору.\nСлот проверяется на занятость оборудования с остыванием и оператора (задания и поручения), простои, рабочие часы, правила рабочего времени оператора, жёсткий дедлайн и порядок операций маршрута. При конфликтах перенос отклоняется с 409 и списком конфликтов; с push мешающие ожидающие незакреплённые задания сдвигаются вправо.\nПеренесённое задание закрепляется: пересчёт плана и починка после сбоев его не двигают. dry_run — только проверить перенос.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1092,17 +1046,24 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "planning"
+                    "device_tasks"
                 ],
-                "summary": "Пересчитать рекомендации/план по workspace",
+                "summary": "Перенести задачу оборудования",
                 "parameters": [
                     {
-                        "description": "workspace_id",
+                        "type": "integer",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Перенос",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/service.RecomputeRequest"
+                            "$ref": "#/definitions/service.MoveRequest"
                         }
                     }
                 ],
@@ -1110,7 +1071,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/service.RecomputeResult"
+                            "$ref": "#/definitions/service.MoveResult"
                         }
                     },
                     "400": {
@@ -1120,33 +1081,17 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/api/priorities": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "priorities"
-                ],
-                "summary": "Список приоритетов",
-                "responses": {
-                    "200": {
-                        "description": "OK",
+                    },
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.Priority"
-                            }
+                            "$ref": "#/definitions/service.MoveResult"
                         }
                     },
                     "500": {
@@ -1157,8 +1102,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/device-tasks/{deviceTaskId}/pin": {
             "post": {
+                "description": "Закреплённое задание остаётся на своём месте при пересчёте плана и починке после сбоев; открепление возвращает его планировщику.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1166,23 +1114,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "priorities"
+                    "device_tasks"
                 ],
-                "summary": "Создать приоритет",
+                "summary": "Закрепить или открепить задачу оборудования",
                 "parameters": [
                     {
-                        "description": "Priority payload",
+                        "type": "integer",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Закрепление",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.NameRequest"
+                            "$ref": "#/definitions/httpapi.DeviceTaskPinRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1195,6 +1150,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1205,39 +1167,83 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/priorities/{priorityId}": {
-            "put": {
-                "consumes": [
-                    "application/json"
-                ],
+        "/api/device-tasks/{deviceTaskId}/requirements": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "priorities"
+                    "device_tasks"
                 ],
-                "summary": "Обновить приоритет",
+                "summary": "Требования задания к оборудованию",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Priority ID",
-                        "name": "priorityId",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Priority payload",
-                        "name": "body",
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.TaskRequirement"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Например, «объём печати ≥ 300 мм» (gte) или «материал ∈ {PETG, ABS}» (in). Планировщик ставит задание только на оборудование, удовлетворяющее всем его требованиям; нет значения характеристики — требование не выполнено.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_tasks"
+                ],
+                "summary": "Добавить требование задания к оборудованию",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Requirement payload",
+                        "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.NameRequest"
+                            "$ref": "#/definitions/httpapi.TaskRequirementRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "200": {
-                        "description": "OK",
+                    "201": {
+                        "description": "Created",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1250,6 +1256,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1258,22 +1271,37 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "delete": {
+            }
+        },
+        "/api/device-tasks/{deviceTaskId}/status": {
+            "post": {
+                "description": "Допустимые переходы: pending → in_progress/on_hold/cancelled, in_progress → done/failed/on_hold, on_hold → pending/cancelled, failed → pending/cancelled, cancelled → pending. Из done переходов нет.\nПереход в in_progress фиксирует фактическое начало, в done/failed — фактическое окончание (поле at или текущее время).\nЗадание, которое больше не ждёт выполнения и не выполняется, в той же транзакции теряет поручения оператору на наладку и снятие; изменения — в operator_tasks.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "priorities"
+                    "device_tasks"
                 ],
-                "summary": "Удалить приоритет",
+                "summary": "Сменить статус задачи оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Priority ID",
-                        "name": "priorityId",
+                        "description": "Device task ID",
+                        "name": "deviceTaskId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "New status",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.DeviceTaskStatusRequest"
+                        }
                     }
                 ],
                 "responses": {
@@ -1291,6 +1319,20 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "409": {
+                        "description": "Conflict",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1301,7 +1343,7 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/user-tasks/{userTaskId}": {
+        "/api/device-types/{deviceTypeId}": {
             "put": {
                 "consumes": [
                     "application/json"
@@ -1310,14 +1352,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user_tasks"
+                    "device_types"
                 ],
-                "summary": "Обновить пользовательскую задачу",
+                "summary": "Обновить тип оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User task ID",
-                        "name": "userTaskId",
+                        "description": "Device type ID",
+                        "name": "deviceTypeId",
                         "in": "path",
                         "required": true
                     },
@@ -1329,12 +1371,12 @@ const docTemplate = `{
                         "required": true
                     },
                     {
-                        "description": "User task payload",
+                        "description": "Device type payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.UserTaskRequest"
+                            "$ref": "#/definitions/httpapi.DeviceTypeRequest"
                         }
                     }
                 ],
@@ -1367,14 +1409,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "user_tasks"
+                    "device_types"
                 ],
-                "summary": "Удалить пользовательскую задачу",
+                "summary": "Удалить тип оборудования",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "User task ID",
-                        "name": "userTaskId",
+                        "description": "Device type ID",
+                        "name": "deviceTypeId",
                         "in": "path",
                         "required": true
                     }
@@ -1404,23 +1446,56 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/users": {
-            "get": {
+        "/api/devices/{deviceId}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "devices"
+                ],
+                "summary": "Обновить оборудование",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "deviceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Device payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.DeviceRequest"
+                        }
+                    }
                 ],
-                "summary": "Список пользователей",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.User"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "500": {
@@ -1432,31 +1507,26 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "devices"
                 ],
-                "summary": "Создать пользователя",
+                "summary": "Удалить оборудование",
                 "parameters": [
                     {
-                        "description": "User payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.UserRequest"
-                        }
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "deviceId",
+                        "in": "path",
+                        "required": true
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1479,29 +1549,43 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/users/{login}": {
-            "get": {
+        "/api/devices/{deviceId}/loaded-material": {
+            "post": {
+                "description": "Планировщик предпочитает оборудование, в которое уже заправлен материал задания. При завершении задания материал обновляется автоматически.",
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "devices"
                 ],
-                "summary": "Получить пользователя по логину",
+                "summary": "Отметить материал, заправленный в оборудование",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User login",
-                        "name": "login",
+                        "type": "integer",
+                        "description": "Device ID",
+                        "name": "deviceId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Материал",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.LoadedMaterialRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/storage.User"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1511,6 +1595,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -1519,7 +1610,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/equipment-characteristics/{characteristicId}": {
             "put": {
                 "consumes": [
                     "application/json"
@@ -1528,24 +1621,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "equipment_characteristics"
                 ],
-                "summary": "Обновить пользователя",
+                "summary": "Обновить характеристику оборудования",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User login",
-                        "name": "login",
+                        "type": "integer",
+                        "description": "Characteristic ID",
+                        "name": "characteristicId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "User payload",
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Characteristic payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.UserRequest"
+                            "$ref": "#/definitions/httpapi.EquipmentCharacteristicRequest"
                         }
                     }
                 ],
@@ -1578,14 +1678,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "users"
+                    "equipment_characteristics"
                 ],
-                "summary": "Удалить пользователя",
+                "summary": "Удалить характеристику оборудования",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User login",
-                        "name": "login",
+                        "type": "integer",
+                        "description": "Characteristic ID",
+                        "name": "characteristicId",
                         "in": "path",
                         "required": true
                     }
@@ -1615,67 +1715,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/workspaces": {
-            "get": {
+        "/api/material-changeovers/{changeoverId}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "workspaces"
+                    "material_changeovers"
                 ],
-                "summary": "Список рабочих пространств",
+                "summary": "Обновить переналадку между материалами",
                 "parameters": [
                     {
-                        "type": "string",
-                        "description": "User login",
-                        "name": "user_login",
-                        "in": "query"
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.Workspace"
-                            }
-                        }
+                        "type": "integer",
+                        "description": "Changeover ID",
+                        "name": "changeoverId",
+                        "in": "path",
+                        "required": true
                     },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
-                }
-            },
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "workspaces"
-                ],
-                "summary": "Создать рабочее пространство",
-                "parameters": [
                     {
-                        "description": "Workspace payload",
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Changeover payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.WorkspaceRequest"
+                            "$ref": "#/definitions/httpapi.MaterialChangeoverRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1696,22 +1775,20 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/workspaces/{workspaceId}": {
-            "get": {
+            },
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "workspaces"
+                    "material_changeovers"
                 ],
-                "summary": "Получить рабочее пространство",
+                "summary": "Удалить переналадку между материалами",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Changeover ID",
+                        "name": "changeoverId",
                         "in": "path",
                         "required": true
                     }
@@ -1720,7 +1797,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "$ref": "#/definitions/storage.Workspace"
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1738,8 +1816,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/material-stock/{stockId}": {
             "put": {
+                "description": "Остаток после поставки или инвентаризации задаётся здесь же; restock_at = null — поставка не ожидается.",
                 "consumes": [
                     "application/json"
                 ],
@@ -1747,24 +1828,31 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspaces"
+                    "material_stock"
                 ],
-                "summary": "Обновить рабочее пространство",
+                "summary": "Обновить остаток материала",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Stock ID",
+                        "name": "stockId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Workspace payload",
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.WorkspaceRequest"
+                            "$ref": "#/definitions/httpapi.MaterialStockRequest"
                         }
                     }
                 ],
@@ -1797,14 +1885,14 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "workspaces"
+                    "material_stock"
                 ],
-                "summary": "Удалить рабочее пространство",
+                "summary": "Убрать материал со склада",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Stock ID",
+                        "name": "stockId",
                         "in": "path",
                         "required": true
                     }
@@ -1834,82 +1922,46 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/workspaces/{workspaceId}/device-task-types": {
-            "get": {
+        "/api/operator-competencies/{competencyId}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_task_types"
+                    "operator_competencies"
                 ],
-                "summary": "Список типов задач оборудования",
+                "summary": "Обновить компетенцию оператора",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Competency ID",
+                        "name": "competencyId",
                         "in": "path",
                         "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.DeviceTaskType"
-                            }
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    }
-                }
-            },
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "device_task_types"
-                ],
-                "summary": "Создать тип задачи оборудования",
-                "parameters": [
                     {
                         "type": "integer",
                         "description": "Workspace ID",
-                        "name": "workspaceId",
-                        "in": "path",
+                        "name": "workspace_id",
+                        "in": "query",
                         "required": true
                     },
                     {
-                        "description": "Device task type payload",
+                        "description": "Competency payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceTaskTypeRequest"
+                            "$ref": "#/definitions/httpapi.OperatorCompetencyRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -1930,22 +1982,20 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/workspaces/{workspaceId}/device-tasks": {
-            "get": {
+            },
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_task"
+                    "operator_competencies"
                 ],
-                "summary": "Список заданий по workspace",
+                "summary": "Удалить компетенцию оператора",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Competency ID",
+                        "name": "competencyId",
                         "in": "path",
                         "required": true
                     }
@@ -1954,10 +2004,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/httpapi.DeviceTaskDTO"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -1975,8 +2023,10 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/api/operator-devices/{operatorDeviceId}": {
+            "put": {
                 "consumes": [
                     "application/json"
                 ],
@@ -1984,30 +2034,30 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "device_tasks"
+                    "operator_devices"
                 ],
-                "summary": "Создать задачу оборудования",
+                "summary": "Обновить связку оператор-оборудование",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Operator device ID",
+                        "name": "operatorDeviceId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Device task payload",
+                        "description": "Operator device payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceTaskRequest"
+                            "$ref": "#/definitions/httpapi.OperatorDeviceRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2028,22 +2078,20 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/workspaces/{workspaceId}/device-types": {
-            "get": {
+            },
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "device_types"
+                    "operator_devices"
                 ],
-                "summary": "Список типов оборудования",
+                "summary": "Удалить связку оператор-оборудование",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Operator device ID",
+                        "name": "operatorDeviceId",
                         "in": "path",
                         "required": true
                     }
@@ -2052,10 +2100,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.DeviceType"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -2073,8 +2119,10 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
+            }
+        },
+        "/api/operators/{operatorId}": {
+            "put": {
                 "consumes": [
                     "application/json"
                 ],
@@ -2082,30 +2130,37 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "device_types"
+                    "operators"
                 ],
-                "summary": "Создать тип оборудования",
+                "summary": "Обновить оператора",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Operator ID",
+                        "name": "operatorId",
                         "in": "path",
                         "required": true
                     },
                     {
-                        "description": "Device type payload",
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "Operator payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceTypeRequest"
+                            "$ref": "#/definitions/httpapi.OperatorRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2126,22 +2181,20 @@ const docTemplate = `{
                         }
                     }
                 }
-            }
-        },
-        "/api/workspaces/{workspaceId}/devices": {
-            "get": {
+            },
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "operators"
                 ],
-                "summary": "Список оборудования",
+                "summary": "Удалить оператора",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Operator ID",
+                        "name": "operatorId",
                         "in": "path",
                         "required": true
                     }
@@ -2150,10 +2203,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.Device"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -2171,8 +2222,11 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/plans/disruptions": {
             "post": {
+                "description": "device_down — оборудование недоступно с from (по умолчанию сейчас) до until; выполняемое на нём задание прерывается и переделывается. task_failed — задание ушло в брак и выполняется заново. Пересчитываются только задетые сбоем задания и те, которые они вытеснили.",
                 "consumes": [
                     "application/json"
                 ],
@@ -2180,33 +2234,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "devices"
+                    "planning"
                 ],
-                "summary": "Создать оборудование",
+                "summary": "Сообщить о сбое и локально починить план",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Device payload",
+                        "description": "Сбой",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.DeviceRequest"
+                            "$ref": "#/definitions/service.DisruptionRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/service.RepairResult"
                         }
                     },
                     "400": {
@@ -2216,46 +2262,15 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
-                    "500": {
-                        "description": "Internal Server Error",
+                    "404": {
+                        "description": "Not Found",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
                         }
-                    }
-                }
-            }
-        },
-        "/api/workspaces/{workspaceId}/equipment-characteristics": {
-            "get": {
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "equipment_characteristics"
-                ],
-                "summary": "Список характеристик оборудования",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
-                        "in": "path",
-                        "required": true
-                    }
-                ],
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.EquipmentCharacteristic"
-                            }
-                        }
                     },
-                    "400": {
-                        "description": "Bad Request",
+                    "409": {
+                        "description": "Conflict",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2269,7 +2284,9 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
+            }
+        },
+        "/api/plans/recompute": {
             "post": {
                 "consumes": [
                     "application/json"
@@ -2278,33 +2295,25 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "equipment_characteristics"
+                    "planning"
                 ],
-                "summary": "Создать характеристику оборудования",
+                "summary": "Пересчитать рекомендации/план по workspace",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Characteristic payload",
+                        "description": "workspace_id",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.EquipmentCharacteristicRequest"
+                            "$ref": "#/definitions/service.RecomputeRequest"
                         }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
-                            "type": "object",
-                            "additionalProperties": true
+                            "$ref": "#/definitions/service.RecomputeResult"
                         }
                     },
                     "400": {
@@ -2324,41 +2333,25 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/workspaces/{workspaceId}/operator-competencies": {
+        "/api/priorities": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operator_competencies"
-                ],
-                "summary": "Список компетенций операторов",
-                "parameters": [
-                    {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
-                        "in": "path",
-                        "required": true
-                    }
+                    "priorities"
                 ],
+                "summary": "Список приоритетов",
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
                             "type": "array",
                             "items": {
-                                "$ref": "#/definitions/storage.OperatorCompetency"
+                                "$ref": "#/definitions/storage.Priority"
                             }
                         }
                     },
-                    "400": {
-                        "description": "Bad Request",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2376,24 +2369,17 @@ const docTemplate = `{
                     "application/json"
                 ],
                 "tags": [
-                    "operator_competencies"
+                    "priorities"
                 ],
-                "summary": "Создать компетенцию оператора",
+                "summary": "Создать приоритет",
                 "parameters": [
                     {
-                        "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
-                        "in": "path",
-                        "required": true
-                    },
-                    {
-                        "description": "Competency payload",
+                        "description": "Priority payload",
                         "name": "body",
                         "in": "body",
                         "required": true,
                         "schema": {
-                            "$ref": "#/definitions/httpapi.OperatorCompetencyRequest"
+                            "$ref": "#/definitions/httpapi.PriorityRequest"
                         }
                     }
                 ],
@@ -2422,32 +2408,42 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/workspaces/{workspaceId}/operator-devices": {
-            "get": {
+        "/api/priorities/{priorityId}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operator_devices"
+                    "priorities"
                 ],
-                "summary": "Список связок оператор-оборудование",
+                "summary": "Обновить приоритет",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Priority ID",
+                        "name": "priorityId",
                         "in": "path",
                         "required": true
+                    },
+                    {
+                        "description": "Priority payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.PriorityRequest"
+                        }
                     }
                 ],
                 "responses": {
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.OperatorDevice"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -2466,38 +2462,26 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operator_devices"
+                    "priorities"
                 ],
-                "summary": "Создать связку оператор-оборудование",
+                "summary": "Удалить приоритет",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Priority ID",
+                        "name": "priorityId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Operator device payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.OperatorDeviceRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2520,20 +2504,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/workspaces/{workspaceId}/operators": {
+        "/api/production-jobs/{jobId}": {
             "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operators"
+                    "production_jobs"
                 ],
-                "summary": "Список операторов",
+                "summary": "Получить производственное задание с маршрутом",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Production job ID",
+                        "name": "jobId",
                         "in": "path",
                         "required": true
                     }
@@ -2542,10 +2526,7 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.Operator"
-                            }
+                            "$ref": "#/definitions/httpapi.ProductionJobDTO"
                         }
                     },
                     "400": {
@@ -2555,6 +2536,13 @@ const docTemplate = `{
                             "additionalProperties": true
                         }
                     },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
                     "500": {
                         "description": "Internal Server Error",
                         "schema": {
@@ -2564,38 +2552,26 @@ const docTemplate = `{
                     }
                 }
             },
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "operators"
+                    "production_jobs"
                 ],
-                "summary": "Создать оператора",
+                "summary": "Удалить производственное задание вместе с операциями",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Production job ID",
+                        "name": "jobId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "Operator payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.OperatorRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2618,20 +2594,20 @@ const docTemplate = `{
                 }
             }
         },
-        "/api/workspaces/{workspaceId}/user-tasks": {
-            "get": {
+        "/api/task-requirements/{requirementId}": {
+            "delete": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user_tasks"
+                    "device_tasks"
                 ],
-                "summary": "Список пользовательских задач",
+                "summary": "Удалить требование задания",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Requirement ID",
+                        "name": "requirementId",
                         "in": "path",
                         "required": true
                     }
@@ -2640,10 +2616,8 @@ const docTemplate = `{
                     "200": {
                         "description": "OK",
                         "schema": {
-                            "type": "array",
-                            "items": {
-                                "$ref": "#/definitions/storage.UserTask"
-                            }
+                            "type": "object",
+                            "additionalProperties": true
                         }
                     },
                     "400": {
@@ -2661,39 +2635,254 @@ const docTemplate = `{
                         }
                     }
                 }
-            },
-            "post": {
-                "consumes": [
-                    "application/json"
-                ],
+            }
+        },
+        "/api/task-templates/{templateId}": {
+            "get": {
                 "produces": [
                     "application/json"
                 ],
                 "tags": [
-                    "user_tasks"
+                    "task_templates"
                 ],
-                "summary": "Создать пользовательскую задачу",
+                "summary": "Получить шаблон повторяющегося задания",
                 "parameters": [
                     {
                         "type": "integer",
-                        "description": "Workspace ID",
-                        "name": "workspaceId",
+                        "description": "Template ID",
+                        "name": "templateId",
                         "in": "path",
                         "required": true
-                    },
-                    {
-                        "description": "User task payload",
-                        "name": "body",
-                        "in": "body",
-                        "required": true,
-                        "schema": {
-                            "$ref": "#/definitions/httpapi.UserTaskRequest"
-                        }
                     }
                 ],
                 "responses": {
-                    "201": {
-                        "description": "Created",
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.TaskTemplateDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "С apply_to_future=true параметры шаблона переносятся и в уже созданные ожидающие задания с повторением позже текущего момента; новое правило повторения действует только для ещё не созданных повторений.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task_templates"
+                ],
+                "summary": "Обновить шаблон повторяющегося задания",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "templateId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Обновить будущие задания шаблона",
+                        "name": "apply_to_future",
+                        "in": "query"
+                    },
+                    {
+                        "description": "Template payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.TaskTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "description": "Созданные по шаблону задания остаются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task_templates"
+                ],
+                "summary": "Удалить шаблон повторяющегося задания",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Template ID",
+                        "name": "templateId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/user-tasks/{userTaskId}": {
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_tasks"
+                ],
+                "summary": "Обновить пользовательскую задачу",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User task ID",
+                        "name": "userTaskId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspace_id",
+                        "in": "query",
+                        "required": true
+                    },
+                    {
+                        "description": "User task payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.UserTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_tasks"
+                ],
+                "summary": "Удалить пользовательскую задачу",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "User task ID",
+                        "name": "userTaskId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
                         "schema": {
                             "type": "object",
                             "additionalProperties": true
@@ -2716,130 +2905,5443 @@ const docTemplate = `{
                 }
             }
         },
-        "/health": {
-            "get": {
-                "description": "Проверяет доступность API и соединение с БД",
-                "produces": [
-                    "application/json"
-                ],
-                "tags": [
-                    "system"
-                ],
-                "summary": "Проверка работоспособности",
-                "responses": {
-                    "200": {
-                        "description": "OK",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
-                    },
-                    "500": {
-                        "description": "Internal Server Error",
-                        "schema": {
-                            "type": "object",
-                            "additionalProperties": true
-                        }
+        "/api/users": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Список пользователей",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.User"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Создать пользователя",
+                "parameters": [
+                    {
+                        "description": "User payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/users/{login}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Получить пользователя по логину",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User login",
+                        "name": "login",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.User"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Обновить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User login",
+                        "name": "login",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.UserRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "users"
+                ],
+                "summary": "Удалить пользователя",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User login",
+                        "name": "login",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspaces"
+                ],
+                "summary": "Список рабочих пространств",
+                "parameters": [
+                    {
+                        "type": "string",
+                        "description": "User login",
+                        "name": "user_login",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.Workspace"
+                            }
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspaces"
+                ],
+                "summary": "Создать рабочее пространство",
+                "parameters": [
+                    {
+                        "description": "Workspace payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.WorkspaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspaces"
+                ],
+                "summary": "Получить рабочее пространство",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/storage.Workspace"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspaces"
+                ],
+                "summary": "Обновить рабочее пространство",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Workspace payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.WorkspaceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "delete": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "workspaces"
+                ],
+                "summary": "Удалить рабочее пространство",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/characteristic-values": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "characteristics"
+                ],
+                "summary": "Значения характеристик у типов оборудования и устройств",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.CharacteristicValue"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Значение задаётся либо типу оборудования (device_type_id), либо устройству (device_id); значение устройства перекрывает значение его типа. Повторный вызов перезаписывает значение. Поля по виду: number — num, range — num и num_max, bool — bool, enum — options.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "characteristics"
+                ],
+                "summary": "Задать значение характеристики",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Value payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.CharacteristicValueRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "404": {
+                        "description": "Not Found",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/characteristics": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "characteristics"
+                ],
+                "summary": "Типизированные характеристики оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.Characteristic"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "kind: number (число с единицей unit), enum (значения из options, пусто — любые), bool, range (диапазон чисел).",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "characteristics"
+                ],
+                "summary": "Создать характеристику оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Characteristic payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.CharacteristicRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/cost-report": {
+            "get": {
+                "description": "Плановая и фактическая себестоимость неотменённых заданий, начавшихся (по факту, иначе по плану) в периоде, по номерам документов или типам заданий и итог. Без from и to входят все задания.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Себестоимость заданий по документам или типам",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "doc_num (по умолчанию) | task_type",
+                        "name": "group_by",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Первый день, ГГГГ-ММ-ДД",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний день включительно, ГГГГ-ММ-ДД",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.CostReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/customer-orders": {
+            "get": {
+                "description": "Сводный статус заказа: cancelled — отменены все задания, done — завершены все неотменённые, failed — есть задание со сбоем, in_progress — задание выполняется или часть завершена, on_hold — есть отложенное, иначе pending.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customer_orders"
+                ],
+                "summary": "Заказы клиентов со сводкой по заданиям",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/httpapi.CustomerOrderDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Задания включаются в заказ полем order_id. Планировщик ставит задания заказа подряд, под общим сроком и приоритетом заказа.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "customer_orders"
+                ],
+                "summary": "Создать заказ клиента",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Order payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.CustomerOrderRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/device-downtime": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Текущие и будущие простои оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.DeviceDowntime"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/device-pools": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_pools"
+                ],
+                "summary": "Пулы оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.DevicePool"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Задание на пул (device_pool_id) планировщик ставит на лучшее оборудование пула.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_pools"
+                ],
+                "summary": "Создать пул оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Pool payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.DevicePoolRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/device-task-types": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_task_types"
+                ],
+                "summary": "Список типов задач оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/httpapi.DeviceTaskTypeDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_task_types"
+                ],
+                "summary": "Создать тип задачи оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Device task type payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.DeviceTaskTypeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/device-tasks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_task"
+                ],
+                "summary": "Список заданий по workspace",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Фильтр по статусам через запятую (pending,in_progress,on_hold,done,failed,cancelled)",
+                        "name": "status",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/httpapi.DeviceTaskDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "В ответе duration_suggestion — оценка длительности по истории выполнения на типе оборудования и типе задания, как в duration-stats/suggest. Если duration_min, setup_time_min и unload_time_min не заданы, а история есть, печать берётся из оценки.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_tasks"
+                ],
+                "summary": "Создать задачу оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Device task payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.DeviceTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/device-types": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_types"
+                ],
+                "summary": "Список типов оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/httpapi.DeviceTypeDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "device_types"
+                ],
+                "summary": "Создать тип оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Device type payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.DeviceTypeRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/devices": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Список оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.Device"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "devices"
+                ],
+                "summary": "Создать оборудование",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Device payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.DeviceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/duration-stats": {
+            "get": {
+                "description": "Коэффициенты «факт / план» и перцентили фактической длительности по типу оборудования и типу задания. Нулевой ID в группе означает «любой».",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Статистика фактических длительностей заданий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.DurationStat"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/duration-stats/suggest": {
+            "get": {
+                "description": "Если переданы плановые минуты, они масштабируются коэффициентом из истории, иначе возвращаются фактические перцентили.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Рекомендуемая длительность нового задания",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Device type ID",
+                        "name": "device_type_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Device task type ID",
+                        "name": "device_task_type_id",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Плановая печать, мин",
+                        "name": "duration_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Плановая наладка, мин",
+                        "name": "setup_time_min",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Плановое снятие, мин",
+                        "name": "unload_time_min",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.DurationSuggestion"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/energy-tariffs": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "energy"
+                ],
+                "summary": "Тарифный план электроэнергии",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.EnergyScheduleDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Заменяет окна тарифов целиком. Энергия задания — мощность оборудования (своя или типа) на длительность слота по цене окон. С cost_weight \u003e 0 планировщик переносит задания в дешёвые окна, если они успевают к дедлайну, а задания без оператора могут допечатываться ночью.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "energy"
+                ],
+                "summary": "Задать тарифный план электроэнергии",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Energy schedule",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.EnergyScheduleDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/equipment-characteristics": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "equipment_characteristics"
+                ],
+                "summary": "Список характеристик оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.EquipmentCharacteristic"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "equipment_characteristics"
+                ],
+                "summary": "Создать характеристику оборудования",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Characteristic payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.EquipmentCharacteristicRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/labour-rules": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labour_rules"
+                ],
+                "summary": "Правила рабочего времени операторов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.LabourRulesDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Планировщик не даёт оператору работать над заданиями дольше дневного и недельного предела, во время перерыва и без отдыха между сменами. С fairness_weight \u003e 0 он выравнивает загрузку допущенных операторов.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "labour_rules"
+                ],
+                "summary": "Задать правила рабочего времени операторов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Labour rules",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.LabourRulesDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/material-changeovers": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "material_changeovers"
+                ],
+                "summary": "Матрица переналадки между материалами",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/httpapi.MaterialChangeoverDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Если переход для этой пары материалов уже задан, его время перезаписывается.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "material_changeovers"
+                ],
+                "summary": "Задать переналадку между материалами",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Changeover payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.MaterialChangeoverRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/material-stock": {
+            "get": {
+                "description": "Остаток, резерв под запланированные задания в статусах pending и in_progress и свободный остаток по каждому материалу. low=true — только материалы с низким остатком.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "material_stock"
+                ],
+                "summary": "Склад материалов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "boolean",
+                        "description": "Только низкий остаток",
+                        "name": "low",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/httpapi.MaterialStockDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "material_stock"
+                ],
+                "summary": "Добавить материал на склад",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Stock payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.MaterialStockRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/operator-competencies": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "operator_competencies"
+                ],
+                "summary": "Список компетенций операторов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.OperatorCompetency"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "operator_competencies"
+                ],
+                "summary": "Создать компетенцию оператора",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Competency payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.OperatorCompetencyRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/operator-devices": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "operator_devices"
+                ],
+                "summary": "Список связок оператор-оборудование",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.OperatorDevice"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "operator_devices"
+                ],
+                "summary": "Создать связку оператор-оборудование",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Operator device payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.OperatorDeviceRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/operator-workload": {
+            "get": {
+                "description": "Минуты заданий с участием оператора (по плану, у завершённых — по факту) и личных поручений по каждому оператору и дню, средняя загрузка и разброс между самым загруженным и самым свободным.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Загрузка операторов за период",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "string",
+                        "description": "Первый день, ГГГГ-ММ-ДД (по умолчанию сегодня)",
+                        "name": "from",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "Последний день включительно, ГГГГ-ММ-ДД (по умолчанию from + 6 дней)",
+                        "name": "to",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.WorkloadReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/operators": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "operators"
+                ],
+                "summary": "Список операторов",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.Operator"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "operators"
+                ],
+                "summary": "Создать оператора",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Operator payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.OperatorRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/plan/conflicts": {
+            "get": {
+                "description": "Пересечения в плане ожидающих и выполняемых заданий и поручений операторам, созданных вручную. device_busy — два задания на одном оборудовании, с учётом остывания после задания. operator_busy — оператор занят двумя заданиями, заданием и поручением или двумя поручениями. outside_working_hours — задание начинается вне 09:00–22:00 или, с оператором, заканчивается после 22:00. deadline — задание заканчивается после дедлайна. device_unavailable — задание на оборудовании в простое. route_order — операции маршрута пересекаются с учётом пролёживания. labour_rule — задание нарушает правила рабочего времени оператора, нарушенное правило — в поле labour_rule.\nКаждый конфликт называет участвующие строки: task_id, other_task_id, user_task_id, other_user_task_id, downtime_id.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "planning"
+                ],
+                "summary": "Конфликты текущего плана",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.ConflictReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/plan/energy": {
+            "get": {
+                "description": "Расход (кВт·ч) и стоимость энергии каждого ожидающего и выполняемого задания плана по мощности оборудования и тарифам workspace и итог по плану. Энергия прогона делится поровну между его заданиями.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "planning"
+                ],
+                "summary": "Энергия и её стоимость по текущему плану",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.EnergyReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/plan/lateness": {
+            "get": {
+                "description": "Ожидающие и выполняемые задания, которые по плану заканчиваются позже дедлайна, от самого долгого опоздания: тип дедлайна (hard | soft), опоздание в минутах и штраф — часы опоздания, умноженные на ставку задания или его приоритета. Итог — суммарный штраф плана. Задания без плана не учитываются.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "planning"
+                ],
+                "summary": "Опоздания текущего плана и штраф за них",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.LatenessReport"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/plan/material-swaps": {
+            "get": {
+                "description": "Задания с материалом на каждом оборудовании по времени начала: где материал задания отличается от заправленного, оператору нужно сменить материал. from_material_id = 0 — заправленный материал неизвестен.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "planning"
+                ],
+                "summary": "Смены материала по текущему плану",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.MaterialSwap"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/plan/priorities": {
+            "get": {
+                "description": "Ожидающие задания в системе рекомендаций в порядке, в котором их берёт планировщик: срок (свой дедлайн или общий срок заказа), запас до него, приоритет и эффективный приоритет со старением по правилу priority-aging. Маршруты и прогоны планировщик ставит целиком, по самому срочному заданию.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "planning"
+                ],
+                "summary": "Очерёдность заданий в планировщике",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/service.TaskPriority"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/plan/risk": {
+            "get": {
+                "description": "Текущий план прогоняется runs раз со случайными длительностями заданий по правилам планировщика. Для каждого задания — вероятность уложиться в дедлайн и ожидаемое опоздание; оборудование и операторы, из-за которых задания ждут дольше всего, помечаются как узкие места.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Вероятность выполнения плана в срок (Monte Carlo)",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Число прогонов (по умолчанию 500, максимум 10000)",
+                        "name": "runs",
+                        "in": "query"
+                    },
+                    {
+                        "type": "string",
+                        "description": "learned (по умолчанию) | lognormal",
+                        "name": "distribution",
+                        "in": "query"
+                    },
+                    {
+                        "type": "number",
+                        "description": "Коэффициент вариации для lognormal (по умолчанию 0.2)",
+                        "name": "spread",
+                        "in": "query"
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Seed генератора для воспроизводимости",
+                        "name": "seed",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.RiskResult"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/priority-aging": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "priority_aging"
+                ],
+                "summary": "Старение приоритета",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.PriorityAgingDTO"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "put": {
+                "description": "Со старением планировщик берёт задания сначала по эффективному приоритету, затем по сроку; без него — по сроку, затем по приоритету. Эффективный приоритет каждого задания — GET /api/workspaces/{workspaceId}/plan/priorities.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "priority_aging"
+                ],
+                "summary": "Задать старение приоритета",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Priority aging",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.PriorityAgingDTO"
+                        }
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/production-jobs": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "production_jobs"
+                ],
+                "summary": "Список производственных заданий с маршрутами",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/httpapi.ProductionJobDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Каждая операция маршрута создаётся как задача оборудования с номером шага. Планировщик ставит операции по порядку и может перенести операцию на другое оборудование того же типа.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "production_jobs"
+                ],
+                "summary": "Создать производственное задание с маршрутом",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Production job payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.ProductionJobRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/snapshot": {
+            "get": {
+                "description": "Оборудование, операторы, ожидающие и выполняемые задания, занятость операторов и история длительностей. Файл передаётся в go run ./cmd/simulate -snapshot.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "analytics"
+                ],
+                "summary": "Снимок рабочего пространства для симулятора",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/simulation.Scenario"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/task-templates": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task_templates"
+                ],
+                "summary": "Шаблоны повторяющихся заданий",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/httpapi.TaskTemplateDTO"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "description": "Задания по шаблону создаёт POST /api/workspaces/{workspaceId}/task-templates/generate.",
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task_templates"
+                ],
+                "summary": "Создать шаблон повторяющегося задания",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "Template payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.TaskTemplateRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/task-templates/generate": {
+            "post": {
+                "description": "Для каждого активного шаблона создаются задания повторений до now + horizon_days, которые ещё не созданы; повторный вызов дублей не создаёт. Срок задания — момент повторения, задание адресовано типу оборудования шаблона.",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "task_templates"
+                ],
+                "summary": "Создать задания по шаблонам на горизонт вперёд",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "type": "integer",
+                        "description": "Горизонт в днях (1–365, по умолчанию 14)",
+                        "name": "horizon_days",
+                        "in": "query"
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "$ref": "#/definitions/service.TemplateRun"
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/api/workspaces/{workspaceId}/user-tasks": {
+            "get": {
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_tasks"
+                ],
+                "summary": "Список пользовательских задач",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    }
+                ],
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "array",
+                            "items": {
+                                "$ref": "#/definitions/storage.UserTask"
+                            }
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            },
+            "post": {
+                "consumes": [
+                    "application/json"
+                ],
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "user_tasks"
+                ],
+                "summary": "Создать пользовательскую задачу",
+                "parameters": [
+                    {
+                        "type": "integer",
+                        "description": "Workspace ID",
+                        "name": "workspaceId",
+                        "in": "path",
+                        "required": true
+                    },
+                    {
+                        "description": "User task payload",
+                        "name": "body",
+                        "in": "body",
+                        "required": true,
+                        "schema": {
+                            "$ref": "#/definitions/httpapi.UserTaskRequest"
+                        }
+                    }
+                ],
+                "responses": {
+                    "201": {
+                        "description": "Created",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "400": {
+                        "description": "Bad Request",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        },
+        "/health": {
+            "get": {
+                "description": "Проверяет доступность API и соединение с БД",
+                "produces": [
+                    "application/json"
+                ],
+                "tags": [
+                    "system"
+                ],
+                "summary": "Проверка работоспособности",
+                "responses": {
+                    "200": {
+                        "description": "OK",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    },
+                    "500": {
+                        "description": "Internal Server Error",
+                        "schema": {
+                            "type": "object",
+                            "additionalProperties": true
+                        }
+                    }
+                }
+            }
+        }
+    },
+    "definitions": {
+        "httpapi.CharacteristicRequest": {
+            "type": "object",
+            "properties": {
+                "key": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "number | enum | bool | range",
+                    "type": "string"
+                },
+                "options": {
+                    "description": "допустимые значения enum",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unit": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.CharacteristicValueRequest": {
+            "type": "object",
+            "properties": {
+                "bool": {
+                    "type": "boolean"
+                },
+                "characteristic_id": {
+                    "type": "integer"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "num": {
+                    "type": "number"
+                },
+                "num_max": {
+                    "type": "number"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "httpapi.CustomerOrderDTO": {
+            "type": "object",
+            "properties": {
+                "customer": {
+                    "type": "string"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "due_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "late": {
+                    "description": "по плану позже срока",
+                    "type": "boolean"
+                },
+                "planned_completion": {
+                    "description": "PlannedCompletion — самое позднее окончание заданий заказа; null — не\nвсе задания в плане.",
+                    "type": "string"
+                },
+                "priority_id": {
+                    "type": "integer"
+                },
+                "status": {
+                    "description": "Status — сводный статус заказа по неотменённым заданиям.",
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/httpapi.DeviceTaskDTO"
+                    }
+                },
+                "tasks_done": {
+                    "type": "integer"
+                },
+                "tasks_total": {
+                    "type": "integer"
+                },
+                "tasks_unplanned": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.CustomerOrderRequest": {
+            "type": "object",
+            "properties": {
+                "customer": {
+                    "type": "string"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "due_date": {
+                    "type": "string"
+                },
+                "priority_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.DevicePoolRequest": {
+            "type": "object",
+            "properties": {
+                "device_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.DeviceRequest": {
+            "type": "object",
+            "properties": {
+                "add_in_rec_system": {
+                    "type": "boolean"
+                },
+                "device_state_id": {
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "hourly_rate": {
+                    "description": "HourlyRate — стоимость часа работы оборудования.",
+                    "type": "number"
+                },
+                "loaded_material_id": {
+                    "description": "LoadedMaterialID — заправленный материал, 0 — неизвестен.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "photo_url": {
+                    "type": "string"
+                },
+                "power_kw": {
+                    "description": "PowerKW — потребляемая мощность, кВт; не задана — как у типа оборудования.",
+                    "type": "number"
+                }
+            }
+        },
+        "httpapi.DeviceTaskDTO": {
+            "type": "object",
+            "properties": {
+                "actual_end": {
+                    "type": "string"
+                },
+                "actual_start": {
+                    "type": "string"
+                },
+                "batch_id": {
+                    "description": "прогон на платформе (ID первого задания), 0 — печатается отдельно",
+                    "type": "integer"
+                },
+                "deadline": {
+                    "type": "string"
+                },
+                "deadline_type": {
+                    "description": "hard | soft",
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_pool_id": {
+                    "description": "задание на пул: device_id выбирает планировщик",
+                    "type": "integer"
+                },
+                "device_task_type_id": {
+                    "type": "integer"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "duration_min": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "job_id": {
+                    "description": "0 — задание вне маршрута",
+                    "type": "integer"
+                },
+                "job_seq": {
+                    "description": "номер операции в маршруте",
+                    "type": "integer"
+                },
+                "material_id": {
+                    "type": "integer"
+                },
+                "material_qty": {
+                    "type": "number"
+                },
+                "min_level": {
+                    "description": "минимальный уровень оператора, пусто — любой",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "need_operator": {
+                    "type": "boolean"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "description": "заказ клиента, 0 — вне заказа",
+                    "type": "integer"
+                },
+                "penalty_rate": {
+                    "description": "штраф за час опоздания, null — по приоритету",
+                    "type": "number"
+                },
+                "pinned": {
+                    "description": "закреплено вручную: пересчёт плана его не двигает",
+                    "type": "boolean"
+                },
+                "plan_end": {
+                    "type": "string"
+                },
+                "plan_start": {
+                    "type": "string"
+                },
+                "plate_size": {
+                    "type": "integer"
+                },
+                "priority_id": {
+                    "type": "integer"
+                },
+                "setup_time_min": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "target_type_id": {
+                    "description": "задание на тип оборудования",
+                    "type": "integer"
+                },
+                "template_id": {
+                    "description": "шаблон, по которому создано задание, 0 — нет",
+                    "type": "integer"
+                },
+                "transfer_lag_min": {
+                    "type": "integer"
+                },
+                "unload_time_min": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.DeviceTaskPinRequest": {
+            "type": "object",
+            "properties": {
+                "pinned": {
+                    "type": "boolean"
+                }
+            }
+        },
+        "httpapi.DeviceTaskRequest": {
+            "type": "object",
+            "properties": {
+                "add_in_rec_system": {
+                    "type": "boolean"
+                },
+                "deadline": {
+                    "type": "string"
+                },
+                "deadline_type": {
+                    "description": "DeadlineType — hard (по умолчанию): задание ставится только с окончанием\nк сроку; soft — срок можно сдвинуть ради жёстких дедлайнов.",
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_pool_id": {
+                    "description": "DevicePoolID и TargetTypeID адресуют задание пулу или типу оборудования:\ndevice_id можно не указывать, оборудование выберет планировщик.",
+                    "type": "integer"
+                },
+                "device_task_type_id": {
+                    "type": "integer"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "duration_min": {
+                    "type": "integer"
+                },
+                "material_id": {
+                    "description": "характеристика-материал, 0 — не указан",
+                    "type": "integer"
+                },
+                "material_qty": {
+                    "description": "расход материала в единицах склада",
+                    "type": "number"
+                },
+                "min_level": {
+                    "description": "MinLevel — минимальный уровень компетенции оператора (trainee | qualified |\nexpert); пусто — подходит любой. Требует need_operator.",
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "need_operator": {
+                    "type": "boolean"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "order_id": {
+                    "description": "OrderID — заказ клиента, 0 — задание вне заказа. Пустой doc_num\nберётся из заказа.",
+                    "type": "integer"
+                },
+                "penalty_rate": {
+                    "description": "PenaltyRate — штраф за час опоздания; null — по приоритету задания.",
+                    "type": "number"
+                },
+                "photo_url": {
+                    "type": "string"
+                },
+                "plan_end": {
+                    "type": "string"
+                },
+                "plan_start": {
+                    "type": "string"
+                },
+                "plate_size": {
+                    "description": "место на платформе, 0 — печатается отдельно",
+                    "type": "integer"
+                },
+                "priority_id": {
+                    "type": "integer"
+                },
+                "setup_time_min": {
+                    "type": "integer"
+                },
+                "status": {
+                    "type": "string"
+                },
+                "target_type_id": {
+                    "type": "integer"
+                },
+                "unload_time_min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.DeviceTaskStatusRequest": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "description": "момент смены статуса, по умолчанию сейчас",
+                    "type": "string"
+                },
+                "status": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.DeviceTaskTypeDTO": {
+            "type": "object",
+            "properties": {
+                "cooldown_min": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.DeviceTaskTypeRequest": {
+            "type": "object",
+            "properties": {
+                "cooldown_min": {
+                    "description": "CooldownMin — остывание оборудования после задания этого типа, мин; 0 — без остывания.",
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.DeviceTypeDTO": {
+            "type": "object",
+            "properties": {
+                "cooldown_min": {
+                    "type": "integer"
+                },
+                "equipment_characteristic_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "plate_capacity": {
+                    "type": "integer"
+                },
+                "power_kw": {
+                    "type": "number"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.DeviceTypeRequest": {
+            "type": "object",
+            "properties": {
+                "cooldown_min": {
+                    "description": "CooldownMin — остывание оборудования после каждого задания, мин; 0 — без остывания.",
+                    "type": "integer"
+                },
+                "equipment_characteristic_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "plate_capacity": {
+                    "description": "вместимость платформы; 0 — без прогонов",
+                    "type": "integer"
+                },
+                "power_kw": {
+                    "description": "PowerKW — потребляемая мощность, кВт; не задана — энергия не учитывается.",
+                    "type": "number"
+                }
+            }
+        },
+        "httpapi.EnergyScheduleDTO": {
+            "type": "object",
+            "properties": {
+                "base_price": {
+                    "description": "цена кВт·ч вне окон",
+                    "type": "number"
+                },
+                "cost_weight": {
+                    "description": "CostWeight — минуты более позднего окончания задания, которые\nпланировщик допускает ради экономии единицы стоимости энергии;\n0 — стоимость только оценивается.",
+                    "type": "number"
+                },
+                "tariffs": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/httpapi.EnergyTariffDTO"
+                    }
+                }
+            }
+        },
+        "httpapi.EnergyTariffDTO": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "description": "ЧЧ:ММ",
+                    "type": "string"
+                },
+                "price": {
+                    "description": "цена кВт·ч",
+                    "type": "number"
+                },
+                "start": {
+                    "description": "ЧЧ:ММ",
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.EquipmentCharacteristicRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.JobOperationRequest": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "description": "необязательно; по умолчанию первое оборудование нужного типа",
+                    "type": "integer"
+                },
+                "device_task_type_id": {
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "duration_min": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "need_operator": {
+                    "type": "boolean"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "setup_time_min": {
+                    "type": "integer"
+                },
+                "transfer_lag_min": {
+                    "description": "пролёживание после предыдущей операции",
+                    "type": "integer"
+                },
+                "unload_time_min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.LabourRulesDTO": {
+            "type": "object",
+            "properties": {
+                "break_min": {
+                    "type": "integer"
+                },
+                "break_start": {
+                    "description": "начало ежедневного перерыва, ЧЧ:ММ",
+                    "type": "string"
+                },
+                "fairness_period": {
+                    "type": "string"
+                },
+                "fairness_weight": {
+                    "description": "FairnessWeight — минуты более позднего окончания задания, которые\nпланировщик допускает ради оператора с часом меньшей загрузки за\nFairnessPeriod (day | week, по умолчанию day); 0 — без выравнивания.",
+                    "type": "number"
+                },
+                "max_day_min": {
+                    "description": "работа над заданиями за день",
+                    "type": "integer"
+                },
+                "max_week_min": {
+                    "description": "работа над заданиями за неделю",
+                    "type": "integer"
+                },
+                "min_rest_min": {
+                    "description": "отдых между сменами",
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.LoadedMaterialRequest": {
+            "type": "object",
+            "properties": {
+                "material_id": {
+                    "description": "0 — материал неизвестен",
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.MaterialChangeoverDTO": {
+            "type": "object",
+            "properties": {
+                "duration_min": {
+                    "type": "integer"
+                },
+                "from_material_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "to_material_id": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.MaterialChangeoverRequest": {
+            "type": "object",
+            "properties": {
+                "duration_min": {
+                    "type": "integer"
+                },
+                "from_material_id": {
+                    "type": "integer"
+                },
+                "to_material_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.MaterialStockDTO": {
+            "type": "object",
+            "properties": {
+                "free": {
+                    "description": "on_hand − reserved",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "low": {
+                    "description": "свободный остаток не выше порога",
+                    "type": "boolean"
+                },
+                "low_level": {
+                    "type": "number"
+                },
+                "material_id": {
+                    "type": "integer"
+                },
+                "on_hand": {
+                    "type": "number"
+                },
+                "reserved": {
+                    "description": "под запланированные задания",
+                    "type": "number"
+                },
+                "restock_at": {
+                    "type": "string"
+                },
+                "restock_qty": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "unit_cost": {
+                    "type": "number"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.MaterialStockRequest": {
+            "type": "object",
+            "properties": {
+                "low_level": {
+                    "type": "number"
+                },
+                "material_id": {
+                    "type": "integer"
+                },
+                "on_hand": {
+                    "type": "number"
+                },
+                "restock_at": {
+                    "type": "string"
+                },
+                "restock_qty": {
+                    "type": "number"
+                },
+                "unit": {
+                    "type": "string"
+                },
+                "unit_cost": {
+                    "description": "стоимость единицы материала",
+                    "type": "number"
+                }
+            }
+        },
+        "httpapi.NameRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.OperatorCompetencyRequest": {
+            "type": "object",
+            "properties": {
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "level": {
+                    "description": "trainee | qualified | expert, пусто — qualified",
+                    "type": "string"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "setup_factor": {
+                    "description": "SetupFactor — множитель наладки и снятия изделия, null — 1.",
+                    "type": "number"
+                }
+            }
+        },
+        "httpapi.OperatorDeviceRequest": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "type": "integer"
+                },
+                "operator_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.OperatorRequest": {
+            "type": "object",
+            "properties": {
+                "full_name": {
+                    "type": "string"
+                },
+                "hourly_rate": {
+                    "description": "HourlyRate — стоимость часа работы оператора.",
+                    "type": "number"
+                },
+                "phone_number": {
+                    "type": "string"
+                },
+                "user_login": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.PriorityAgingDTO": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "none (по умолчанию) | deadline | slack",
+                    "type": "string"
+                },
+                "step_min": {
+                    "type": "integer"
+                },
+                "window_min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.PriorityRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "penalty_rate": {
+                    "description": "0 — опоздание не штрафуется",
+                    "type": "number"
+                }
+            }
+        },
+        "httpapi.ProductionJobDTO": {
+            "type": "object",
+            "properties": {
+                "deadline": {
+                    "type": "string"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/httpapi.DeviceTaskDTO"
+                    }
+                },
+                "priority_id": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.ProductionJobRequest": {
+            "type": "object",
+            "properties": {
+                "deadline": {
+                    "type": "string"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operations": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/httpapi.JobOperationRequest"
+                    }
+                },
+                "priority_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.TaskRequirementRequest": {
+            "type": "object",
+            "properties": {
+                "bool": {
+                    "type": "boolean"
+                },
+                "characteristic_id": {
+                    "type": "integer"
+                },
+                "num": {
+                    "type": "number"
+                },
+                "num_max": {
+                    "type": "number"
+                },
+                "op": {
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "httpapi.TaskTemplateDTO": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "type": "boolean"
+                },
+                "device_task_type_id": {
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "duration_min": {
+                    "type": "integer"
+                },
+                "end_at": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "interval": {
+                    "type": "integer"
+                },
+                "material_id": {
+                    "type": "integer"
+                },
+                "material_qty": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "need_operator": {
+                    "type": "boolean"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "priority_id": {
+                    "type": "integer"
+                },
+                "recurrence": {
+                    "type": "string"
+                },
+                "setup_time_min": {
+                    "type": "integer"
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "unload_time_min": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.TaskTemplateRequest": {
+            "type": "object",
+            "properties": {
+                "active": {
+                    "description": "по умолчанию true",
+                    "type": "boolean"
+                },
+                "device_task_type_id": {
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "description": "задания адресуются этому типу оборудования",
+                    "type": "integer"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "duration_min": {
+                    "type": "integer"
+                },
+                "end_at": {
+                    "type": "string"
+                },
+                "interval": {
+                    "type": "integer"
+                },
+                "material_id": {
+                    "description": "0 — не указан",
+                    "type": "integer"
+                },
+                "material_qty": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "need_operator": {
+                    "type": "boolean"
+                },
+                "operator_id": {
+                    "description": "0 — без оператора; обязателен при need_operator",
+                    "type": "integer"
+                },
+                "priority_id": {
+                    "type": "integer"
+                },
+                "recurrence": {
+                    "description": "Recurrence — weekly | monthly | every_n_days, повторение раз в Interval\nнедель, месяцев или дней (по умолчанию 1) начиная со StartAt.",
+                    "type": "string"
+                },
+                "setup_time_min": {
+                    "type": "integer"
+                },
+                "start_at": {
+                    "type": "string"
+                },
+                "unload_time_min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "httpapi.UserRequest": {
+            "type": "object",
+            "properties": {
+                "email": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "is_admin": {
+                    "type": "boolean"
+                },
+                "login": {
+                    "type": "string"
+                },
+                "password": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.UserTaskRequest": {
+            "type": "object",
+            "properties": {
+                "completion_mark": {
+                    "type": "boolean"
+                },
+                "device_task_id": {
+                    "type": "integer"
+                },
+                "end_time": {
+                    "type": "string"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "priority": {
+                    "type": "integer"
+                },
+                "start_time": {
+                    "type": "string"
+                }
+            }
+        },
+        "httpapi.WorkspaceRequest": {
+            "type": "object",
+            "properties": {
+                "name": {
+                    "type": "string"
+                },
+                "user_login": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.ConflictReport": {
+            "type": "object",
+            "properties": {
+                "by_kind": {
+                    "type": "object",
+                    "additionalProperties": {
+                        "type": "integer"
+                    }
+                },
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PlanConflict"
+                    }
+                },
+                "total": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.CostBreakdown": {
+            "type": "object",
+            "properties": {
+                "energy": {
+                    "description": "энергия по тарифам",
+                    "type": "number"
+                },
+                "labour": {
+                    "description": "ставка оператора × время, только need_operator",
+                    "type": "number"
+                },
+                "machine": {
+                    "description": "ставка оборудования × время",
+                    "type": "number"
+                },
+                "material": {
+                    "description": "material_qty × стоимость единицы материала",
+                    "type": "number"
+                },
+                "minutes": {
+                    "description": "время работы оборудования",
+                    "type": "integer"
+                },
+                "total": {
+                    "type": "number"
+                }
+            }
+        },
+        "service.CostGroup": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "$ref": "#/definitions/service.CostBreakdown"
+                },
+                "actual_tasks": {
+                    "description": "завершённые задания, вошедшие в actual",
+                    "type": "integer"
+                },
+                "estimated": {
+                    "$ref": "#/definitions/service.CostBreakdown"
+                },
+                "key": {
+                    "description": "doc_num или ID типа задания",
+                    "type": "string"
+                },
+                "name": {
+                    "description": "название типа задания",
+                    "type": "string"
+                },
+                "tasks": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.CostReport": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "$ref": "#/definitions/service.CostBreakdown"
+                },
+                "estimated": {
+                    "$ref": "#/definitions/service.CostBreakdown"
+                },
+                "from": {
+                    "type": "string"
+                },
+                "group_by": {
+                    "type": "string"
+                },
+                "groups": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.CostGroup"
+                    }
+                },
+                "to": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.DeviceEligibility": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "eligible": {
+                    "type": "boolean"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "unmet_requirement_ids": {
+                    "description": "невыполненные требования",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "service.DisruptionRequest": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "только рассчитать сдвиги, ничего не сохранять",
+                    "type": "boolean"
+                },
+                "from": {
+                    "description": "начало простоя, по умолчанию — сейчас",
+                    "type": "string"
+                },
+                "reason": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "type": {
+                    "description": "device_down | task_failed",
+                    "type": "string"
+                },
+                "until": {
+                    "description": "конец простоя, обязателен для device_down",
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.DurationStat": {
+            "type": "object",
+            "properties": {
+                "device_task_type_id": {
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "mean_factor": {
+                    "description": "факт / план (наладка + печать + снятие)",
+                    "type": "number"
+                },
+                "p50_factor": {
+                    "type": "number"
+                },
+                "p50_min": {
+                    "description": "фактическая полная длительность, минуты",
+                    "type": "integer"
+                },
+                "p80_factor": {
+                    "type": "number"
+                },
+                "p80_min": {
+                    "type": "integer"
+                },
+                "p90_factor": {
+                    "type": "number"
+                },
+                "p90_min": {
+                    "type": "integer"
+                },
+                "samples": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.DurationSuggestion": {
+            "type": "object",
+            "properties": {
+                "basis": {
+                    "type": "string"
+                },
+                "factor": {
+                    "type": "number"
+                },
+                "nominal_min": {
+                    "type": "integer"
+                },
+                "p80_min": {
+                    "type": "integer"
+                },
+                "samples": {
+                    "type": "integer"
+                },
+                "suggested_min": {
+                    "description": "медиана",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.EnergyReport": {
+            "type": "object",
+            "properties": {
+                "cost": {
+                    "type": "number"
+                },
+                "kwh": {
+                    "type": "number"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TaskEnergy"
+                    }
+                }
+            }
+        },
+        "service.LabourDelay": {
+            "type": "object",
+            "properties": {
+                "delay_min": {
+                    "type": "integer"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "rules": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.Lateness": {
+            "type": "object",
+            "properties": {
+                "deadline": {
+                    "type": "string"
+                },
+                "deadline_type": {
+                    "description": "hard | soft",
+                    "type": "string"
+                },
+                "late_min": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "penalty": {
+                    "type": "number"
+                },
+                "penalty_rate": {
+                    "description": "штраф за час: задания или его приоритета",
+                    "type": "number"
+                },
+                "plan_end": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.LatenessReport": {
+            "type": "object",
+            "properties": {
+                "hard_late": {
+                    "description": "опоздания по жёстким дедлайнам",
+                    "type": "integer"
+                },
+                "penalty": {
+                    "type": "number"
+                },
+                "soft_late": {
+                    "description": "опоздания по мягким дедлайнам",
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.Lateness"
+                    }
+                }
+            }
+        },
+        "service.MaterialShortage": {
+            "type": "object",
+            "properties": {
+                "available": {
+                    "description": "свободный остаток после заданий, поставленных раньше",
+                    "type": "number"
+                },
+                "material_id": {
+                    "type": "integer"
+                },
+                "needed": {
+                    "type": "number"
+                },
+                "restock_at": {
+                    "description": "RestockAt — задание ждёт поставки и стоит в плане не раньше неё;\nnil — материала нет и до известной поставки, задание снято с плана.",
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.MaterialSwap": {
+            "type": "object",
+            "properties": {
+                "at": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "from_material_id": {
+                    "description": "0 — заправленный материал неизвестен",
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "to_material_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.MoveRequest": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "description": "новое оборудование, 0 — прежнее",
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "description": "только проверить перенос, ничего не сохранять",
+                    "type": "boolean"
+                },
+                "operator_id": {
+                    "description": "новый оператор, 0 — прежний",
+                    "type": "integer"
+                },
+                "push": {
+                    "description": "сдвинуть мешающие задания вправо вместо отказа",
+                    "type": "boolean"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
+        "service.MoveResult": {
+            "type": "object",
+            "properties": {
+                "applied": {
+                    "description": "Applied — перенос сохранён; false — отклонён из-за конфликтов или dry_run.",
+                    "type": "boolean"
+                },
+                "conflicts": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.PlanConflict"
+                    }
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "moved": {
+                    "description": "задание и остальные задания его прогона",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TaskMove"
+                    }
+                },
+                "operator_tasks": {
+                    "description": "OperatorTasks — поручения операторам на наладку и снятие, приведённые к\nновому плану; пусто, если перенос не сохранён.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.OperatorTasksSync"
+                        }
+                    ]
+                },
+                "pushed": {
+                    "description": "задания, сдвинутые вправо при push",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TaskMove"
+                    }
+                },
+                "unscheduled_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "service.OperatorTasksSync": {
+            "type": "object",
+            "properties": {
+                "created": {
+                    "type": "integer"
+                },
+                "removed": {
+                    "type": "integer"
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.OperatorWorkload": {
+            "type": "object",
+            "properties": {
+                "days": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.WorkloadDay"
+                    }
+                },
+                "full_name": {
+                    "type": "string"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "task_min": {
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "integer"
+                },
+                "total_min": {
+                    "type": "integer"
+                },
+                "user_task_min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.PlanConflict": {
+            "type": "object",
+            "properties": {
+                "device_id": {
+                    "type": "integer"
+                },
+                "downtime_id": {
+                    "description": "простой оборудования",
+                    "type": "integer"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "kind": {
+                    "type": "string"
+                },
+                "labour_rule": {
+                    "description": "нарушенное правило рабочего времени",
+                    "type": "string"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "other_task_id": {
+                    "description": "задание, с которым пересекается слот",
+                    "type": "integer"
+                },
+                "other_user_task_id": {
+                    "description": "второе поручение того же оператора",
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "description": "0 — пересекаются два поручения",
+                    "type": "integer"
+                },
+                "user_task_id": {
+                    "description": "поручение оператора, с которым пересекается слот",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.RecomputeRequest": {
+            "type": "object",
+            "properties": {
+                "duration_mode": {
+                    "description": "nominal (по умолчанию) | p80",
+                    "type": "string"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.RecomputeResult": {
+            "type": "object",
+            "properties": {
+                "changeover_min": {
+                    "description": "суммарная переналадка между материалами в новом плане",
+                    "type": "integer"
+                },
+                "energy_cost": {
+                    "type": "number"
+                },
+                "energy_kwh": {
+                    "description": "EnergyKWh и EnergyCost — расход и стоимость энергии перепланированных заданий.",
+                    "type": "number"
+                },
+                "labour_delays": {
+                    "description": "LabourDelays — задания, поставленные позже из-за правил рабочего времени операторов.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.LabourDelay"
+                    }
+                },
+                "lateness": {
+                    "description": "Lateness — опоздания нового плана по дедлайнам и их суммарный штраф.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.LatenessReport"
+                        }
+                    ]
+                },
+                "material_shortages": {
+                    "description": "MaterialShortages — задания, которым не хватает материала на складе:\nждущие поставки и снятые с плана.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.MaterialShortage"
+                    }
+                },
+                "material_swaps": {
+                    "description": "MaterialSwaps — смены материала в оборудовании, которые операторы выполняют по новому плану.",
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.MaterialSwap"
+                    }
+                },
+                "operator_tasks": {
+                    "description": "OperatorTasks — поручения операторам на наладку и снятие, приведённые к новому плану.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.OperatorTasksSync"
+                        }
+                    ]
+                },
+                "unscheduled_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "updated": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.RepairResult": {
+            "type": "object",
+            "properties": {
+                "downtime_id": {
+                    "type": "integer"
+                },
+                "dry_run": {
+                    "type": "boolean"
+                },
+                "interrupted_ids": {
+                    "description": "прерванные задания, возвращены в очередь на переделку",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "moved": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TaskMove"
+                    }
+                },
+                "operator_tasks": {
+                    "description": "OperatorTasks — поручения операторам на наладку и снятие, приведённые к\nпочиненному плану; пусто при dry_run.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.OperatorTasksSync"
+                        }
+                    ]
+                },
+                "unscheduled_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                }
+            }
+        },
+        "service.ResourceRisk": {
+            "type": "object",
+            "properties": {
+                "bottleneck": {
+                    "type": "boolean"
+                },
+                "expected_late_tasks": {
+                    "type": "number"
+                },
+                "expected_wait_min": {
+                    "description": "суммарное ожидание заданий из-за ресурса за прогон",
+                    "type": "number"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "tasks": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.RiskResult": {
+            "type": "object",
+            "properties": {
+                "all_on_time_probability": {
+                    "type": "number"
+                },
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ResourceRisk"
+                    }
+                },
+                "distribution": {
+                    "type": "string"
+                },
+                "expected_late_tasks": {
+                    "type": "number"
+                },
+                "operators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.ResourceRisk"
+                    }
+                },
+                "runs": {
+                    "type": "integer"
+                },
+                "seed": {
+                    "type": "integer"
+                },
+                "spread": {
+                    "type": "number"
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.TaskRisk"
+                    }
+                }
+            }
+        },
+        "service.TaskCost": {
+            "type": "object",
+            "properties": {
+                "actual": {
+                    "description": "Actual — по фактическому выполнению; nil — задание не завершено.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.CostBreakdown"
+                        }
+                    ]
+                },
+                "device_task_type_id": {
+                    "type": "integer"
+                },
+                "doc_num": {
+                    "type": "string"
+                },
+                "estimated": {
+                    "description": "Estimated — по слоту плана, а у незапланированного задания — по\nналадке, печати и снятию.",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/service.CostBreakdown"
+                        }
+                    ]
+                },
+                "name": {
+                    "type": "string"
+                },
+                "status": {
+                    "$ref": "#/definitions/storage.TaskStatus"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TaskEnergy": {
+            "type": "object",
+            "properties": {
+                "batch_id": {
+                    "type": "integer"
+                },
+                "cost": {
+                    "type": "number"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "end": {
+                    "type": "string"
+                },
+                "kwh": {
+                    "type": "number"
+                },
+                "start": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TaskMove": {
+            "type": "object",
+            "properties": {
+                "deadline_missed": {
+                    "type": "boolean"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "new_end": {
+                    "type": "string"
+                },
+                "new_start": {
+                    "type": "string"
+                },
+                "old_device_id": {
+                    "description": "прежнее оборудование, если задание переставлено на другое",
+                    "type": "integer"
+                },
+                "old_end": {
+                    "type": "string"
+                },
+                "old_start": {
+                    "description": "nil — задания не было в плане",
+                    "type": "string"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "shift_min": {
+                    "description": "сдвиг старта относительно старого плана",
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TaskPriority": {
+            "type": "object",
+            "properties": {
+                "boost": {
+                    "description": "на сколько уровней поднялся приоритет",
+                    "type": "integer"
+                },
+                "deadline": {
+                    "type": "string"
+                },
+                "due": {
+                    "description": "Due — срок, по которому задание стоит в очереди: свой дедлайн или\nобщий срок заказа; nil — без срока.",
+                    "type": "string"
+                },
+                "effective_priority_id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "position": {
+                    "description": "место в очереди планировщика, с 1",
+                    "type": "integer"
+                },
+                "priority_id": {
+                    "description": "приоритет задания, у задания заказа — заказа",
+                    "type": "integer"
+                },
+                "slack_min": {
+                    "description": "SlackMin — запас: минуты до срока минус оставшаяся работа; nil — без срока.",
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TaskRisk": {
+            "type": "object",
+            "properties": {
+                "deadline": {
+                    "type": "string"
+                },
+                "device_id": {
+                    "type": "integer"
+                },
+                "expected_end": {
+                    "type": "string"
+                },
+                "expected_lateness_min": {
+                    "type": "number"
+                },
+                "name": {
+                    "type": "string"
+                },
+                "on_time_probability": {
+                    "description": "1, если дедлайна нет",
+                    "type": "number"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "plan_end": {
+                    "type": "string"
+                },
+                "plan_start": {
+                    "type": "string"
+                },
+                "task_id": {
+                    "type": "integer"
+                },
+                "unplaceable_runs": {
+                    "description": "прогоны, где слот не нашёлся за горизонт",
+                    "type": "integer"
+                }
+            }
+        },
+        "service.TemplateRun": {
+            "type": "object",
+            "properties": {
+                "created_ids": {
+                    "description": "созданные задания",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
+                },
+                "skipped_template_ids": {
+                    "description": "Skipped — активные шаблоны, для типа оборудования которых нет\nоборудования в системе рекомендаций.",
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
                     }
+                },
+                "until": {
+                    "description": "горизонт: повторения до этого момента включительно",
+                    "type": "string"
                 }
             }
-        }
-    },
-    "definitions": {
-        "httpapi.DeviceRequest": {
+        },
+        "service.WorkloadDay": {
             "type": "object",
             "properties": {
-                "add_in_rec_system": {
-                    "type": "boolean"
+                "date": {
+                    "description": "ГГГГ-ММ-ДД",
+                    "type": "string"
                 },
-                "device_state_id": {
+                "task_min": {
                     "type": "integer"
                 },
-                "device_type_id": {
+                "user_task_min": {
+                    "type": "integer"
+                }
+            }
+        },
+        "service.WorkloadReport": {
+            "type": "object",
+            "properties": {
+                "from": {
+                    "type": "string"
+                },
+                "mean_min": {
+                    "description": "средняя загрузка оператора",
                     "type": "integer"
                 },
-                "name": {
+                "operators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/service.OperatorWorkload"
+                    }
+                },
+                "spread_min": {
+                    "description": "разница самой большой и самой малой загрузки",
+                    "type": "integer"
+                },
+                "to": {
                     "type": "string"
+                }
+            }
+        },
+        "simulation.Scenario": {
+            "type": "object",
+            "properties": {
+                "changeovers": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.MaterialChangeover"
+                    }
                 },
-                "photo_url": {
+                "competencies": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.OperatorCompetency"
+                    }
+                },
+                "device_pools": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.DevicePool"
+                    }
+                },
+                "device_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.DeviceType"
+                    }
+                },
+                "devices": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Device"
+                    }
+                },
+                "energy": {
+                    "$ref": "#/definitions/storage.EnergySchedule"
+                },
+                "history": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.CompletedTaskDuration"
+                    }
+                },
+                "labour_rules": {
+                    "$ref": "#/definitions/storage.LabourRules"
+                },
+                "operator_busy": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.UserTaskBusy"
+                    }
+                },
+                "operators": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Operator"
+                    }
+                },
+                "orders": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.CustomerOrder"
+                    }
+                },
+                "priorities": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.Priority"
+                    }
+                },
+                "priority_aging": {
+                    "$ref": "#/definitions/storage.PriorityAging"
+                },
+                "start": {
                     "type": "string"
+                },
+                "task_types": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.DeviceTaskType"
+                    }
+                },
+                "tasks": {
+                    "type": "array",
+                    "items": {
+                        "$ref": "#/definitions/storage.DeviceTaskRow"
+                    }
                 }
             }
         },
-        "httpapi.DeviceTaskDTO": {
+        "storage.Characteristic": {
             "type": "object",
             "properties": {
-                "deadline": {
+                "id": {
+                    "type": "integer"
+                },
+                "key": {
+                    "type": "string"
+                },
+                "kind": {
+                    "description": "number | enum | bool | range",
+                    "type": "string"
+                },
+                "options": {
+                    "description": "допустимые значения enum, пусто — любые",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                },
+                "unit": {
                     "type": "string"
                 },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.CharacteristicValue": {
+            "type": "object",
+            "properties": {
+                "bool": {
+                    "type": "boolean"
+                },
+                "characteristic_id": {
+                    "type": "integer"
+                },
                 "device_id": {
+                    "description": "0 — значение типа",
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "description": "0 — значение устройства",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "num": {
+                    "description": "number; нижняя граница range",
+                    "type": "number"
+                },
+                "num_max": {
+                    "description": "верхняя граница range",
+                    "type": "number"
+                },
+                "options": {
+                    "description": "значения enum",
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
+                }
+            }
+        },
+        "storage.CompetencyLevel": {
+            "type": "string",
+            "enum": [
+                "trainee",
+                "qualified",
+                "expert"
+            ],
+            "x-enum-comments": {
+                "CompetencyExpert": "эксперт",
+                "CompetencyQualified": "допущен к самостоятельной работе",
+                "CompetencyTrainee": "стажёр"
+            },
+            "x-enum-descriptions": [
+                "стажёр",
+                "допущен к самостоятельной работе",
+                "эксперт"
+            ],
+            "x-enum-varnames": [
+                "CompetencyTrainee",
+                "CompetencyQualified",
+                "CompetencyExpert"
+            ]
+        },
+        "storage.CompletedTaskDuration": {
+            "type": "object",
+            "properties": {
+                "actual": {
                     "type": "integer"
                 },
                 "device_task_type_id": {
                     "type": "integer"
                 },
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "nominal": {
+                    "description": "наладка + печать + снятие",
+                    "type": "integer"
+                },
+                "task_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.CustomerOrder": {
+            "type": "object",
+            "properties": {
+                "customer": {
+                    "type": "string"
+                },
                 "doc_num": {
                     "type": "string"
                 },
-                "duration_min": {
+                "due_date": {
+                    "type": "string"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "priority_id": {
+                    "type": "integer"
+                },
+                "workspace_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "storage.Device": {
+            "type": "object",
+            "properties": {
+                "add_in_rec_system": {
+                    "type": "boolean"
+                },
+                "device_state_id": {
+                    "type": "integer"
+                },
+                "device_type_id": {
+                    "type": "integer"
+                },
+                "hourly_rate": {
+                    "description": "HourlyRate — стоимость часа работы оборудования; 0 — не учитывается.",
+                    "type": "number"
                 },
                 "id": {
                     "type": "integer"
                 },
+                "loaded_material_id": {
+                    "description": "LoadedMaterialID — заправленный материал (характеристика оборудования), 0 — неизвестен.",
+                    "type": "integer"
+                },
                 "name": {
                     "type": "string"
                 },
-                "need_operator": {
-                    "type": "boolean"
+                "photo_url": {
+                    "type": "string"
                 },
-                "operator_id": {
+                "power_kw": {
+                    "description": "PowerKW — потребляемая мощность, кВт; nil — как у типа оборудования.",
+                    "type": "number"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.DeviceDowntime": {
+            "type": "object",
+            "properties": {
+                "device_id": {
                     "type": "integer"
                 },
-                "plan_end": {
+                "end": {
                     "type": "string"
                 },
-                "plan_start": {
+                "id": {
+                    "type": "integer"
+                },
+                "reason": {
                     "type": "string"
                 },
-                "priority_id": {
-                    "type": "integer"
+                "start": {
+                    "type": "string"
                 },
-                "setup_time_min": {
+                "workspace_id": {
                     "type": "integer"
+                }
+            }
+        },
+        "storage.DevicePool": {
+            "type": "object",
+            "properties": {
+                "device_ids": {
+                    "type": "array",
+                    "items": {
+                        "type": "integer"
+                    }
                 },
-                "unload_time_min": {
+                "id": {
                     "type": "integer"
                 },
+                "name": {
+                    "type": "string"
+                },
                 "workspace_id": {
                     "type": "integer"
                 }
             }
         },
-        "httpapi.DeviceTaskRequest": {
+        "storage.DeviceState": {
+            "type": "object",
+            "properties": {
+                "id": {
+                    "type": "integer"
+                },
+                "name": {
+                    "type": "string"
+                }
+            }
+        },
+        "storage.DeviceTaskRow": {
             "type": "object",
             "properties": {
-                "add_in_rec_system": {
-                    "type": "boolean"
+                "actual_end": {
+                    "type": "string"
                 },
-                "completion_mark": {
+                "actual_start": {
                     "type": "string"
                 },
+                "batch_id": {
+                    "description": "прогон (ID первого задания), 0 — вне прогона",
+                    "type": "integer"
+                },
                 "deadline": {
                     "type": "string"
                 },
+                "deadline_type": {
+                    "description": "hard | soft",
+                    "type": "string"
+                },
                 "device_id": {
                     "type": "integer"
                 },
+                "device_pool_id": {
+                    "description": "задание на любое оборудование пула, 0 — нет",
+                    "type": "integer"
+                },
                 "device_task_type_id": {
                     "type": "integer"
                 },
                 "doc_num": {
                     "type": "string"
                 },
-                "duration_min": {
+                "duration": {
+                    "description": "печать",
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "job_id": {
+                    "description": "0 — задание вне маршрута",
+                    "type": "integer"
+                },
+                "job_seq": {
+                    "description": "номер операции в маршруте",
+                    "type": "integer"
+                },
+                "material_id": {
+                    "description": "характеристика-материал, 0 — не указан",
                     "type": "integer"
                 },
+                "material_qty": {
+                    "description": "расход материала в единицах склада",
+                    "type": "number"
+                },
+                "min_level": {
+                    "description": "минимальный уровень оператора, пусто — любой",
+                    "allOf": [
+                        {
+                            "$ref": "#/definitions/storage.CompetencyLevel"
+                        }
+                    ]
+                },
                 "name": {
                     "type": "string"
                 },
@@ -2849,8 +8351,17 @@ const docTemplate = `{
                 "operator_id": {
                     "type": "integer"
                 },
-                "photo_url": {
-                    "type": "string"
+                "order_id": {
+                    "description": "заказ клиента, 0 — вне заказа",
+                    "type": "integer"
+                },
+                "penalty_rate": {
+                    "description": "штраф за час опоздания, nil — по приоритету",
+                    "type": "number"
+                },
+                "pinned": {
+                    "description": "перенесено вручную, пересчёт не двигает",
+                    "type": "boolean"
                 },
                 "plan_end": {
                     "type": "string"
@@ -2858,202 +8369,129 @@ const docTemplate = `{
                 "plan_start": {
                     "type": "string"
                 },
-                "priority_id": {
+                "plate_size": {
+                    "description": "место на платформе, 0 — печатается отдельно",
                     "type": "integer"
                 },
-                "setup_time_min": {
+                "priority_id": {
                     "type": "integer"
                 },
-                "unload_time_min": {
-                    "type": "integer"
-                }
-            }
-        },
-        "httpapi.DeviceTaskTypeRequest": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "httpapi.DeviceTypeRequest": {
-            "type": "object",
-            "properties": {
-                "equipment_characteristic_id": {
+                "setup_time": {
+                    "description": "наладка",
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "httpapi.EquipmentCharacteristicRequest": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "httpapi.NameRequest": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
-                }
-            }
-        },
-        "httpapi.OperatorCompetencyRequest": {
-            "type": "object",
-            "properties": {
-                "device_type_id": {
-                    "type": "integer"
+                "status": {
+                    "$ref": "#/definitions/storage.TaskStatus"
                 },
-                "operator_id": {
+                "target_type_id": {
+                    "description": "задание на любое оборудование типа, 0 — нет",
                     "type": "integer"
-                }
-            }
-        },
-        "httpapi.OperatorDeviceRequest": {
-            "type": "object",
-            "properties": {
-                "device_id": {
+                },
+                "template_id": {
+                    "description": "шаблон, по которому создано задание, 0 — нет",
                     "type": "integer"
                 },
-                "operator_id": {
+                "transfer_lag": {
+                    "description": "пролёживание после предыдущей операции",
                     "type": "integer"
-                }
-            }
-        },
-        "httpapi.OperatorRequest": {
-            "type": "object",
-            "properties": {
-                "full_name": {
-                    "type": "string"
                 },
-                "phone_number": {
-                    "type": "string"
+                "unload_time": {
+                    "description": "снятие изделия",
+                    "type": "integer"
                 },
-                "user_login": {
-                    "type": "string"
+                "workspace_id": {
+                    "type": "integer"
                 }
             }
         },
-        "httpapi.UserRequest": {
+        "storage.DeviceTaskType": {
             "type": "object",
             "properties": {
-                "email": {
-                    "type": "string"
+                "cooldown": {
+                    "description": "Cooldown — остывание оборудования после задания этого типа; 0 — без остывания.",
+                    "type": "integer"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "login": {
+                "name": {
                     "type": "string"
                 },
-                "password": {
-                    "type": "string"
+                "workspace_id": {
+                    "type": "integer"
                 }
             }
         },
-        "httpapi.UserTaskRequest": {
+        "storage.DeviceType": {
             "type": "object",
             "properties": {
-                "completion_mark": {
-                    "type": "boolean"
+                "cooldown": {
+                    "description": "Cooldown — остывание оборудования типа после каждого задания; 0 — без остывания.",
+                    "type": "integer"
                 },
-                "device_task_id": {
+                "equipment_characteristic_id": {
                     "type": "integer"
                 },
-                "end_time": {
-                    "type": "string"
+                "id": {
+                    "type": "integer"
                 },
                 "name": {
                     "type": "string"
                 },
-                "operator_id": {
-                    "type": "integer"
-                },
-                "priority": {
+                "plate_capacity": {
+                    "description": "0 — задания не объединяются в прогоны",
                     "type": "integer"
                 },
-                "start_time": {
-                    "type": "string"
-                }
-            }
-        },
-        "httpapi.WorkspaceRequest": {
-            "type": "object",
-            "properties": {
-                "name": {
-                    "type": "string"
+                "power_kw": {
+                    "description": "PowerKW — потребляемая мощность оборудования типа, кВт; nil — не задана.",
+                    "type": "number"
                 },
-                "user_login": {
-                    "type": "string"
-                }
-            }
-        },
-        "service.RecomputeRequest": {
-            "type": "object",
-            "properties": {
                 "workspace_id": {
                     "type": "integer"
                 }
             }
         },
-        "service.RecomputeResult": {
+        "storage.EnergySchedule": {
             "type": "object",
             "properties": {
-                "unscheduled_ids": {
+                "base_price": {
+                    "description": "BasePrice — цена кВт·ч вне окон тарифов.",
+                    "type": "number"
+                },
+                "cost_weight": {
+                    "description": "CostWeight — на сколько минут позже может закончиться задание ради\nэкономии единицы стоимости энергии; 0 — стоимость только оценивается.",
+                    "type": "number"
+                },
+                "tariffs": {
                     "type": "array",
                     "items": {
-                        "type": "integer"
+                        "$ref": "#/definitions/storage.EnergyTariff"
                     }
                 },
-                "updated": {
+                "workspace_id": {
                     "type": "integer"
                 }
             }
         },
-        "storage.Device": {
+        "storage.EnergyTariff": {
             "type": "object",
             "properties": {
-                "add_in_rec_system": {
-                    "type": "boolean"
-                },
-                "device_state_id": {
-                    "type": "integer"
-                },
-                "device_type_id": {
+                "end": {
                     "type": "integer"
                 },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
-                },
-                "photo_url": {
-                    "type": "string"
+                "price": {
+                    "type": "number"
                 },
-                "workspace_id": {
-                    "type": "integer"
-                }
-            }
-        },
-        "storage.DeviceState": {
-            "type": "object",
-            "properties": {
-                "id": {
+                "start": {
+                    "description": "от полуночи",
                     "type": "integer"
-                },
-                "name": {
-                    "type": "string"
                 }
             }
         },
-        "storage.DeviceTaskType": {
+        "storage.EquipmentCharacteristic": {
             "type": "object",
             "properties": {
                 "id": {
@@ -3067,31 +8505,55 @@ const docTemplate = `{
                 }
             }
         },
-        "storage.DeviceType": {
+        "storage.LabourRules": {
             "type": "object",
             "properties": {
-                "equipment_characteristic_id": {
+                "break": {
                     "type": "integer"
                 },
-                "id": {
+                "break_start": {
+                    "description": "BreakStart — начало ежедневного перерыва от полуночи; перерыв длится Break.",
                     "type": "integer"
                 },
-                "name": {
+                "fairness_period": {
+                    "description": "day | week",
                     "type": "string"
                 },
+                "fairness_weight": {
+                    "description": "FairnessWeight — на сколько минут позже может закончиться задание ради\nоператора, у которого на час меньше работы в периоде FairnessPeriod;\n0 — загрузка не выравнивается.",
+                    "type": "number"
+                },
+                "max_day": {
+                    "description": "работа над заданиями за календарный день",
+                    "type": "integer"
+                },
+                "max_week": {
+                    "description": "работа над заданиями за неделю (с понедельника)",
+                    "type": "integer"
+                },
+                "min_rest": {
+                    "description": "MinRest — отдых между последней работой дня и первой работой следующего.",
+                    "type": "integer"
+                },
                 "workspace_id": {
                     "type": "integer"
                 }
             }
         },
-        "storage.EquipmentCharacteristic": {
+        "storage.MaterialChangeover": {
             "type": "object",
             "properties": {
+                "duration": {
+                    "type": "integer"
+                },
+                "from_material_id": {
+                    "type": "integer"
+                },
                 "id": {
                     "type": "integer"
                 },
-                "name": {
-                    "type": "string"
+                "to_material_id": {
+                    "type": "integer"
                 },
                 "workspace_id": {
                     "type": "integer"
@@ -3104,6 +8566,10 @@ const docTemplate = `{
                 "full_name": {
                     "type": "string"
                 },
+                "hourly_rate": {
+                    "description": "HourlyRate — стоимость часа работы оператора; 0 — не учитывается.",
+                    "type": "number"
+                },
                 "id": {
                     "type": "integer"
                 },
@@ -3127,9 +8593,16 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "level": {
+                    "$ref": "#/definitions/storage.CompetencyLevel"
+                },
                 "operator_id": {
                     "type": "integer"
                 },
+                "setup_factor": {
+                    "description": "множитель наладки и снятия, nil — 1",
+                    "type": "number"
+                },
                 "workspace_id": {
                     "type": "integer"
                 }
@@ -3157,9 +8630,99 @@ const docTemplate = `{
                 },
                 "name": {
                     "type": "string"
+                },
+                "penalty_rate": {
+                    "description": "PenaltyRate — штраф за час опоздания заданий приоритета; 0 — опоздание не штрафуется.",
+                    "type": "number"
+                }
+            }
+        },
+        "storage.PriorityAging": {
+            "type": "object",
+            "properties": {
+                "mode": {
+                    "description": "none | deadline | slack",
+                    "type": "string"
+                },
+                "step": {
+                    "type": "integer"
+                },
+                "window": {
+                    "type": "integer"
+                },
+                "workspace_id": {
+                    "type": "integer"
+                }
+            }
+        },
+        "storage.TaskRequirement": {
+            "type": "object",
+            "properties": {
+                "bool": {
+                    "type": "boolean"
+                },
+                "characteristic_id": {
+                    "type": "integer"
+                },
+                "device_task_id": {
+                    "type": "integer"
+                },
+                "id": {
+                    "type": "integer"
+                },
+                "num": {
+                    "type": "number"
+                },
+                "num_max": {
+                    "type": "number"
+                },
+                "op": {
+                    "description": "gte | lte | eq | in | covers",
+                    "type": "string"
+                },
+                "options": {
+                    "type": "array",
+                    "items": {
+                        "type": "string"
+                    }
                 }
             }
         },
+        "storage.TaskStatus": {
+            "type": "string",
+            "enum": [
+                "pending",
+                "in_progress",
+                "on_hold",
+                "done",
+                "failed",
+                "cancelled"
+            ],
+            "x-enum-comments": {
+                "TaskStatusCancelled": "отменено",
+                "TaskStatusDone": "завершено",
+                "TaskStatusFailed": "брак/сбой, можно перезапустить",
+                "TaskStatusInProgress": "выполняется",
+                "TaskStatusOnHold": "отложено, не планируется",
+                "TaskStatusPending": "ожидает планирования/запуска"
+            },
+            "x-enum-descriptions": [
+                "ожидает планирования/запуска",
+                "выполняется",
+                "отложено, не планируется",
+                "завершено",
+                "брак/сбой, можно перезапустить",
+                "отменено"
+            ],
+            "x-enum-varnames": [
+                "TaskStatusPending",
+                "TaskStatusInProgress",
+                "TaskStatusOnHold",
+                "TaskStatusDone",
+                "TaskStatusFailed",
+                "TaskStatusCancelled"
+            ]
+        },
         "storage.User": {
             "type": "object",
             "properties": {
@@ -3169,6 +8732,9 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "is_admin": {
+                    "type": "boolean"
+                },
                 "login": {
                     "type": "string"
                 }
@@ -3189,6 +8755,10 @@ const docTemplate = `{
                 "id": {
                     "type": "integer"
                 },
+                "kind": {
+                    "description": "Kind — setup | unload у поручений, созданных планировщиком; пусто — создано вручную.",
+                    "type": "string"
+                },
                 "name": {
                     "type": "string"
                 },
@@ -3206,6 +8776,20 @@ const docTemplate = `{
                 }
             }
         },
+        "storage.UserTaskBusy": {
+            "type": "object",
+            "properties": {
+                "end": {
+                    "type": "string"
+                },
+                "operator_id": {
+                    "type": "integer"
+                },
+                "start": {
+                    "type": "string"
+                }
+            }
+        },
         "storage.Workspace": {
             "type": "object",
             "properties": {
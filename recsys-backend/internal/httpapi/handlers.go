@@ -5,6 +5,7 @@ import (
 	"errors"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -25,6 +26,7 @@ type sessionEntry struct {
 type Handlers struct {
 	repos      *storage.Repos
 	planner    *service.Planner
+	durations  *service.DurationStats
 	sessions   map[string]sessionEntry
 	sessionsMu sync.RWMutex
 	registerMu sync.Mutex
@@ -32,29 +34,86 @@ type Handlers struct {
 
 func NewHandlers(repos *storage.Repos, planner *service.Planner) *Handlers {
 	return &Handlers{
-		repos:    repos,
-		planner:  planner,
-		sessions: make(map[string]sessionEntry),
+		repos:     repos,
+		planner:   planner,
+		durations: service.NewDurationStats(repos),
+		sessions:  make(map[string]sessionEntry),
 	}
 }
 
 // DeviceTaskDTO — DTO для Swagger (без time.Duration)
 type DeviceTaskDTO struct {
-	ID            int64      `json:"id"`
-	Name          string     `json:"name"`
-	Deadline      *time.Time `json:"deadline"`
-	DurationMin   int        `json:"duration_min"`
-	SetupTimeMin  int        `json:"setup_time_min"`
-	UnloadTimeMin int        `json:"unload_time_min"`
-	NeedOperator  bool       `json:"need_operator"`
-	PlanStart     *time.Time `json:"plan_start"`
-	PlanEnd       *time.Time `json:"plan_end"`
-	DocNum        string     `json:"doc_num"`
-	PriorityID    int64      `json:"priority_id"`
-	OperatorID    int64      `json:"operator_id"`
-	DeviceID      int64      `json:"device_id"`
-	TaskTypeID    int64      `json:"device_task_type_id"`
-	WorkspaceID   int64      `json:"workspace_id"`
+	ID             int64      `json:"id"`
+	Name           string     `json:"name"`
+	Deadline       *time.Time `json:"deadline"`
+	DurationMin    int        `json:"duration_min"`
+	SetupTimeMin   int        `json:"setup_time_min"`
+	UnloadTimeMin  int        `json:"unload_time_min"`
+	NeedOperator   bool       `json:"need_operator"`
+	PlanStart      *time.Time `json:"plan_start"`
+	PlanEnd        *time.Time `json:"plan_end"`
+	DocNum         string     `json:"doc_num"`
+	Status         string     `json:"status"`
+	ActualStart    *time.Time `json:"actual_start"`
+	ActualEnd      *time.Time `json:"actual_end"`
+	PriorityID     int64      `json:"priority_id"`
+	OperatorID     int64      `json:"operator_id"`
+	DeviceID       int64      `json:"device_id"`
+	TaskTypeID     int64      `json:"device_task_type_id"`
+	WorkspaceID    int64      `json:"workspace_id"`
+	JobID          int64      `json:"job_id"`  // 0 — задание вне маршрута
+	JobSeq         int        `json:"job_seq"` // номер операции в маршруте
+	TransferLagMin int        `json:"transfer_lag_min"`
+	MaterialID     int64      `json:"material_id"`
+	PlateSize      int        `json:"plate_size"`
+	BatchID        int64      `json:"batch_id"` // прогон на платформе (ID первого задания), 0 — печатается отдельно
+	MaterialQty    float64    `json:"material_qty"`
+	DevicePoolID   int64      `json:"device_pool_id"` // задание на пул: device_id выбирает планировщик
+	TargetTypeID   int64      `json:"target_type_id"` // задание на тип оборудования
+	MinLevel       string     `json:"min_level"`      // минимальный уровень оператора, пусто — любой
+	OrderID        int64      `json:"order_id"`       // заказ клиента, 0 — вне заказа
+	TemplateID     int64      `json:"template_id"`    // шаблон, по которому создано задание, 0 — нет
+	DeadlineType   string     `json:"deadline_type"`  // hard | soft
+	PenaltyRate    *float64   `json:"penalty_rate"`   // штраф за час опоздания, null — по приоритету
+	Pinned         bool       `json:"pinned"`         // закреплено вручную: пересчёт плана его не двигает
+}
+
+func deviceTaskRowDTO(t storage.DeviceTaskRow) DeviceTaskDTO {
+	return DeviceTaskDTO{
+		ID:             t.ID,
+		Name:           t.Name,
+		Deadline:       t.Deadline,
+		DurationMin:    int(t.Duration.Minutes()),
+		SetupTimeMin:   int(t.SetupTime.Minutes()),
+		UnloadTimeMin:  int(t.UnloadTime.Minutes()),
+		NeedOperator:   t.NeedOperator,
+		PlanStart:      t.PlanStart,
+		PlanEnd:        t.PlanEnd,
+		DocNum:         t.DocNum,
+		Status:         string(t.Status),
+		ActualStart:    t.ActualStart,
+		ActualEnd:      t.ActualEnd,
+		PriorityID:     t.PriorityID,
+		OperatorID:     t.OperatorID,
+		DeviceID:       t.DeviceID,
+		TaskTypeID:     t.DeviceTaskTypeID,
+		WorkspaceID:    t.WorkspaceID,
+		JobID:          t.JobID,
+		JobSeq:         t.JobSeq,
+		TransferLagMin: int(t.TransferLag.Minutes()),
+		MaterialID:     t.MaterialID,
+		PlateSize:      t.PlateSize,
+		BatchID:        t.BatchID,
+		MaterialQty:    t.MaterialQty,
+		DevicePoolID:   t.DevicePoolID,
+		TargetTypeID:   t.TargetTypeID,
+		MinLevel:       string(t.MinLevel),
+		OrderID:        t.OrderID,
+		TemplateID:     t.TemplateID,
+		DeadlineType:   t.DeadlineType,
+		PenaltyRate:    t.PenaltyRate,
+		Pinned:         t.Pinned,
+	}
 }
 
 // Health godoc
@@ -77,7 +136,8 @@ func (h *Handlers) Health(w http.ResponseWriter, r *http.Request) {
 // @Summary      Список заданий по workspace
 // @Tags         device_task
 // @Produce      json
-// @Param        workspaceId  path      int  true  "Workspace ID"
+// @Param        workspaceId  path      int     true   "Workspace ID"
+// @Param        status       query     string  false  "Фильтр по статусам через запятую (pending,in_progress,on_hold,done,failed,cancelled)"
 // @Success      200  {array}   DeviceTaskDTO
 // @Failure      400  {object}  map[string]any
 // @Failure      500  {object}  map[string]any
@@ -90,7 +150,13 @@ func (h *Handlers) ListDeviceTasks(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	tasks, err := h.repos.ListDeviceTasksForWorkspace(r.Context(), workspaceID)
+	statuses, err := parseStatusFilter(r.URL.Query().Get("status"))
+	if err != nil {
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+		return
+	}
+
+	tasks, err := h.repos.ListDeviceTasksForWorkspace(r.Context(), workspaceID, statuses...)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -98,23 +164,7 @@ func (h *Handlers) ListDeviceTasks(w http.ResponseWriter, r *http.Request) {
 
 	dtos := make([]DeviceTaskDTO, 0, len(tasks))
 	for _, t := range tasks {
-		dtos = append(dtos, DeviceTaskDTO{
-			ID:            t.ID,
-			Name:          t.Name,
-			Deadline:      t.Deadline,
-			DurationMin:   int(t.Duration.Minutes()),
-			SetupTimeMin:  int(t.SetupTime.Minutes()),
-			UnloadTimeMin: int(t.UnloadTime.Minutes()),
-			NeedOperator:  t.NeedOperator,
-			PlanStart:     t.PlanStart,
-			PlanEnd:       t.PlanEnd,
-			DocNum:        t.DocNum,
-			PriorityID:    t.PriorityID,
-			OperatorID:    t.OperatorID,
-			DeviceID:      t.DeviceID,
-			TaskTypeID:    t.DeviceTaskTypeID,
-			WorkspaceID:   t.WorkspaceID,
-		})
+		dtos = append(dtos, deviceTaskRowDTO(t))
 	}
 
 	writeJSON(w, 200, dtos)
@@ -140,8 +190,14 @@ func (h *Handlers) RecomputePlan(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 400, map[string]any{"error": "workspace_id must be > 0"})
 		return
 	}
+	switch req.DurationMode {
+	case "", service.DurationModeNominal, service.DurationModeP80:
+	default:
+		writeJSON(w, 400, map[string]any{"error": "duration_mode must be nominal or p80"})
+		return
+	}
 
-	res, err := h.planner.Recompute(r.Context(), req.WorkspaceID)
+	res, err := h.planner.Recompute(r.Context(), req)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -158,3 +214,62 @@ func writeJSON(w http.ResponseWriter, code int, v any) {
 func isNotFound(err error) bool {
 	return errors.Is(err, pgx.ErrNoRows)
 }
+
+// parseStatusFilter разбирает значение ?status=a,b; пустая строка — без фильтра.
+func parseStatusFilter(raw string) ([]storage.TaskStatus, error) {
+	if raw == "" {
+		return nil, nil
+	}
+	var res []storage.TaskStatus
+	for _, part := range strings.Split(raw, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		st, err := storage.ParseTaskStatus(part)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, st)
+	}
+	return res, nil
+}
+
+// ReportDisruption godoc
+// @Summary      Сообщить о сбое и локально починить план
+// @Description  device_down — оборудование недоступно с from (по умолчанию сейчас) до until; выполняемое на нём задание прерывается и переделывается. task_failed — задание ушло в брак и выполняется заново. Пересчитываются только задетые сбоем задания и те, которые они вытеснили.
+// @Tags         planning
+// @Accept       json
+// @Produce      json
+// @Param        body  body      service.DisruptionRequest  true  "Сбой"
+// @Success      200   {object}  service.RepairResult
+// @Failure      400   {object}  map[string]any
+// @Failure      404   {object}  map[string]any
+// @Failure      409   {object}  map[string]any
+// @Failure      500   {object}  map[string]any
+// @Router       /api/plans/disruptions [post]
+func (h *Handlers) ReportDisruption(w http.ResponseWriter, r *http.Request) {
+	var req service.DisruptionRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if req.WorkspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id must be > 0"})
+		return
+	}
+
+	res, err := h.planner.Repair(r.Context(), req)
+	switch {
+	case err == nil:
+		writeJSON(w, 200, res)
+	case errors.Is(err, service.ErrInvalidDisruption):
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+	case errors.Is(err, service.ErrDisruptionTarget):
+		writeJSON(w, 404, map[string]any{"error": err.Error()})
+	case errors.Is(err, storage.ErrInvalidStatusTransition):
+		writeJSON(w, 409, map[string]any{"error": err.Error()})
+	default:
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+	}
+}
@@ -0,0 +1,441 @@
+package httpapi
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"recsys-backend/internal/service"
+	"recsys-backend/internal/simulation"
+)
+
+// ListDurationStats godoc
+// @Summary      Статистика фактических длительностей заданий
+// @Description  Коэффициенты «факт / план» и перцентили фактической длительности по типу оборудования и типу задания. Нулевой ID в группе означает «любой».
+// @Tags         analytics
+// @Produce      json
+// @Param        workspaceId  path      int  true  "Workspace ID"
+// @Success      200  {array}   service.DurationStat
+// @Failure      400  {object}  map[string]any
+// @Failure      500  {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/duration-stats [get]
+func (h *Handlers) ListDurationStats(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	model, err := h.durations.Model(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, model.Stats())
+}
+
+// SuggestDuration godoc
+// @Summary      Рекомендуемая длительность нового задания
+// @Description  Если переданы плановые минуты, они масштабируются коэффициентом из истории, иначе возвращаются фактические перцентили.
+// @Tags         analytics
+// @Produce      json
+// @Param        workspaceId          path      int  true   "Workspace ID"
+// @Param        device_type_id       query     int  false  "Device type ID"
+// @Param        device_task_type_id  query     int  false  "Device task type ID"
+// @Param        duration_min         query     int  false  "Плановая печать, мин"
+// @Param        setup_time_min       query     int  false  "Плановая наладка, мин"
+// @Param        unload_time_min      query     int  false  "Плановое снятие, мин"
+// @Success      200  {object}  service.DurationSuggestion
+// @Failure      400  {object}  map[string]any
+// @Failure      500  {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/duration-stats/suggest [get]
+func (h *Handlers) SuggestDuration(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	params := map[string]int64{}
+	for _, key := range []string{"device_type_id", "device_task_type_id", "duration_min", "setup_time_min", "unload_time_min"} {
+		v, err := queryInt64(r, key)
+		if err != nil {
+			writeJSON(w, 400, map[string]any{"error": err.Error()})
+			return
+		}
+		params[key] = v
+	}
+	model, err := h.durations.Model(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	nominal := minutesToDuration(int(params["duration_min"] + params["setup_time_min"] + params["unload_time_min"]))
+	writeJSON(w, 200, model.Suggest(params["device_type_id"], params["device_task_type_id"], nominal))
+}
+
+// ExportSnapshot godoc
+// @Summary      Снимок рабочего пространства для симулятора
+// @Description  Оборудование, операторы, ожидающие и выполняемые задания, занятость операторов и история длительностей. Файл передаётся в go run ./cmd/simulate -snapshot.
+// @Tags         analytics
+// @Produce      json
+// @Param        workspaceId  path      int  true  "Workspace ID"
+// @Success      200  {object}  simulation.Scenario
+// @Failure      400  {object}  map[string]any
+// @Failure      500  {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/snapshot [get]
+func (h *Handlers) ExportSnapshot(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	sc, err := simulation.Snapshot(r.Context(), h.repos, workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, sc)
+}
+
+// queryInt64 читает необязательный неотрицательный целый query-параметр.
+func queryInt64(r *http.Request, key string) (int64, error) {
+	raw := r.URL.Query().Get(key)
+	if raw == "" {
+		return 0, nil
+	}
+	v, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || v < 0 {
+		return 0, fmt.Errorf("invalid %s", key)
+	}
+	return v, nil
+}
+
+// AnalyzePlanRisk godoc
+// @Summary      Вероятность выполнения плана в срок (Monte Carlo)
+// @Description  Текущий план прогоняется runs раз со случайными длительностями заданий по правилам планировщика. Для каждого задания — вероятность уложиться в дедлайн и ожидаемое опоздание; оборудование и операторы, из-за которых задания ждут дольше всего, помечаются как узкие места.
+// @Tags         analytics
+// @Produce      json
+// @Param        workspaceId   path      int     true   "Workspace ID"
+// @Param        runs          query     int     false  "Число прогонов (по умолчанию 500, максимум 10000)"
+// @Param        distribution  query     string  false  "learned (по умолчанию) | lognormal"
+// @Param        spread        query     number  false  "Коэффициент вариации для lognormal (по умолчанию 0.2)"
+// @Param        seed          query     int     false  "Seed генератора для воспроизводимости"
+// @Success      200  {object}  service.RiskResult
+// @Failure      400  {object}  map[string]any
+// @Failure      500  {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/plan/risk [get]
+func (h *Handlers) AnalyzePlanRisk(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	q := r.URL.Query()
+	req := service.RiskRequest{Distribution: q.Get("distribution")}
+	switch req.Distribution {
+	case "", service.DistributionLearned, service.DistributionLognormal:
+	default:
+		writeJSON(w, 400, map[string]any{"error": "distribution must be learned or lognormal"})
+		return
+	}
+	runs, err := queryInt64(r, "runs")
+	if err != nil {
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+		return
+	}
+	req.Runs = int(runs)
+	if req.Seed, err = queryInt64(r, "seed"); err != nil {
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+		return
+	}
+	if raw := q.Get("spread"); raw != "" {
+		req.Spread, err = strconv.ParseFloat(raw, 64)
+		if err != nil || req.Spread < 0 {
+			writeJSON(w, 400, map[string]any{"error": "invalid spread"})
+			return
+		}
+	}
+
+	res, err := h.planner.AnalyzeRisk(r.Context(), workspaceID, req)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// ListMaterialSwaps godoc
+// @Summary      Смены материала по текущему плану
+// @Description  Задания с материалом на каждом оборудовании по времени начала: где материал задания отличается от заправленного, оператору нужно сменить материал. from_material_id = 0 — заправленный материал неизвестен.
+// @Tags         planning
+// @Produce      json
+// @Param        workspaceId  path      int  true  "Workspace ID"
+// @Success      200          {array}   service.MaterialSwap
+// @Failure      400          {object}  map[string]any
+// @Failure      500          {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/plan/material-swaps [get]
+func (h *Handlers) ListMaterialSwaps(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	res, err := h.planner.MaterialSwaps(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// ListTaskPriorities godoc
+// @Summary      Очерёдность заданий в планировщике
+// @Description  Ожидающие задания в системе рекомендаций в порядке, в котором их берёт планировщик: срок (свой дедлайн или общий срок заказа), запас до него, приоритет и эффективный приоритет со старением по правилу priority-aging. Маршруты и прогоны планировщик ставит целиком, по самому срочному заданию.
+// @Tags         planning
+// @Produce      json
+// @Param        workspaceId  path      int  true  "Workspace ID"
+// @Success      200          {array}   service.TaskPriority
+// @Failure      400          {object}  map[string]any
+// @Failure      500          {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/plan/priorities [get]
+func (h *Handlers) ListTaskPriorities(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	res, err := h.planner.TaskPriorities(r.Context(), workspaceID, time.Now())
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// PlanLateness godoc
+// @Summary      Опоздания текущего плана и штраф за них
+// @Description  Ожидающие и выполняемые задания, которые по плану заканчиваются позже дедлайна, от самого долгого опоздания: тип дедлайна (hard | soft), опоздание в минутах и штраф — часы опоздания, умноженные на ставку задания или его приоритета. Итог — суммарный штраф плана. Задания без плана не учитываются.
+// @Tags         planning
+// @Produce      json
+// @Param        workspaceId  path      int  true  "Workspace ID"
+// @Success      200          {object}  service.LatenessReport
+// @Failure      400          {object}  map[string]any
+// @Failure      500          {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/plan/lateness [get]
+func (h *Handlers) PlanLateness(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	res, err := h.planner.Lateness(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// PlanConflicts godoc
+// @Summary      Конфликты текущего плана
+// @Description  Пересечения в плане ожидающих и выполняемых заданий и поручений операторам, созданных вручную. device_busy — два задания на одном оборудовании, с учётом остывания после задания. operator_busy — оператор занят двумя заданиями, заданием и поручением или двумя поручениями. outside_working_hours — задание начинается вне 09:00–22:00 или, с оператором, заканчивается после 22:00. deadline — задание заканчивается после дедлайна. device_unavailable — задание на оборудовании в простое. route_order — операции маршрута пересекаются с учётом пролёживания. labour_rule — задание нарушает правила рабочего времени оператора, нарушенное правило — в поле labour_rule.
+// @Description  Каждый конфликт называет участвующие строки: task_id, other_task_id, user_task_id, other_user_task_id, downtime_id.
+// @Tags         planning
+// @Produce      json
+// @Param        workspaceId  path      int  true  "Workspace ID"
+// @Success      200          {object}  service.ConflictReport
+// @Failure      400          {object}  map[string]any
+// @Failure      500          {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/plan/conflicts [get]
+func (h *Handlers) PlanConflicts(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	res, err := h.planner.PlanConflicts(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// EnergyEstimate godoc
+// @Summary      Энергия и её стоимость по текущему плану
+// @Description  Расход (кВт·ч) и стоимость энергии каждого ожидающего и выполняемого задания плана по мощности оборудования и тарифам workspace и итог по плану. Энергия прогона делится поровну между его заданиями.
+// @Tags         planning
+// @Produce      json
+// @Param        workspaceId  path      int  true  "Workspace ID"
+// @Success      200          {object}  service.EnergyReport
+// @Failure      400          {object}  map[string]any
+// @Failure      500          {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/plan/energy [get]
+func (h *Handlers) EnergyEstimate(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	res, err := h.planner.EnergyEstimate(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// OperatorWorkload godoc
+// @Summary      Загрузка операторов за период
+// @Description  Минуты заданий с участием оператора (по плану, у завершённых — по факту) и личных поручений по каждому оператору и дню, средняя загрузка и разброс между самым загруженным и самым свободным.
+// @Tags         analytics
+// @Produce      json
+// @Param        workspaceId  path      int     true   "Workspace ID"
+// @Param        from         query     string  false  "Первый день, ГГГГ-ММ-ДД (по умолчанию сегодня)"
+// @Param        to           query     string  false  "Последний день включительно, ГГГГ-ММ-ДД (по умолчанию from + 6 дней)"
+// @Success      200  {object}  service.WorkloadReport
+// @Failure      400  {object}  map[string]any
+// @Failure      500  {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/operator-workload [get]
+func (h *Handlers) OperatorWorkload(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	now := time.Now()
+	from := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		if from, err = time.ParseInLocation("2006-01-02", raw, now.Location()); err != nil {
+			writeJSON(w, 400, map[string]any{"error": "invalid from"})
+			return
+		}
+	}
+	to := from.AddDate(0, 0, 7)
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		last, err := time.ParseInLocation("2006-01-02", raw, now.Location())
+		if err != nil || last.Before(from) {
+			writeJSON(w, 400, map[string]any{"error": "invalid to"})
+			return
+		}
+		to = last.AddDate(0, 0, 1)
+	}
+	res, err := h.planner.OperatorWorkload(r.Context(), workspaceID, from, to)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// ListEligibleDevices godoc
+// @Summary     Оборудование, подходящее заданию по характеристикам
+// @Description Всё оборудование workspace задания: сначала удовлетворяющее всем требованиям задания, затем остальное с перечнем невыполненных требований.
+// @Tags        device_tasks
+// @Produce     json
+// @Param       deviceTaskId  path      int  true  "Device task ID"
+// @Success     200           {array}   service.DeviceEligibility
+// @Failure     400           {object}  map[string]any
+// @Failure     404           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId}/eligible-devices [get]
+func (h *Handlers) ListEligibleDevices(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	res, err := h.planner.EligibleDevices(r.Context(), id)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// GetTaskCost godoc
+// @Summary     Себестоимость задания
+// @Description Плановая себестоимость — по слоту плана (у незапланированного задания — по наладке, печати и снятию), фактическая — по выполнению завершённого задания. Статьи: оборудование и оператор по часовым ставкам, материал по стоимости единицы на складе, энергия по тарифам. Время, ставки и энергия прогона делятся между его заданиями.
+// @Tags        device_tasks
+// @Produce     json
+// @Param       deviceTaskId  path      int  true  "Device task ID"
+// @Success     200           {object}  service.TaskCost
+// @Failure     400           {object}  map[string]any
+// @Failure     404           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId}/cost [get]
+func (h *Handlers) GetTaskCost(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	res, err := h.planner.TaskCost(r.Context(), id)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
+
+// CostReport godoc
+// @Summary      Себестоимость заданий по документам или типам
+// @Description  Плановая и фактическая себестоимость неотменённых заданий, начавшихся (по факту, иначе по плану) в периоде, по номерам документов или типам заданий и итог. Без from и to входят все задания.
+// @Tags         analytics
+// @Produce      json
+// @Param        workspaceId  path      int     true   "Workspace ID"
+// @Param        group_by     query     string  false  "doc_num (по умолчанию) | task_type"
+// @Param        from         query     string  false  "Первый день, ГГГГ-ММ-ДД"
+// @Param        to           query     string  false  "Последний день включительно, ГГГГ-ММ-ДД"
+// @Success      200  {object}  service.CostReport
+// @Failure      400  {object}  map[string]any
+// @Failure      500  {object}  map[string]any
+// @Router       /api/workspaces/{workspaceId}/cost-report [get]
+func (h *Handlers) CostReport(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	groupBy := r.URL.Query().Get("group_by")
+	switch groupBy {
+	case "":
+		groupBy = service.CostGroupDocNum
+	case service.CostGroupDocNum, service.CostGroupTaskType:
+	default:
+		writeJSON(w, 400, map[string]any{"error": "group_by must be doc_num or task_type"})
+		return
+	}
+	var from, to *time.Time
+	if raw := r.URL.Query().Get("from"); raw != "" {
+		day, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil {
+			writeJSON(w, 400, map[string]any{"error": "invalid from"})
+			return
+		}
+		from = &day
+	}
+	if raw := r.URL.Query().Get("to"); raw != "" {
+		last, err := time.ParseInLocation("2006-01-02", raw, time.Local)
+		if err != nil || (from != nil && last.Before(*from)) {
+			writeJSON(w, 400, map[string]any{"error": "invalid to"})
+			return
+		}
+		end := last.AddDate(0, 0, 1)
+		to = &end
+	}
+	res, err := h.planner.CostReport(r.Context(), workspaceID, groupBy, from, to)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
@@ -62,12 +62,12 @@ func (h *Handlers) SeedDevData(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	priorityID, err := h.repos.CreatePriority(r.Context(), faker.RandomString([]string{
+	priorityID, err := h.repos.CreatePriority(r.Context(), storage.Priority{Name: faker.RandomString([]string{
 		"Низкий",
 		"Средний",
 		"Высокий",
 		"Критичный",
-	}))
+	})})
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -164,7 +164,7 @@ func (h *Handlers) SeedDevData(w http.ResponseWriter, r *http.Request) {
 		PlanStart:        &planStart,
 		PlanEnd:          &planEnd,
 		DocNum:           docNum,
-		CompletionMark:   "false",
+		Status:           storage.TaskStatusPending,
 		AddInRecSystem:   &addInRec,
 		DeviceTaskTypeID: taskTypeID,
 		WorkspaceID:      workspaceID,
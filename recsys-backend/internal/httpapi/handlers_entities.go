@@ -1,12 +1,17 @@
 package httpapi
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
+	"slices"
 	"strconv"
 	"strings"
 	"time"
 
+	"recsys-backend/internal/service"
 	"recsys-backend/internal/storage"
 
 	"github.com/go-chi/chi/v5"
@@ -17,6 +22,12 @@ type NameRequest struct {
 	Name string `json:"name"`
 }
 
+// PriorityRequest — приоритет и штраф за час опоздания его заданий.
+type PriorityRequest struct {
+	Name        string  `json:"name"`
+	PenaltyRate float64 `json:"penalty_rate"` // 0 — опоздание не штрафуется
+}
+
 type UserRequest struct {
 	Login    string `json:"login"`
 	ID       int64  `json:"id"`
@@ -37,6 +48,141 @@ type EquipmentCharacteristicRequest struct {
 type DeviceTypeRequest struct {
 	Name                      string `json:"name"`
 	EquipmentCharacteristicID int64  `json:"equipment_characteristic_id"`
+	PlateCapacity             int    `json:"plate_capacity"` // вместимость платформы; 0 — без прогонов
+	// PowerKW — потребляемая мощность, кВт; не задана — энергия не учитывается.
+	PowerKW *float64 `json:"power_kw"`
+	// CooldownMin — остывание оборудования после каждого задания, мин; 0 — без остывания.
+	CooldownMin int `json:"cooldown_min"`
+}
+
+type DeviceTypeDTO struct {
+	ID                        int64    `json:"id"`
+	Name                      string   `json:"name"`
+	EquipmentCharacteristicID int64    `json:"equipment_characteristic_id"`
+	PlateCapacity             int      `json:"plate_capacity"`
+	WorkspaceID               int64    `json:"workspace_id"`
+	PowerKW                   *float64 `json:"power_kw"`
+	CooldownMin               int      `json:"cooldown_min"`
+}
+
+// MaterialChangeoverRequest — переход с материала from на материал to.
+type MaterialChangeoverRequest struct {
+	FromMaterialID int64 `json:"from_material_id"`
+	ToMaterialID   int64 `json:"to_material_id"`
+	DurationMin    int   `json:"duration_min"`
+}
+
+type MaterialChangeoverDTO struct {
+	ID             int64 `json:"id"`
+	FromMaterialID int64 `json:"from_material_id"`
+	ToMaterialID   int64 `json:"to_material_id"`
+	DurationMin    int   `json:"duration_min"`
+	WorkspaceID    int64 `json:"workspace_id"`
+}
+
+// MaterialStockRequest — остаток материала на складе и ожидаемая поставка.
+type MaterialStockRequest struct {
+	MaterialID int64      `json:"material_id"`
+	Unit       string     `json:"unit"`
+	OnHand     float64    `json:"on_hand"`
+	LowLevel   float64    `json:"low_level"`
+	RestockAt  *time.Time `json:"restock_at"`
+	RestockQty float64    `json:"restock_qty"`
+	UnitCost   float64    `json:"unit_cost"` // стоимость единицы материала
+}
+
+type MaterialStockDTO struct {
+	ID          int64      `json:"id"`
+	MaterialID  int64      `json:"material_id"`
+	Unit        string     `json:"unit"`
+	OnHand      float64    `json:"on_hand"`
+	Reserved    float64    `json:"reserved"` // под запланированные задания
+	Free        float64    `json:"free"`     // on_hand − reserved
+	LowLevel    float64    `json:"low_level"`
+	Low         bool       `json:"low"` // свободный остаток не выше порога
+	RestockAt   *time.Time `json:"restock_at"`
+	RestockQty  float64    `json:"restock_qty"`
+	UnitCost    float64    `json:"unit_cost"`
+	WorkspaceID int64      `json:"workspace_id"`
+}
+
+// CharacteristicRequest — типизированная характеристика оборудования.
+type CharacteristicRequest struct {
+	Key     string   `json:"key"`
+	Kind    string   `json:"kind"` // number | enum | bool | range
+	Unit    string   `json:"unit"`
+	Options []string `json:"options"` // допустимые значения enum
+}
+
+// CharacteristicValueRequest — значение характеристики у типа оборудования
+// (device_type_id) или у отдельного устройства (device_id).
+type CharacteristicValueRequest struct {
+	CharacteristicID int64    `json:"characteristic_id"`
+	DeviceTypeID     int64    `json:"device_type_id"`
+	DeviceID         int64    `json:"device_id"`
+	Num              *float64 `json:"num"`
+	NumMax           *float64 `json:"num_max"`
+	Bool             *bool    `json:"bool"`
+	Options          []string `json:"options"`
+}
+
+// TaskRequirementRequest — требование задания к характеристике оборудования:
+// gte/lte/eq для number, eq для bool, in для enum, covers для range.
+type TaskRequirementRequest struct {
+	CharacteristicID int64    `json:"characteristic_id"`
+	Op               string   `json:"op"`
+	Num              *float64 `json:"num"`
+	NumMax           *float64 `json:"num_max"`
+	Bool             *bool    `json:"bool"`
+	Options          []string `json:"options"`
+}
+
+// LabourRulesDTO — правила рабочего времени операторов; 0 или пустое поле —
+// правило не действует.
+type LabourRulesDTO struct {
+	MaxDayMin  int    `json:"max_day_min"`  // работа над заданиями за день
+	MaxWeekMin int    `json:"max_week_min"` // работа над заданиями за неделю
+	BreakStart string `json:"break_start"`  // начало ежедневного перерыва, ЧЧ:ММ
+	BreakMin   int    `json:"break_min"`
+	MinRestMin int    `json:"min_rest_min"` // отдых между сменами
+	// FairnessWeight — минуты более позднего окончания задания, которые
+	// планировщик допускает ради оператора с часом меньшей загрузки за
+	// FairnessPeriod (day | week, по умолчанию day); 0 — без выравнивания.
+	FairnessWeight float64 `json:"fairness_weight"`
+	FairnessPeriod string  `json:"fairness_period"`
+}
+
+// PriorityAgingDTO — старение приоритета: внутри окна window_min до дедлайна
+// (в режиме slack — до запаса: время до дедлайна минус оставшаяся работа)
+// задание поднимается на уровень приоритета за каждые step_min.
+type PriorityAgingDTO struct {
+	Mode      string `json:"mode"` // none (по умолчанию) | deadline | slack
+	WindowMin int    `json:"window_min"`
+	StepMin   int    `json:"step_min"`
+}
+
+// EnergyTariffDTO — окно тарифа по времени суток; окно с end не позже start
+// переходит через полночь.
+type EnergyTariffDTO struct {
+	Start string  `json:"start"` // ЧЧ:ММ
+	End   string  `json:"end"`   // ЧЧ:ММ
+	Price float64 `json:"price"` // цена кВт·ч
+}
+
+// EnergyScheduleDTO — тарифный план электроэнергии.
+type EnergyScheduleDTO struct {
+	BasePrice float64 `json:"base_price"` // цена кВт·ч вне окон
+	// CostWeight — минуты более позднего окончания задания, которые
+	// планировщик допускает ради экономии единицы стоимости энергии;
+	// 0 — стоимость только оценивается.
+	CostWeight float64           `json:"cost_weight"`
+	Tariffs    []EnergyTariffDTO `json:"tariffs"`
+}
+
+// DevicePoolRequest — пул взаимозаменяемого оборудования.
+type DevicePoolRequest struct {
+	Name      string  `json:"name"`
+	DeviceIDs []int64 `json:"device_ids"`
 }
 
 type DeviceRequest struct {
@@ -45,17 +191,32 @@ type DeviceRequest struct {
 	AddInRecSystem *bool  `json:"add_in_rec_system"`
 	DeviceTypeID   int64  `json:"device_type_id"`
 	DeviceStateID  int64  `json:"device_state_id"`
+	// LoadedMaterialID — заправленный материал, 0 — неизвестен.
+	LoadedMaterialID int64 `json:"loaded_material_id"`
+	// PowerKW — потребляемая мощность, кВт; не задана — как у типа оборудования.
+	PowerKW *float64 `json:"power_kw"`
+	// HourlyRate — стоимость часа работы оборудования.
+	HourlyRate float64 `json:"hourly_rate"`
+}
+
+type LoadedMaterialRequest struct {
+	MaterialID int64 `json:"material_id"` // 0 — материал неизвестен
 }
 
 type OperatorRequest struct {
 	FullName    string `json:"full_name"`
 	PhoneNumber string `json:"phone_number"`
 	UserLogin   string `json:"user_login"`
+	// HourlyRate — стоимость часа работы оператора.
+	HourlyRate float64 `json:"hourly_rate"`
 }
 
 type OperatorCompetencyRequest struct {
-	DeviceTypeID int64 `json:"device_type_id"`
-	OperatorID   int64 `json:"operator_id"`
+	DeviceTypeID int64  `json:"device_type_id"`
+	OperatorID   int64  `json:"operator_id"`
+	Level        string `json:"level"` // trainee | qualified | expert, пусто — qualified
+	// SetupFactor — множитель наладки и снятия изделия, null — 1.
+	SetupFactor *float64 `json:"setup_factor"`
 }
 
 type OperatorDeviceRequest struct {
@@ -65,6 +226,15 @@ type OperatorDeviceRequest struct {
 
 type DeviceTaskTypeRequest struct {
 	Name string `json:"name"`
+	// CooldownMin — остывание оборудования после задания этого типа, мин; 0 — без остывания.
+	CooldownMin int `json:"cooldown_min"`
+}
+
+type DeviceTaskTypeDTO struct {
+	ID          int64  `json:"id"`
+	Name        string `json:"name"`
+	WorkspaceID int64  `json:"workspace_id"`
+	CooldownMin int    `json:"cooldown_min"`
 }
 
 type DeviceTaskRequest struct {
@@ -78,12 +248,144 @@ type DeviceTaskRequest struct {
 	PlanStart        *time.Time `json:"plan_start"`
 	PlanEnd          *time.Time `json:"plan_end"`
 	DocNum           string     `json:"doc_num"`
-	CompletionMark   string     `json:"completion_mark"`
+	Status           string     `json:"status"`
 	AddInRecSystem   *bool      `json:"add_in_rec_system"`
 	DeviceTaskTypeID int64      `json:"device_task_type_id"`
 	OperatorID       int64      `json:"operator_id"`
 	DeviceID         int64      `json:"device_id"`
 	PriorityID       int64      `json:"priority_id"`
+	MaterialID       int64      `json:"material_id"`  // характеристика-материал, 0 — не указан
+	PlateSize        int        `json:"plate_size"`   // место на платформе, 0 — печатается отдельно
+	MaterialQty      float64    `json:"material_qty"` // расход материала в единицах склада
+	// DevicePoolID и TargetTypeID адресуют задание пулу или типу оборудования:
+	// device_id можно не указывать, оборудование выберет планировщик.
+	DevicePoolID int64 `json:"device_pool_id"`
+	TargetTypeID int64 `json:"target_type_id"`
+	// MinLevel — минимальный уровень компетенции оператора (trainee | qualified |
+	// expert); пусто — подходит любой. Требует need_operator.
+	MinLevel string `json:"min_level"`
+	// OrderID — заказ клиента, 0 — задание вне заказа. Пустой doc_num
+	// берётся из заказа.
+	OrderID int64 `json:"order_id"`
+	// DeadlineType — hard (по умолчанию): задание ставится только с окончанием
+	// к сроку; soft — срок можно сдвинуть ради жёстких дедлайнов.
+	DeadlineType string `json:"deadline_type"`
+	// PenaltyRate — штраф за час опоздания; null — по приоритету задания.
+	PenaltyRate *float64 `json:"penalty_rate"`
+}
+
+// ProductionJobRequest — задание с маршрутом; операции выполняются в порядке массива.
+type ProductionJobRequest struct {
+	Name       string                `json:"name"`
+	Deadline   *time.Time            `json:"deadline"`
+	DocNum     string                `json:"doc_num"`
+	PriorityID int64                 `json:"priority_id"`
+	Operations []JobOperationRequest `json:"operations"`
+}
+
+type JobOperationRequest struct {
+	Name             string `json:"name"`
+	DeviceTypeID     int64  `json:"device_type_id"`
+	DeviceID         int64  `json:"device_id"` // необязательно; по умолчанию первое оборудование нужного типа
+	DeviceTaskTypeID int64  `json:"device_task_type_id"`
+	OperatorID       int64  `json:"operator_id"`
+	NeedOperator     bool   `json:"need_operator"`
+	DurationMin      int    `json:"duration_min"`
+	SetupTimeMin     int    `json:"setup_time_min"`
+	UnloadTimeMin    int    `json:"unload_time_min"`
+	TransferLagMin   int    `json:"transfer_lag_min"` // пролёживание после предыдущей операции
+}
+
+type ProductionJobDTO struct {
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Deadline    *time.Time      `json:"deadline"`
+	DocNum      string          `json:"doc_num"`
+	PriorityID  int64           `json:"priority_id"`
+	WorkspaceID int64           `json:"workspace_id"`
+	Operations  []DeviceTaskDTO `json:"operations"`
+}
+
+// CustomerOrderRequest — заказ клиента.
+type CustomerOrderRequest struct {
+	Customer   string     `json:"customer"`
+	DocNum     string     `json:"doc_num"`
+	DueDate    *time.Time `json:"due_date"`
+	PriorityID int64      `json:"priority_id"`
+}
+
+// CustomerOrderDTO — заказ со сводкой по его заданиям.
+type CustomerOrderDTO struct {
+	ID          int64      `json:"id"`
+	Customer    string     `json:"customer"`
+	DocNum      string     `json:"doc_num"`
+	DueDate     *time.Time `json:"due_date"`
+	PriorityID  int64      `json:"priority_id"`
+	WorkspaceID int64      `json:"workspace_id"`
+	// Status — сводный статус заказа по неотменённым заданиям.
+	Status         string `json:"status"`
+	TasksTotal     int    `json:"tasks_total"`
+	TasksDone      int    `json:"tasks_done"`
+	TasksUnplanned int    `json:"tasks_unplanned"`
+	// PlannedCompletion — самое позднее окончание заданий заказа; null — не
+	// все задания в плане.
+	PlannedCompletion *time.Time      `json:"planned_completion"`
+	Late              bool            `json:"late"` // по плану позже срока
+	Tasks             []DeviceTaskDTO `json:"tasks"`
+}
+
+// TaskTemplateRequest — шаблон повторяющегося задания.
+type TaskTemplateRequest struct {
+	Name             string  `json:"name"`
+	DocNum           string  `json:"doc_num"`
+	DeviceTaskTypeID int64   `json:"device_task_type_id"`
+	DeviceTypeID     int64   `json:"device_type_id"` // задания адресуются этому типу оборудования
+	OperatorID       int64   `json:"operator_id"`    // 0 — без оператора; обязателен при need_operator
+	PriorityID       int64   `json:"priority_id"`
+	NeedOperator     bool    `json:"need_operator"`
+	DurationMin      int     `json:"duration_min"`
+	SetupTimeMin     int     `json:"setup_time_min"`
+	UnloadTimeMin    int     `json:"unload_time_min"`
+	MaterialID       int64   `json:"material_id"` // 0 — не указан
+	MaterialQty      float64 `json:"material_qty"`
+	// Recurrence — weekly | monthly | every_n_days, повторение раз в Interval
+	// недель, месяцев или дней (по умолчанию 1) начиная со StartAt.
+	Recurrence string     `json:"recurrence"`
+	Interval   int        `json:"interval"`
+	StartAt    time.Time  `json:"start_at"`
+	EndAt      *time.Time `json:"end_at"`
+	Active     *bool      `json:"active"` // по умолчанию true
+}
+
+type TaskTemplateDTO struct {
+	ID               int64      `json:"id"`
+	Name             string     `json:"name"`
+	DocNum           string     `json:"doc_num"`
+	DeviceTaskTypeID int64      `json:"device_task_type_id"`
+	DeviceTypeID     int64      `json:"device_type_id"`
+	OperatorID       int64      `json:"operator_id"`
+	PriorityID       int64      `json:"priority_id"`
+	NeedOperator     bool       `json:"need_operator"`
+	DurationMin      int        `json:"duration_min"`
+	SetupTimeMin     int        `json:"setup_time_min"`
+	UnloadTimeMin    int        `json:"unload_time_min"`
+	MaterialID       int64      `json:"material_id"`
+	MaterialQty      float64    `json:"material_qty"`
+	Recurrence       string     `json:"recurrence"`
+	Interval         int        `json:"interval"`
+	StartAt          time.Time  `json:"start_at"`
+	EndAt            *time.Time `json:"end_at"`
+	Active           bool       `json:"active"`
+	WorkspaceID      int64      `json:"workspace_id"`
+}
+
+type DeviceTaskStatusRequest struct {
+	Status string     `json:"status"`
+	At     *time.Time `json:"at"` // момент смены статуса, по умолчанию сейчас
+}
+
+type DeviceTaskPinRequest struct {
+	Pinned bool `json:"pinned"`
 }
 
 type UserTaskRequest struct {
@@ -534,13 +836,13 @@ func (h *Handlers) ListPriorities(w http.ResponseWriter, r *http.Request) {
 // @Tags        priorities
 // @Accept      json
 // @Produce     json
-// @Param       body  body      NameRequest  true  "Priority payload"
+// @Param       body  body      PriorityRequest  true  "Priority payload"
 // @Success     201   {object}  map[string]any
 // @Failure     400   {object}  map[string]any
 // @Failure     500   {object}  map[string]any
 // @Router      /api/priorities [post]
 func (h *Handlers) CreatePriority(w http.ResponseWriter, r *http.Request) {
-	var req NameRequest
+	var req PriorityRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
@@ -549,7 +851,11 @@ func (h *Handlers) CreatePriority(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 400, map[string]any{"error": "name required"})
 		return
 	}
-	id, err := h.repos.CreatePriority(r.Context(), req.Name)
+	if msg := penaltyRate(&req.PenaltyRate); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreatePriority(r.Context(), storage.Priority{Name: req.Name, PenaltyRate: req.PenaltyRate})
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -563,7 +869,7 @@ func (h *Handlers) CreatePriority(w http.ResponseWriter, r *http.Request) {
 // @Accept      json
 // @Produce     json
 // @Param       priorityId  path      int          true  "Priority ID"
-// @Param       body        body      NameRequest  true  "Priority payload"
+// @Param       body        body      PriorityRequest  true  "Priority payload"
 // @Success     200         {object}  map[string]any
 // @Failure     400         {object}  map[string]any
 // @Failure     500         {object}  map[string]any
@@ -574,12 +880,16 @@ func (h *Handlers) UpdatePriority(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 400, map[string]any{"error": "invalid priorityId"})
 		return
 	}
-	var req NameRequest
+	var req PriorityRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	if err := h.repos.UpdatePriority(r.Context(), id, req.Name); err != nil {
+	if msg := penaltyRate(&req.PenaltyRate); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if err := h.repos.UpdatePriority(r.Context(), storage.Priority{ID: id, Name: req.Name, PenaltyRate: req.PenaltyRate}); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
@@ -723,52 +1033,72 @@ func (h *Handlers) DeleteEquipmentCharacteristic(w http.ResponseWriter, r *http.
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// ListDeviceTypes godoc
-// @Summary     Список типов оборудования
-// @Tags        device_types
+// ListMaterialChangeovers godoc
+// @Summary     Матрица переналадки между материалами
+// @Tags        material_changeovers
 // @Produce     json
 // @Param       workspaceId  path      int  true  "Workspace ID"
-// @Success     200          {array}   storage.DeviceType
+// @Success     200          {array}   MaterialChangeoverDTO
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/device-types [get]
-func (h *Handlers) ListDeviceTypes(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/material-changeovers [get]
+func (h *Handlers) ListMaterialChangeovers(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	items, err := h.repos.ListDeviceTypes(r.Context(), workspaceID)
+	items, err := h.repos.ListMaterialChangeovers(r.Context(), workspaceID)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, items)
+	dtos := make([]MaterialChangeoverDTO, 0, len(items))
+	for _, c := range items {
+		dtos = append(dtos, MaterialChangeoverDTO{
+			ID:             c.ID,
+			FromMaterialID: c.FromMaterialID,
+			ToMaterialID:   c.ToMaterialID,
+			DurationMin:    int(c.Duration.Minutes()),
+			WorkspaceID:    c.WorkspaceID,
+		})
+	}
+	writeJSON(w, 200, dtos)
 }
 
-// CreateDeviceType godoc
-// @Summary     Создать тип оборудования
-// @Tags        device_types
+// CreateMaterialChangeover godoc
+// @Summary     Задать переналадку между материалами
+// @Description Если переход для этой пары материалов уже задан, его время перезаписывается.
+// @Tags        material_changeovers
 // @Accept      json
 // @Produce     json
-// @Param       workspaceId  path      int              true  "Workspace ID"
-// @Param       body         body      DeviceTypeRequest  true  "Device type payload"
+// @Param       workspaceId  path      int                        true  "Workspace ID"
+// @Param       body         body      MaterialChangeoverRequest  true  "Changeover payload"
 // @Success     201          {object}  map[string]any
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/device-types [post]
-func (h *Handlers) CreateDeviceType(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/material-changeovers [post]
+func (h *Handlers) CreateMaterialChangeover(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	var req DeviceTypeRequest
+	var req MaterialChangeoverRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	id, err := h.repos.CreateDeviceType(r.Context(), storage.DeviceType{Name: req.Name, EquipmentCharacteristicID: req.EquipmentCharacteristicID, WorkspaceID: workspaceID})
+	if msg := validateMaterialChangeover(req); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreateMaterialChangeover(r.Context(), storage.MaterialChangeover{
+		FromMaterialID: req.FromMaterialID,
+		ToMaterialID:   req.ToMaterialID,
+		Duration:       minutesToDuration(req.DurationMin),
+		WorkspaceID:    workspaceID,
+	})
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -776,29 +1106,33 @@ func (h *Handlers) CreateDeviceType(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 201, map[string]any{"id": id})
 }
 
-// UpdateDeviceType godoc
-// @Summary     Обновить тип оборудования
-// @Tags        device_types
+// UpdateMaterialChangeover godoc
+// @Summary     Обновить переналадку между материалами
+// @Tags        material_changeovers
 // @Accept      json
 // @Produce     json
-// @Param       deviceTypeId  path      int               true  "Device type ID"
-// @Param       workspace_id  query     int               true  "Workspace ID"
-// @Param       body          body      DeviceTypeRequest  true  "Device type payload"
+// @Param       changeoverId  path      int                        true  "Changeover ID"
+// @Param       workspace_id  query     int                        true  "Workspace ID"
+// @Param       body          body      MaterialChangeoverRequest  true  "Changeover payload"
 // @Success     200           {object}  map[string]any
 // @Failure     400           {object}  map[string]any
 // @Failure     500           {object}  map[string]any
-// @Router      /api/device-types/{deviceTypeId} [put]
-func (h *Handlers) UpdateDeviceType(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceTypeId")
+// @Router      /api/material-changeovers/{changeoverId} [put]
+func (h *Handlers) UpdateMaterialChangeover(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "changeoverId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceTypeId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid changeoverId"})
 		return
 	}
-	var req DeviceTypeRequest
+	var req MaterialChangeoverRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
+	if msg := validateMaterialChangeover(req); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
 	workspaceIDStr := r.URL.Query().Get("workspace_id")
 	if workspaceIDStr == "" {
 		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
@@ -809,91 +1143,128 @@ func (h *Handlers) UpdateDeviceType(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
 		return
 	}
-	if err := h.repos.UpdateDeviceType(r.Context(), storage.DeviceType{ID: id, Name: req.Name, EquipmentCharacteristicID: req.EquipmentCharacteristicID, WorkspaceID: workspaceID}); err != nil {
+	if err := h.repos.UpdateMaterialChangeover(r.Context(), storage.MaterialChangeover{
+		ID:             id,
+		FromMaterialID: req.FromMaterialID,
+		ToMaterialID:   req.ToMaterialID,
+		Duration:       minutesToDuration(req.DurationMin),
+		WorkspaceID:    workspaceID,
+	}); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// DeleteDeviceType godoc
-// @Summary     Удалить тип оборудования
-// @Tags        device_types
+// DeleteMaterialChangeover godoc
+// @Summary     Удалить переналадку между материалами
+// @Tags        material_changeovers
 // @Produce     json
-// @Param       deviceTypeId  path      int  true  "Device type ID"
+// @Param       changeoverId  path      int  true  "Changeover ID"
 // @Success     200           {object}  map[string]any
 // @Failure     400           {object}  map[string]any
 // @Failure     500           {object}  map[string]any
-// @Router      /api/device-types/{deviceTypeId} [delete]
-func (h *Handlers) DeleteDeviceType(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceTypeId")
+// @Router      /api/material-changeovers/{changeoverId} [delete]
+func (h *Handlers) DeleteMaterialChangeover(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "changeoverId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceTypeId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid changeoverId"})
 		return
 	}
-	if err := h.repos.DeleteDeviceType(r.Context(), id); err != nil {
+	if err := h.repos.DeleteMaterialChangeover(r.Context(), id); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// ListDevices godoc
-// @Summary     Список оборудования
-// @Tags        devices
+// ListMaterialStock godoc
+// @Summary     Склад материалов
+// @Description Остаток, резерв под запланированные задания в статусах pending и in_progress и свободный остаток по каждому материалу. low=true — только материалы с низким остатком.
+// @Tags        material_stock
 // @Produce     json
-// @Param       workspaceId  path      int  true  "Workspace ID"
-// @Success     200          {array}   storage.Device
+// @Param       workspaceId  path      int   true   "Workspace ID"
+// @Param       low          query     bool  false  "Только низкий остаток"
+// @Success     200          {array}   MaterialStockDTO
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/devices [get]
-func (h *Handlers) ListDevices(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/material-stock [get]
+func (h *Handlers) ListMaterialStock(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	items, err := h.repos.ListDevices(r.Context(), workspaceID)
+	lowOnly := false
+	if raw := r.URL.Query().Get("low"); raw != "" {
+		if lowOnly, err = strconv.ParseBool(raw); err != nil {
+			writeJSON(w, 400, map[string]any{"error": "invalid low"})
+			return
+		}
+	}
+	items, err := h.repos.ListMaterialStock(r.Context(), workspaceID)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, items)
+	dtos := make([]MaterialStockDTO, 0, len(items))
+	for _, s := range items {
+		if lowOnly && !s.Low() {
+			continue
+		}
+		dtos = append(dtos, MaterialStockDTO{
+			ID:          s.ID,
+			MaterialID:  s.MaterialID,
+			Unit:        s.Unit,
+			OnHand:      s.OnHand,
+			Reserved:    s.Reserved,
+			Free:        s.Free(),
+			LowLevel:    s.LowLevel,
+			Low:         s.Low(),
+			RestockAt:   s.RestockAt,
+			RestockQty:  s.RestockQty,
+			UnitCost:    s.UnitCost,
+			WorkspaceID: s.WorkspaceID,
+		})
+	}
+	writeJSON(w, 200, dtos)
 }
 
-// CreateDevice godoc
-// @Summary     Создать оборудование
-// @Tags        devices
+// CreateMaterialStock godoc
+// @Summary     Добавить материал на склад
+// @Tags        material_stock
 // @Accept      json
 // @Produce     json
-// @Param       workspaceId  path      int            true  "Workspace ID"
-// @Param       body         body      DeviceRequest  true  "Device payload"
+// @Param       workspaceId  path      int                   true  "Workspace ID"
+// @Param       body         body      MaterialStockRequest  true  "Stock payload"
 // @Success     201          {object}  map[string]any
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/devices [post]
-func (h *Handlers) CreateDevice(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/material-stock [post]
+func (h *Handlers) CreateMaterialStock(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	var req DeviceRequest
+	var req MaterialStockRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	if req.Name == "" {
-		writeJSON(w, 400, map[string]any{"error": "name required"})
+	if msg := validateMaterialStock(req); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
 		return
 	}
-	id, err := h.repos.CreateDevice(r.Context(), storage.Device{
-		Name:           req.Name,
-		PhotoURL:       req.PhotoURL,
-		AddInRecSystem: req.AddInRecSystem,
-		DeviceTypeID:   req.DeviceTypeID,
-		DeviceStateID:  req.DeviceStateID,
-		WorkspaceID:    workspaceID,
+	id, err := h.repos.CreateMaterialStock(r.Context(), storage.MaterialStock{
+		MaterialID:  req.MaterialID,
+		Unit:        req.Unit,
+		OnHand:      req.OnHand,
+		LowLevel:    req.LowLevel,
+		RestockAt:   req.RestockAt,
+		RestockQty:  req.RestockQty,
+		UnitCost:    req.UnitCost,
+		WorkspaceID: workspaceID,
 	})
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
@@ -902,29 +1273,34 @@ func (h *Handlers) CreateDevice(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 201, map[string]any{"id": id})
 }
 
-// UpdateDevice godoc
-// @Summary     Обновить оборудование
-// @Tags        devices
+// UpdateMaterialStock godoc
+// @Summary     Обновить остаток материала
+// @Description Остаток после поставки или инвентаризации задаётся здесь же; restock_at = null — поставка не ожидается.
+// @Tags        material_stock
 // @Accept      json
 // @Produce     json
-// @Param       deviceId      path      int            true  "Device ID"
-// @Param       workspace_id  query     int            true  "Workspace ID"
-// @Param       body          body      DeviceRequest  true  "Device payload"
+// @Param       stockId       path      int                   true  "Stock ID"
+// @Param       workspace_id  query     int                   true  "Workspace ID"
+// @Param       body          body      MaterialStockRequest  true  "Stock payload"
 // @Success     200           {object}  map[string]any
 // @Failure     400           {object}  map[string]any
 // @Failure     500           {object}  map[string]any
-// @Router      /api/devices/{deviceId} [put]
-func (h *Handlers) UpdateDevice(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceId")
+// @Router      /api/material-stock/{stockId} [put]
+func (h *Handlers) UpdateMaterialStock(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "stockId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid stockId"})
 		return
 	}
-	var req DeviceRequest
+	var req MaterialStockRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
+	if msg := validateMaterialStock(req); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
 	workspaceIDStr := r.URL.Query().Get("workspace_id")
 	if workspaceIDStr == "" {
 		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
@@ -935,14 +1311,16 @@ func (h *Handlers) UpdateDevice(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
 		return
 	}
-	if err := h.repos.UpdateDevice(r.Context(), storage.Device{
-		ID:             id,
-		Name:           req.Name,
-		PhotoURL:       req.PhotoURL,
-		AddInRecSystem: req.AddInRecSystem,
-		DeviceTypeID:   req.DeviceTypeID,
-		DeviceStateID:  req.DeviceStateID,
-		WorkspaceID:    workspaceID,
+	if err := h.repos.UpdateMaterialStock(r.Context(), storage.MaterialStock{
+		ID:          id,
+		MaterialID:  req.MaterialID,
+		Unit:        req.Unit,
+		OnHand:      req.OnHand,
+		LowLevel:    req.LowLevel,
+		RestockAt:   req.RestockAt,
+		RestockQty:  req.RestockQty,
+		UnitCost:    req.UnitCost,
+		WorkspaceID: workspaceID,
 	}); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -950,44 +1328,68 @@ func (h *Handlers) UpdateDevice(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// DeleteDevice godoc
-// @Summary     Удалить оборудование
-// @Tags        devices
+// DeleteMaterialStock godoc
+// @Summary     Убрать материал со склада
+// @Tags        material_stock
 // @Produce     json
-// @Param       deviceId  path      int  true  "Device ID"
-// @Success     200       {object}  map[string]any
-// @Failure     400       {object}  map[string]any
-// @Failure     500       {object}  map[string]any
-// @Router      /api/devices/{deviceId} [delete]
-func (h *Handlers) DeleteDevice(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceId")
+// @Param       stockId  path      int  true  "Stock ID"
+// @Success     200      {object}  map[string]any
+// @Failure     400      {object}  map[string]any
+// @Failure     500      {object}  map[string]any
+// @Router      /api/material-stock/{stockId} [delete]
+func (h *Handlers) DeleteMaterialStock(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "stockId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid stockId"})
 		return
 	}
-	if err := h.repos.DeleteDevice(r.Context(), id); err != nil {
+	if err := h.repos.DeleteMaterialStock(r.Context(), id); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// ListOperators godoc
-// @Summary     Список операторов
-// @Tags        operators
+func validateMaterialStock(req MaterialStockRequest) string {
+	if req.MaterialID <= 0 {
+		return "material_id required"
+	}
+	if req.OnHand < 0 || req.LowLevel < 0 || req.RestockQty < 0 {
+		return "on_hand, low_level and restock_qty must not be negative"
+	}
+	// mtrstk_unitcost — NUMERIC(12,4).
+	if req.UnitCost < 0 || req.UnitCost >= 1e8 {
+		return "unit_cost must be in [0, 100000000)"
+	}
+	return ""
+}
+
+func validateMaterialChangeover(req MaterialChangeoverRequest) string {
+	if req.FromMaterialID <= 0 || req.ToMaterialID <= 0 {
+		return "from_material_id and to_material_id required"
+	}
+	if req.DurationMin < 0 {
+		return "duration_min must not be negative"
+	}
+	return ""
+}
+
+// ListCharacteristics godoc
+// @Summary     Типизированные характеристики оборудования
+// @Tags        characteristics
 // @Produce     json
 // @Param       workspaceId  path      int  true  "Workspace ID"
-// @Success     200          {array}   storage.Operator
+// @Success     200          {array}   storage.Characteristic
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/operators [get]
-func (h *Handlers) ListOperators(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/characteristics [get]
+func (h *Handlers) ListCharacteristics(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	items, err := h.repos.ListOperators(r.Context(), workspaceID)
+	items, err := h.repos.ListCharacteristics(r.Context(), workspaceID)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -995,37 +1397,39 @@ func (h *Handlers) ListOperators(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, items)
 }
 
-// CreateOperator godoc
-// @Summary     Создать оператора
-// @Tags        operators
+// CreateCharacteristic godoc
+// @Summary     Создать характеристику оборудования
+// @Description kind: number (число с единицей unit), enum (значения из options, пусто — любые), bool, range (диапазон чисел).
+// @Tags        characteristics
 // @Accept      json
 // @Produce     json
-// @Param       workspaceId  path      int              true  "Workspace ID"
-// @Param       body         body      OperatorRequest  true  "Operator payload"
+// @Param       workspaceId  path      int                    true  "Workspace ID"
+// @Param       body         body      CharacteristicRequest  true  "Characteristic payload"
 // @Success     201          {object}  map[string]any
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/operators [post]
-func (h *Handlers) CreateOperator(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/characteristics [post]
+func (h *Handlers) CreateCharacteristic(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	var req OperatorRequest
+	var req CharacteristicRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	if req.FullName == "" || req.PhoneNumber == "" || req.UserLogin == "" {
-		writeJSON(w, 400, map[string]any{"error": "full_name, phone_number, user_login required"})
+	if msg := validateCharacteristic(req); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
 		return
 	}
-	id, err := h.repos.CreateOperator(r.Context(), storage.Operator{
-		FullName:    req.FullName,
-		PhoneNumber: req.PhoneNumber,
+	id, err := h.repos.CreateCharacteristic(r.Context(), storage.Characteristic{
+		Key:         strings.TrimSpace(req.Key),
+		Kind:        req.Kind,
+		Unit:        req.Unit,
+		Options:     req.Options,
 		WorkspaceID: workspaceID,
-		UserLogin:   req.UserLogin,
 	})
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
@@ -1034,29 +1438,33 @@ func (h *Handlers) CreateOperator(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 201, map[string]any{"id": id})
 }
 
-// UpdateOperator godoc
-// @Summary     Обновить оператора
-// @Tags        operators
+// UpdateCharacteristic godoc
+// @Summary     Обновить характеристику оборудования
+// @Tags        characteristics
 // @Accept      json
 // @Produce     json
-// @Param       operatorId    path      int              true  "Operator ID"
-// @Param       workspace_id  query     int              true  "Workspace ID"
-// @Param       body          body      OperatorRequest  true  "Operator payload"
-// @Success     200           {object}  map[string]any
-// @Failure     400           {object}  map[string]any
-// @Failure     500           {object}  map[string]any
-// @Router      /api/operators/{operatorId} [put]
-func (h *Handlers) UpdateOperator(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "operatorId")
+// @Param       characteristicId  path      int                    true  "Characteristic ID"
+// @Param       workspace_id      query     int                    true  "Workspace ID"
+// @Param       body              body      CharacteristicRequest  true  "Characteristic payload"
+// @Success     200               {object}  map[string]any
+// @Failure     400               {object}  map[string]any
+// @Failure     500               {object}  map[string]any
+// @Router      /api/characteristics/{characteristicId} [put]
+func (h *Handlers) UpdateCharacteristic(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "characteristicId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid operatorId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid characteristicId"})
 		return
 	}
-	var req OperatorRequest
+	var req CharacteristicRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
+	if msg := validateCharacteristic(req); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
 	workspaceIDStr := r.URL.Query().Get("workspace_id")
 	if workspaceIDStr == "" {
 		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
@@ -1067,12 +1475,13 @@ func (h *Handlers) UpdateOperator(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
 		return
 	}
-	if err := h.repos.UpdateOperator(r.Context(), storage.Operator{
+	if err := h.repos.UpdateCharacteristic(r.Context(), storage.Characteristic{
 		ID:          id,
-		FullName:    req.FullName,
-		PhoneNumber: req.PhoneNumber,
+		Key:         strings.TrimSpace(req.Key),
+		Kind:        req.Kind,
+		Unit:        req.Unit,
+		Options:     req.Options,
 		WorkspaceID: workspaceID,
-		UserLogin:   req.UserLogin,
 	}); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -1080,44 +1489,45 @@ func (h *Handlers) UpdateOperator(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// DeleteOperator godoc
-// @Summary     Удалить оператора
-// @Tags        operators
+// DeleteCharacteristic godoc
+// @Summary     Удалить характеристику оборудования
+// @Description Вместе с характеристикой удаляются её значения и требования заданий к ней.
+// @Tags        characteristics
 // @Produce     json
-// @Param       operatorId  path      int  true  "Operator ID"
-// @Success     200         {object}  map[string]any
-// @Failure     400         {object}  map[string]any
-// @Failure     500         {object}  map[string]any
-// @Router      /api/operators/{operatorId} [delete]
-func (h *Handlers) DeleteOperator(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "operatorId")
+// @Param       characteristicId  path      int  true  "Characteristic ID"
+// @Success     200               {object}  map[string]any
+// @Failure     400               {object}  map[string]any
+// @Failure     500               {object}  map[string]any
+// @Router      /api/characteristics/{characteristicId} [delete]
+func (h *Handlers) DeleteCharacteristic(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "characteristicId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid operatorId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid characteristicId"})
 		return
 	}
-	if err := h.repos.DeleteOperator(r.Context(), id); err != nil {
+	if err := h.repos.DeleteCharacteristic(r.Context(), id); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// ListOperatorCompetencies godoc
-// @Summary     Список компетенций операторов
-// @Tags        operator_competencies
+// ListCharacteristicValues godoc
+// @Summary     Значения характеристик у типов оборудования и устройств
+// @Tags        characteristics
 // @Produce     json
 // @Param       workspaceId  path      int  true  "Workspace ID"
-// @Success     200          {array}   storage.OperatorCompetency
+// @Success     200          {array}   storage.CharacteristicValue
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/operator-competencies [get]
-func (h *Handlers) ListOperatorCompetencies(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/characteristic-values [get]
+func (h *Handlers) ListCharacteristicValues(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	items, err := h.repos.ListOperatorCompetencies(r.Context(), workspaceID)
+	items, err := h.repos.ListCharacteristicValues(r.Context(), workspaceID)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -1125,123 +1535,106 @@ func (h *Handlers) ListOperatorCompetencies(w http.ResponseWriter, r *http.Reque
 	writeJSON(w, 200, items)
 }
 
-// CreateOperatorCompetency godoc
-// @Summary     Создать компетенцию оператора
-// @Tags        operator_competencies
+// SetCharacteristicValue godoc
+// @Summary     Задать значение характеристики
+// @Description Значение задаётся либо типу оборудования (device_type_id), либо устройству (device_id); значение устройства перекрывает значение его типа. Повторный вызов перезаписывает значение. Поля по виду: number — num, range — num и num_max, bool — bool, enum — options.
+// @Tags        characteristics
 // @Accept      json
 // @Produce     json
 // @Param       workspaceId  path      int                         true  "Workspace ID"
-// @Param       body         body      OperatorCompetencyRequest  true  "Competency payload"
+// @Param       body         body      CharacteristicValueRequest  true  "Value payload"
 // @Success     201          {object}  map[string]any
 // @Failure     400          {object}  map[string]any
+// @Failure     404          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/operator-competencies [post]
-func (h *Handlers) CreateOperatorCompetency(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/characteristic-values [post]
+func (h *Handlers) SetCharacteristicValue(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	var req OperatorCompetencyRequest
+	var req CharacteristicValueRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	id, err := h.repos.CreateOperatorCompetency(r.Context(), storage.OperatorCompetency{
-		WorkspaceID:  workspaceID,
-		DeviceTypeID: req.DeviceTypeID,
-		OperatorID:   req.OperatorID,
-	})
-	if err != nil {
-		writeJSON(w, 500, map[string]any{"error": err.Error()})
+	if (req.DeviceTypeID > 0) == (req.DeviceID > 0) {
+		writeJSON(w, 400, map[string]any{"error": "exactly one of device_type_id and device_id required"})
 		return
 	}
-	writeJSON(w, 201, map[string]any{"id": id})
-}
-
-// UpdateOperatorCompetency godoc
-// @Summary     Обновить компетенцию оператора
-// @Tags        operator_competencies
-// @Accept      json
-// @Produce     json
-// @Param       competencyId  path      int                         true  "Competency ID"
-// @Param       workspace_id  query     int                         true  "Workspace ID"
-// @Param       body          body      OperatorCompetencyRequest  true  "Competency payload"
-// @Success     200           {object}  map[string]any
-// @Failure     400           {object}  map[string]any
-// @Failure     500           {object}  map[string]any
-// @Router      /api/operator-competencies/{competencyId} [put]
-func (h *Handlers) UpdateOperatorCompetency(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "competencyId")
-	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid competencyId"})
+	c, err := h.repos.GetCharacteristic(r.Context(), req.CharacteristicID)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "characteristic not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	var req OperatorCompetencyRequest
-	if err := decodeJSON(r, &req); err != nil {
-		writeJSON(w, 400, map[string]any{"error": "bad json"})
+	if c.WorkspaceID != workspaceID {
+		writeJSON(w, 400, map[string]any{"error": "characteristic belongs to another workspace"})
 		return
 	}
-	workspaceIDStr := r.URL.Query().Get("workspace_id")
-	if workspaceIDStr == "" {
-		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
-		return
+	v := storage.CharacteristicValue{
+		CharacteristicID: req.CharacteristicID,
+		DeviceTypeID:     req.DeviceTypeID,
+		DeviceID:         req.DeviceID,
+		Num:              req.Num,
+		NumMax:           req.NumMax,
+		Bool:             req.Bool,
+		Options:          req.Options,
 	}
-	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
-	if err != nil || workspaceID <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+	if err := service.ValidateCharacteristicValue(c, v); err != nil {
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
 		return
 	}
-	if err := h.repos.UpdateOperatorCompetency(r.Context(), storage.OperatorCompetency{
-		ID:           id,
-		WorkspaceID:  workspaceID,
-		DeviceTypeID: req.DeviceTypeID,
-		OperatorID:   req.OperatorID,
-	}); err != nil {
+	id, err := h.repos.SetCharacteristicValue(r.Context(), v)
+	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, map[string]any{"ok": true})
+	writeJSON(w, 201, map[string]any{"id": id})
 }
 
-// DeleteOperatorCompetency godoc
-// @Summary     Удалить компетенцию оператора
-// @Tags        operator_competencies
+// DeleteCharacteristicValue godoc
+// @Summary     Удалить значение характеристики
+// @Tags        characteristics
 // @Produce     json
-// @Param       competencyId  path      int  true  "Competency ID"
-// @Success     200           {object}  map[string]any
-// @Failure     400           {object}  map[string]any
-// @Failure     500           {object}  map[string]any
-// @Router      /api/operator-competencies/{competencyId} [delete]
-func (h *Handlers) DeleteOperatorCompetency(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "competencyId")
+// @Param       valueId  path      int  true  "Value ID"
+// @Success     200      {object}  map[string]any
+// @Failure     400      {object}  map[string]any
+// @Failure     500      {object}  map[string]any
+// @Router      /api/characteristic-values/{valueId} [delete]
+func (h *Handlers) DeleteCharacteristicValue(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "valueId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid competencyId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid valueId"})
 		return
 	}
-	if err := h.repos.DeleteOperatorCompetency(r.Context(), id); err != nil {
+	if err := h.repos.DeleteCharacteristicValue(r.Context(), id); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// ListOperatorDevices godoc
-// @Summary     Список связок оператор-оборудование
-// @Tags        operator_devices
+// ListTaskRequirements godoc
+// @Summary     Требования задания к оборудованию
+// @Tags        device_tasks
 // @Produce     json
-// @Param       workspaceId  path      int  true  "Workspace ID"
-// @Success     200          {array}   storage.OperatorDevice
-// @Failure     400          {object}  map[string]any
-// @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/operator-devices [get]
-func (h *Handlers) ListOperatorDevices(w http.ResponseWriter, r *http.Request) {
-	workspaceID, err := parseIDParam(r, "workspaceId")
-	if err != nil || workspaceID <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+// @Param       deviceTaskId  path      int  true  "Device task ID"
+// @Success     200           {array}   storage.TaskRequirement
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId}/requirements [get]
+func (h *Handlers) ListTaskRequirements(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
 		return
 	}
-	items, err := h.repos.ListOperatorDevices(r.Context(), workspaceID)
+	items, err := h.repos.ListTaskRequirements(r.Context(), id)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -1249,272 +1642,2579 @@ func (h *Handlers) ListOperatorDevices(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, items)
 }
 
-// CreateOperatorDevice godoc
-// @Summary     Создать связку оператор-оборудование
-// @Tags        operator_devices
+// CreateTaskRequirement godoc
+// @Summary     Добавить требование задания к оборудованию
+// @Description Например, «объём печати ≥ 300 мм» (gte) или «материал ∈ {PETG, ABS}» (in). Планировщик ставит задание только на оборудование, удовлетворяющее всем его требованиям; нет значения характеристики — требование не выполнено.
+// @Tags        device_tasks
 // @Accept      json
 // @Produce     json
-// @Param       workspaceId  path      int                      true  "Workspace ID"
-// @Param       body         body      OperatorDeviceRequest  true  "Operator device payload"
-// @Success     201          {object}  map[string]any
-// @Failure     400          {object}  map[string]any
-// @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/operator-devices [post]
-func (h *Handlers) CreateOperatorDevice(w http.ResponseWriter, r *http.Request) {
-	var req OperatorDeviceRequest
+// @Param       deviceTaskId  path      int                     true  "Device task ID"
+// @Param       body          body      TaskRequirementRequest  true  "Requirement payload"
+// @Success     201           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     404           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId}/requirements [post]
+func (h *Handlers) CreateTaskRequirement(w http.ResponseWriter, r *http.Request) {
+	taskID, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || taskID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	var req TaskRequirementRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	id, err := h.repos.CreateOperatorDevice(r.Context(), storage.OperatorDevice{OperatorID: req.OperatorID, DeviceID: req.DeviceID})
+	task, err := h.repos.GetDeviceTask(r.Context(), taskID)
 	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "not found"})
+			return
+		}
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 201, map[string]any{"id": id})
-}
-
-// UpdateOperatorDevice godoc
-// @Summary     Обновить связку оператор-оборудование
-// @Tags        operator_devices
-// @Accept      json
-// @Produce     json
-// @Param       operatorDeviceId  path      int                      true  "Operator device ID"
-// @Param       body              body      OperatorDeviceRequest  true  "Operator device payload"
-// @Success     200               {object}  map[string]any
-// @Failure     400               {object}  map[string]any
-// @Failure     500               {object}  map[string]any
-// @Router      /api/operator-devices/{operatorDeviceId} [put]
-func (h *Handlers) UpdateOperatorDevice(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "operatorDeviceId")
-	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid operatorDeviceId"})
+	c, err := h.repos.GetCharacteristic(r.Context(), req.CharacteristicID)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "characteristic not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	var req OperatorDeviceRequest
-	if err := decodeJSON(r, &req); err != nil {
-		writeJSON(w, 400, map[string]any{"error": "bad json"})
+	if c.WorkspaceID != task.WorkspaceID {
+		writeJSON(w, 400, map[string]any{"error": "characteristic belongs to another workspace"})
 		return
 	}
-	if err := h.repos.UpdateOperatorDevice(r.Context(), storage.OperatorDevice{ID: id, OperatorID: req.OperatorID, DeviceID: req.DeviceID}); err != nil {
+	q := storage.TaskRequirement{
+		DeviceTaskID:     taskID,
+		CharacteristicID: req.CharacteristicID,
+		Op:               req.Op,
+		Num:              req.Num,
+		NumMax:           req.NumMax,
+		Bool:             req.Bool,
+		Options:          req.Options,
+	}
+	if err := service.ValidateRequirement(c, q); err != nil {
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+		return
+	}
+	id, err := h.repos.CreateTaskRequirement(r.Context(), q)
+	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, map[string]any{"ok": true})
+	writeJSON(w, 201, map[string]any{"id": id})
 }
 
-// DeleteOperatorDevice godoc
-// @Summary     Удалить связку оператор-оборудование
-// @Tags        operator_devices
+// DeleteTaskRequirement godoc
+// @Summary     Удалить требование задания
+// @Tags        device_tasks
 // @Produce     json
-// @Param       operatorDeviceId  path      int  true  "Operator device ID"
-// @Success     200               {object}  map[string]any
-// @Failure     400               {object}  map[string]any
-// @Failure     500               {object}  map[string]any
-// @Router      /api/operator-devices/{operatorDeviceId} [delete]
-func (h *Handlers) DeleteOperatorDevice(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "operatorDeviceId")
+// @Param       requirementId  path      int  true  "Requirement ID"
+// @Success     200            {object}  map[string]any
+// @Failure     400            {object}  map[string]any
+// @Failure     500            {object}  map[string]any
+// @Router      /api/task-requirements/{requirementId} [delete]
+func (h *Handlers) DeleteTaskRequirement(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "requirementId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid operatorDeviceId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid requirementId"})
 		return
 	}
-	if err := h.repos.DeleteOperatorDevice(r.Context(), id); err != nil {
+	if err := h.repos.DeleteTaskRequirement(r.Context(), id); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// ListDeviceTaskTypes godoc
-// @Summary     Список типов задач оборудования
-// @Tags        device_task_types
+// GetLabourRules godoc
+// @Summary     Правила рабочего времени операторов
+// @Tags        labour_rules
 // @Produce     json
 // @Param       workspaceId  path      int  true  "Workspace ID"
-// @Success     200          {array}   storage.DeviceTaskType
+// @Success     200          {object}  LabourRulesDTO
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/device-task-types [get]
-func (h *Handlers) ListDeviceTaskTypes(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/labour-rules [get]
+func (h *Handlers) GetLabourRules(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	items, err := h.repos.ListDeviceTaskTypes(r.Context(), workspaceID)
+	rules, err := h.repos.GetLabourRules(r.Context(), workspaceID)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, items)
+	dto := LabourRulesDTO{
+		MaxDayMin:      int(rules.MaxDay.Minutes()),
+		MaxWeekMin:     int(rules.MaxWeek.Minutes()),
+		BreakMin:       int(rules.Break.Minutes()),
+		MinRestMin:     int(rules.MinRest.Minutes()),
+		FairnessWeight: rules.FairnessWeight,
+		FairnessPeriod: rules.FairnessPeriod,
+	}
+	if rules.Break > 0 {
+		dto.BreakStart = time.Time{}.Add(rules.BreakStart).Format("15:04")
+	}
+	writeJSON(w, 200, dto)
 }
 
-// GetDeviceTaskType godoc
-// @Summary     Получить тип задачи оборудования
-// @Tags        device_task_types
+// SetLabourRules godoc
+// @Summary     Задать правила рабочего времени операторов
+// @Description Планировщик не даёт оператору работать над заданиями дольше дневного и недельного предела, во время перерыва и без отдыха между сменами. С fairness_weight > 0 он выравнивает загрузку допущенных операторов.
+// @Tags        labour_rules
+// @Accept      json
 // @Produce     json
-// @Param       deviceTaskTypeId  path      int  true  "Device task type ID"
-// @Success     200               {object}  storage.DeviceTaskType
-// @Failure     400               {object}  map[string]any
-// @Failure     500               {object}  map[string]any
-// @Router      /api/device-task-types/{deviceTaskTypeId} [get]
-func (h *Handlers) GetDeviceTaskType(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceTaskTypeId")
-	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskTypeId"})
+// @Param       workspaceId  path      int             true  "Workspace ID"
+// @Param       body         body      LabourRulesDTO  true  "Labour rules"
+// @Success     200          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/labour-rules [put]
+func (h *Handlers) SetLabourRules(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	item, err := h.repos.GetDeviceTaskType(r.Context(), id)
-	if err != nil {
-		if isNotFound(err) {
-			writeJSON(w, 404, map[string]any{"error": "device task type not found"})
-			return
-		}
+	var req LabourRulesDTO
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	rules, msg := labourRules(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	rules.WorkspaceID = workspaceID
+	if err := h.repos.SetLabourRules(r.Context(), rules); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, item)
+	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// CreateDeviceTaskType godoc
-// @Summary     Создать тип задачи оборудования
-// @Tags        device_task_types
-// @Accept      json
+// labourRules проверяет правила рабочего времени: перерыв должен целиком
+// лежать в сутках, а дневной предел — не превышать недельный.
+func labourRules(req LabourRulesDTO) (storage.LabourRules, string) {
+	if req.MaxDayMin < 0 || req.MaxWeekMin < 0 || req.BreakMin < 0 || req.MinRestMin < 0 {
+		return storage.LabourRules{}, "labour rules must not be negative"
+	}
+	if req.MaxDayMin > 0 && req.MaxWeekMin > 0 && req.MaxDayMin > req.MaxWeekMin {
+		return storage.LabourRules{}, "max_day_min must not exceed max_week_min"
+	}
+	// lbrrl_fairnessweight — NUMERIC(8,2).
+	if req.FairnessWeight < 0 || req.FairnessWeight >= 1e6 {
+		return storage.LabourRules{}, "fairness_weight must be in [0, 1000000)"
+	}
+	switch req.FairnessPeriod {
+	case "":
+		req.FairnessPeriod = storage.FairnessPeriodDay
+	case storage.FairnessPeriodDay, storage.FairnessPeriodWeek:
+	default:
+		return storage.LabourRules{}, "fairness_period must be day or week"
+	}
+	rules := storage.LabourRules{
+		MaxDay:         minutesToDuration(req.MaxDayMin),
+		MaxWeek:        minutesToDuration(req.MaxWeekMin),
+		Break:          minutesToDuration(req.BreakMin),
+		MinRest:        minutesToDuration(req.MinRestMin),
+		FairnessWeight: req.FairnessWeight,
+		FairnessPeriod: req.FairnessPeriod,
+	}
+	if req.BreakMin > 0 {
+		at, err := time.Parse("15:04", req.BreakStart)
+		if err != nil {
+			return storage.LabourRules{}, "break_start must be HH:MM"
+		}
+		rules.BreakStart = time.Duration(at.Hour())*time.Hour + time.Duration(at.Minute())*time.Minute
+		if rules.BreakStart+rules.Break > 24*time.Hour {
+			return storage.LabourRules{}, "break must end before midnight"
+		}
+	}
+	return rules, ""
+}
+
+// GetPriorityAging godoc
+// @Summary     Старение приоритета
+// @Tags        priority_aging
 // @Produce     json
-// @Param       workspaceId  path      int                   true  "Workspace ID"
-// @Param       body         body      DeviceTaskTypeRequest  true  "Device task type payload"
-// @Success     201          {object}  map[string]any
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {object}  PriorityAgingDTO
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/device-task-types [post]
-func (h *Handlers) CreateDeviceTaskType(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/priority-aging [get]
+func (h *Handlers) GetPriorityAging(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	var req DeviceTaskTypeRequest
-	if err := decodeJSON(r, &req); err != nil {
-		writeJSON(w, 400, map[string]any{"error": "bad json"})
-		return
-	}
-	id, err := h.repos.CreateDeviceTaskType(r.Context(), storage.DeviceTaskType{Name: req.Name, WorkspaceID: workspaceID})
+	aging, err := h.repos.GetPriorityAging(r.Context(), workspaceID)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 201, map[string]any{"id": id})
+	writeJSON(w, 200, PriorityAgingDTO{
+		Mode:      aging.Mode,
+		WindowMin: int(aging.Window.Minutes()),
+		StepMin:   int(aging.Step.Minutes()),
+	})
 }
 
-// UpdateDeviceTaskType godoc
-// @Summary     Обновить тип задачи оборудования
-// @Tags        device_task_types
+// SetPriorityAging godoc
+// @Summary     Задать старение приоритета
+// @Description Со старением планировщик берёт задания сначала по эффективному приоритету, затем по сроку; без него — по сроку, затем по приоритету. Эффективный приоритет каждого задания — GET /api/workspaces/{workspaceId}/plan/priorities.
+// @Tags        priority_aging
 // @Accept      json
 // @Produce     json
-// @Param       deviceTaskTypeId  path      int                   true  "Device task type ID"
-// @Param       workspace_id      query     int                   true  "Workspace ID"
-// @Param       body              body      DeviceTaskTypeRequest  true  "Device task type payload"
-// @Success     200               {object}  map[string]any
-// @Failure     400               {object}  map[string]any
-// @Failure     500               {object}  map[string]any
-// @Router      /api/device-task-types/{deviceTaskTypeId} [put]
-func (h *Handlers) UpdateDeviceTaskType(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceTaskTypeId")
-	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskTypeId"})
+// @Param       workspaceId  path      int               true  "Workspace ID"
+// @Param       body         body      PriorityAgingDTO  true  "Priority aging"
+// @Success     200          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/priority-aging [put]
+func (h *Handlers) SetPriorityAging(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	var req DeviceTaskTypeRequest
+	var req PriorityAgingDTO
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	workspaceIDStr := r.URL.Query().Get("workspace_id")
-	if workspaceIDStr == "" {
-		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
-		return
-	}
-	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
-	if err != nil || workspaceID <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+	aging, msg := priorityAging(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
 		return
 	}
-	if err := h.repos.UpdateDeviceTaskType(r.Context(), storage.DeviceTaskType{ID: id, Name: req.Name, WorkspaceID: workspaceID}); err != nil {
+	aging.WorkspaceID = workspaceID
+	if err := h.repos.SetPriorityAging(r.Context(), aging); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// DeleteDeviceTaskType godoc
-// @Summary     Удалить тип задачи оборудования
-// @Tags        device_task_types
+// priorityAging проверяет правило старения: окно не длиннее горизонта
+// планирования, шаг — не длиннее окна.
+func priorityAging(req PriorityAgingDTO) (storage.PriorityAging, string) {
+	switch req.Mode {
+	case "", storage.AgingNone:
+		return storage.PriorityAging{Mode: storage.AgingNone}, ""
+	case storage.AgingDeadline, storage.AgingSlack:
+	default:
+		return storage.PriorityAging{}, "mode must be none, deadline or slack"
+	}
+	if req.WindowMin <= 0 || req.WindowMin > 365*24*60 {
+		return storage.PriorityAging{}, "window_min must be in [1, 525600]"
+	}
+	if req.StepMin <= 0 || req.StepMin > req.WindowMin {
+		return storage.PriorityAging{}, "step_min must be in [1, window_min]"
+	}
+	return storage.PriorityAging{
+		Mode:   req.Mode,
+		Window: minutesToDuration(req.WindowMin),
+		Step:   minutesToDuration(req.StepMin),
+	}, ""
+}
+
+// GetEnergySchedule godoc
+// @Summary     Тарифный план электроэнергии
+// @Tags        energy
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {object}  EnergyScheduleDTO
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/energy-tariffs [get]
+func (h *Handlers) GetEnergySchedule(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	schedule, err := h.repos.GetEnergySchedule(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	dto := EnergyScheduleDTO{
+		BasePrice:  schedule.BasePrice,
+		CostWeight: schedule.CostWeight,
+		Tariffs:    make([]EnergyTariffDTO, 0, len(schedule.Tariffs)),
+	}
+	for _, t := range schedule.Tariffs {
+		dto.Tariffs = append(dto.Tariffs, EnergyTariffDTO{
+			Start: time.Time{}.Add(t.Start).Format("15:04"),
+			End:   time.Time{}.Add(t.End).Format("15:04"),
+			Price: t.Price,
+		})
+	}
+	writeJSON(w, 200, dto)
+}
+
+// SetEnergySchedule godoc
+// @Summary     Задать тарифный план электроэнергии
+// @Description Заменяет окна тарифов целиком. Энергия задания — мощность оборудования (своя или типа) на длительность слота по цене окон. С cost_weight > 0 планировщик переносит задания в дешёвые окна, если они успевают к дедлайну, а задания без оператора могут допечатываться ночью.
+// @Tags        energy
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int                true  "Workspace ID"
+// @Param       body         body      EnergyScheduleDTO  true  "Energy schedule"
+// @Success     200          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/energy-tariffs [put]
+func (h *Handlers) SetEnergySchedule(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req EnergyScheduleDTO
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	schedule, msg := energySchedule(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	schedule.WorkspaceID = workspaceID
+	if err := h.repos.SetEnergySchedule(r.Context(), schedule); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// energySchedule проверяет тарифный план: окна не пустые и не пересекаются.
+func energySchedule(req EnergyScheduleDTO) (storage.EnergySchedule, string) {
+	// enrsch_baseprice, enrtrf_price — NUMERIC(10,4); enrsch_costweight — NUMERIC(8,2).
+	if req.BasePrice < 0 || req.BasePrice >= 1e6 {
+		return storage.EnergySchedule{}, "base_price must be in [0, 1000000)"
+	}
+	if req.CostWeight < 0 || req.CostWeight >= 1e6 {
+		return storage.EnergySchedule{}, "cost_weight must be in [0, 1000000)"
+	}
+	schedule := storage.EnergySchedule{BasePrice: req.BasePrice, CostWeight: req.CostWeight}
+	var taken [24 * 60]bool
+	for _, t := range req.Tariffs {
+		if t.Price < 0 || t.Price >= 1e6 {
+			return storage.EnergySchedule{}, "tariff price must be in [0, 1000000)"
+		}
+		start, err := time.Parse("15:04", t.Start)
+		if err != nil {
+			return storage.EnergySchedule{}, "tariff start must be HH:MM"
+		}
+		end, err := time.Parse("15:04", t.End)
+		if err != nil {
+			return storage.EnergySchedule{}, "tariff end must be HH:MM"
+		}
+		from, to := start.Hour()*60+start.Minute(), end.Hour()*60+end.Minute()
+		if from == to {
+			return storage.EnergySchedule{}, "tariff window must not be empty"
+		}
+		for m := from; m != to; m = (m + 1) % len(taken) {
+			if taken[m] {
+				return storage.EnergySchedule{}, "tariff windows must not overlap"
+			}
+			taken[m] = true
+		}
+		schedule.Tariffs = append(schedule.Tariffs, storage.EnergyTariff{
+			Start: minutesToDuration(from),
+			End:   minutesToDuration(to),
+			Price: t.Price,
+		})
+	}
+	return schedule, ""
+}
+
+// powerKW проверяет мощность оборудования: dvc_powerkw, dvctp_powerkw — NUMERIC(8,3).
+func powerKW(p *float64) string {
+	if p != nil && (*p < 0 || *p >= 1e5) {
+		return "power_kw must be in [0, 100000)"
+	}
+	return ""
+}
+
+// hourlyRate проверяет ставку оборудования или оператора: dvc_hourlyrate, oprt_hourlyrate — NUMERIC(10,2).
+func hourlyRate(rate float64) string {
+	if rate < 0 || rate >= 1e8 {
+		return "hourly_rate must be in [0, 100000000)"
+	}
+	return ""
+}
+
+// cooldownMin проверяет остывание оборудования: не больше недели.
+func cooldownMin(m int) string {
+	if m < 0 || m > 7*24*60 {
+		return "cooldown_min must be in [0, 10080]"
+	}
+	return ""
+}
+
+// penaltyRate проверяет штраф за час опоздания: dvctsk_penaltyrate, prts_penaltyrate — NUMERIC(12,2).
+func penaltyRate(rate *float64) string {
+	if rate != nil && (*rate < 0 || *rate >= 1e10) {
+		return "penalty_rate must be in [0, 10000000000)"
+	}
+	return ""
+}
+
+// ListDevicePools godoc
+// @Summary     Пулы оборудования
+// @Tags        device_pools
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   storage.DevicePool
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/device-pools [get]
+func (h *Handlers) ListDevicePools(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListDevicePools(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, items)
+}
+
+// CreateDevicePool godoc
+// @Summary     Создать пул оборудования
+// @Description Задание на пул (device_pool_id) планировщик ставит на лучшее оборудование пула.
+// @Tags        device_pools
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int                true  "Workspace ID"
+// @Param       body         body      DevicePoolRequest  true  "Pool payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/device-pools [post]
+func (h *Handlers) CreateDevicePool(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req DevicePoolRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	devices, err := h.repos.ListDevices(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if msg := validateDevicePool(req, devices); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreateDevicePool(r.Context(), storage.DevicePool{
+		Name:        strings.TrimSpace(req.Name),
+		WorkspaceID: workspaceID,
+		DeviceIDs:   req.DeviceIDs,
+	})
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// UpdateDevicePool godoc
+// @Summary     Обновить пул оборудования
+// @Description Состав пула заменяется целиком. Задания на выбывшем оборудовании пересчёт плана переносит на оборудование пула.
+// @Tags        device_pools
+// @Accept      json
+// @Produce     json
+// @Param       poolId        path      int                true  "Pool ID"
+// @Param       workspace_id  query     int                true  "Workspace ID"
+// @Param       body          body      DevicePoolRequest  true  "Pool payload"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-pools/{poolId} [put]
+func (h *Handlers) UpdateDevicePool(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "poolId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid poolId"})
+		return
+	}
+	var req DevicePoolRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	workspaceIDStr := r.URL.Query().Get("workspace_id")
+	if workspaceIDStr == "" {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
+		return
+	}
+	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+		return
+	}
+	devices, err := h.repos.ListDevices(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if msg := validateDevicePool(req, devices); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if err := h.repos.UpdateDevicePool(r.Context(), storage.DevicePool{
+		ID:          id,
+		Name:        strings.TrimSpace(req.Name),
+		WorkspaceID: workspaceID,
+		DeviceIDs:   req.DeviceIDs,
+	}); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// DeleteDevicePool godoc
+// @Summary     Удалить пул оборудования
+// @Description Задания на пул остаются на выбранном для них оборудовании.
+// @Tags        device_pools
+// @Produce     json
+// @Param       poolId  path      int  true  "Pool ID"
+// @Success     200     {object}  map[string]any
+// @Failure     400     {object}  map[string]any
+// @Failure     500     {object}  map[string]any
+// @Router      /api/device-pools/{poolId} [delete]
+func (h *Handlers) DeleteDevicePool(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "poolId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid poolId"})
+		return
+	}
+	if err := h.repos.DeleteDevicePool(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+func validateDevicePool(req DevicePoolRequest, devices []storage.Device) string {
+	if strings.TrimSpace(req.Name) == "" {
+		return "name required"
+	}
+	known := make(map[int64]bool, len(devices))
+	for _, d := range devices {
+		known[d.ID] = true
+	}
+	seen := map[int64]bool{}
+	for _, id := range req.DeviceIDs {
+		if !known[id] {
+			return fmt.Sprintf("device %d not found in workspace", id)
+		}
+		if seen[id] {
+			return fmt.Sprintf("device %d listed twice", id)
+		}
+		seen[id] = true
+	}
+	return ""
+}
+
+// taskDevice — оборудование задания. Для задания на пул или тип без device_id
+// берётся первое включённое в рекомендации оборудование пула или типа, дальше
+// его выбирает планировщик. Непустое сообщение — ошибка запроса.
+func (h *Handlers) taskDevice(ctx context.Context, workspaceID int64, req DeviceTaskRequest) (int64, string, error) {
+	if req.DevicePoolID > 0 && req.TargetTypeID > 0 {
+		return 0, "device_pool_id and target_type_id are mutually exclusive", nil
+	}
+	if req.DevicePoolID < 0 || req.TargetTypeID < 0 {
+		return 0, "device_pool_id and target_type_id must not be negative", nil
+	}
+	if req.DeviceID > 0 || (req.DevicePoolID == 0 && req.TargetTypeID == 0) {
+		return req.DeviceID, "", nil
+	}
+	devices, err := h.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return 0, "", err
+	}
+	if req.TargetTypeID > 0 {
+		id, ok := routingDevice(devices, req.TargetTypeID, 0)
+		if !ok {
+			return 0, fmt.Sprintf("no device of type %d", req.TargetTypeID), nil
+		}
+		return id, "", nil
+	}
+	pool, err := h.repos.GetDevicePool(ctx, req.DevicePoolID)
+	if err != nil {
+		if isNotFound(err) {
+			return 0, "device pool not found", nil
+		}
+		return 0, "", err
+	}
+	if pool.WorkspaceID != workspaceID {
+		return 0, "device pool belongs to another workspace", nil
+	}
+	for _, d := range devices {
+		if slices.Contains(pool.DeviceIDs, d.ID) && (d.AddInRecSystem == nil || *d.AddInRecSystem) {
+			return d.ID, "", nil
+		}
+	}
+	return 0, "device pool is empty", nil
+}
+
+// taskOrder проверяет заказ задания и возвращает номер документа: указанный
+// в запросе или, если он пуст, номер заказа. Непустое сообщение — ошибка
+// запроса.
+func (h *Handlers) taskOrder(ctx context.Context, workspaceID int64, req DeviceTaskRequest) (string, string, error) {
+	if req.OrderID < 0 {
+		return "", "order_id must not be negative", nil
+	}
+	if req.OrderID == 0 {
+		return req.DocNum, "", nil
+	}
+	order, err := h.repos.GetCustomerOrder(ctx, req.OrderID)
+	if err != nil {
+		if isNotFound(err) {
+			return "", "customer order not found", nil
+		}
+		return "", "", err
+	}
+	if order.WorkspaceID != workspaceID {
+		return "", "customer order belongs to another workspace", nil
+	}
+	if req.DocNum == "" {
+		return order.DocNum, "", nil
+	}
+	return req.DocNum, "", nil
+}
+
+// competencyLevel проверяет уровень и множитель компетенции; пустой уровень —
+// qualified.
+func competencyLevel(req OperatorCompetencyRequest) (storage.CompetencyLevel, string) {
+	// compt_setupfactor — NUMERIC(4,2).
+	if req.SetupFactor != nil && (*req.SetupFactor <= 0 || *req.SetupFactor >= 100) {
+		return "", "setup_factor must be in (0, 100)"
+	}
+	if req.Level == "" {
+		return storage.CompetencyQualified, ""
+	}
+	level, err := storage.ParseCompetencyLevel(req.Level)
+	if err != nil {
+		return "", err.Error()
+	}
+	return level, ""
+}
+
+// taskDeadlineType проверяет тип дедлайна и штраф за опоздание задания;
+// пустой тип — hard.
+func taskDeadlineType(req DeviceTaskRequest) (string, string) {
+	if msg := penaltyRate(req.PenaltyRate); msg != "" {
+		return "", msg
+	}
+	switch req.DeadlineType {
+	case "":
+		return storage.DeadlineHard, ""
+	case storage.DeadlineHard, storage.DeadlineSoft:
+		return req.DeadlineType, ""
+	}
+	return "", "deadline_type must be hard or soft"
+}
+
+// taskMinLevel проверяет требование задания к уровню оператора.
+func taskMinLevel(req DeviceTaskRequest) (storage.CompetencyLevel, string) {
+	if req.MinLevel == "" {
+		return "", ""
+	}
+	if !req.NeedOperator {
+		return "", "min_level requires need_operator"
+	}
+	level, err := storage.ParseCompetencyLevel(req.MinLevel)
+	if err != nil {
+		return "", err.Error()
+	}
+	return level, ""
+}
+
+func validateCharacteristic(req CharacteristicRequest) string {
+	if strings.TrimSpace(req.Key) == "" {
+		return "key required"
+	}
+	switch req.Kind {
+	case storage.CharacteristicNumber, storage.CharacteristicBool, storage.CharacteristicRange:
+		if len(req.Options) > 0 {
+			return "options are allowed only for enum"
+		}
+	case storage.CharacteristicEnum:
+	default:
+		return "kind must be number, enum, bool or range"
+	}
+	return ""
+}
+
+// ListDeviceTypes godoc
+// @Summary     Список типов оборудования
+// @Tags        device_types
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   DeviceTypeDTO
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/device-types [get]
+func (h *Handlers) ListDeviceTypes(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListDeviceTypes(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	dtos := make([]DeviceTypeDTO, 0, len(items))
+	for _, t := range items {
+		dtos = append(dtos, deviceTypeDTO(t))
+	}
+	writeJSON(w, 200, dtos)
+}
+
+func deviceTypeDTO(t storage.DeviceType) DeviceTypeDTO {
+	return DeviceTypeDTO{
+		ID:                        t.ID,
+		Name:                      t.Name,
+		EquipmentCharacteristicID: t.EquipmentCharacteristicID,
+		PlateCapacity:             t.PlateCapacity,
+		WorkspaceID:               t.WorkspaceID,
+		PowerKW:                   t.PowerKW,
+		CooldownMin:               int(t.Cooldown.Minutes()),
+	}
+}
+
+// CreateDeviceType godoc
+// @Summary     Создать тип оборудования
+// @Tags        device_types
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int              true  "Workspace ID"
+// @Param       body         body      DeviceTypeRequest  true  "Device type payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/device-types [post]
+func (h *Handlers) CreateDeviceType(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req DeviceTypeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if req.PlateCapacity < 0 {
+		writeJSON(w, 400, map[string]any{"error": "plate_capacity must not be negative"})
+		return
+	}
+	if msg := powerKW(req.PowerKW); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if msg := cooldownMin(req.CooldownMin); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreateDeviceType(r.Context(), storage.DeviceType{Name: req.Name, EquipmentCharacteristicID: req.EquipmentCharacteristicID, PlateCapacity: req.PlateCapacity, WorkspaceID: workspaceID, PowerKW: req.PowerKW, Cooldown: time.Duration(req.CooldownMin) * time.Minute})
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// UpdateDeviceType godoc
+// @Summary     Обновить тип оборудования
+// @Tags        device_types
+// @Accept      json
+// @Produce     json
+// @Param       deviceTypeId  path      int               true  "Device type ID"
+// @Param       workspace_id  query     int               true  "Workspace ID"
+// @Param       body          body      DeviceTypeRequest  true  "Device type payload"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-types/{deviceTypeId} [put]
+func (h *Handlers) UpdateDeviceType(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTypeId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTypeId"})
+		return
+	}
+	var req DeviceTypeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	workspaceIDStr := r.URL.Query().Get("workspace_id")
+	if workspaceIDStr == "" {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
+		return
+	}
+	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+		return
+	}
+	if req.PlateCapacity < 0 {
+		writeJSON(w, 400, map[string]any{"error": "plate_capacity must not be negative"})
+		return
+	}
+	if msg := powerKW(req.PowerKW); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if msg := cooldownMin(req.CooldownMin); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if err := h.repos.UpdateDeviceType(r.Context(), storage.DeviceType{ID: id, Name: req.Name, EquipmentCharacteristicID: req.EquipmentCharacteristicID, PlateCapacity: req.PlateCapacity, WorkspaceID: workspaceID, PowerKW: req.PowerKW, Cooldown: time.Duration(req.CooldownMin) * time.Minute}); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// DeleteDeviceType godoc
+// @Summary     Удалить тип оборудования
+// @Tags        device_types
+// @Produce     json
+// @Param       deviceTypeId  path      int  true  "Device type ID"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-types/{deviceTypeId} [delete]
+func (h *Handlers) DeleteDeviceType(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTypeId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTypeId"})
+		return
+	}
+	if err := h.repos.DeleteDeviceType(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// ListDevices godoc
+// @Summary     Список оборудования
+// @Tags        devices
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   storage.Device
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/devices [get]
+func (h *Handlers) ListDevices(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListDevices(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, items)
+}
+
+// CreateDevice godoc
+// @Summary     Создать оборудование
+// @Tags        devices
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int            true  "Workspace ID"
+// @Param       body         body      DeviceRequest  true  "Device payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/devices [post]
+func (h *Handlers) CreateDevice(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req DeviceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if req.Name == "" {
+		writeJSON(w, 400, map[string]any{"error": "name required"})
+		return
+	}
+	if req.LoadedMaterialID < 0 {
+		writeJSON(w, 400, map[string]any{"error": "loaded_material_id must not be negative"})
+		return
+	}
+	if msg := powerKW(req.PowerKW); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if msg := hourlyRate(req.HourlyRate); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreateDevice(r.Context(), storage.Device{
+		Name:             req.Name,
+		PhotoURL:         req.PhotoURL,
+		AddInRecSystem:   req.AddInRecSystem,
+		DeviceTypeID:     req.DeviceTypeID,
+		DeviceStateID:    req.DeviceStateID,
+		WorkspaceID:      workspaceID,
+		LoadedMaterialID: req.LoadedMaterialID,
+		PowerKW:          req.PowerKW,
+		HourlyRate:       req.HourlyRate,
+	})
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// UpdateDevice godoc
+// @Summary     Обновить оборудование
+// @Tags        devices
+// @Accept      json
+// @Produce     json
+// @Param       deviceId      path      int            true  "Device ID"
+// @Param       workspace_id  query     int            true  "Workspace ID"
+// @Param       body          body      DeviceRequest  true  "Device payload"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/devices/{deviceId} [put]
+func (h *Handlers) UpdateDevice(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceId"})
+		return
+	}
+	var req DeviceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	workspaceIDStr := r.URL.Query().Get("workspace_id")
+	if workspaceIDStr == "" {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
+		return
+	}
+	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+		return
+	}
+	if req.LoadedMaterialID < 0 {
+		writeJSON(w, 400, map[string]any{"error": "loaded_material_id must not be negative"})
+		return
+	}
+	if msg := powerKW(req.PowerKW); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if msg := hourlyRate(req.HourlyRate); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if err := h.repos.UpdateDevice(r.Context(), storage.Device{
+		ID:               id,
+		Name:             req.Name,
+		PhotoURL:         req.PhotoURL,
+		AddInRecSystem:   req.AddInRecSystem,
+		DeviceTypeID:     req.DeviceTypeID,
+		DeviceStateID:    req.DeviceStateID,
+		WorkspaceID:      workspaceID,
+		LoadedMaterialID: req.LoadedMaterialID,
+		PowerKW:          req.PowerKW,
+		HourlyRate:       req.HourlyRate,
+	}); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// SetDeviceLoadedMaterial godoc
+// @Summary     Отметить материал, заправленный в оборудование
+// @Description Планировщик предпочитает оборудование, в которое уже заправлен материал задания. При завершении задания материал обновляется автоматически.
+// @Tags        devices
+// @Accept      json
+// @Produce     json
+// @Param       deviceId  path      int                    true  "Device ID"
+// @Param       body      body      LoadedMaterialRequest  true  "Материал"
+// @Success     200       {object}  map[string]any
+// @Failure     400       {object}  map[string]any
+// @Failure     404       {object}  map[string]any
+// @Failure     500       {object}  map[string]any
+// @Router      /api/devices/{deviceId}/loaded-material [post]
+func (h *Handlers) SetDeviceLoadedMaterial(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceId"})
+		return
+	}
+	var req LoadedMaterialRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if req.MaterialID < 0 {
+		writeJSON(w, 400, map[string]any{"error": "material_id must not be negative"})
+		return
+	}
+	if _, err := h.repos.GetDevice(r.Context(), id); err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if err := h.repos.SetDeviceLoadedMaterial(r.Context(), id, req.MaterialID); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// DeleteDevice godoc
+// @Summary     Удалить оборудование
+// @Tags        devices
+// @Produce     json
+// @Param       deviceId  path      int  true  "Device ID"
+// @Success     200       {object}  map[string]any
+// @Failure     400       {object}  map[string]any
+// @Failure     500       {object}  map[string]any
+// @Router      /api/devices/{deviceId} [delete]
+func (h *Handlers) DeleteDevice(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceId"})
+		return
+	}
+	if err := h.repos.DeleteDevice(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// ListOperators godoc
+// @Summary     Список операторов
+// @Tags        operators
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   storage.Operator
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/operators [get]
+func (h *Handlers) ListOperators(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListOperators(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, items)
+}
+
+// CreateOperator godoc
+// @Summary     Создать оператора
+// @Tags        operators
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int              true  "Workspace ID"
+// @Param       body         body      OperatorRequest  true  "Operator payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/operators [post]
+func (h *Handlers) CreateOperator(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req OperatorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if req.FullName == "" || req.PhoneNumber == "" || req.UserLogin == "" {
+		writeJSON(w, 400, map[string]any{"error": "full_name, phone_number, user_login required"})
+		return
+	}
+	if msg := hourlyRate(req.HourlyRate); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreateOperator(r.Context(), storage.Operator{
+		FullName:    req.FullName,
+		PhoneNumber: req.PhoneNumber,
+		WorkspaceID: workspaceID,
+		UserLogin:   req.UserLogin,
+		HourlyRate:  req.HourlyRate,
+	})
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// UpdateOperator godoc
+// @Summary     Обновить оператора
+// @Tags        operators
+// @Accept      json
+// @Produce     json
+// @Param       operatorId    path      int              true  "Operator ID"
+// @Param       workspace_id  query     int              true  "Workspace ID"
+// @Param       body          body      OperatorRequest  true  "Operator payload"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/operators/{operatorId} [put]
+func (h *Handlers) UpdateOperator(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "operatorId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid operatorId"})
+		return
+	}
+	var req OperatorRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	workspaceIDStr := r.URL.Query().Get("workspace_id")
+	if workspaceIDStr == "" {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
+		return
+	}
+	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+		return
+	}
+	if msg := hourlyRate(req.HourlyRate); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if err := h.repos.UpdateOperator(r.Context(), storage.Operator{
+		ID:          id,
+		FullName:    req.FullName,
+		PhoneNumber: req.PhoneNumber,
+		WorkspaceID: workspaceID,
+		UserLogin:   req.UserLogin,
+		HourlyRate:  req.HourlyRate,
+	}); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// DeleteOperator godoc
+// @Summary     Удалить оператора
+// @Tags        operators
+// @Produce     json
+// @Param       operatorId  path      int  true  "Operator ID"
+// @Success     200         {object}  map[string]any
+// @Failure     400         {object}  map[string]any
+// @Failure     500         {object}  map[string]any
+// @Router      /api/operators/{operatorId} [delete]
+func (h *Handlers) DeleteOperator(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "operatorId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid operatorId"})
+		return
+	}
+	if err := h.repos.DeleteOperator(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// ListOperatorCompetencies godoc
+// @Summary     Список компетенций операторов
+// @Tags        operator_competencies
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   storage.OperatorCompetency
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/operator-competencies [get]
+func (h *Handlers) ListOperatorCompetencies(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListOperatorCompetencies(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, items)
+}
+
+// CreateOperatorCompetency godoc
+// @Summary     Создать компетенцию оператора
+// @Tags        operator_competencies
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int                         true  "Workspace ID"
+// @Param       body         body      OperatorCompetencyRequest  true  "Competency payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/operator-competencies [post]
+func (h *Handlers) CreateOperatorCompetency(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req OperatorCompetencyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	level, msg := competencyLevel(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreateOperatorCompetency(r.Context(), storage.OperatorCompetency{
+		WorkspaceID:  workspaceID,
+		DeviceTypeID: req.DeviceTypeID,
+		OperatorID:   req.OperatorID,
+		Level:        level,
+		SetupFactor:  req.SetupFactor,
+	})
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// UpdateOperatorCompetency godoc
+// @Summary     Обновить компетенцию оператора
+// @Tags        operator_competencies
+// @Accept      json
+// @Produce     json
+// @Param       competencyId  path      int                         true  "Competency ID"
+// @Param       workspace_id  query     int                         true  "Workspace ID"
+// @Param       body          body      OperatorCompetencyRequest  true  "Competency payload"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/operator-competencies/{competencyId} [put]
+func (h *Handlers) UpdateOperatorCompetency(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "competencyId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid competencyId"})
+		return
+	}
+	var req OperatorCompetencyRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	level, msg := competencyLevel(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	workspaceIDStr := r.URL.Query().Get("workspace_id")
+	if workspaceIDStr == "" {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
+		return
+	}
+	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+		return
+	}
+	if err := h.repos.UpdateOperatorCompetency(r.Context(), storage.OperatorCompetency{
+		ID:           id,
+		WorkspaceID:  workspaceID,
+		DeviceTypeID: req.DeviceTypeID,
+		OperatorID:   req.OperatorID,
+		Level:        level,
+		SetupFactor:  req.SetupFactor,
+	}); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// DeleteOperatorCompetency godoc
+// @Summary     Удалить компетенцию оператора
+// @Tags        operator_competencies
+// @Produce     json
+// @Param       competencyId  path      int  true  "Competency ID"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/operator-competencies/{competencyId} [delete]
+func (h *Handlers) DeleteOperatorCompetency(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "competencyId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid competencyId"})
+		return
+	}
+	if err := h.repos.DeleteOperatorCompetency(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// ListOperatorDevices godoc
+// @Summary     Список связок оператор-оборудование
+// @Tags        operator_devices
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   storage.OperatorDevice
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/operator-devices [get]
+func (h *Handlers) ListOperatorDevices(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListOperatorDevices(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, items)
+}
+
+// CreateOperatorDevice godoc
+// @Summary     Создать связку оператор-оборудование
+// @Tags        operator_devices
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int                      true  "Workspace ID"
+// @Param       body         body      OperatorDeviceRequest  true  "Operator device payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/operator-devices [post]
+func (h *Handlers) CreateOperatorDevice(w http.ResponseWriter, r *http.Request) {
+	var req OperatorDeviceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	id, err := h.repos.CreateOperatorDevice(r.Context(), storage.OperatorDevice{OperatorID: req.OperatorID, DeviceID: req.DeviceID})
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// UpdateOperatorDevice godoc
+// @Summary     Обновить связку оператор-оборудование
+// @Tags        operator_devices
+// @Accept      json
+// @Produce     json
+// @Param       operatorDeviceId  path      int                      true  "Operator device ID"
+// @Param       body              body      OperatorDeviceRequest  true  "Operator device payload"
+// @Success     200               {object}  map[string]any
+// @Failure     400               {object}  map[string]any
+// @Failure     500               {object}  map[string]any
+// @Router      /api/operator-devices/{operatorDeviceId} [put]
+func (h *Handlers) UpdateOperatorDevice(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "operatorDeviceId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid operatorDeviceId"})
+		return
+	}
+	var req OperatorDeviceRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if err := h.repos.UpdateOperatorDevice(r.Context(), storage.OperatorDevice{ID: id, OperatorID: req.OperatorID, DeviceID: req.DeviceID}); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// DeleteOperatorDevice godoc
+// @Summary     Удалить связку оператор-оборудование
+// @Tags        operator_devices
+// @Produce     json
+// @Param       operatorDeviceId  path      int  true  "Operator device ID"
+// @Success     200               {object}  map[string]any
+// @Failure     400               {object}  map[string]any
+// @Failure     500               {object}  map[string]any
+// @Router      /api/operator-devices/{operatorDeviceId} [delete]
+func (h *Handlers) DeleteOperatorDevice(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "operatorDeviceId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid operatorDeviceId"})
+		return
+	}
+	if err := h.repos.DeleteOperatorDevice(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// ListDeviceTaskTypes godoc
+// @Summary     Список типов задач оборудования
+// @Tags        device_task_types
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   DeviceTaskTypeDTO
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/device-task-types [get]
+func (h *Handlers) ListDeviceTaskTypes(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListDeviceTaskTypes(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	dtos := make([]DeviceTaskTypeDTO, 0, len(items))
+	for _, t := range items {
+		dtos = append(dtos, deviceTaskTypeDTO(t))
+	}
+	writeJSON(w, 200, dtos)
+}
+
+func deviceTaskTypeDTO(t storage.DeviceTaskType) DeviceTaskTypeDTO {
+	return DeviceTaskTypeDTO{ID: t.ID, Name: t.Name, WorkspaceID: t.WorkspaceID, CooldownMin: int(t.Cooldown.Minutes())}
+}
+
+// GetDeviceTaskType godoc
+// @Summary     Получить тип задачи оборудования
+// @Tags        device_task_types
+// @Produce     json
+// @Param       deviceTaskTypeId  path      int  true  "Device task type ID"
+// @Success     200               {object}  DeviceTaskTypeDTO
+// @Failure     400               {object}  map[string]any
+// @Failure     500               {object}  map[string]any
+// @Router      /api/device-task-types/{deviceTaskTypeId} [get]
+func (h *Handlers) GetDeviceTaskType(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskTypeId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskTypeId"})
+		return
+	}
+	item, err := h.repos.GetDeviceTaskType(r.Context(), id)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "device task type not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, deviceTaskTypeDTO(item))
+}
+
+// CreateDeviceTaskType godoc
+// @Summary     Создать тип задачи оборудования
+// @Tags        device_task_types
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int                   true  "Workspace ID"
+// @Param       body         body      DeviceTaskTypeRequest  true  "Device task type payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/device-task-types [post]
+func (h *Handlers) CreateDeviceTaskType(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req DeviceTaskTypeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if msg := cooldownMin(req.CooldownMin); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreateDeviceTaskType(r.Context(), storage.DeviceTaskType{Name: req.Name, WorkspaceID: workspaceID, Cooldown: time.Duration(req.CooldownMin) * time.Minute})
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// UpdateDeviceTaskType godoc
+// @Summary     Обновить тип задачи оборудования
+// @Tags        device_task_types
+// @Accept      json
+// @Produce     json
+// @Param       deviceTaskTypeId  path      int                   true  "Device task type ID"
+// @Param       workspace_id      query     int                   true  "Workspace ID"
+// @Param       body              body      DeviceTaskTypeRequest  true  "Device task type payload"
+// @Success     200               {object}  map[string]any
+// @Failure     400               {object}  map[string]any
+// @Failure     500               {object}  map[string]any
+// @Router      /api/device-task-types/{deviceTaskTypeId} [put]
+func (h *Handlers) UpdateDeviceTaskType(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskTypeId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskTypeId"})
+		return
+	}
+	var req DeviceTaskTypeRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	workspaceIDStr := r.URL.Query().Get("workspace_id")
+	if workspaceIDStr == "" {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
+		return
+	}
+	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+		return
+	}
+	if msg := cooldownMin(req.CooldownMin); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if err := h.repos.UpdateDeviceTaskType(r.Context(), storage.DeviceTaskType{ID: id, Name: req.Name, WorkspaceID: workspaceID, Cooldown: time.Duration(req.CooldownMin) * time.Minute}); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// DeleteDeviceTaskType godoc
+// @Summary     Удалить тип задачи оборудования
+// @Tags        device_task_types
+// @Produce     json
+// @Param       deviceTaskTypeId  path      int  true  "Device task type ID"
+// @Success     200               {object}  map[string]any
+// @Failure     400               {object}  map[string]any
+// @Failure     500               {object}  map[string]any
+// @Router      /api/device-task-types/{deviceTaskTypeId} [delete]
+func (h *Handlers) DeleteDeviceTaskType(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskTypeId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskTypeId"})
+		return
+	}
+	if err := h.repos.DeleteDeviceTaskType(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// CreateDeviceTask godoc
+// @Summary     Создать задачу оборудования
+// @Description В ответе duration_suggestion — оценка длительности по истории выполнения на типе оборудования и типе задания, как в duration-stats/suggest. Если duration_min, setup_time_min и unload_time_min не заданы, а история есть, печать берётся из оценки.
+// @Tags        device_tasks
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int               true  "Workspace ID"
+// @Param       body         body      DeviceTaskRequest  true  "Device task payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/device-tasks [post]
+func (h *Handlers) CreateDeviceTask(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req DeviceTaskRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	docNum, msg, err := h.taskOrder(r.Context(), workspaceID, req)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	if req.Name == "" || docNum == "" {
+		writeJSON(w, 400, map[string]any{"error": "name and doc_num required"})
+		return
+	}
+	if req.PlateSize < 0 {
+		writeJSON(w, 400, map[string]any{"error": "plate_size must not be negative"})
+		return
+	}
+	if req.MaterialQty < 0 {
+		writeJSON(w, 400, map[string]any{"error": "material_qty must not be negative"})
+		return
+	}
+	// Новое задание всегда ждёт выполнения; статус меняет только set-status.
+	if req.Status != "" && req.Status != string(storage.TaskStatusPending) {
+		writeJSON(w, 400, map[string]any{"error": "new device task must be pending"})
+		return
+	}
+	minLevel, msg := taskMinLevel(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	deadlineType, msg := taskDeadlineType(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	deviceID, msg, err := h.taskDevice(r.Context(), workspaceID, req)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	suggestion, err := h.taskDuration(r.Context(), workspaceID, deviceID, &req)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	id, err := h.repos.CreateDeviceTask(r.Context(), storage.DeviceTask{
+		Name:             req.Name,
+		Deadline:         req.Deadline,
+		Duration:         minutesToDuration(req.DurationMin),
+		SetupTime:        minutesToDuration(req.SetupTimeMin),
+		UnloadTime:       minutesToDuration(req.UnloadTimeMin),
+		NeedOperator:     req.NeedOperator,
+		PhotoURL:         req.PhotoURL,
+		PlanStart:        req.PlanStart,
+		PlanEnd:          req.PlanEnd,
+		DocNum:           docNum,
+		Status:           storage.TaskStatusPending,
+		AddInRecSystem:   req.AddInRecSystem,
+		DeviceTaskTypeID: req.DeviceTaskTypeID,
+		WorkspaceID:      workspaceID,
+		OperatorID:       req.OperatorID,
+		DeviceID:         deviceID,
+		PriorityID:       req.PriorityID,
+		MaterialID:       req.MaterialID,
+		PlateSize:        req.PlateSize,
+		MaterialQty:      req.MaterialQty,
+		DevicePoolID:     req.DevicePoolID,
+		TargetTypeID:     req.TargetTypeID,
+		MinLevel:         minLevel,
+		OrderID:          req.OrderID,
+		DeadlineType:     deadlineType,
+		PenaltyRate:      req.PenaltyRate,
+	})
+	if errors.Is(err, storage.ErrDeviceOutsideTarget) {
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id, "duration_suggestion": suggestion})
+}
+
+// taskDuration оценивает длительность задания по истории выполнения на типе
+// оборудования deviceID и типе задания. Если в запросе нет ни печати, ни
+// наладки, ни снятия, а история есть, печать берётся из оценки.
+func (h *Handlers) taskDuration(ctx context.Context, workspaceID, deviceID int64, req *DeviceTaskRequest) (service.DurationSuggestion, error) {
+	var deviceTypeID int64
+	if deviceID > 0 {
+		d, err := h.repos.GetDevice(ctx, deviceID)
+		if err != nil && !isNotFound(err) {
+			return service.DurationSuggestion{}, err
+		}
+		deviceTypeID = d.DeviceTypeID
+	}
+	model, err := h.durations.Model(ctx, workspaceID)
+	if err != nil {
+		return service.DurationSuggestion{}, err
+	}
+	nominal := minutesToDuration(req.DurationMin + req.SetupTimeMin + req.UnloadTimeMin)
+	suggestion := model.Suggest(deviceTypeID, req.DeviceTaskTypeID, nominal)
+	if nominal == 0 && suggestion.Basis != service.DurationBasisNominal {
+		req.DurationMin = suggestion.SuggestedMin
+	}
+	return suggestion, nil
+}
+
+// GetDeviceTask godoc
+// @Summary     Получить задачу оборудования
+// @Tags        device_tasks
+// @Produce     json
+// @Param       deviceTaskId  path      int  true  "Device task ID"
+// @Success     200           {object}  DeviceTaskDTO
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId} [get]
+func (h *Handlers) GetDeviceTask(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	item, err := h.repos.GetDeviceTask(r.Context(), id)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "device task not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, DeviceTaskDTO{
+		ID:             item.ID,
+		Name:           item.Name,
+		Deadline:       item.Deadline,
+		DurationMin:    int(item.Duration.Minutes()),
+		SetupTimeMin:   int(item.SetupTime.Minutes()),
+		UnloadTimeMin:  int(item.UnloadTime.Minutes()),
+		NeedOperator:   item.NeedOperator,
+		PlanStart:      item.PlanStart,
+		PlanEnd:        item.PlanEnd,
+		DocNum:         item.DocNum,
+		Status:         string(item.Status),
+		ActualStart:    item.ActualStart,
+		ActualEnd:      item.ActualEnd,
+		PriorityID:     item.PriorityID,
+		OperatorID:     item.OperatorID,
+		DeviceID:       item.DeviceID,
+		TaskTypeID:     item.DeviceTaskTypeID,
+		WorkspaceID:    item.WorkspaceID,
+		JobID:          item.JobID,
+		JobSeq:         item.JobSeq,
+		TransferLagMin: int(item.TransferLag.Minutes()),
+		MaterialID:     item.MaterialID,
+		PlateSize:      item.PlateSize,
+		BatchID:        item.BatchID,
+		MaterialQty:    item.MaterialQty,
+		DevicePoolID:   item.DevicePoolID,
+		TargetTypeID:   item.TargetTypeID,
+		MinLevel:       string(item.MinLevel),
+		OrderID:        item.OrderID,
+		TemplateID:     item.TemplateID,
+		DeadlineType:   item.DeadlineType,
+		PenaltyRate:    item.PenaltyRate,
+		Pinned:         item.Pinned,
+	})
+}
+
+// UpdateDeviceTask godoc
+// @Summary     Обновить задачу оборудования
+// @Description Поручения оператору на наладку и снятие в той же транзакции пересчитываются под новый слот, оператора и статус задания; изменения — в operator_tasks.
+// @Tags        device_tasks
+// @Accept      json
+// @Produce     json
+// @Param       deviceTaskId  path      int               true  "Device task ID"
+// @Param       workspace_id  query     int               true  "Workspace ID"
+// @Param       body          body      DeviceTaskRequest  true  "Device task payload"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     404           {object}  map[string]any
+// @Failure     409           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId} [put]
+func (h *Handlers) UpdateDeviceTask(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	var req DeviceTaskRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	workspaceIDStr := r.URL.Query().Get("workspace_id")
+	if workspaceIDStr == "" {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
+		return
+	}
+	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+		return
+	}
+	if req.PlateSize < 0 {
+		writeJSON(w, 400, map[string]any{"error": "plate_size must not be negative"})
+		return
+	}
+	if req.MaterialQty < 0 {
+		writeJSON(w, 400, map[string]any{"error": "material_qty must not be negative"})
+		return
+	}
+	docNum, msg, err := h.taskOrder(r.Context(), workspaceID, req)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	// Пустой статус оставляет текущий: UI отправляет задание без него.
+	var status storage.TaskStatus
+	if req.Status != "" {
+		if status, err = storage.ParseTaskStatus(req.Status); err != nil {
+			writeJSON(w, 400, map[string]any{"error": err.Error()})
+			return
+		}
+	}
+	minLevel, msg := taskMinLevel(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	deadlineType, msg := taskDeadlineType(req)
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	deviceID, msg, err := h.taskDevice(r.Context(), workspaceID, req)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	opTasks, err := h.planner.UpdateTask(r.Context(), storage.DeviceTask{
+		ID:               id,
+		Name:             req.Name,
+		Deadline:         req.Deadline,
+		Duration:         minutesToDuration(req.DurationMin),
+		SetupTime:        minutesToDuration(req.SetupTimeMin),
+		UnloadTime:       minutesToDuration(req.UnloadTimeMin),
+		NeedOperator:     req.NeedOperator,
+		PhotoURL:         req.PhotoURL,
+		PlanStart:        req.PlanStart,
+		PlanEnd:          req.PlanEnd,
+		DocNum:           docNum,
+		Status:           status,
+		AddInRecSystem:   req.AddInRecSystem,
+		DeviceTaskTypeID: req.DeviceTaskTypeID,
+		WorkspaceID:      workspaceID,
+		OperatorID:       req.OperatorID,
+		DeviceID:         deviceID,
+		PriorityID:       req.PriorityID,
+		MaterialID:       req.MaterialID,
+		PlateSize:        req.PlateSize,
+		MaterialQty:      req.MaterialQty,
+		DevicePoolID:     req.DevicePoolID,
+		TargetTypeID:     req.TargetTypeID,
+		MinLevel:         minLevel,
+		OrderID:          req.OrderID,
+		DeadlineType:     deadlineType,
+		PenaltyRate:      req.PenaltyRate,
+	})
+	if err != nil {
+		writeDeviceTaskStatusError(w, err)
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true, "operator_tasks": opTasks})
+}
+
+// SetDeviceTaskStatus godoc
+// @Summary     Сменить статус задачи оборудования
+// @Description Допустимые переходы: pending → in_progress/on_hold/cancelled, in_progress → done/failed/on_hold, on_hold → pending/cancelled, failed → pending/cancelled, cancelled → pending. Из done переходов нет.
+// @Description Переход в in_progress фиксирует фактическое начало, в done/failed — фактическое окончание (поле at или текущее время).
+// @Description Задание, которое больше не ждёт выполнения и не выполняется, в той же транзакции теряет поручения оператору на наладку и снятие; изменения — в operator_tasks.
+// @Tags        device_tasks
+// @Accept      json
+// @Produce     json
+// @Param       deviceTaskId  path      int                      true  "Device task ID"
+// @Param       body          body      DeviceTaskStatusRequest  true  "New status"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     404           {object}  map[string]any
+// @Failure     409           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId}/status [post]
+func (h *Handlers) SetDeviceTaskStatus(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	var req DeviceTaskStatusRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	status, err := storage.ParseTaskStatus(req.Status)
+	if err != nil {
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+		return
+	}
+	at := time.Now()
+	if req.At != nil {
+		at = *req.At
+	}
+	opTasks, err := h.planner.SetTaskStatus(r.Context(), id, status, at)
+	if err != nil {
+		writeDeviceTaskStatusError(w, err)
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true, "status": status, "operator_tasks": opTasks})
+}
+
+// MoveDeviceTask godoc
+// @Summary     Перенести задачу оборудования
+// @Description Ручной перенос ожидающего задания (вместе с его прогоном) на новый старт, при необходимости на другое оборудование (из пула, целевого типа или того же типа) и к другому оператору.
+// @Description Слот проверяется на занятость оборудования с остыванием и оператора (задания и поручения), простои, рабочие часы, правила рабочего времени оператора, жёсткий дедлайн и порядок операций маршрута. При конфликтах перенос отклоняется с 409 и списком конфликтов; с push мешающие ожидающие незакреплённые задания сдвигаются вправо.
+// @Description Перенесённое задание закрепляется: пересчёт плана и починка после сбоев его не двигают. dry_run — только проверить перенос.
+// @Tags        device_tasks
+// @Accept      json
+// @Produce     json
+// @Param       deviceTaskId  path      int                  true  "Device task ID"
+// @Param       body          body      service.MoveRequest  true  "Перенос"
+// @Success     200           {object}  service.MoveResult
+// @Failure     400           {object}  map[string]any
+// @Failure     404           {object}  map[string]any
+// @Failure     409           {object}  service.MoveResult
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId}/move [post]
+func (h *Handlers) MoveDeviceTask(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	var req service.MoveRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	req.TaskID = id
+
+	res, err := h.planner.Move(r.Context(), req)
+	switch {
+	case err == nil && len(res.Conflicts) > 0:
+		writeJSON(w, 409, res)
+	case err == nil:
+		writeJSON(w, 200, res)
+	case isNotFound(err):
+		writeJSON(w, 404, map[string]any{"error": "device task not found"})
+	case errors.Is(err, service.ErrInvalidMove):
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+	case errors.Is(err, service.ErrTaskNotMovable):
+		writeJSON(w, 409, map[string]any{"error": err.Error()})
+	default:
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+	}
+}
+
+// PinDeviceTask godoc
+// @Summary     Закрепить или открепить задачу оборудования
+// @Description Закреплённое задание остаётся на своём месте при пересчёте плана и починке после сбоев; открепление возвращает его планировщику.
+// @Tags        device_tasks
+// @Accept      json
+// @Produce     json
+// @Param       deviceTaskId  path      int                   true  "Device task ID"
+// @Param       body          body      DeviceTaskPinRequest  true  "Закрепление"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     404           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId}/pin [post]
+func (h *Handlers) PinDeviceTask(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	var req DeviceTaskPinRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if err := h.repos.SetDeviceTaskPinned(r.Context(), id, req.Pinned); err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "device task not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true, "pinned": req.Pinned})
+}
+
+// writeDeviceTaskStatusError отвечает 400/404/409/500 на ошибку обновления задания.
+func writeDeviceTaskStatusError(w http.ResponseWriter, err error) {
+	switch {
+	case isNotFound(err):
+		writeJSON(w, 404, map[string]any{"error": "device task not found"})
+	case errors.Is(err, storage.ErrInvalidStatusTransition):
+		writeJSON(w, 409, map[string]any{"error": err.Error()})
+	case errors.Is(err, storage.ErrDeviceOutsideTarget):
+		writeJSON(w, 400, map[string]any{"error": err.Error()})
+	default:
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+	}
+}
+
+// DeleteDeviceTask godoc
+// @Summary     Удалить задачу оборудования
+// @Tags        device_tasks
+// @Produce     json
+// @Param       deviceTaskId  path      int  true  "Device task ID"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/device-tasks/{deviceTaskId} [delete]
+func (h *Handlers) DeleteDeviceTask(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "deviceTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		return
+	}
+	if err := h.repos.DeleteDeviceTask(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// ListUserTasks godoc
+// @Summary     Список пользовательских задач
+// @Tags        user_tasks
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   storage.UserTask
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/user-tasks [get]
+func (h *Handlers) ListUserTasks(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListUserTasks(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, items)
+}
+
+// CreateUserTask godoc
+// @Summary     Создать пользовательскую задачу
+// @Tags        user_tasks
+// @Accept      json
 // @Produce     json
-// @Param       deviceTaskTypeId  path      int  true  "Device task type ID"
-// @Success     200               {object}  map[string]any
-// @Failure     400               {object}  map[string]any
-// @Failure     500               {object}  map[string]any
-// @Router      /api/device-task-types/{deviceTaskTypeId} [delete]
-func (h *Handlers) DeleteDeviceTaskType(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceTaskTypeId")
+// @Param       workspaceId  path      int              true  "Workspace ID"
+// @Param       body         body      UserTaskRequest  true  "User task payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/user-tasks [post]
+func (h *Handlers) CreateUserTask(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req UserTaskRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	id, err := h.repos.CreateUserTask(r.Context(), storage.UserTask{
+		Name:           req.Name,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Priority:       req.Priority,
+		CompletionMark: req.CompletionMark,
+		WorkspaceID:    workspaceID,
+		DeviceTaskID:   req.DeviceTaskID,
+		OperatorID:     req.OperatorID,
+	})
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// UpdateUserTask godoc
+// @Summary     Обновить пользовательскую задачу
+// @Tags        user_tasks
+// @Accept      json
+// @Produce     json
+// @Param       userTaskId    path      int              true  "User task ID"
+// @Param       workspace_id  query     int              true  "Workspace ID"
+// @Param       body          body      UserTaskRequest  true  "User task payload"
+// @Success     200           {object}  map[string]any
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/user-tasks/{userTaskId} [put]
+func (h *Handlers) UpdateUserTask(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "userTaskId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskTypeId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid userTaskId"})
+		return
+	}
+	var req UserTaskRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	workspaceIDStr := r.URL.Query().Get("workspace_id")
+	if workspaceIDStr == "" {
+		writeJSON(w, 400, map[string]any{"error": "workspace_id required"})
+		return
+	}
+	workspaceID, err := strconv.ParseInt(workspaceIDStr, 10, 64)
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
+		return
+	}
+	if err := h.repos.UpdateUserTask(r.Context(), storage.UserTask{
+		ID:             id,
+		Name:           req.Name,
+		StartTime:      req.StartTime,
+		EndTime:        req.EndTime,
+		Priority:       req.Priority,
+		CompletionMark: req.CompletionMark,
+		WorkspaceID:    workspaceID,
+		DeviceTaskID:   req.DeviceTaskID,
+		OperatorID:     req.OperatorID,
+	}); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// DeleteUserTask godoc
+// @Summary     Удалить пользовательскую задачу
+// @Tags        user_tasks
+// @Produce     json
+// @Param       userTaskId  path      int  true  "User task ID"
+// @Success     200         {object}  map[string]any
+// @Failure     400         {object}  map[string]any
+// @Failure     500         {object}  map[string]any
+// @Router      /api/user-tasks/{userTaskId} [delete]
+func (h *Handlers) DeleteUserTask(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "userTaskId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid userTaskId"})
+		return
+	}
+	if err := h.repos.DeleteUserTask(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+// ListDeviceDowntime godoc
+// @Summary     Текущие и будущие простои оборудования
+// @Tags        devices
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   storage.DeviceDowntime
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/device-downtime [get]
+func (h *Handlers) ListDeviceDowntime(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListDeviceDowntime(r.Context(), workspaceID, time.Now())
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, items)
+}
+
+// DeleteDeviceDowntime godoc
+// @Summary     Удалить простой оборудования (например, ремонт закончился раньше)
+// @Tags        devices
+// @Produce     json
+// @Param       downtimeId  path      int  true  "Downtime ID"
+// @Success     200         {object}  map[string]any
+// @Failure     400         {object}  map[string]any
+// @Failure     500         {object}  map[string]any
+// @Router      /api/device-downtime/{downtimeId} [delete]
+func (h *Handlers) DeleteDeviceDowntime(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "downtimeId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid downtimeId"})
+		return
+	}
+	if err := h.repos.DeleteDeviceDowntime(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+func productionJobDTO(j storage.ProductionJob) ProductionJobDTO {
+	dto := ProductionJobDTO{
+		ID:          j.ID,
+		Name:        j.Name,
+		Deadline:    j.Deadline,
+		DocNum:      j.DocNum,
+		PriorityID:  j.PriorityID,
+		WorkspaceID: j.WorkspaceID,
+		Operations:  make([]DeviceTaskDTO, 0, len(j.Operations)),
+	}
+	for _, op := range j.Operations {
+		dto.Operations = append(dto.Operations, deviceTaskRowDTO(op))
+	}
+	return dto
+}
+
+// ListProductionJobs godoc
+// @Summary     Список производственных заданий с маршрутами
+// @Tags        production_jobs
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   ProductionJobDTO
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/production-jobs [get]
+func (h *Handlers) ListProductionJobs(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	items, err := h.repos.ListProductionJobs(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	dtos := make([]ProductionJobDTO, 0, len(items))
+	for _, j := range items {
+		dtos = append(dtos, productionJobDTO(j))
+	}
+	writeJSON(w, 200, dtos)
+}
+
+// CreateProductionJob godoc
+// @Summary     Создать производственное задание с маршрутом
+// @Description Каждая операция маршрута создаётся как задача оборудования с номером шага. Планировщик ставит операции по порядку и может перенести операцию на другое оборудование того же типа.
+// @Tags        production_jobs
+// @Accept      json
+// @Produce     json
+// @Param       workspaceId  path      int                   true  "Workspace ID"
+// @Param       body         body      ProductionJobRequest  true  "Production job payload"
+// @Success     201          {object}  map[string]any
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/production-jobs [post]
+func (h *Handlers) CreateProductionJob(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	var req ProductionJobRequest
+	if err := decodeJSON(r, &req); err != nil {
+		writeJSON(w, 400, map[string]any{"error": "bad json"})
+		return
+	}
+	if req.Name == "" || req.PriorityID <= 0 || len(req.Operations) == 0 {
+		writeJSON(w, 400, map[string]any{"error": "name, priority_id and operations required"})
+		return
+	}
+	devices, err := h.repos.ListDevices(r.Context(), workspaceID)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+
+	ops := make([]storage.DeviceTask, 0, len(req.Operations))
+	addInRecSystem := true
+	for i, op := range req.Operations {
+		step := i + 1
+		if op.DeviceTaskTypeID <= 0 {
+			writeJSON(w, 400, map[string]any{"error": fmt.Sprintf("operation %d: device_task_type_id required", step)})
+			return
+		}
+		// Оператор нужен только операции с его участием; мойка или
+		// отверждение без оператора идут сами.
+		if op.NeedOperator && op.OperatorID <= 0 {
+			writeJSON(w, 400, map[string]any{"error": fmt.Sprintf("operation %d: operator_id required when need_operator is set", step)})
+			return
+		}
+		if op.DurationMin < 0 || op.SetupTimeMin < 0 || op.UnloadTimeMin < 0 || op.TransferLagMin < 0 {
+			writeJSON(w, 400, map[string]any{"error": fmt.Sprintf("operation %d: durations must be >= 0", step)})
+			return
+		}
+		deviceID, ok := routingDevice(devices, op.DeviceTypeID, op.DeviceID)
+		if !ok {
+			writeJSON(w, 400, map[string]any{"error": fmt.Sprintf("operation %d: no device of type %d in workspace", step, op.DeviceTypeID)})
+			return
+		}
+		name := op.Name
+		if name == "" {
+			name = fmt.Sprintf("%s — шаг %d", req.Name, step)
+		}
+		ops = append(ops, storage.DeviceTask{
+			Name:             name,
+			Deadline:         req.Deadline,
+			Duration:         minutesToDuration(op.DurationMin),
+			SetupTime:        minutesToDuration(op.SetupTimeMin),
+			UnloadTime:       minutesToDuration(op.UnloadTimeMin),
+			NeedOperator:     op.NeedOperator,
+			DocNum:           req.DocNum,
+			Status:           storage.TaskStatusPending,
+			AddInRecSystem:   &addInRecSystem,
+			DeviceTaskTypeID: op.DeviceTaskTypeID,
+			WorkspaceID:      workspaceID,
+			OperatorID:       op.OperatorID,
+			DeviceID:         deviceID,
+			PriorityID:       req.PriorityID,
+			TransferLag:      minutesToDuration(op.TransferLagMin),
+		})
+	}
+
+	id, err := h.repos.CreateProductionJob(r.Context(), storage.ProductionJob{
+		Name:        req.Name,
+		Deadline:    req.Deadline,
+		DocNum:      req.DocNum,
+		PriorityID:  req.PriorityID,
+		WorkspaceID: workspaceID,
+	}, ops)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 201, map[string]any{"id": id})
+}
+
+// routingDevice выбирает оборудование для операции маршрута: явно указанное
+// (если оно подходит по типу) или первое включённое в рекомендации нужного типа.
+func routingDevice(devices []storage.Device, deviceTypeID, deviceID int64) (int64, bool) {
+	for _, d := range devices {
+		if deviceID > 0 && d.ID == deviceID {
+			return d.ID, deviceTypeID <= 0 || d.DeviceTypeID == deviceTypeID
+		}
+	}
+	if deviceID > 0 || deviceTypeID <= 0 {
+		return 0, false
+	}
+	for _, d := range devices {
+		if d.DeviceTypeID == deviceTypeID && (d.AddInRecSystem == nil || *d.AddInRecSystem) {
+			return d.ID, true
+		}
+	}
+	return 0, false
+}
+
+// GetProductionJob godoc
+// @Summary     Получить производственное задание с маршрутом
+// @Tags        production_jobs
+// @Produce     json
+// @Param       jobId  path      int  true  "Production job ID"
+// @Success     200    {object}  ProductionJobDTO
+// @Failure     400    {object}  map[string]any
+// @Failure     404    {object}  map[string]any
+// @Failure     500    {object}  map[string]any
+// @Router      /api/production-jobs/{jobId} [get]
+func (h *Handlers) GetProductionJob(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "jobId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid jobId"})
+		return
+	}
+	item, err := h.repos.GetProductionJob(r.Context(), id)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "production job not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, productionJobDTO(item))
+}
+
+// DeleteProductionJob godoc
+// @Summary     Удалить производственное задание вместе с операциями
+// @Tags        production_jobs
+// @Produce     json
+// @Param       jobId  path      int  true  "Production job ID"
+// @Success     200    {object}  map[string]any
+// @Failure     400    {object}  map[string]any
+// @Failure     500    {object}  map[string]any
+// @Router      /api/production-jobs/{jobId} [delete]
+func (h *Handlers) DeleteProductionJob(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "jobId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid jobId"})
+		return
+	}
+	if err := h.repos.DeleteProductionJob(r.Context(), id); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, map[string]any{"ok": true})
+}
+
+func customerOrderDTO(v service.CustomerOrderView) CustomerOrderDTO {
+	dto := CustomerOrderDTO{
+		ID:                v.Order.ID,
+		Customer:          v.Order.Customer,
+		DocNum:            v.Order.DocNum,
+		DueDate:           v.Order.DueDate,
+		PriorityID:        v.Order.PriorityID,
+		WorkspaceID:       v.Order.WorkspaceID,
+		Status:            string(v.Progress.Status),
+		TasksTotal:        v.Progress.Tasks,
+		TasksDone:         v.Progress.Done,
+		TasksUnplanned:    v.Progress.Unplanned,
+		PlannedCompletion: v.Progress.PlannedCompletion,
+		Late:              v.Progress.Late,
+		Tasks:             make([]DeviceTaskDTO, 0, len(v.Tasks)),
+	}
+	for _, t := range v.Tasks {
+		dto.Tasks = append(dto.Tasks, deviceTaskRowDTO(t))
+	}
+	return dto
+}
+
+func validateCustomerOrder(req CustomerOrderRequest) string {
+	if strings.TrimSpace(req.Customer) == "" || req.PriorityID <= 0 {
+		return "customer and priority_id required"
+	}
+	return ""
+}
+
+// ListCustomerOrders godoc
+// @Summary     Заказы клиентов со сводкой по заданиям
+// @Description Сводный статус заказа: cancelled — отменены все задания, done — завершены все неотменённые, failed — есть задание со сбоем, in_progress — задание выполняется или часть завершена, on_hold — есть отложенное, иначе pending.
+// @Tags        customer_orders
+// @Produce     json
+// @Param       workspaceId  path      int  true  "Workspace ID"
+// @Success     200          {array}   CustomerOrderDTO
+// @Failure     400          {object}  map[string]any
+// @Failure     500          {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/customer-orders [get]
+func (h *Handlers) ListCustomerOrders(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	if err := h.repos.DeleteDeviceTaskType(r.Context(), id); err != nil {
+	items, err := h.planner.CustomerOrders(r.Context(), workspaceID)
+	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, map[string]any{"ok": true})
+	dtos := make([]CustomerOrderDTO, 0, len(items))
+	for _, v := range items {
+		dtos = append(dtos, customerOrderDTO(v))
+	}
+	writeJSON(w, 200, dtos)
 }
 
-// CreateDeviceTask godoc
-// @Summary     Создать задачу оборудования
-// @Tags        device_tasks
+// CreateCustomerOrder godoc
+// @Summary     Создать заказ клиента
+// @Description Задания включаются в заказ полем order_id. Планировщик ставит задания заказа подряд, под общим сроком и приоритетом заказа.
+// @Tags        customer_orders
 // @Accept      json
 // @Produce     json
-// @Param       workspaceId  path      int               true  "Workspace ID"
-// @Param       body         body      DeviceTaskRequest  true  "Device task payload"
+// @Param       workspaceId  path      int                   true  "Workspace ID"
+// @Param       body         body      CustomerOrderRequest  true  "Order payload"
 // @Success     201          {object}  map[string]any
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/device-tasks [post]
-func (h *Handlers) CreateDeviceTask(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/customer-orders [post]
+func (h *Handlers) CreateCustomerOrder(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	var req DeviceTaskRequest
+	var req CustomerOrderRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	if req.Name == "" || req.DocNum == "" {
-		writeJSON(w, 400, map[string]any{"error": "name and doc_num required"})
+	if msg := validateCustomerOrder(req); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
 		return
 	}
-	completion := req.CompletionMark
-	if completion == "" {
-		completion = "false"
-	}
-	id, err := h.repos.CreateDeviceTask(r.Context(), storage.DeviceTask{
-		Name:             req.Name,
-		Deadline:         req.Deadline,
-		Duration:         minutesToDuration(req.DurationMin),
-		SetupTime:        minutesToDuration(req.SetupTimeMin),
-		UnloadTime:       minutesToDuration(req.UnloadTimeMin),
-		NeedOperator:     req.NeedOperator,
-		PhotoURL:         req.PhotoURL,
-		PlanStart:        req.PlanStart,
-		PlanEnd:          req.PlanEnd,
-		DocNum:           req.DocNum,
-		CompletionMark:   completion,
-		AddInRecSystem:   req.AddInRecSystem,
-		DeviceTaskTypeID: req.DeviceTaskTypeID,
-		WorkspaceID:      workspaceID,
-		OperatorID:       req.OperatorID,
-		DeviceID:         req.DeviceID,
-		PriorityID:       req.PriorityID,
+	id, err := h.repos.CreateCustomerOrder(r.Context(), storage.CustomerOrder{
+		Customer:    strings.TrimSpace(req.Customer),
+		DocNum:      req.DocNum,
+		DueDate:     req.DueDate,
+		PriorityID:  req.PriorityID,
+		WorkspaceID: workspaceID,
 	})
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
@@ -1523,68 +4223,53 @@ func (h *Handlers) CreateDeviceTask(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 201, map[string]any{"id": id})
 }
 
-// GetDeviceTask godoc
-// @Summary     Получить задачу оборудования
-// @Tags        device_tasks
+// GetCustomerOrder godoc
+// @Summary     Получить заказ клиента с заданиями
+// @Tags        customer_orders
 // @Produce     json
-// @Param       deviceTaskId  path      int  true  "Device task ID"
-// @Success     200           {object}  DeviceTaskDTO
-// @Failure     400           {object}  map[string]any
-// @Failure     500           {object}  map[string]any
-// @Router      /api/device-tasks/{deviceTaskId} [get]
-func (h *Handlers) GetDeviceTask(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceTaskId")
+// @Param       orderId  path      int  true  "Customer order ID"
+// @Success     200      {object}  CustomerOrderDTO
+// @Failure     400      {object}  map[string]any
+// @Failure     404      {object}  map[string]any
+// @Failure     500      {object}  map[string]any
+// @Router      /api/customer-orders/{orderId} [get]
+func (h *Handlers) GetCustomerOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "orderId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid orderId"})
 		return
 	}
-	item, err := h.repos.GetDeviceTask(r.Context(), id)
+	item, err := h.planner.CustomerOrder(r.Context(), id)
 	if err != nil {
 		if isNotFound(err) {
-			writeJSON(w, 404, map[string]any{"error": "device task not found"})
+			writeJSON(w, 404, map[string]any{"error": "customer order not found"})
 			return
 		}
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, DeviceTaskDTO{
-		ID:            item.ID,
-		Name:          item.Name,
-		Deadline:      item.Deadline,
-		DurationMin:   int(item.Duration.Minutes()),
-		SetupTimeMin:  int(item.SetupTime.Minutes()),
-		UnloadTimeMin: int(item.UnloadTime.Minutes()),
-		NeedOperator:  item.NeedOperator,
-		PlanStart:     item.PlanStart,
-		PlanEnd:       item.PlanEnd,
-		DocNum:        item.DocNum,
-		PriorityID:    item.PriorityID,
-		OperatorID:    item.OperatorID,
-		DeviceID:      item.DeviceID,
-		TaskTypeID:    item.DeviceTaskTypeID,
-		WorkspaceID:   item.WorkspaceID,
-	})
+	writeJSON(w, 200, customerOrderDTO(item))
 }
 
-// UpdateDeviceTask godoc
-// @Summary     Обновить задачу оборудования
-// @Tags        device_tasks
+// UpdateCustomerOrder godoc
+// @Summary     Обновить заказ клиента
+// @Tags        customer_orders
 // @Accept      json
 // @Produce     json
-// @Param       deviceTaskId  path      int               true  "Device task ID"
-// @Param       workspace_id  query     int               true  "Workspace ID"
-// @Param       body          body      DeviceTaskRequest  true  "Device task payload"
+// @Param       orderId       path      int                   true  "Customer order ID"
+// @Param       workspace_id  query     int                   true  "Workspace ID"
+// @Param       body          body      CustomerOrderRequest  true  "Order payload"
 // @Success     200           {object}  map[string]any
 // @Failure     400           {object}  map[string]any
 // @Failure     500           {object}  map[string]any
-// @Router      /api/device-tasks/{deviceTaskId} [put]
-func (h *Handlers) UpdateDeviceTask(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceTaskId")
+// @Router      /api/customer-orders/{orderId} [put]
+func (h *Handlers) UpdateCustomerOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "orderId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid orderId"})
 		return
 	}
-	var req DeviceTaskRequest
+	var req CustomerOrderRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
@@ -1599,29 +4284,17 @@ func (h *Handlers) UpdateDeviceTask(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
 		return
 	}
-	completion := req.CompletionMark
-	if completion == "" {
-		completion = "false"
+	if msg := validateCustomerOrder(req); msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
 	}
-	if err := h.repos.UpdateDeviceTask(r.Context(), storage.DeviceTask{
-		ID:               id,
-		Name:             req.Name,
-		Deadline:         req.Deadline,
-		Duration:         minutesToDuration(req.DurationMin),
-		SetupTime:        minutesToDuration(req.SetupTimeMin),
-		UnloadTime:       minutesToDuration(req.UnloadTimeMin),
-		NeedOperator:     req.NeedOperator,
-		PhotoURL:         req.PhotoURL,
-		PlanStart:        req.PlanStart,
-		PlanEnd:          req.PlanEnd,
-		DocNum:           req.DocNum,
-		CompletionMark:   completion,
-		AddInRecSystem:   req.AddInRecSystem,
-		DeviceTaskTypeID: req.DeviceTaskTypeID,
-		WorkspaceID:      workspaceID,
-		OperatorID:       req.OperatorID,
-		DeviceID:         req.DeviceID,
-		PriorityID:       req.PriorityID,
+	if err := h.repos.UpdateCustomerOrder(r.Context(), storage.CustomerOrder{
+		ID:          id,
+		Customer:    strings.TrimSpace(req.Customer),
+		DocNum:      req.DocNum,
+		DueDate:     req.DueDate,
+		PriorityID:  req.PriorityID,
+		WorkspaceID: workspaceID,
 	}); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -1629,83 +4302,193 @@ func (h *Handlers) UpdateDeviceTask(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// DeleteDeviceTask godoc
-// @Summary     Удалить задачу оборудования
-// @Tags        device_tasks
+// DeleteCustomerOrder godoc
+// @Summary     Удалить заказ клиента
+// @Description Задания заказа остаются, но без заказа.
+// @Tags        customer_orders
 // @Produce     json
-// @Param       deviceTaskId  path      int  true  "Device task ID"
-// @Success     200           {object}  map[string]any
-// @Failure     400           {object}  map[string]any
-// @Failure     500           {object}  map[string]any
-// @Router      /api/device-tasks/{deviceTaskId} [delete]
-func (h *Handlers) DeleteDeviceTask(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "deviceTaskId")
+// @Param       orderId  path      int  true  "Customer order ID"
+// @Success     200      {object}  map[string]any
+// @Failure     400      {object}  map[string]any
+// @Failure     500      {object}  map[string]any
+// @Router      /api/customer-orders/{orderId} [delete]
+func (h *Handlers) DeleteCustomerOrder(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "orderId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid deviceTaskId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid orderId"})
 		return
 	}
-	if err := h.repos.DeleteDeviceTask(r.Context(), id); err != nil {
+	if err := h.repos.DeleteCustomerOrder(r.Context(), id); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
 
-// ListUserTasks godoc
-// @Summary     Список пользовательских задач
-// @Tags        user_tasks
+func taskTemplateDTO(t storage.TaskTemplate) TaskTemplateDTO {
+	return TaskTemplateDTO{
+		ID:               t.ID,
+		Name:             t.Name,
+		DocNum:           t.DocNum,
+		DeviceTaskTypeID: t.DeviceTaskTypeID,
+		DeviceTypeID:     t.DeviceTypeID,
+		OperatorID:       t.OperatorID,
+		PriorityID:       t.PriorityID,
+		NeedOperator:     t.NeedOperator,
+		DurationMin:      int(t.Duration.Minutes()),
+		SetupTimeMin:     int(t.SetupTime.Minutes()),
+		UnloadTimeMin:    int(t.UnloadTime.Minutes()),
+		MaterialID:       t.MaterialID,
+		MaterialQty:      t.MaterialQty,
+		Recurrence:       t.Recurrence,
+		Interval:         t.Interval,
+		StartAt:          t.StartAt,
+		EndAt:            t.EndAt,
+		Active:           t.Active,
+		WorkspaceID:      t.WorkspaceID,
+	}
+}
+
+// taskTemplate проверяет шаблон: тип задания и тип оборудования должны быть
+// из workspace. Непустое сообщение — ошибка запроса.
+func (h *Handlers) taskTemplate(ctx context.Context, workspaceID int64, req TaskTemplateRequest) (storage.TaskTemplate, string, error) {
+	if strings.TrimSpace(req.Name) == "" || req.DocNum == "" {
+		return storage.TaskTemplate{}, "name and doc_num required", nil
+	}
+	if req.DeviceTaskTypeID <= 0 || req.DeviceTypeID <= 0 || req.PriorityID <= 0 {
+		return storage.TaskTemplate{}, "device_task_type_id, device_type_id and priority_id required", nil
+	}
+	if req.NeedOperator && req.OperatorID <= 0 {
+		return storage.TaskTemplate{}, "operator_id required when need_operator is set", nil
+	}
+	// Длительности хранятся в TIME.
+	for _, m := range []int{req.DurationMin, req.SetupTimeMin, req.UnloadTimeMin} {
+		if m < 0 || m >= 24*60 {
+			return storage.TaskTemplate{}, "durations must be in [0, 1440) minutes", nil
+		}
+	}
+	if req.MaterialQty < 0 {
+		return storage.TaskTemplate{}, "material_qty must not be negative", nil
+	}
+	switch req.Recurrence {
+	case storage.RecurrenceWeekly, storage.RecurrenceMonthly, storage.RecurrenceEveryNDays:
+	default:
+		return storage.TaskTemplate{}, "recurrence must be weekly, monthly or every_n_days", nil
+	}
+	if req.Interval == 0 {
+		req.Interval = 1
+	}
+	if req.Interval < 0 || req.Interval > 366 {
+		return storage.TaskTemplate{}, "interval must be in [1, 366]", nil
+	}
+	if req.StartAt.IsZero() {
+		return storage.TaskTemplate{}, "start_at required", nil
+	}
+	if req.EndAt != nil && req.EndAt.Before(req.StartAt) {
+		return storage.TaskTemplate{}, "end_at must not be before start_at", nil
+	}
+
+	taskTypes, err := h.repos.ListDeviceTaskTypes(ctx, workspaceID)
+	if err != nil {
+		return storage.TaskTemplate{}, "", err
+	}
+	if !slices.ContainsFunc(taskTypes, func(t storage.DeviceTaskType) bool { return t.ID == req.DeviceTaskTypeID }) {
+		return storage.TaskTemplate{}, "device task type not found in workspace", nil
+	}
+	deviceTypes, err := h.repos.ListDeviceTypes(ctx, workspaceID)
+	if err != nil {
+		return storage.TaskTemplate{}, "", err
+	}
+	if !slices.ContainsFunc(deviceTypes, func(t storage.DeviceType) bool { return t.ID == req.DeviceTypeID }) {
+		return storage.TaskTemplate{}, "device type not found in workspace", nil
+	}
+
+	active := true
+	if req.Active != nil {
+		active = *req.Active
+	}
+	return storage.TaskTemplate{
+		Name:             strings.TrimSpace(req.Name),
+		DocNum:           req.DocNum,
+		Duration:         minutesToDuration(req.DurationMin),
+		SetupTime:        minutesToDuration(req.SetupTimeMin),
+		UnloadTime:       minutesToDuration(req.UnloadTimeMin),
+		NeedOperator:     req.NeedOperator,
+		MaterialID:       req.MaterialID,
+		MaterialQty:      req.MaterialQty,
+		Recurrence:       req.Recurrence,
+		Interval:         req.Interval,
+		StartAt:          req.StartAt,
+		EndAt:            req.EndAt,
+		Active:           active,
+		DeviceTaskTypeID: req.DeviceTaskTypeID,
+		DeviceTypeID:     req.DeviceTypeID,
+		OperatorID:       req.OperatorID,
+		PriorityID:       req.PriorityID,
+		WorkspaceID:      workspaceID,
+	}, "", nil
+}
+
+// ListTaskTemplates godoc
+// @Summary     Шаблоны повторяющихся заданий
+// @Tags        task_templates
 // @Produce     json
 // @Param       workspaceId  path      int  true  "Workspace ID"
-// @Success     200          {array}   storage.UserTask
+// @Success     200          {array}   TaskTemplateDTO
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/user-tasks [get]
-func (h *Handlers) ListUserTasks(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/task-templates [get]
+func (h *Handlers) ListTaskTemplates(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	items, err := h.repos.ListUserTasks(r.Context(), workspaceID)
+	items, err := h.repos.ListTaskTemplates(r.Context(), workspaceID)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, items)
+	dtos := make([]TaskTemplateDTO, 0, len(items))
+	for _, t := range items {
+		dtos = append(dtos, taskTemplateDTO(t))
+	}
+	writeJSON(w, 200, dtos)
 }
 
-// CreateUserTask godoc
-// @Summary     Создать пользовательскую задачу
-// @Tags        user_tasks
+// CreateTaskTemplate godoc
+// @Summary     Создать шаблон повторяющегося задания
+// @Description Задания по шаблону создаёт POST /api/workspaces/{workspaceId}/task-templates/generate.
+// @Tags        task_templates
 // @Accept      json
 // @Produce     json
-// @Param       workspaceId  path      int              true  "Workspace ID"
-// @Param       body         body      UserTaskRequest  true  "User task payload"
+// @Param       workspaceId  path      int                  true  "Workspace ID"
+// @Param       body         body      TaskTemplateRequest  true  "Template payload"
 // @Success     201          {object}  map[string]any
 // @Failure     400          {object}  map[string]any
 // @Failure     500          {object}  map[string]any
-// @Router      /api/workspaces/{workspaceId}/user-tasks [post]
-func (h *Handlers) CreateUserTask(w http.ResponseWriter, r *http.Request) {
+// @Router      /api/workspaces/{workspaceId}/task-templates [post]
+func (h *Handlers) CreateTaskTemplate(w http.ResponseWriter, r *http.Request) {
 	workspaceID, err := parseIDParam(r, "workspaceId")
 	if err != nil || workspaceID <= 0 {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
 		return
 	}
-	var req UserTaskRequest
+	var req TaskTemplateRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
 	}
-	id, err := h.repos.CreateUserTask(r.Context(), storage.UserTask{
-		Name:           req.Name,
-		StartTime:      req.StartTime,
-		EndTime:        req.EndTime,
-		Priority:       req.Priority,
-		CompletionMark: req.CompletionMark,
-		WorkspaceID:    workspaceID,
-		DeviceTaskID:   req.DeviceTaskID,
-		OperatorID:     req.OperatorID,
-	})
+	tpl, msg, err := h.taskTemplate(r.Context(), workspaceID, req)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	id, err := h.repos.CreateTaskTemplate(r.Context(), tpl)
 	if err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
@@ -1713,25 +4496,56 @@ func (h *Handlers) CreateUserTask(w http.ResponseWriter, r *http.Request) {
 	writeJSON(w, 201, map[string]any{"id": id})
 }
 
-// UpdateUserTask godoc
-// @Summary     Обновить пользовательскую задачу
-// @Tags        user_tasks
+// GetTaskTemplate godoc
+// @Summary     Получить шаблон повторяющегося задания
+// @Tags        task_templates
+// @Produce     json
+// @Param       templateId  path      int  true  "Template ID"
+// @Success     200         {object}  TaskTemplateDTO
+// @Failure     400         {object}  map[string]any
+// @Failure     404         {object}  map[string]any
+// @Failure     500         {object}  map[string]any
+// @Router      /api/task-templates/{templateId} [get]
+func (h *Handlers) GetTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "templateId")
+	if err != nil || id <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid templateId"})
+		return
+	}
+	item, err := h.repos.GetTaskTemplate(r.Context(), id)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "task template not found"})
+			return
+		}
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, taskTemplateDTO(item))
+}
+
+// UpdateTaskTemplate godoc
+// @Summary     Обновить шаблон повторяющегося задания
+// @Description С apply_to_future=true параметры шаблона переносятся и в уже созданные ожидающие задания с повторением позже текущего момента; новое правило повторения действует только для ещё не созданных повторений.
+// @Tags        task_templates
 // @Accept      json
 // @Produce     json
-// @Param       userTaskId    path      int              true  "User task ID"
-// @Param       workspace_id  query     int              true  "Workspace ID"
-// @Param       body          body      UserTaskRequest  true  "User task payload"
-// @Success     200           {object}  map[string]any
-// @Failure     400           {object}  map[string]any
-// @Failure     500           {object}  map[string]any
-// @Router      /api/user-tasks/{userTaskId} [put]
-func (h *Handlers) UpdateUserTask(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "userTaskId")
+// @Param       templateId       path      int                  true   "Template ID"
+// @Param       workspace_id     query     int                  true   "Workspace ID"
+// @Param       apply_to_future  query     bool                 false  "Обновить будущие задания шаблона"
+// @Param       body             body      TaskTemplateRequest  true   "Template payload"
+// @Success     200              {object}  map[string]any
+// @Failure     400              {object}  map[string]any
+// @Failure     404              {object}  map[string]any
+// @Failure     500              {object}  map[string]any
+// @Router      /api/task-templates/{templateId} [put]
+func (h *Handlers) UpdateTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "templateId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid userTaskId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid templateId"})
 		return
 	}
-	var req UserTaskRequest
+	var req TaskTemplateRequest
 	if err := decodeJSON(r, &req); err != nil {
 		writeJSON(w, 400, map[string]any{"error": "bad json"})
 		return
@@ -1746,41 +4560,102 @@ func (h *Handlers) UpdateUserTask(w http.ResponseWriter, r *http.Request) {
 		writeJSON(w, 400, map[string]any{"error": "invalid workspace_id"})
 		return
 	}
-	if err := h.repos.UpdateUserTask(r.Context(), storage.UserTask{
-		ID:             id,
-		Name:           req.Name,
-		StartTime:      req.StartTime,
-		EndTime:        req.EndTime,
-		Priority:       req.Priority,
-		CompletionMark: req.CompletionMark,
-		WorkspaceID:    workspaceID,
-		DeviceTaskID:   req.DeviceTaskID,
-		OperatorID:     req.OperatorID,
-	}); err != nil {
+	applyToFuture := false
+	if raw := r.URL.Query().Get("apply_to_future"); raw != "" {
+		if applyToFuture, err = strconv.ParseBool(raw); err != nil {
+			writeJSON(w, 400, map[string]any{"error": "invalid apply_to_future"})
+			return
+		}
+	}
+	current, err := h.repos.GetTaskTemplate(r.Context(), id)
+	if err != nil {
+		if isNotFound(err) {
+			writeJSON(w, 404, map[string]any{"error": "task template not found"})
+			return
+		}
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
-	writeJSON(w, 200, map[string]any{"ok": true})
+	if current.WorkspaceID != workspaceID {
+		writeJSON(w, 404, map[string]any{"error": "task template not found"})
+		return
+	}
+	tpl, msg, err := h.taskTemplate(r.Context(), workspaceID, req)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	if msg != "" {
+		writeJSON(w, 400, map[string]any{"error": msg})
+		return
+	}
+	tpl.ID = id
+	if err := h.repos.UpdateTaskTemplate(r.Context(), tpl); err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	var updated int64
+	if applyToFuture {
+		if updated, err = h.planner.ApplyTemplate(r.Context(), tpl, time.Now()); err != nil {
+			writeJSON(w, 500, map[string]any{"error": err.Error()})
+			return
+		}
+	}
+	writeJSON(w, 200, map[string]any{"ok": true, "updated_tasks": updated})
 }
 
-// DeleteUserTask godoc
-// @Summary     Удалить пользовательскую задачу
-// @Tags        user_tasks
+// DeleteTaskTemplate godoc
+// @Summary     Удалить шаблон повторяющегося задания
+// @Description Созданные по шаблону задания остаются.
+// @Tags        task_templates
 // @Produce     json
-// @Param       userTaskId  path      int  true  "User task ID"
+// @Param       templateId  path      int  true  "Template ID"
 // @Success     200         {object}  map[string]any
 // @Failure     400         {object}  map[string]any
 // @Failure     500         {object}  map[string]any
-// @Router      /api/user-tasks/{userTaskId} [delete]
-func (h *Handlers) DeleteUserTask(w http.ResponseWriter, r *http.Request) {
-	id, err := parseIDParam(r, "userTaskId")
+// @Router      /api/task-templates/{templateId} [delete]
+func (h *Handlers) DeleteTaskTemplate(w http.ResponseWriter, r *http.Request) {
+	id, err := parseIDParam(r, "templateId")
 	if err != nil || id <= 0 {
-		writeJSON(w, 400, map[string]any{"error": "invalid userTaskId"})
+		writeJSON(w, 400, map[string]any{"error": "invalid templateId"})
 		return
 	}
-	if err := h.repos.DeleteUserTask(r.Context(), id); err != nil {
+	if err := h.repos.DeleteTaskTemplate(r.Context(), id); err != nil {
 		writeJSON(w, 500, map[string]any{"error": err.Error()})
 		return
 	}
 	writeJSON(w, 200, map[string]any{"ok": true})
 }
+
+// GenerateTemplateTasks godoc
+// @Summary     Создать задания по шаблонам на горизонт вперёд
+// @Description Для каждого активного шаблона создаются задания повторений до now + horizon_days, которые ещё не созданы; повторный вызов дублей не создаёт. Срок задания — момент повторения, задание адресовано типу оборудования шаблона.
+// @Tags        task_templates
+// @Produce     json
+// @Param       workspaceId   path      int  true   "Workspace ID"
+// @Param       horizon_days  query     int  false  "Горизонт в днях (1–365, по умолчанию 14)"
+// @Success     200           {object}  service.TemplateRun
+// @Failure     400           {object}  map[string]any
+// @Failure     500           {object}  map[string]any
+// @Router      /api/workspaces/{workspaceId}/task-templates/generate [post]
+func (h *Handlers) GenerateTemplateTasks(w http.ResponseWriter, r *http.Request) {
+	workspaceID, err := parseIDParam(r, "workspaceId")
+	if err != nil || workspaceID <= 0 {
+		writeJSON(w, 400, map[string]any{"error": "invalid workspaceId"})
+		return
+	}
+	days, err := queryInt64(r, "horizon_days")
+	if err != nil || days > 365 {
+		writeJSON(w, 400, map[string]any{"error": "horizon_days must be in [1, 365]"})
+		return
+	}
+	if days == 0 {
+		days = 14
+	}
+	res, err := h.planner.GenerateTemplateTasks(r.Context(), workspaceID, time.Now(), time.Duration(days)*24*time.Hour)
+	if err != nil {
+		writeJSON(w, 500, map[string]any{"error": err.Error()})
+		return
+	}
+	writeJSON(w, 200, res)
+}
@@ -62,6 +62,13 @@ func NewRouter(h *Handlers) http.Handler {
 
 				ws.Get("/device-tasks", h.ListDeviceTasks)
 				ws.Post("/device-tasks", h.CreateDeviceTask)
+				ws.Get("/production-jobs", h.ListProductionJobs)
+				ws.Post("/production-jobs", h.CreateProductionJob)
+				ws.Get("/customer-orders", h.ListCustomerOrders)
+				ws.Post("/customer-orders", h.CreateCustomerOrder)
+				ws.Get("/task-templates", h.ListTaskTemplates)
+				ws.Post("/task-templates", h.CreateTaskTemplate)
+				ws.Post("/task-templates/generate", h.GenerateTemplateTasks)
 				ws.Get("/device-task-types", h.ListDeviceTaskTypes)
 				ws.Post("/device-task-types", h.CreateDeviceTaskType)
 				ws.Get("/user-tasks", h.ListUserTasks)
@@ -73,13 +80,42 @@ func NewRouter(h *Handlers) http.Handler {
 				ws.Post("/operator-competencies", h.CreateOperatorCompetency)
 				ws.Get("/operator-devices", h.ListOperatorDevices)
 				ws.Post("/operator-devices", h.CreateOperatorDevice)
+				ws.Get("/labour-rules", h.GetLabourRules)
+				ws.Put("/labour-rules", h.SetLabourRules)
+				ws.Get("/priority-aging", h.GetPriorityAging)
+				ws.Put("/priority-aging", h.SetPriorityAging)
+				ws.Get("/operator-workload", h.OperatorWorkload)
+				ws.Get("/cost-report", h.CostReport)
 
 				ws.Get("/devices", h.ListDevices)
 				ws.Post("/devices", h.CreateDevice)
+				ws.Get("/device-downtime", h.ListDeviceDowntime)
+				ws.Get("/device-pools", h.ListDevicePools)
+				ws.Post("/device-pools", h.CreateDevicePool)
 				ws.Get("/device-types", h.ListDeviceTypes)
 				ws.Post("/device-types", h.CreateDeviceType)
 				ws.Get("/equipment-characteristics", h.ListEquipmentCharacteristics)
 				ws.Post("/equipment-characteristics", h.CreateEquipmentCharacteristic)
+				ws.Get("/material-changeovers", h.ListMaterialChangeovers)
+				ws.Post("/material-changeovers", h.CreateMaterialChangeover)
+				ws.Get("/material-stock", h.ListMaterialStock)
+				ws.Post("/material-stock", h.CreateMaterialStock)
+				ws.Get("/energy-tariffs", h.GetEnergySchedule)
+				ws.Put("/energy-tariffs", h.SetEnergySchedule)
+				ws.Get("/characteristics", h.ListCharacteristics)
+				ws.Post("/characteristics", h.CreateCharacteristic)
+				ws.Get("/characteristic-values", h.ListCharacteristicValues)
+				ws.Post("/characteristic-values", h.SetCharacteristicValue)
+
+				ws.Get("/duration-stats", h.ListDurationStats)
+				ws.Get("/duration-stats/suggest", h.SuggestDuration)
+				ws.Get("/plan/risk", h.AnalyzePlanRisk)
+				ws.Get("/plan/material-swaps", h.ListMaterialSwaps)
+				ws.Get("/plan/energy", h.EnergyEstimate)
+				ws.Get("/plan/priorities", h.ListTaskPriorities)
+				ws.Get("/plan/lateness", h.PlanLateness)
+				ws.Get("/plan/conflicts", h.PlanConflicts)
+				ws.Get("/snapshot", h.ExportSnapshot)
 			})
 		})
 
@@ -107,11 +143,45 @@ func NewRouter(h *Handlers) http.Handler {
 			r.Get("/{deviceTaskId}", h.GetDeviceTask)
 			r.Put("/{deviceTaskId}", h.UpdateDeviceTask)
 			r.Delete("/{deviceTaskId}", h.DeleteDeviceTask)
+			r.Post("/{deviceTaskId}/status", h.SetDeviceTaskStatus)
+			r.Post("/{deviceTaskId}/move", h.MoveDeviceTask)
+			r.Post("/{deviceTaskId}/pin", h.PinDeviceTask)
+			r.Get("/{deviceTaskId}/requirements", h.ListTaskRequirements)
+			r.Post("/{deviceTaskId}/requirements", h.CreateTaskRequirement)
+			r.Get("/{deviceTaskId}/eligible-devices", h.ListEligibleDevices)
+			r.Get("/{deviceTaskId}/cost", h.GetTaskCost)
+		})
+
+		api.Route("/production-jobs", func(r chi.Router) {
+			r.Get("/{jobId}", h.GetProductionJob)
+			r.Delete("/{jobId}", h.DeleteProductionJob)
+		})
+
+		api.Route("/customer-orders", func(r chi.Router) {
+			r.Get("/{orderId}", h.GetCustomerOrder)
+			r.Put("/{orderId}", h.UpdateCustomerOrder)
+			r.Delete("/{orderId}", h.DeleteCustomerOrder)
+		})
+
+		api.Route("/task-templates", func(r chi.Router) {
+			r.Get("/{templateId}", h.GetTaskTemplate)
+			r.Put("/{templateId}", h.UpdateTaskTemplate)
+			r.Delete("/{templateId}", h.DeleteTaskTemplate)
 		})
 
 		api.Route("/devices", func(r chi.Router) {
 			r.Put("/{deviceId}", h.UpdateDevice)
 			r.Delete("/{deviceId}", h.DeleteDevice)
+			r.Post("/{deviceId}/loaded-material", h.SetDeviceLoadedMaterial)
+		})
+
+		api.Route("/device-pools", func(r chi.Router) {
+			r.Put("/{poolId}", h.UpdateDevicePool)
+			r.Delete("/{poolId}", h.DeleteDevicePool)
+		})
+
+		api.Route("/device-downtime", func(r chi.Router) {
+			r.Delete("/{downtimeId}", h.DeleteDeviceDowntime)
 		})
 
 		api.Route("/device-types", func(r chi.Router) {
@@ -124,6 +194,29 @@ func NewRouter(h *Handlers) http.Handler {
 			r.Delete("/{characteristicId}", h.DeleteEquipmentCharacteristic)
 		})
 
+		api.Route("/material-changeovers", func(r chi.Router) {
+			r.Put("/{changeoverId}", h.UpdateMaterialChangeover)
+			r.Delete("/{changeoverId}", h.DeleteMaterialChangeover)
+		})
+
+		api.Route("/material-stock", func(r chi.Router) {
+			r.Put("/{stockId}", h.UpdateMaterialStock)
+			r.Delete("/{stockId}", h.DeleteMaterialStock)
+		})
+
+		api.Route("/characteristics", func(r chi.Router) {
+			r.Put("/{characteristicId}", h.UpdateCharacteristic)
+			r.Delete("/{characteristicId}", h.DeleteCharacteristic)
+		})
+
+		api.Route("/characteristic-values", func(r chi.Router) {
+			r.Delete("/{valueId}", h.DeleteCharacteristicValue)
+		})
+
+		api.Route("/task-requirements", func(r chi.Router) {
+			r.Delete("/{requirementId}", h.DeleteTaskRequirement)
+		})
+
 		api.Route("/operators", func(r chi.Router) {
 			r.Put("/{operatorId}", h.UpdateOperator)
 			r.Delete("/{operatorId}", h.DeleteOperator)
@@ -145,6 +238,7 @@ func NewRouter(h *Handlers) http.Handler {
 		})
 
 		api.Post("/plans/recompute", h.RecomputePlan)
+		api.Post("/plans/disruptions", h.ReportDisruption)
 
 		// Catch-all for unknown API endpoints → JSON 404.
 		api.NotFound(func(w http.ResponseWriter, r *http.Request) {
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Aging — старение приоритета: по мере приближения дедлайна единица
+// планирования поднимается по уровням приоритета.
+type Aging struct {
+	rules  storage.PriorityAging
+	levels []int64       // ID приоритетов от высшего (меньший ID) к низшему
+	level  map[int64]int // ID приоритета -> уровень
+}
+
+// NewAging — старение по правилу workspace и глобальному списку приоритетов;
+// nil — приоритет не стареет.
+func NewAging(rules storage.PriorityAging, priorities []storage.Priority) *Aging {
+	if !rules.Enabled() || len(priorities) == 0 {
+		return nil
+	}
+	a := &Aging{rules: rules, level: make(map[int64]int, len(priorities))}
+	for _, p := range priorities {
+		a.levels = append(a.levels, p.ID)
+	}
+	sort.Slice(a.levels, func(i, j int) bool { return a.levels[i] < a.levels[j] })
+	for i, id := range a.levels {
+		a.level[id] = i
+	}
+	return a
+}
+
+// effective — эффективный приоритет единицы с приоритетом priorityID, сроком
+// due и оставшейся работой work на момент now и число уровней, на которые он
+// поднялся. Внутри окна до дедлайна (в режиме slack — до запаса) приоритет
+// поднимается на уровень за каждый полный шаг, но не выше высшего.
+func (a *Aging) effective(priorityID int64, due time.Time, work time.Duration, now time.Time) (int64, int) {
+	lvl, ok := a.level[priorityID]
+	if !ok {
+		return priorityID, 0
+	}
+	left := due.Sub(now)
+	if a.rules.Mode == storage.AgingSlack {
+		left -= work
+	}
+	if left >= a.rules.Window {
+		return priorityID, 0
+	}
+	boost := min(int((a.rules.Window-left)/a.rules.Step), lvl)
+	return a.levels[lvl-boost], boost
+}
+
+// unitWork — оставшаяся работа единицы: прогон длится как самое долгое
+// задание в нём, операции маршрута идут одна за другой.
+func unitWork(u planUnit) time.Duration {
+	var res time.Duration
+	for _, t := range u.tasks {
+		d := t.SetupTime + t.Duration + t.UnloadTime
+		if u.batch {
+			res = max(res, d)
+		} else {
+			res += d
+		}
+	}
+	return res
+}
+
+// TaskPriority — очерёдность задания в плане: эффективный приоритет с
+// учётом старения и срок, под которым задание планируется.
+type TaskPriority struct {
+	TaskID   int64      `json:"task_id"`
+	Name     string     `json:"name"`
+	Deadline *time.Time `json:"deadline"`
+	// Due — срок, по которому задание стоит в очереди: свой дедлайн или
+	// общий срок заказа; nil — без срока.
+	Due                 *time.Time `json:"due"`
+	PriorityID          int64      `json:"priority_id"` // приоритет задания, у задания заказа — заказа
+	EffectivePriorityID int64      `json:"effective_priority_id"`
+	Boost               int        `json:"boost"` // на сколько уровней поднялся приоритет
+	// SlackMin — запас: минуты до срока минус оставшаяся работа; nil — без срока.
+	SlackMin *int `json:"slack_min"`
+	Position int  `json:"position"` // место в очереди планировщика, с 1
+}
+
+// TaskPriorities — задания к планированию в порядке, в котором их берёт
+// планировщик, с эффективным приоритетом на момент now. Маршруты и прогоны
+// планировщик ставит целиком, по самому срочному заданию.
+func (p *Planner) TaskPriorities(ctx context.Context, workspaceID int64, now time.Time) ([]TaskPriority, error) {
+	tasks, err := p.repos.ListTasksForPlanning(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	orders, err := p.repos.ListCustomerOrders(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	aging, err := p.aging(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+
+	farFuture := now.Add(maxScheduleAhead)
+	ranks := newOrderRanks(OrderIndex(orders), tasks, aging, now, farFuture)
+	type ranked struct {
+		item TaskPriority
+		rank unitRank
+	}
+	items := make([]ranked, 0, len(tasks))
+	for _, t := range tasks {
+		u := planUnit{tasks: []storage.DeviceTaskRow{t}}
+		r := ranks.rank(u, farFuture)
+		item := TaskPriority{
+			TaskID:              t.ID,
+			Name:                t.Name,
+			Deadline:            t.Deadline,
+			PriorityID:          r.base,
+			EffectivePriorityID: r.priority,
+			Boost:               r.boost,
+		}
+		if r.due.Before(farFuture) {
+			due := r.due
+			slack := int((due.Sub(now) - unitWork(u)).Minutes())
+			item.Due, item.SlackMin = &due, &slack
+		}
+		items = append(items, ranked{item: item, rank: r})
+	}
+	sort.SliceStable(items, func(i, j int) bool { return items[i].rank.before(items[j].rank) })
+	res := make([]TaskPriority, 0, len(items))
+	for i, it := range items {
+		it.item.Position = i + 1
+		res = append(res, it.item)
+	}
+	return res, nil
+}
+
+// aging — старение приоритета workspace; nil — приоритет не стареет.
+func (p *Planner) aging(ctx context.Context, workspaceID int64) (*Aging, error) {
+	rules, err := p.repos.GetPriorityAging(ctx, workspaceID)
+	if err != nil || !rules.Enabled() {
+		return nil, err
+	}
+	priorities, err := p.repos.ListPriorities(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return NewAging(rules, priorities), nil
+}
@@ -0,0 +1,61 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Порядок приоритетов не совпадает с их ID: 1 — высший, затем 2, затем 3.
+var agingPriorities = []storage.Priority{{ID: 3}, {ID: 1}, {ID: 2}}
+
+// Низший приоритет поднимается на уровень за каждые полные сутки внутри
+// трёхдневного окна и дальше высшего не идёт.
+func TestAgingClimbsTowardsDeadline(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	a := NewAging(storage.PriorityAging{Mode: storage.AgingDeadline, Window: 3 * day, Step: day}, agingPriorities)
+
+	wantByDaysLeft := []struct {
+		priority int64
+		boost    int
+	}{
+		3: {3, 0},
+		2: {2, 1},
+		1: {1, 2},
+		0: {1, 2},
+	}
+	for left := 3; left >= 0; left-- {
+		got, boost := a.effective(3, now.Add(time.Duration(left)*day), 0, now)
+		if want := wantByDaysLeft[left]; got != want.priority || boost != want.boost {
+			t.Errorf("%d days left: got (%d, %d), want (%d, %d)", left, got, boost, want.priority, want.boost)
+		}
+	}
+
+	if got, boost := a.effective(2, now, 0, now); got != 1 || boost != 1 {
+		t.Errorf("priority 2 at deadline: got (%d, %d), want (1, 1)", got, boost)
+	}
+	if got, boost := a.effective(1, now.Add(day), 0, now); got != 1 || boost != 0 {
+		t.Errorf("highest priority: got (%d, %d), want (1, 0)", got, boost)
+	}
+	if got, _ := a.effective(9, now, 0, now); got != 9 {
+		t.Errorf("unknown priority: got %d, want 9", got)
+	}
+}
+
+// В режиме slack окно отсчитывается от запаса: срока минус оставшаяся работа.
+func TestAgingSlack(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	day := 24 * time.Hour
+	a := NewAging(storage.PriorityAging{Mode: storage.AgingSlack, Window: 3 * day, Step: day}, agingPriorities)
+	if got, boost := a.effective(3, now.Add(3*day), day, now); got != 2 || boost != 1 {
+		t.Errorf("got (%d, %d), want (2, 1)", got, boost)
+	}
+}
+
+func TestNewAgingDisabled(t *testing.T) {
+	if a := NewAging(storage.PriorityAging{Mode: storage.AgingNone, Window: time.Hour, Step: time.Hour}, []storage.Priority{{ID: 1}}); a != nil {
+		t.Errorf("got %+v, want nil", a)
+	}
+}
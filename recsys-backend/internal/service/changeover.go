@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+type materialPair struct {
+	from int64
+	to   int64
+}
+
+// Changeovers — матрица переналадки оборудования между материалами.
+type Changeovers map[materialPair]time.Duration
+
+func NewChangeovers(items []storage.MaterialChangeover) Changeovers {
+	res := make(Changeovers, len(items))
+	for _, c := range items {
+		res[materialPair{from: c.FromMaterialID, to: c.ToMaterialID}] = c.Duration
+	}
+	return res
+}
+
+// Between — переналадка перед заданием из материала to после задания из from.
+// Неизвестный материал и пара, не заданная в матрице, переналадки не требуют.
+func (c Changeovers) Between(from, to int64) time.Duration {
+	if from == 0 || to == 0 {
+		return 0
+	}
+	return c[materialPair{from: from, to: to}]
+}
+
+// loadedMaterial — материал последнего задания на оборудовании, закончившегося
+// не позже at; 0 — неизвестен.
+func loadedMaterial(deviceBusy []interval, at time.Time) int64 {
+	var (
+		material int64
+		last     time.Time
+	)
+	for _, iv := range deviceBusy {
+		if iv.material != 0 && !iv.end.After(at) && !iv.end.Before(last) {
+			material, last = iv.material, iv.end
+		}
+	}
+	return material
+}
+
+// nextMaterial — ближайшее задание на оборудовании, начинающееся не раньше at.
+func nextMaterial(deviceBusy []interval, at time.Time) (interval, bool) {
+	var (
+		next  interval
+		found bool
+	)
+	for _, iv := range deviceBusy {
+		if iv.material != 0 && !iv.start.Before(at) && (!found || iv.start.Before(next.start)) {
+			next, found = iv, true
+		}
+	}
+	return next, found
+}
+
+// loadedMarker — отметка материала, заправленного в оборудование к моменту at:
+// интервал нулевой длины, который само оборудование не занимает.
+func loadedMarker(material int64, at time.Time) interval {
+	return interval{start: at, end: at, material: material}
+}
+
+// MaterialSwap — смена материала, которую оператор выполняет перед заданием.
+type MaterialSwap struct {
+	DeviceID       int64     `json:"device_id"`
+	TaskID         int64     `json:"task_id"`
+	At             time.Time `json:"at"`
+	FromMaterialID int64     `json:"from_material_id"` // 0 — заправленный материал неизвестен
+	ToMaterialID   int64     `json:"to_material_id"`
+}
+
+// MaterialSwaps — смены материала по плану: задания с материалом на каждом
+// оборудовании идут по времени начала, начиная с заправленного сейчас материала.
+// Выполняемое задание смены не требует — его материал уже заправлен.
+func MaterialSwaps(devices []storage.Device, tasks []storage.DeviceTaskRow) []MaterialSwap {
+	loaded := make(map[int64]int64, len(devices))
+	for _, d := range devices {
+		loaded[d.ID] = d.LoadedMaterialID
+	}
+	var planned []storage.DeviceTaskRow
+	for _, t := range tasks {
+		if t.MaterialID == 0 || t.DeviceID <= 0 || t.PlanStart == nil {
+			continue
+		}
+		if t.Status == storage.TaskStatusPending || t.Status == storage.TaskStatusInProgress {
+			planned = append(planned, t)
+		}
+	}
+	sort.SliceStable(planned, func(i, j int) bool {
+		if !planned[i].PlanStart.Equal(*planned[j].PlanStart) {
+			return planned[i].PlanStart.Before(*planned[j].PlanStart)
+		}
+		return planned[i].ID < planned[j].ID
+	})
+
+	res := []MaterialSwap{}
+	for _, t := range planned {
+		from := loaded[t.DeviceID]
+		loaded[t.DeviceID] = t.MaterialID
+		if from == t.MaterialID || t.Status == storage.TaskStatusInProgress {
+			continue
+		}
+		res = append(res, MaterialSwap{
+			DeviceID:       t.DeviceID,
+			TaskID:         t.ID,
+			At:             *t.PlanStart,
+			FromMaterialID: from,
+			ToMaterialID:   t.MaterialID,
+		})
+	}
+	return res
+}
+
+// planChangeovers — переналадка в начале слота заданий по сменам материала
+// плана и матрице changeovers. Переналадку прогона ведёт его первое задание.
+func planChangeovers(devices []storage.Device, tasks []storage.DeviceTaskRow, changeovers Changeovers) map[int64]time.Duration {
+	batch := make(map[int64]int64, len(tasks))
+	for _, t := range tasks {
+		batch[t.ID] = t.BatchID
+	}
+	res := map[int64]time.Duration{}
+	if len(changeovers) == 0 {
+		return res
+	}
+	for _, s := range MaterialSwaps(devices, tasks) {
+		id := s.TaskID
+		if batch[id] > 0 {
+			id = batch[id]
+		}
+		if d := changeovers.Between(s.FromMaterialID, s.ToMaterialID); d > 0 {
+			res[id] = d
+		}
+	}
+	return res
+}
+
+// applySlots — задания с планом из out: запланированные получают новые
+// оборудование и слот, незапланированные остаются без плана.
+func applySlots(tasks []storage.DeviceTaskRow, out PlanOutput) []storage.DeviceTaskRow {
+	slots := make(map[int64]PlannedSlot, len(out.Slots))
+	for _, s := range out.Slots {
+		slots[s.TaskID] = s
+	}
+	unscheduled := make(map[int64]bool, len(out.Unscheduled))
+	for _, id := range out.Unscheduled {
+		unscheduled[id] = true
+	}
+	res := make([]storage.DeviceTaskRow, 0, len(tasks))
+	for _, t := range tasks {
+		if s, ok := slots[t.ID]; ok {
+			start, end := s.Start, s.End
+			t.DeviceID, t.PlanStart, t.PlanEnd = s.DeviceID, &start, &end
+		} else if unscheduled[t.ID] {
+			t.PlanStart, t.PlanEnd = nil, nil
+		}
+		res = append(res, t)
+	}
+	return res
+}
+
+// MaterialSwaps — смены материала по текущему плану workspace.
+func (p *Planner) MaterialSwaps(ctx context.Context, workspaceID int64) ([]MaterialSwap, error) {
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID, storage.TaskStatusPending, storage.TaskStatusInProgress)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return MaterialSwaps(devices, tasks), nil
+}
@@ -0,0 +1,101 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// testChangeovers — переход 1 -> 2 за 30 минут и 2 -> 1 за полтора часа.
+func testChangeovers() Changeovers {
+	return NewChangeovers([]storage.MaterialChangeover{
+		{FromMaterialID: 1, ToMaterialID: 2, Duration: 30 * time.Minute},
+		{FromMaterialID: 2, ToMaterialID: 1, Duration: 90 * time.Minute},
+	})
+}
+
+func TestChangeoversBetween(t *testing.T) {
+	c := testChangeovers()
+	cases := []struct {
+		name     string
+		from, to int64
+		want     time.Duration
+	}{
+		{"pair", 1, 2, 30 * time.Minute},
+		{"reverse pair", 2, 1, 90 * time.Minute},
+		{"same material", 1, 1, 0},
+		{"pair not in matrix", 1, 3, 0},
+		{"unknown loaded", 0, 2, 0},
+		{"unknown next", 1, 0, 0},
+	}
+	for _, tc := range cases {
+		if got := c.Between(tc.from, tc.to); got != tc.want {
+			t.Errorf("%s: Between(%d, %d) = %v, want %v", tc.name, tc.from, tc.to, got, tc.want)
+		}
+	}
+	if got := Changeovers(nil).Between(1, 2); got != 0 {
+		t.Errorf("nil matrix: got %v", got)
+	}
+}
+
+// Переналадка с материала предыдущего задания входит в слот перед заданием.
+func TestFindChangeoverSlotAfterPrevious(t *testing.T) {
+	deviceBusy := []interval{{start: mar(3, 8), end: mar(3, 9), material: 1}}
+	start, end, change, ok := findChangeoverSlot(mar(3, 9), time.Hour, deviceBusy, nil, nil, 2, testChangeovers(), 0, false)
+	if !ok || !start.Equal(mar(3, 9)) || !end.Equal(mar(3, 10).Add(30*time.Minute)) || change != 30*time.Minute {
+		t.Errorf("got %v-%v with changeover %v (ok %v), want 09:00-10:30 with 30m", start, end, change, ok)
+	}
+
+	// Без матрицы переналадки слот равен длительности задания.
+	start, end, change, ok = findChangeoverSlot(mar(3, 9), time.Hour, deviceBusy, nil, nil, 2, nil, 0, false)
+	if !ok || !start.Equal(mar(3, 9)) || !end.Equal(mar(3, 10)) || change != 0 {
+		t.Errorf("without matrix: got %v-%v with changeover %v (ok %v)", start, end, change, ok)
+	}
+}
+
+// Слот не должен оставлять следующему заданию меньше времени, чем нужно на
+// обратную переналадку: иначе задание ставится после него.
+func TestFindChangeoverSlotBeforeNextTask(t *testing.T) {
+	deviceBusy := []interval{
+		{start: mar(3, 8), end: mar(3, 9), material: 2},
+		{start: mar(3, 11), end: mar(3, 12), material: 1},
+	}
+	start, end, change, ok := findChangeoverSlot(mar(3, 9), time.Hour, deviceBusy, nil, nil, 2, testChangeovers(), 0, false)
+	if !ok || !start.Equal(mar(3, 12)) || !end.Equal(mar(3, 13).Add(30*time.Minute)) || change != 30*time.Minute {
+		t.Errorf("got %v-%v with changeover %v (ok %v), want 12:00-13:30 with 30m", start, end, change, ok)
+	}
+
+	// Короткая обратная переналадка укладывается в промежуток до следующего задания.
+	short := NewChangeovers([]storage.MaterialChangeover{{FromMaterialID: 2, ToMaterialID: 1, Duration: time.Hour}})
+	start, end, change, ok = findChangeoverSlot(mar(3, 9), time.Hour, deviceBusy, nil, nil, 2, short, 0, false)
+	if !ok || !start.Equal(mar(3, 9)) || !end.Equal(mar(3, 10)) || change != 0 {
+		t.Errorf("short changeover: got %v-%v with changeover %v (ok %v), want 09:00-10:00", start, end, change, ok)
+	}
+}
+
+// Смены материала идут по плану от заправленного материала; выполняемое
+// задание смены не требует, а отменённые, незапланированные и задания без
+// материала в последовательность не входят.
+func TestMaterialSwaps(t *testing.T) {
+	devices := []storage.Device{{ID: 1, LoadedMaterialID: 1}, {ID: 2}}
+	tasks := []storage.DeviceTaskRow{
+		{ID: 3, DeviceID: 1, MaterialID: 2, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 12))},
+		{ID: 2, DeviceID: 1, MaterialID: 1, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 11))},
+		{ID: 1, DeviceID: 1, MaterialID: 2, Status: storage.TaskStatusInProgress, PlanStart: slotAt(mar(3, 9))},
+		{ID: 4, DeviceID: 1, MaterialID: 3, Status: storage.TaskStatusCancelled, PlanStart: slotAt(mar(3, 10))},
+		{ID: 5, DeviceID: 1, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 10))},
+		{ID: 6, DeviceID: 2, MaterialID: 1, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9))},
+		{ID: 7, DeviceID: 2, MaterialID: 1, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 10))},
+		{ID: 8, DeviceID: 2, MaterialID: 3, Status: storage.TaskStatusPending},
+	}
+	want := []MaterialSwap{
+		{DeviceID: 2, TaskID: 6, At: mar(3, 9), FromMaterialID: 0, ToMaterialID: 1},
+		{DeviceID: 1, TaskID: 2, At: mar(3, 11), FromMaterialID: 2, ToMaterialID: 1},
+		{DeviceID: 1, TaskID: 3, At: mar(3, 12), FromMaterialID: 1, ToMaterialID: 2},
+	}
+	if got := MaterialSwaps(devices, tasks); !slices.Equal(got, want) {
+		t.Errorf("swaps\n%+v\nwant\n%+v", got, want)
+	}
+}
@@ -0,0 +1,71 @@
+package service
+
+import (
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Competencies — компетенции операторов: оператор -> тип оборудования -> компетенция.
+type Competencies map[int64]map[int64]storage.OperatorCompetency
+
+func NewCompetencies(items []storage.OperatorCompetency) Competencies {
+	c := Competencies{}
+	for _, it := range items {
+		if c[it.OperatorID] == nil {
+			c[it.OperatorID] = map[int64]storage.OperatorCompetency{}
+		}
+		c[it.OperatorID][it.DeviceTypeID] = it
+	}
+	return c
+}
+
+// qualified — оператор допущен к заданию уровня min на типе оборудования.
+// Без требования подходит любой оператор.
+func (c Competencies) qualified(operatorID, deviceTypeID int64, min storage.CompetencyLevel) bool {
+	if min == "" {
+		return true
+	}
+	comp, ok := c[operatorID][deviceTypeID]
+	return ok && comp.Level.AtLeast(min)
+}
+
+// operators — операторы, которым можно поручить задание на типе оборудования:
+// свой оператор задания, если он допущен, затем остальные допущенные. Задание
+// без требования к уровню остаётся за своим оператором, а при выравнивании
+// загрузки (balance) его может получить и любой оператор с компетенцией на
+// этом типе. Без компетенций задание с требованием к уровню не допущено никому.
+func (c Competencies) operators(t storage.DeviceTaskRow, deviceTypeID int64, balance bool) []int64 {
+	if !t.NeedOperator || (t.MinLevel == "" && !balance) {
+		if t.NeedOperator && t.OperatorID <= 0 {
+			return nil
+		}
+		return []int64{t.OperatorID}
+	}
+	var res []int64
+	if t.OperatorID > 0 && c.qualified(t.OperatorID, deviceTypeID, t.MinLevel) {
+		res = append(res, t.OperatorID)
+	}
+	var others []int64
+	for id, types := range c {
+		if _, ok := types[deviceTypeID]; ok && id != t.OperatorID && c.qualified(id, deviceTypeID, t.MinLevel) {
+			others = append(others, id)
+		}
+	}
+	sort.Slice(others, func(i, j int) bool { return others[i] < others[j] })
+	return append(res, others...)
+}
+
+// scale пересчитывает длительность задания под оператора: наладка и снятие
+// умножаются на его множитель для типа оборудования.
+func (c Competencies) scale(t storage.DeviceTaskRow, operatorID, deviceTypeID int64, total time.Duration) time.Duration {
+	if !t.NeedOperator {
+		return total
+	}
+	comp, ok := c[operatorID][deviceTypeID]
+	if !ok || comp.SetupFactor == nil {
+		return total
+	}
+	return total + time.Duration(float64(t.SetupTime+t.UnloadTime)*(*comp.SetupFactor-1))
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// testCompetencies — на типе оборудования 1 оператор 5 эксперт с наладкой
+// вдвое быстрее, 6 — стажёр с наладкой в полтора раза дольше, у 7 множителя нет.
+func testCompetencies() Competencies {
+	fast, slow := 0.5, 1.5
+	return NewCompetencies([]storage.OperatorCompetency{
+		{OperatorID: 5, DeviceTypeID: 1, Level: storage.CompetencyExpert, SetupFactor: &fast},
+		{OperatorID: 6, DeviceTypeID: 1, Level: storage.CompetencyTrainee, SetupFactor: &slow},
+		{OperatorID: 7, DeviceTypeID: 1, Level: storage.CompetencyQualified},
+	})
+}
+
+func TestCompetenciesQualified(t *testing.T) {
+	c := testCompetencies()
+	cases := []struct {
+		name       string
+		operatorID int64
+		deviceType int64
+		min        storage.CompetencyLevel
+		want       bool
+	}{
+		{"no requirement", 8, 2, "", true},
+		{"expert for qualified", 5, 1, storage.CompetencyQualified, true},
+		{"qualified for qualified", 7, 1, storage.CompetencyQualified, true},
+		{"trainee for qualified", 6, 1, storage.CompetencyQualified, false},
+		{"qualified for expert", 7, 1, storage.CompetencyExpert, false},
+		{"other device type", 5, 2, storage.CompetencyTrainee, false},
+		{"unknown operator", 8, 1, storage.CompetencyTrainee, false},
+	}
+	for _, tc := range cases {
+		if got := c.qualified(tc.operatorID, tc.deviceType, tc.min); got != tc.want {
+			t.Errorf("%s: qualified = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Свой допущенный оператор идёт первым, остальные допущенные — по порядку.
+func TestCompetenciesOperators(t *testing.T) {
+	c := testCompetencies()
+	task := storage.DeviceTaskRow{NeedOperator: true, OperatorID: 7, MinLevel: storage.CompetencyQualified}
+	if got := c.operators(task, 1, false); !slices.Equal(got, []int64{7, 5}) {
+		t.Errorf("got %v, want [7 5]", got)
+	}
+	task.OperatorID = 6
+	if got := c.operators(task, 1, false); !slices.Equal(got, []int64{5, 7}) {
+		t.Errorf("unqualified own operator: got %v, want [5 7]", got)
+	}
+	task.MinLevel = ""
+	if got := c.operators(task, 1, false); !slices.Equal(got, []int64{6}) {
+		t.Errorf("no requirement: got %v, want own operator", got)
+	}
+}
+
+// Без компетенций задание с требованием к уровню не получает никто, даже
+// свой оператор, а задание без требования остаётся за ним.
+func TestCompetenciesOperatorsWithoutCompetencies(t *testing.T) {
+	var c Competencies
+	task := storage.DeviceTaskRow{NeedOperator: true, OperatorID: 7, MinLevel: storage.CompetencyTrainee}
+	for _, balance := range []bool{false, true} {
+		if got := c.operators(task, 1, balance); len(got) != 0 {
+			t.Errorf("balance %v: got %v, want nobody", balance, got)
+		}
+	}
+	task.MinLevel = ""
+	for _, balance := range []bool{false, true} {
+		if got := c.operators(task, 1, balance); !slices.Equal(got, []int64{7}) {
+			t.Errorf("no requirement, balance %v: got %v, want own operator", balance, got)
+		}
+	}
+
+	// Планировщик такое задание не ставит.
+	out := PlanTasks(PlanInput{
+		Now: mar(3, 9),
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, OperatorID: 7, NeedOperator: true, MinLevel: storage.CompetencyQualified, Duration: time.Hour, Status: storage.TaskStatusPending},
+		},
+	})
+	if len(out.Slots) != 0 || !slices.Equal(out.Unscheduled, []int64{1}) {
+		t.Errorf("slots %+v, unscheduled %v, want the task unscheduled", out.Slots, out.Unscheduled)
+	}
+}
+
+// Множитель меняет только наладку и снятие, а не всю длительность.
+func TestCompetenciesScale(t *testing.T) {
+	c := testCompetencies()
+	task := storage.DeviceTaskRow{NeedOperator: true, SetupTime: 30 * time.Minute, UnloadTime: 10 * time.Minute}
+	total := 2 * time.Hour
+	cases := []struct {
+		name       string
+		operatorID int64
+		deviceType int64
+		want       time.Duration
+	}{
+		{"faster", 5, 1, total - 20*time.Minute},
+		{"slower", 6, 1, total + 20*time.Minute},
+		{"no factor", 7, 1, total},
+		{"no competency", 5, 2, total},
+	}
+	for _, tc := range cases {
+		if got := c.scale(task, tc.operatorID, tc.deviceType, total); got != tc.want {
+			t.Errorf("%s: scale = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	task.NeedOperator = false
+	if got := c.scale(task, 5, 1, total); got != total {
+		t.Errorf("without operator: got %v, want %v", got, total)
+	}
+}
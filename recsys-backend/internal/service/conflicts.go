@@ -0,0 +1,286 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Виды конфликтов плана.
+const (
+	ConflictDeviceBusy   = "device_busy"           // оборудование занято другим заданием или его остыванием
+	ConflictOperatorBusy = "operator_busy"         // оператор занят другим заданием или поручением
+	ConflictDowntime     = "device_unavailable"    // оборудование в простое
+	ConflictWorkingHours = "outside_working_hours" // слот вне рабочих часов
+	ConflictDeadline     = "deadline"              // окончание позже дедлайна
+	ConflictRouteOrder   = "route_order"           // нарушен порядок операций маршрута
+	ConflictLabourRule   = "labour_rule"           // слот нарушает правила рабочего времени оператора
+)
+
+// PlanConflict — конфликт слота задания с планом: пересечение с другим
+// заданием, поручением оператора или простоем, выход за рабочие часы или
+// дедлайн, нарушение правил рабочего времени. Start и End — пересечение, а у
+// конфликтов без второй стороны — слот.
+type PlanConflict struct {
+	Kind            string    `json:"kind"`
+	TaskID          int64     `json:"task_id,omitempty"`            // 0 — пересекаются два поручения
+	OtherTaskID     int64     `json:"other_task_id,omitempty"`      // задание, с которым пересекается слот
+	UserTaskID      int64     `json:"user_task_id,omitempty"`       // поручение оператора, с которым пересекается слот
+	OtherUserTaskID int64     `json:"other_user_task_id,omitempty"` // второе поручение того же оператора
+	DowntimeID      int64     `json:"downtime_id,omitempty"`        // простой оборудования
+	LabourRule      string    `json:"labour_rule,omitempty"`        // нарушенное правило рабочего времени
+	DeviceID        int64     `json:"device_id,omitempty"`
+	OperatorID      int64     `json:"operator_id,omitempty"`
+	Start           time.Time `json:"start"`
+	End             time.Time `json:"end"`
+}
+
+type ConflictReport struct {
+	Total     int            `json:"total"`
+	ByKind    map[string]int `json:"by_kind"`
+	Conflicts []PlanConflict `json:"conflicts"`
+}
+
+// bookings — занятость оборудования и операторов по плану workspace.
+type bookings struct {
+	tasks     []storage.DeviceTaskRow // ожидающие и выполняемые задания с планом
+	userTasks []storage.UserTask      // поручения операторам, созданные вручную
+	downtime  []storage.DeviceDowntime
+	cooldowns *Cooldowns
+	labour    storage.LabourRules
+}
+
+// bookings загружает занятость по плану workspace: задания tasks, поручения
+// операторам, простои, не закончившиеся к from, остывание оборудования и
+// правила рабочего времени.
+func (p *Planner) bookings(ctx context.Context, workspaceID int64, tasks []storage.DeviceTaskRow, from time.Time) (bookings, error) {
+	userTasks, err := p.repos.ListUserTasks(ctx, workspaceID)
+	if err != nil {
+		return bookings{}, err
+	}
+	downtime, err := p.repos.ListDeviceDowntime(ctx, workspaceID, from)
+	if err != nil {
+		return bookings{}, err
+	}
+	cooldowns, err := p.cooldowns(ctx, workspaceID, nil)
+	if err != nil {
+		return bookings{}, err
+	}
+	labour, err := p.repos.GetLabourRules(ctx, workspaceID)
+	if err != nil {
+		return bookings{}, err
+	}
+	b := bookings{downtime: downtime, cooldowns: cooldowns, labour: labour}
+	for _, t := range tasks {
+		if planned(t) {
+			b.tasks = append(b.tasks, t)
+		}
+	}
+	for _, u := range userTasks {
+		if u.Kind == "" && u.StartTime != nil && u.EndTime != nil && u.OperatorID > 0 {
+			b.userTasks = append(b.userTasks, u)
+		}
+	}
+	return b, nil
+}
+
+// planned — задание занимает оборудование по плану: ждёт выполнения или
+// выполняется и стоит в плане на оборудовании.
+func planned(t storage.DeviceTaskRow) bool {
+	return (t.Status == storage.TaskStatusPending || t.Status == storage.TaskStatusInProgress) &&
+		t.PlanStart != nil && t.PlanEnd != nil && t.DeviceID > 0
+}
+
+// slotConflicts — конфликты слота [start, end) заданий group (задание или
+// прогон) на оборудовании deviceID с оператором operatorID. Сами задания
+// group и задания их прогона в плане не учитываются. Оборудование занято и
+// остыванием после заданий; оператора остывание не занимает. Работа оператора
+// по плану и его поручения проверяются по правилам рабочего времени.
+func (b bookings) slotConflicts(group []storage.DeviceTaskRow, deviceID, operatorID int64, start, end time.Time) []PlanConflict {
+	lead := group[0]
+	own := make(map[int64]bool, len(group))
+	for _, t := range group {
+		own[t.ID] = true
+	}
+	for _, o := range b.tasks {
+		if lead.BatchID > 0 && o.BatchID == lead.BatchID {
+			own[o.ID] = true
+		}
+	}
+	if !lead.NeedOperator {
+		operatorID = 0
+	}
+	conflict := func(kind string, s, e time.Time) PlanConflict {
+		return PlanConflict{Kind: kind, TaskID: lead.ID, DeviceID: deviceID, OperatorID: operatorID, Start: maxTime(s, start), End: minTime(e, end)}
+	}
+
+	var res []PlanConflict
+	if outsideWorkingHours(start, end, lead.NeedOperator) {
+		res = append(res, conflict(ConflictWorkingHours, start, end))
+	}
+	if d := hardDeadline(group); d != nil && end.After(*d) {
+		res = append(res, conflict(ConflictDeadline, *d, end))
+	}
+	busyEnd := end.Add(b.cooldowns.After(deviceID, group...))
+	for _, o := range b.tasks {
+		if own[o.ID] {
+			continue
+		}
+		if o.DeviceID == deviceID {
+			oEnd := o.PlanEnd.Add(b.cooldowns.After(o.DeviceID, o))
+			if intersects(start, busyEnd, *o.PlanStart, oEnd) {
+				c := conflict(ConflictDeviceBusy, *o.PlanStart, oEnd)
+				c.End = minTime(oEnd, busyEnd)
+				c.OtherTaskID = o.ID
+				res = append(res, c)
+			}
+		}
+		if operatorID > 0 && o.NeedOperator && o.OperatorID == operatorID && intersects(start, end, *o.PlanStart, *o.PlanEnd) {
+			c := conflict(ConflictOperatorBusy, *o.PlanStart, *o.PlanEnd)
+			c.OtherTaskID = o.ID
+			res = append(res, c)
+		}
+	}
+	for _, u := range b.userTasks {
+		if operatorID > 0 && u.OperatorID == operatorID && intersects(start, end, *u.StartTime, *u.EndTime) {
+			c := conflict(ConflictOperatorBusy, *u.StartTime, *u.EndTime)
+			c.UserTaskID = u.ID
+			res = append(res, c)
+		}
+	}
+	for _, d := range b.downtime {
+		if d.DeviceID == deviceID && intersects(start, end, d.Start, d.End) {
+			c := conflict(ConflictDowntime, d.Start, d.End)
+			c.DowntimeID = d.ID
+			res = append(res, c)
+		}
+	}
+	if operatorID > 0 && b.labour.Enabled() {
+		labour := newLabourLedger(b.labour, nil)
+		for _, o := range b.tasks {
+			if !own[o.ID] && o.NeedOperator && o.OperatorID == operatorID {
+				labour.work[operatorID] = append(labour.work[operatorID], interval{start: *o.PlanStart, end: *o.PlanEnd})
+			}
+		}
+		for _, u := range b.userTasks {
+			if u.OperatorID == operatorID {
+				labour.personal[operatorID] = append(labour.personal[operatorID], interval{start: *u.StartTime, end: *u.EndTime})
+			}
+		}
+		if _, rule := labour.check(operatorID, start, end); rule != "" {
+			c := conflict(ConflictLabourRule, start, end)
+			c.LabourRule = rule
+			res = append(res, c)
+		}
+	}
+	// Операция маршрута начинается не раньше готовности предыдущей и
+	// заканчивается до начала следующей с её пролёживанием.
+	for _, t := range group {
+		if t.JobID <= 0 {
+			continue
+		}
+		for _, o := range b.tasks {
+			if o.JobID != t.JobID || own[o.ID] {
+				continue
+			}
+			switch o.JobSeq {
+			case t.JobSeq - 1:
+				if ready := o.PlanEnd.Add(t.TransferLag); start.Before(ready) {
+					c := conflict(ConflictRouteOrder, start, ready)
+					c.OtherTaskID = o.ID
+					res = append(res, c)
+				}
+			case t.JobSeq + 1:
+				if next := o.PlanStart.Add(-o.TransferLag); end.After(next) {
+					c := conflict(ConflictRouteOrder, next, end)
+					c.OtherTaskID = o.ID
+					res = append(res, c)
+				}
+			}
+		}
+	}
+	return res
+}
+
+// outsideWorkingHours — слот не начинается в рабочее время или заканчивается
+// после конца рабочего дня. Задание без оператора может допечатываться ночью.
+func outsideWorkingHours(start, end time.Time, needOperator bool) bool {
+	if !alignToWorkday(start).Equal(start) {
+		return true
+	}
+	dayEnd := time.Date(start.Year(), start.Month(), start.Day(), workDayEndHour, 0, 0, 0, start.Location())
+	return needOperator && end.After(dayEnd)
+}
+
+// PlanConflicts — конфликты текущего плана workspace: двойная занятость
+// оборудования (с остыванием) и операторов, включая поручения, созданные
+// вручную, задания вне рабочих часов, после дедлайна, на оборудовании в
+// простое, с нарушенным порядком маршрута и правилами рабочего времени.
+// Каждое пересечение двух заданий или поручений попадает в отчёт один раз.
+func (p *Planner) PlanConflicts(ctx context.Context, workspaceID int64) (ConflictReport, error) {
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return ConflictReport{}, err
+	}
+	b, err := p.bookings(ctx, workspaceID, tasks, time.Time{})
+	if err != nil {
+		return ConflictReport{}, err
+	}
+	return b.report(), nil
+}
+
+// report — конфликты всех заданий и поручений плана, по одному на каждое
+// пересечение, в порядке начала.
+func (b bookings) report() ConflictReport {
+	res := ConflictReport{ByKind: map[string]int{}, Conflicts: []PlanConflict{}}
+	add := func(c PlanConflict) {
+		res.Conflicts = append(res.Conflicts, c)
+		res.ByKind[c.Kind]++
+	}
+	for _, t := range b.tasks {
+		for _, c := range b.slotConflicts([]storage.DeviceTaskRow{t}, t.DeviceID, t.OperatorID, *t.PlanStart, *t.PlanEnd) {
+			// Пересечение двух заданий находится с обеих сторон — берётся одна.
+			if c.OtherTaskID > 0 && c.OtherTaskID < t.ID {
+				continue
+			}
+			add(c)
+		}
+		// Мягкий дедлайн планировщик может сорвать, но это тоже конфликт плана.
+		if t.DeadlineType == storage.DeadlineSoft && t.Deadline != nil && t.PlanEnd.After(*t.Deadline) {
+			add(PlanConflict{Kind: ConflictDeadline, TaskID: t.ID, DeviceID: t.DeviceID, OperatorID: operatorOf(t), Start: *t.Deadline, End: *t.PlanEnd})
+		}
+	}
+	for i, u := range b.userTasks {
+		for _, o := range b.userTasks[i+1:] {
+			if u.OperatorID == o.OperatorID && intersects(*u.StartTime, *u.EndTime, *o.StartTime, *o.EndTime) {
+				add(PlanConflict{
+					Kind:            ConflictOperatorBusy,
+					UserTaskID:      u.ID,
+					OtherUserTaskID: o.ID,
+					OperatorID:      u.OperatorID,
+					Start:           maxTime(*u.StartTime, *o.StartTime),
+					End:             minTime(*u.EndTime, *o.EndTime),
+				})
+			}
+		}
+	}
+	sort.SliceStable(res.Conflicts, func(i, j int) bool {
+		a, c := res.Conflicts[i], res.Conflicts[j]
+		if !a.Start.Equal(c.Start) {
+			return a.Start.Before(c.Start)
+		}
+		return a.TaskID < c.TaskID
+	})
+	res.Total = len(res.Conflicts)
+	return res
+}
+
+// operatorOf — оператор, занятый заданием; 0 — задание без оператора.
+func operatorOf(t storage.DeviceTaskRow) int64 {
+	if !t.NeedOperator {
+		return 0
+	}
+	return t.OperatorID
+}
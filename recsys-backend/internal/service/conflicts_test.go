@@ -0,0 +1,151 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// testBookings — план дня: задание 10 на оборудовании 1 с 10 до 12, задание
+// 11 оператора 5 с 14 до 16, первая операция маршрута 30 с 9 до 12, личное
+// поручение оператора 6 с 17 до 18 и простой оборудования 4 с 12 до 13.
+func testBookings() bookings {
+	return bookings{
+		tasks: []storage.DeviceTaskRow{
+			{ID: 10, DeviceID: 1, Status: storage.TaskStatusPending, PlanStart: hourOf(10), PlanEnd: hourOf(12)},
+			{ID: 11, DeviceID: 2, OperatorID: 5, NeedOperator: true, Status: storage.TaskStatusPending, PlanStart: hourOf(14), PlanEnd: hourOf(16)},
+			{ID: 13, DeviceID: 2, JobID: 30, JobSeq: 1, Status: storage.TaskStatusPending, PlanStart: hourOf(9), PlanEnd: hourOf(12)},
+		},
+		userTasks: []storage.UserTask{{ID: 40, OperatorID: 6, StartTime: hourOf(17), EndTime: hourOf(18)}},
+		downtime:  []storage.DeviceDowntime{{ID: 50, DeviceID: 4, Start: *hourOf(12), End: *hourOf(13)}},
+	}
+}
+
+// conflictsOf — конфликты слота одного задания с from до to часов.
+func conflictsOf(b bookings, t storage.DeviceTaskRow, from, to int) []PlanConflict {
+	t.Status = storage.TaskStatusPending
+	return b.slotConflicts([]storage.DeviceTaskRow{t}, t.DeviceID, t.OperatorID, *hourOf(from), *hourOf(to))
+}
+
+func TestSlotConflicts(t *testing.T) {
+	b := testBookings()
+	withOperator := func(id int64) storage.DeviceTaskRow {
+		return storage.DeviceTaskRow{ID: 1, DeviceID: 1, OperatorID: id, NeedOperator: true}
+	}
+
+	if got := conflictsOf(b, storage.DeviceTaskRow{ID: 1, DeviceID: 1}, 12, 14); len(got) != 0 {
+		t.Errorf("free slot: got %+v", got)
+	}
+
+	checks := []struct {
+		what  string
+		got   []PlanConflict
+		kind  string
+		other int64
+	}{
+		{"device busy", conflictsOf(b, storage.DeviceTaskRow{ID: 1, DeviceID: 1}, 11, 13), ConflictDeviceBusy, 10},
+		{"operator busy", conflictsOf(b, withOperator(5), 15, 17), ConflictOperatorBusy, 11},
+		{"personal task", conflictsOf(b, withOperator(6), 16, 18), ConflictOperatorBusy, 0},
+		{"downtime", conflictsOf(b, storage.DeviceTaskRow{ID: 1, DeviceID: 4}, 11, 14), ConflictDowntime, 0},
+		{"after hours", conflictsOf(b, withOperator(5), 20, 23), ConflictWorkingHours, 0},
+	}
+	for _, c := range checks {
+		if len(c.got) != 1 || c.got[0].Kind != c.kind || c.got[0].OtherTaskID != c.other {
+			t.Errorf("%s: got %+v, want one %s with task %d", c.what, c.got, c.kind, c.other)
+		}
+	}
+}
+
+// Вторая операция маршрута ставится не раньше конца первой плюс пролёживание.
+func TestSlotConflictsRouteOrder(t *testing.T) {
+	b := testBookings()
+	next := storage.DeviceTaskRow{ID: 15, DeviceID: 1, JobID: 30, JobSeq: 2, TransferLag: 2 * time.Hour}
+
+	if got := conflictsOf(b, next, 14, 16); len(got) != 0 {
+		t.Errorf("after lag: got %+v", got)
+	}
+	got := conflictsOf(b, next, 13, 15)
+	if len(got) != 1 || got[0].Kind != ConflictRouteOrder || got[0].OtherTaskID != 13 {
+		t.Errorf("within lag: got %+v, want route_order with task 13", got)
+	}
+}
+
+// Дедлайн прогона — самый ранний жёсткий: мягкие сроки слот может сорвать.
+func TestSlotConflictsHardDeadline(t *testing.T) {
+	start := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	hard, soft := start.Add(2*time.Hour), start.Add(time.Hour)
+	group := []storage.DeviceTaskRow{
+		{ID: 1, DeviceID: 1, Deadline: &soft, DeadlineType: storage.DeadlineSoft},
+		{ID: 2, DeviceID: 1, Deadline: &hard, DeadlineType: storage.DeadlineHard},
+	}
+	got := bookings{}.slotConflicts(group, 1, 0, start, start.Add(3*time.Hour))
+	if len(got) != 1 || got[0].Kind != ConflictDeadline || !got[0].Start.Equal(hard) {
+		t.Errorf("got %+v, want deadline conflict from the hard deadline", got)
+	}
+}
+
+// Задания одного прогона стоят в одном слоте и друг другу не мешают.
+func TestSlotConflictsSkipsBatchMates(t *testing.T) {
+	b := bookings{tasks: []storage.DeviceTaskRow{
+		{ID: 12, DeviceID: 3, BatchID: 20, Status: storage.TaskStatusPending, PlanStart: hourOf(9), PlanEnd: hourOf(11)},
+	}}
+	if got := conflictsOf(b, storage.DeviceTaskRow{ID: 14, DeviceID: 3, BatchID: 20}, 9, 11); len(got) != 0 {
+		t.Errorf("got %+v, want no conflicts", got)
+	}
+}
+
+// Слот, с которым оператор превысит дневной предел, — конфликт правил
+// рабочего времени; поручения оператора входят в предел.
+func TestSlotConflictsLabourRule(t *testing.T) {
+	b := testBookings()
+	b.labour = storage.LabourRules{MaxDay: 4 * time.Hour}
+	task := storage.DeviceTaskRow{ID: 1, DeviceID: 3, OperatorID: 5, NeedOperator: true}
+
+	if got := conflictsOf(b, task, 9, 11); len(got) != 0 {
+		t.Errorf("within limit: got %+v", got)
+	}
+	got := conflictsOf(b, task, 11, 14)
+	if len(got) != 1 || got[0].Kind != ConflictLabourRule || got[0].LabourRule != LabourRuleMaxDay {
+		t.Errorf("over limit: got %+v, want labour_rule %s", got, LabourRuleMaxDay)
+	}
+	task.OperatorID = 6
+	if got := conflictsOf(b, task, 9, 13); len(got) != 1 || got[0].Kind != ConflictLabourRule {
+		t.Errorf("with personal task: got %+v, want labour_rule", got)
+	}
+}
+
+// Отчёт по плану: пересечение двух заданий попадает в него один раз,
+// пересечения с поручениями и двух поручений — тоже, а мягкий дедлайн, в
+// отличие от проверки слота, считается конфликтом.
+func TestConflictReport(t *testing.T) {
+	b := testBookings()
+	soft := hourOf(11)
+	b.tasks = append(b.tasks,
+		storage.DeviceTaskRow{ID: 12, DeviceID: 1, Status: storage.TaskStatusInProgress, PlanStart: hourOf(11), PlanEnd: hourOf(13)},
+		storage.DeviceTaskRow{ID: 14, DeviceID: 3, Status: storage.TaskStatusPending, PlanStart: hourOf(9), PlanEnd: hourOf(12), Deadline: soft, DeadlineType: storage.DeadlineSoft},
+	)
+	b.userTasks = append(b.userTasks,
+		storage.UserTask{ID: 41, OperatorID: 6, StartTime: hourOf(17), EndTime: hourOf(19)},
+		storage.UserTask{ID: 42, OperatorID: 5, StartTime: hourOf(15), EndTime: hourOf(16)},
+	)
+
+	got := b.report()
+	want := []PlanConflict{
+		{Kind: ConflictDeviceBusy, TaskID: 10, OtherTaskID: 12, DeviceID: 1, Start: *hourOf(11), End: *hourOf(12)},
+		{Kind: ConflictDeadline, TaskID: 14, DeviceID: 3, Start: *hourOf(11), End: *hourOf(12)},
+		{Kind: ConflictOperatorBusy, TaskID: 11, UserTaskID: 42, DeviceID: 2, OperatorID: 5, Start: *hourOf(15), End: *hourOf(16)},
+		{Kind: ConflictOperatorBusy, UserTaskID: 40, OtherUserTaskID: 41, OperatorID: 6, Start: *hourOf(17), End: *hourOf(18)},
+	}
+	if len(got.Conflicts) != len(want) {
+		t.Fatalf("got %+v, want %+v", got.Conflicts, want)
+	}
+	for i, c := range got.Conflicts {
+		if c != want[i] {
+			t.Errorf("conflict %d: got %+v, want %+v", i, c, want[i])
+		}
+	}
+	if got.Total != 4 || got.ByKind[ConflictDeviceBusy] != 1 || got.ByKind[ConflictOperatorBusy] != 2 || got.ByKind[ConflictDeadline] != 1 {
+		t.Errorf("total %d, by kind %v", got.Total, got.ByKind)
+	}
+}
@@ -0,0 +1,74 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Cooldowns — остывание оборудования после заданий: стол после
+// высокотемпературной печати, отдых ванны с фотополимером. Оборудование в это
+// время занято, оператор свободен.
+type Cooldowns struct {
+	devices   map[int64]time.Duration // устройство -> остывание его типа
+	taskTypes map[int64]time.Duration // тип задания -> остывание
+}
+
+// NewCooldowns собирает остывание каждого устройства по его типу и остывание
+// типов заданий; nil — остывания нет.
+func NewCooldowns(devices []storage.Device, deviceTypes []storage.DeviceType, taskTypes []storage.DeviceTaskType) *Cooldowns {
+	byType := make(map[int64]time.Duration, len(deviceTypes))
+	for _, t := range deviceTypes {
+		if t.Cooldown > 0 {
+			byType[t.ID] = t.Cooldown
+		}
+	}
+	c := &Cooldowns{devices: map[int64]time.Duration{}, taskTypes: map[int64]time.Duration{}}
+	for _, d := range devices {
+		if cd, ok := byType[d.DeviceTypeID]; ok {
+			c.devices[d.ID] = cd
+		}
+	}
+	for _, t := range taskTypes {
+		if t.Cooldown > 0 {
+			c.taskTypes[t.ID] = t.Cooldown
+		}
+	}
+	if len(c.devices) == 0 && len(c.taskTypes) == 0 {
+		return nil
+	}
+	return c
+}
+
+// After — остывание устройства deviceID после задания или прогона tasks:
+// большее из остывания типа оборудования и типов заданий.
+func (c *Cooldowns) After(deviceID int64, tasks ...storage.DeviceTaskRow) time.Duration {
+	if c == nil {
+		return 0
+	}
+	res := c.devices[deviceID]
+	for _, t := range tasks {
+		res = max(res, c.taskTypes[t.DeviceTaskTypeID])
+	}
+	return res
+}
+
+// cooldowns — остывание оборудования workspace; devices == nil — загрузить.
+func (p *Planner) cooldowns(ctx context.Context, workspaceID int64, devices []storage.Device) (*Cooldowns, error) {
+	var err error
+	if devices == nil {
+		if devices, err = p.repos.ListDevices(ctx, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+	deviceTypes, err := p.repos.ListDeviceTypes(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	taskTypes, err := p.repos.ListDeviceTaskTypes(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return NewCooldowns(devices, deviceTypes, taskTypes), nil
+}
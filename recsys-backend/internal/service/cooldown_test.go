@@ -0,0 +1,67 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// testCooldowns — тип оборудования 1 остывает 30 минут, тип задания 2 — час.
+// Оборудование 1 типа 1, у оборудования 2 типа 2 остывания нет.
+func testCooldowns() *Cooldowns {
+	return NewCooldowns(
+		[]storage.Device{{ID: 1, DeviceTypeID: 1}, {ID: 2, DeviceTypeID: 2}},
+		[]storage.DeviceType{{ID: 1, Cooldown: 30 * time.Minute}, {ID: 2}},
+		[]storage.DeviceTaskType{{ID: 1}, {ID: 2, Cooldown: time.Hour}},
+	)
+}
+
+func TestCooldownsAfter(t *testing.T) {
+	c := testCooldowns()
+	plain := storage.DeviceTaskRow{DeviceTaskTypeID: 1}
+	hot := storage.DeviceTaskRow{DeviceTaskTypeID: 2}
+	cases := []struct {
+		name     string
+		deviceID int64
+		tasks    []storage.DeviceTaskRow
+		want     time.Duration
+	}{
+		{"device type", 1, []storage.DeviceTaskRow{plain}, 30 * time.Minute},
+		{"task type is longer", 1, []storage.DeviceTaskRow{hot}, time.Hour},
+		{"batch takes the longest", 1, []storage.DeviceTaskRow{plain, hot}, time.Hour},
+		{"task type only", 2, []storage.DeviceTaskRow{hot}, time.Hour},
+		{"none", 2, []storage.DeviceTaskRow{plain}, 0},
+	}
+	for _, tc := range cases {
+		if got := c.After(tc.deviceID, tc.tasks...); got != tc.want {
+			t.Errorf("%s: After = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+}
+
+// Без остывания у типов оборудования и заданий Cooldowns нет, и After
+// работает на nil.
+func TestCooldownsNone(t *testing.T) {
+	c := NewCooldowns([]storage.Device{{ID: 1, DeviceTypeID: 1}}, []storage.DeviceType{{ID: 1}}, []storage.DeviceTaskType{{ID: 1}})
+	if c != nil {
+		t.Fatalf("got %+v, want nil", c)
+	}
+	if got := c.After(1, storage.DeviceTaskRow{DeviceTaskTypeID: 1}); got != 0 {
+		t.Errorf("After = %v, want 0", got)
+	}
+}
+
+// Остывание после слота не должно налезать на следующее задание на
+// оборудовании.
+func TestFindChangeoverSlotCooldown(t *testing.T) {
+	deviceBusy := []interval{{start: mar(3, 11), end: mar(3, 12)}}
+	start, end, _, ok := findChangeoverSlot(mar(3, 9), time.Hour, deviceBusy, nil, nil, 0, nil, 90*time.Minute, false)
+	if !ok || !start.Equal(mar(3, 12)) || !end.Equal(mar(3, 13)) {
+		t.Errorf("got %v-%v (ok %v), want 12:00-13:00", start, end, ok)
+	}
+	start, end, _, ok = findChangeoverSlot(mar(3, 9), time.Hour, deviceBusy, nil, nil, 0, nil, time.Hour, false)
+	if !ok || !start.Equal(mar(3, 9)) || !end.Equal(mar(3, 10)) {
+		t.Errorf("cooldown ending with the next task: got %v-%v (ok %v), want 09:00-10:00", start, end, ok)
+	}
+}
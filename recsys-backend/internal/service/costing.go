@@ -0,0 +1,291 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"recsys-backend/internal/storage"
+)
+
+// Группировка отчёта о себестоимости.
+const (
+	CostGroupDocNum   = "doc_num"
+	CostGroupTaskType = "task_type"
+)
+
+// CostBreakdown — себестоимость задания по статьям.
+type CostBreakdown struct {
+	Minutes  int     `json:"minutes"`  // время работы оборудования
+	Machine  float64 `json:"machine"`  // ставка оборудования × время
+	Labour   float64 `json:"labour"`   // ставка оператора × время, только need_operator
+	Material float64 `json:"material"` // material_qty × стоимость единицы материала
+	Energy   float64 `json:"energy"`   // энергия по тарифам
+	Total    float64 `json:"total"`
+}
+
+func (c *CostBreakdown) add(o CostBreakdown) {
+	c.Minutes += o.Minutes
+	c.Machine += o.Machine
+	c.Labour += o.Labour
+	c.Material += o.Material
+	c.Energy += o.Energy
+	c.Total += o.Total
+}
+
+func (c CostBreakdown) rounded() CostBreakdown {
+	return CostBreakdown{
+		Minutes:  c.Minutes,
+		Machine:  roundHundredths(c.Machine),
+		Labour:   roundHundredths(c.Labour),
+		Material: roundHundredths(c.Material),
+		Energy:   roundHundredths(c.Energy),
+		Total:    roundHundredths(c.Total),
+	}
+}
+
+// TaskCost — плановая и фактическая себестоимость задания.
+type TaskCost struct {
+	TaskID           int64              `json:"task_id"`
+	Name             string             `json:"name"`
+	DocNum           string             `json:"doc_num"`
+	DeviceTaskTypeID int64              `json:"device_task_type_id"`
+	Status           storage.TaskStatus `json:"status"`
+	// Estimated — по слоту плана, а у незапланированного задания — по
+	// наладке, печати и снятию.
+	Estimated CostBreakdown `json:"estimated"`
+	// Actual — по фактическому выполнению; nil — задание не завершено.
+	Actual *CostBreakdown `json:"actual"`
+}
+
+// costing — ставки workspace для расчёта себестоимости заданий.
+type costing struct {
+	device   map[int64]float64 // оборудование -> стоимость часа
+	operator map[int64]float64 // оператор -> стоимость часа
+	material map[int64]float64 // материал -> стоимость единицы
+	energy   *Energy
+	members  map[int64]int // прогон -> число его заданий
+}
+
+func newCosting(devices []storage.Device, operators []storage.Operator, stock []storage.MaterialStock, energy *Energy, tasks []storage.DeviceTaskRow) *costing {
+	c := &costing{
+		device:   make(map[int64]float64, len(devices)),
+		operator: make(map[int64]float64, len(operators)),
+		material: make(map[int64]float64, len(stock)),
+		energy:   energy,
+		members:  map[int64]int{},
+	}
+	for _, d := range devices {
+		c.device[d.ID] = d.HourlyRate
+	}
+	for _, o := range operators {
+		c.operator[o.ID] = o.HourlyRate
+	}
+	for _, s := range stock {
+		c.material[s.MaterialID] = s.UnitCost
+	}
+	for _, t := range tasks {
+		if t.BatchID > 0 && t.Status != storage.TaskStatusCancelled {
+			c.members[t.BatchID]++
+		}
+	}
+	return c
+}
+
+// task — плановая и фактическая себестоимость задания. Время, ставки и
+// энергия прогона делятся поровну между его заданиями, материал — свой у
+// каждого.
+func (c *costing) task(t storage.DeviceTaskRow) TaskCost {
+	res := TaskCost{
+		TaskID:           t.ID,
+		Name:             t.Name,
+		DocNum:           t.DocNum,
+		DeviceTaskTypeID: t.DeviceTaskTypeID,
+		Status:           t.Status,
+	}
+	if t.PlanStart != nil && t.PlanEnd != nil {
+		res.Estimated = c.breakdown(t, *t.PlanStart, *t.PlanEnd)
+	} else {
+		res.Estimated = c.breakdown(t, time.Time{}, time.Time{}.Add(t.SetupTime+t.Duration+t.UnloadTime))
+	}
+	if t.ActualStart != nil && t.ActualEnd != nil {
+		actual := c.breakdown(t, *t.ActualStart, *t.ActualEnd)
+		res.Actual = &actual
+	}
+	return res
+}
+
+// breakdown — себестоимость работы задания в [start, end). Нулевой start —
+// время работы неизвестно, энергия считается по базовой цене.
+func (c *costing) breakdown(t storage.DeviceTaskRow, start, end time.Time) CostBreakdown {
+	share := 1.0
+	if n := c.members[t.BatchID]; t.BatchID > 0 && n > 1 {
+		share = 1 / float64(n)
+	}
+	hours := end.Sub(start).Hours() * share
+	b := CostBreakdown{
+		Minutes:  int(end.Sub(start).Minutes() * share),
+		Machine:  c.device[t.DeviceID] * hours,
+		Material: c.material[t.MaterialID] * t.MaterialQty,
+	}
+	if t.NeedOperator {
+		b.Labour = c.operator[t.OperatorID] * hours
+	}
+	if start.IsZero() {
+		if c.energy != nil {
+			b.Energy = c.energy.power[t.DeviceID] * hours * c.energy.schedule.BasePrice
+		}
+	} else {
+		_, cost := c.energy.estimate(t.DeviceID, start, end)
+		b.Energy = cost * share
+	}
+	b.Total = b.Machine + b.Labour + b.Material + b.Energy
+	return b.rounded()
+}
+
+// TaskCost — плановая и фактическая себестоимость задания.
+func (p *Planner) TaskCost(ctx context.Context, taskID int64) (TaskCost, error) {
+	task, err := p.repos.GetDeviceTask(ctx, taskID)
+	if err != nil {
+		return TaskCost{}, err
+	}
+	tasks, c, err := p.costing(ctx, task.WorkspaceID)
+	if err != nil {
+		return TaskCost{}, err
+	}
+	for _, t := range tasks {
+		if t.ID == taskID {
+			return c.task(t), nil
+		}
+	}
+	return TaskCost{}, pgx.ErrNoRows
+}
+
+// CostGroup — себестоимость заданий с одним номером документа или типом.
+type CostGroup struct {
+	Key         string        `json:"key"`  // doc_num или ID типа задания
+	Name        string        `json:"name"` // название типа задания
+	Tasks       int           `json:"tasks"`
+	Estimated   CostBreakdown `json:"estimated"`
+	ActualTasks int           `json:"actual_tasks"` // завершённые задания, вошедшие в actual
+	Actual      CostBreakdown `json:"actual"`
+}
+
+// CostReport — себестоимость заданий workspace по группам и итог.
+type CostReport struct {
+	GroupBy   string        `json:"group_by"`
+	From      *time.Time    `json:"from,omitempty"`
+	To        *time.Time    `json:"to,omitempty"`
+	Groups    []CostGroup   `json:"groups"`
+	Estimated CostBreakdown `json:"estimated"`
+	Actual    CostBreakdown `json:"actual"`
+}
+
+// CostReport считает себестоимость неотменённых заданий, начавшихся (по
+// факту, иначе по плану) в [from, to), по группам groupBy. Без from и to
+// входят все задания.
+func (p *Planner) CostReport(ctx context.Context, workspaceID int64, groupBy string, from, to *time.Time) (CostReport, error) {
+	tasks, c, err := p.costing(ctx, workspaceID)
+	if err != nil {
+		return CostReport{}, err
+	}
+	names := map[int64]string{}
+	if groupBy == CostGroupTaskType {
+		types, err := p.repos.ListDeviceTaskTypes(ctx, workspaceID)
+		if err != nil {
+			return CostReport{}, err
+		}
+		for _, tt := range types {
+			names[tt.ID] = tt.Name
+		}
+	}
+	return costReport(tasks, c, groupBy, names, from, to), nil
+}
+
+// costReport собирает отчёт о себестоимости заданий tasks; names — названия
+// типов заданий для группировки по типу.
+func costReport(tasks []storage.DeviceTaskRow, c *costing, groupBy string, names map[int64]string, from, to *time.Time) CostReport {
+	res := CostReport{GroupBy: groupBy, From: from, To: to, Groups: []CostGroup{}}
+	index := map[string]int{}
+	for _, t := range tasks {
+		if t.Status == storage.TaskStatusCancelled || !startsWithin(t, from, to) {
+			continue
+		}
+		key, name := t.DocNum, ""
+		if groupBy == CostGroupTaskType {
+			key, name = strconv.FormatInt(t.DeviceTaskTypeID, 10), names[t.DeviceTaskTypeID]
+		}
+		i, ok := index[key]
+		if !ok {
+			i = len(res.Groups)
+			index[key] = i
+			res.Groups = append(res.Groups, CostGroup{Key: key, Name: name})
+		}
+		g := &res.Groups[i]
+		cost := c.task(t)
+		g.Tasks++
+		g.Estimated.add(cost.Estimated)
+		res.Estimated.add(cost.Estimated)
+		if cost.Actual != nil {
+			g.ActualTasks++
+			g.Actual.add(*cost.Actual)
+			res.Actual.add(*cost.Actual)
+		}
+	}
+	for i := range res.Groups {
+		res.Groups[i].Estimated = res.Groups[i].Estimated.rounded()
+		res.Groups[i].Actual = res.Groups[i].Actual.rounded()
+	}
+	sort.Slice(res.Groups, func(i, j int) bool {
+		if res.Groups[i].Name != res.Groups[j].Name {
+			return res.Groups[i].Name < res.Groups[j].Name
+		}
+		return res.Groups[i].Key < res.Groups[j].Key
+	})
+	res.Estimated, res.Actual = res.Estimated.rounded(), res.Actual.rounded()
+	return res
+}
+
+// startsWithin — задание начинается (по факту, иначе по плану) в [from, to);
+// nil — граница не задана. Задание без начала входит, только если границ нет.
+func startsWithin(t storage.DeviceTaskRow, from, to *time.Time) bool {
+	if from == nil && to == nil {
+		return true
+	}
+	start := t.PlanStart
+	if t.ActualStart != nil {
+		start = t.ActualStart
+	}
+	if start == nil {
+		return false
+	}
+	return (from == nil || !start.Before(*from)) && (to == nil || start.Before(*to))
+}
+
+// costing — задания workspace и ставки для их себестоимости.
+func (p *Planner) costing(ctx context.Context, workspaceID int64) ([]storage.DeviceTaskRow, *costing, error) {
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	operators, err := p.repos.ListOperators(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	stock, err := p.repos.ListMaterialStock(ctx, workspaceID)
+	if err != nil {
+		return nil, nil, err
+	}
+	energy, err := p.energy(ctx, workspaceID, devices)
+	if err != nil {
+		return nil, nil, err
+	}
+	return tasks, newCosting(devices, operators, stock, energy, tasks), nil
+}
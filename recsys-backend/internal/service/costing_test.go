@@ -0,0 +1,115 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// testCosting — час оборудования 1 и 3 стоит 100, час оператора 5 — 60,
+// единица материала 7 — 10; энергия по тарифам testEnergy.
+func testCosting(tasks []storage.DeviceTaskRow) *costing {
+	return newCosting(
+		[]storage.Device{{ID: 1, HourlyRate: 100}, {ID: 3, HourlyRate: 100}},
+		[]storage.Operator{{ID: 5, HourlyRate: 60}},
+		[]storage.MaterialStock{{MaterialID: 7, UnitCost: 10}},
+		testEnergy(0),
+		tasks,
+	)
+}
+
+// Время, ставки и энергия прогона делятся между его неотменёнными
+// заданиями, а материал у каждого задания свой.
+func TestCostingBatchShare(t *testing.T) {
+	tasks := []storage.DeviceTaskRow{
+		{ID: 1, DeviceID: 1, BatchID: 3, OperatorID: 5, NeedOperator: true, MaterialID: 7, MaterialQty: 2, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 11))},
+		{ID: 2, DeviceID: 1, BatchID: 3, OperatorID: 5, NeedOperator: true, MaterialID: 7, MaterialQty: 1, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 11))},
+		{ID: 3, DeviceID: 1, BatchID: 3, Status: storage.TaskStatusCancelled},
+	}
+	got := testCosting(tasks).task(tasks[0])
+	want := CostBreakdown{Minutes: 60, Machine: 100, Labour: 60, Material: 20, Energy: 10, Total: 190}
+	if got.Estimated != want {
+		t.Errorf("estimated %+v, want %+v", got.Estimated, want)
+	}
+	if got.Actual != nil {
+		t.Errorf("actual %+v for a pending task", got.Actual)
+	}
+}
+
+// Плановая себестоимость считается по слоту плана, фактическая — по
+// выполнению, в том числе по ночному тарифу.
+func TestCostingEstimatedAndActual(t *testing.T) {
+	task := storage.DeviceTaskRow{
+		ID: 1, DeviceID: 1, Status: storage.TaskStatusDone,
+		PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 10)),
+		ActualStart: slotAt(mar(3, 21)), ActualEnd: slotAt(mar(3, 23)),
+	}
+	got := testCosting([]storage.DeviceTaskRow{task}).task(task)
+	if want := (CostBreakdown{Minutes: 60, Machine: 100, Energy: 10, Total: 110}); got.Estimated != want {
+		t.Errorf("estimated %+v, want %+v", got.Estimated, want)
+	}
+	// Час по 5 и час по ночному тарифу 1 при 2 кВт.
+	if want := (CostBreakdown{Minutes: 120, Machine: 200, Energy: 12, Total: 212}); got.Actual == nil || *got.Actual != want {
+		t.Errorf("actual %+v, want %+v", got.Actual, want)
+	}
+}
+
+// Незапланированное задание оценивается по наладке, печати и снятию, а его
+// энергия — по базовой цене.
+func TestCostingUnplanned(t *testing.T) {
+	task := storage.DeviceTaskRow{ID: 1, DeviceID: 1, SetupTime: 30 * time.Minute, Duration: time.Hour, UnloadTime: 30 * time.Minute, Status: storage.TaskStatusPending}
+	got := testCosting([]storage.DeviceTaskRow{task}).task(task)
+	if want := (CostBreakdown{Minutes: 120, Machine: 200, Energy: 20, Total: 220}); got.Estimated != want {
+		t.Errorf("estimated %+v, want %+v", got.Estimated, want)
+	}
+}
+
+// В отчёт за период входят неотменённые задания, начавшиеся в нём по факту,
+// а без факта — по плану.
+func TestCostReportWindow(t *testing.T) {
+	tasks := []storage.DeviceTaskRow{
+		{ID: 1, DocNum: "A-1", DeviceTaskTypeID: 1, DeviceID: 3, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 10)), PlanEnd: slotAt(mar(3, 11))},
+		// Начато по факту на следующий день.
+		{ID: 2, DocNum: "A-1", DeviceTaskTypeID: 2, DeviceID: 3, Status: storage.TaskStatusDone, PlanStart: slotAt(mar(3, 12)), PlanEnd: slotAt(mar(3, 13)), ActualStart: slotAt(mar(4, 9)), ActualEnd: slotAt(mar(4, 10))},
+		{ID: 3, DocNum: "A-1", DeviceTaskTypeID: 1, DeviceID: 3, Status: storage.TaskStatusCancelled, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 10))},
+		{ID: 4, DocNum: "B-2", DeviceTaskTypeID: 1, DeviceID: 3, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 12)), PlanEnd: slotAt(mar(3, 13))},
+		{ID: 5, DocNum: "A-1", DeviceTaskTypeID: 2, DeviceID: 3, Status: storage.TaskStatusDone, PlanStart: slotAt(mar(3, 14)), PlanEnd: slotAt(mar(3, 15)), ActualStart: slotAt(mar(3, 14)), ActualEnd: slotAt(mar(3, 15))},
+		// Без плана в отчёт за период не входит.
+		{ID: 6, DocNum: "B-2", DeviceTaskTypeID: 1, DeviceID: 3, Duration: time.Hour, Status: storage.TaskStatusPending},
+	}
+	c := testCosting(tasks)
+	from, to := mar(3, 0), mar(4, 0)
+
+	byDoc := costReport(tasks, c, CostGroupDocNum, nil, &from, &to)
+	if len(byDoc.Groups) != 2 {
+		t.Fatalf("groups %+v, want A-1 and B-2", byDoc.Groups)
+	}
+	a, b := byDoc.Groups[0], byDoc.Groups[1]
+	if a.Key != "A-1" || a.Tasks != 2 || a.Estimated.Total != 200 || a.ActualTasks != 1 || a.Actual.Total != 100 {
+		t.Errorf("group A-1 %+v", a)
+	}
+	if b.Key != "B-2" || b.Tasks != 1 || b.Estimated.Total != 100 || b.ActualTasks != 0 {
+		t.Errorf("group B-2 %+v", b)
+	}
+	if byDoc.Estimated.Total != 300 || byDoc.Actual.Total != 100 || byDoc.Estimated.Minutes != 180 {
+		t.Errorf("totals estimated %+v, actual %+v", byDoc.Estimated, byDoc.Actual)
+	}
+
+	names := map[int64]string{1: "Печать", 2: "Постобработка"}
+	byType := costReport(tasks, c, CostGroupTaskType, names, &from, &to)
+	if len(byType.Groups) != 2 {
+		t.Fatalf("groups %+v, want two task types", byType.Groups)
+	}
+	printing, post := byType.Groups[0], byType.Groups[1]
+	if printing.Key != "1" || printing.Name != "Печать" || printing.Tasks != 2 || printing.Estimated.Total != 200 {
+		t.Errorf("print group %+v", printing)
+	}
+	if post.Key != "2" || post.Name != "Постобработка" || post.Tasks != 1 || post.Actual.Total != 100 {
+		t.Errorf("post-processing group %+v", post)
+	}
+
+	if all := costReport(tasks, c, CostGroupDocNum, nil, nil, nil); all.Estimated.Total != 500 {
+		t.Errorf("report without bounds: estimated %+v, want all 5 tasks", all.Estimated)
+	}
+}
@@ -0,0 +1,234 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// minDurationSamples — сколько завершённых заданий нужно группе, чтобы её
+// статистике можно было доверять.
+const minDurationSamples = 3
+
+// Источник оценки длительности в DurationSuggestion.Basis.
+const (
+	DurationBasisExact      = "device_type+task_type"
+	DurationBasisDeviceType = "device_type"
+	DurationBasisTaskType   = "task_type"
+	DurationBasisWorkspace  = "workspace"
+	DurationBasisNominal    = "nominal"
+)
+
+// DurationStats оценивает реальные длительности заданий по истории выполнения.
+type DurationStats struct {
+	repos *storage.Repos
+}
+
+func NewDurationStats(repos *storage.Repos) *DurationStats {
+	return &DurationStats{repos: repos}
+}
+
+// DurationStat — статистика по группе завершённых заданий. Нулевой
+// DeviceTypeID или DeviceTaskTypeID означает «любой».
+type DurationStat struct {
+	DeviceTypeID     int64   `json:"device_type_id"`
+	DeviceTaskTypeID int64   `json:"device_task_type_id"`
+	Samples          int     `json:"samples"`
+	MeanFactor       float64 `json:"mean_factor"` // факт / план (наладка + печать + снятие)
+	P50Factor        float64 `json:"p50_factor"`
+	P80Factor        float64 `json:"p80_factor"`
+	P90Factor        float64 `json:"p90_factor"`
+	P50Min           int     `json:"p50_min"` // фактическая полная длительность, минуты
+	P80Min           int     `json:"p80_min"`
+	P90Min           int     `json:"p90_min"`
+}
+
+type DurationSuggestion struct {
+	Basis        string  `json:"basis"`
+	Samples      int     `json:"samples"`
+	NominalMin   int     `json:"nominal_min"`
+	Factor       float64 `json:"factor"`
+	SuggestedMin int     `json:"suggested_min"` // медиана
+	P80Min       int     `json:"p80_min"`
+}
+
+type durationKey struct {
+	deviceTypeID int64
+	taskTypeID   int64
+}
+
+// DurationModel — рассчитанная статистика по всем группам workspace.
+type DurationModel struct {
+	groups  map[durationKey]DurationStat
+	factors map[durationKey][]float64 // отсортированные коэффициенты факт / план
+}
+
+func (s *DurationStats) Model(ctx context.Context, workspaceID int64) (DurationModel, error) {
+	samples, err := s.repos.ListCompletedTaskDurations(ctx, workspaceID)
+	if err != nil {
+		return DurationModel{}, err
+	}
+	return BuildDurationModel(samples), nil
+}
+
+// BuildDurationModel группирует выборку по (тип оборудования, тип задания),
+// а также по каждому измерению отдельно и по workspace целиком.
+func BuildDurationModel(samples []storage.CompletedTaskDuration) DurationModel {
+	byKey := map[durationKey][]storage.CompletedTaskDuration{}
+	for _, c := range samples {
+		for _, k := range []durationKey{
+			{c.DeviceTypeID, c.DeviceTaskTypeID},
+			{c.DeviceTypeID, 0},
+			{0, c.DeviceTaskTypeID},
+			{0, 0},
+		} {
+			byKey[k] = append(byKey[k], c)
+		}
+	}
+
+	m := DurationModel{
+		groups:  make(map[durationKey]DurationStat, len(byKey)),
+		factors: make(map[durationKey][]float64, len(byKey)),
+	}
+	for k, group := range byKey {
+		m.groups[k], m.factors[k] = computeDurationStat(k, group)
+	}
+	return m
+}
+
+func computeDurationStat(k durationKey, group []storage.CompletedTaskDuration) (DurationStat, []float64) {
+	var factors, minutes []float64
+	for _, c := range group {
+		minutes = append(minutes, c.Actual.Minutes())
+		if c.Nominal > 0 {
+			factors = append(factors, float64(c.Actual)/float64(c.Nominal))
+		}
+	}
+	sort.Float64s(factors)
+	sort.Float64s(minutes)
+
+	stat := DurationStat{
+		DeviceTypeID:     k.deviceTypeID,
+		DeviceTaskTypeID: k.taskTypeID,
+		Samples:          len(group),
+		P50Min:           roundMinutes(percentile(minutes, 0.5)),
+		P80Min:           roundMinutes(percentile(minutes, 0.8)),
+		P90Min:           roundMinutes(percentile(minutes, 0.9)),
+	}
+	if len(factors) > 0 {
+		var sum float64
+		for _, f := range factors {
+			sum += f
+		}
+		stat.MeanFactor = sum / float64(len(factors))
+		stat.P50Factor = percentile(factors, 0.5)
+		stat.P80Factor = percentile(factors, 0.8)
+		stat.P90Factor = percentile(factors, 0.9)
+	}
+	return stat, factors
+}
+
+// Stats возвращает группы с достаточной выборкой: сначала точные, затем обобщённые.
+func (m DurationModel) Stats() []DurationStat {
+	res := make([]DurationStat, 0, len(m.groups))
+	for _, st := range m.groups {
+		if st.Samples >= minDurationSamples {
+			res = append(res, st)
+		}
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].DeviceTypeID != res[j].DeviceTypeID {
+			return res[i].DeviceTypeID > res[j].DeviceTypeID
+		}
+		return res[i].DeviceTaskTypeID > res[j].DeviceTaskTypeID
+	})
+	return res
+}
+
+// Lookup ищет самую точную группу с достаточной выборкой.
+func (m DurationModel) Lookup(deviceTypeID, taskTypeID int64) (DurationStat, string, bool) {
+	candidates := []struct {
+		key   durationKey
+		basis string
+	}{
+		{durationKey{deviceTypeID, taskTypeID}, DurationBasisExact},
+		{durationKey{deviceTypeID, 0}, DurationBasisDeviceType},
+		{durationKey{0, taskTypeID}, DurationBasisTaskType},
+		{durationKey{0, 0}, DurationBasisWorkspace},
+	}
+	for _, c := range candidates {
+		if st, ok := m.groups[c.key]; ok && st.Samples >= minDurationSamples {
+			return st, c.basis, true
+		}
+	}
+	return DurationStat{}, DurationBasisNominal, false
+}
+
+// Suggest предлагает длительность для нового задания. При известной плановой
+// длительности она масштабируется коэффициентами, иначе берутся фактические
+// перцентили группы.
+func (m DurationModel) Suggest(deviceTypeID, taskTypeID int64, nominal time.Duration) DurationSuggestion {
+	res := DurationSuggestion{
+		Basis:        DurationBasisNominal,
+		NominalMin:   roundMinutes(nominal.Minutes()),
+		Factor:       1,
+		SuggestedMin: roundMinutes(nominal.Minutes()),
+		P80Min:       roundMinutes(nominal.Minutes()),
+	}
+	st, basis, ok := m.Lookup(deviceTypeID, taskTypeID)
+	if !ok {
+		return res
+	}
+	res.Basis = basis
+	res.Samples = st.Samples
+	if nominal > 0 && st.P50Factor > 0 {
+		res.Factor = st.P50Factor
+		res.SuggestedMin = roundMinutes(nominal.Minutes() * st.P50Factor)
+		res.P80Min = roundMinutes(nominal.Minutes() * st.P80Factor)
+		return res
+	}
+	res.SuggestedMin = st.P50Min
+	res.P80Min = st.P80Min
+	return res
+}
+
+// P80 масштабирует плановую длительность коэффициентом 80-го перцентиля.
+// Без статистики возвращает nominal без изменений.
+func (m DurationModel) P80(deviceTypeID, taskTypeID int64, nominal time.Duration) time.Duration {
+	st, _, ok := m.Lookup(deviceTypeID, taskTypeID)
+	if !ok || st.P80Factor <= 0 {
+		return nominal
+	}
+	return time.Duration(float64(nominal) * st.P80Factor).Round(time.Minute)
+}
+
+// Factors возвращает выборку коэффициентов факт / план самой точной группы
+// с достаточной статистикой. Срез общий, изменять его нельзя.
+func (m DurationModel) Factors(deviceTypeID, taskTypeID int64) []float64 {
+	st, _, ok := m.Lookup(deviceTypeID, taskTypeID)
+	if !ok {
+		return nil
+	}
+	return m.factors[durationKey{st.DeviceTypeID, st.DeviceTaskTypeID}]
+}
+
+// percentile — линейная интерполяция по отсортированной выборке.
+func percentile(sorted []float64, p float64) float64 {
+	if len(sorted) == 0 {
+		return 0
+	}
+	pos := p * float64(len(sorted)-1)
+	lo := int(math.Floor(pos))
+	hi := int(math.Ceil(pos))
+	if lo == hi {
+		return sorted[lo]
+	}
+	return sorted[lo] + (sorted[hi]-sorted[lo])*(pos-float64(lo))
+}
+
+func roundMinutes(v float64) int {
+	return int(math.Round(v))
+}
@@ -0,0 +1,115 @@
+package service
+
+import (
+	"math"
+	"slices"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// durationSamples — n завершённых заданий на типе оборудования deviceType с
+// типом задания taskType: час по плану и factor часов по факту.
+func durationSamples(deviceType, taskType int64, n int, factor float64) []storage.CompletedTaskDuration {
+	res := make([]storage.CompletedTaskDuration, n)
+	for i := range res {
+		res[i] = storage.CompletedTaskDuration{
+			DeviceTypeID:     deviceType,
+			DeviceTaskTypeID: taskType,
+			Nominal:          time.Hour,
+			Actual:           time.Duration(factor * float64(time.Hour)),
+		}
+	}
+	return res
+}
+
+// Без достаточной выборки в точной группе оценка берётся из более общей:
+// тип оборудования, тип задания, весь workspace.
+func TestDurationModelGrouping(t *testing.T) {
+	var samples []storage.CompletedTaskDuration
+	samples = append(samples, durationSamples(1, 1, 3, 2)...)
+	samples = append(samples, durationSamples(1, 2, 2, 1.5)...)
+	samples = append(samples, durationSamples(2, 1, 1, 1)...)
+	m := BuildDurationModel(samples)
+
+	cases := []struct {
+		name               string
+		deviceType, task   int64
+		basis              string
+		samples            int
+		p50                float64
+		statType, statTask int64
+	}{
+		{"exact group", 1, 1, DurationBasisExact, 3, 2, 1, 1},
+		{"small task group falls back to device type", 1, 2, DurationBasisDeviceType, 5, 2, 1, 0},
+		{"small device type falls back to task type", 2, 1, DurationBasisTaskType, 4, 2, 0, 1},
+		{"unknown types fall back to workspace", 3, 3, DurationBasisWorkspace, 6, 1.75, 0, 0},
+	}
+	for _, c := range cases {
+		st, basis, ok := m.Lookup(c.deviceType, c.task)
+		if !ok || basis != c.basis || st.Samples != c.samples || st.P50Factor != c.p50 ||
+			st.DeviceTypeID != c.statType || st.DeviceTaskTypeID != c.statTask {
+			t.Errorf("%s: got (%+v, %s, %v), want basis %s with %d samples", c.name, st, basis, ok, c.basis, c.samples)
+		}
+	}
+
+	var keys [][2]int64
+	for _, st := range m.Stats() {
+		keys = append(keys, [2]int64{st.DeviceTypeID, st.DeviceTaskTypeID})
+	}
+	if want := [][2]int64{{1, 1}, {1, 0}, {0, 1}, {0, 0}}; !slices.Equal(keys, want) {
+		t.Errorf("stats groups %v, want %v", keys, want)
+	}
+}
+
+// Пока истории меньше минимальной выборки, используются введённые вручную
+// длительности.
+func TestDurationModelNominalFallback(t *testing.T) {
+	for _, m := range []DurationModel{BuildDurationModel(nil), BuildDurationModel(durationSamples(1, 1, minDurationSamples-1, 3))} {
+		got := m.Suggest(1, 1, 90*time.Minute)
+		want := DurationSuggestion{Basis: DurationBasisNominal, NominalMin: 90, Factor: 1, SuggestedMin: 90, P80Min: 90}
+		if got != want {
+			t.Errorf("suggestion %+v, want %+v", got, want)
+		}
+		if d := m.P80(1, 1, 90*time.Minute); d != 90*time.Minute {
+			t.Errorf("P80 %v, want the nominal 1h30m", d)
+		}
+		if f := m.Factors(1, 1); f != nil {
+			t.Errorf("factors %v, want none", f)
+		}
+	}
+}
+
+// Оценка идёт по медиане, поэтому один выброс её не сдвигает, а в P80 и P90
+// он входит. Задание без плановой длительности коэффициента не даёт, но
+// входит в перцентили фактической длительности.
+func TestDurationModelOutliers(t *testing.T) {
+	var samples []storage.CompletedTaskDuration
+	for _, factor := range []float64{1, 1, 1.1, 1.2, 10} {
+		samples = append(samples, durationSamples(1, 1, 1, factor)...)
+	}
+	m := BuildDurationModel(samples)
+	st, _, _ := m.Lookup(1, 1)
+	if st.P50Factor != 1.1 || math.Abs(st.P80Factor-2.96) > 1e-9 || st.P90Factor <= st.P80Factor {
+		t.Errorf("factors p50 %v, p80 %v, p90 %v, want 1.1, 2.96 and above", st.P50Factor, st.P80Factor, st.P90Factor)
+	}
+	got := m.Suggest(1, 1, 100*time.Minute)
+	if got.Basis != DurationBasisExact || got.SuggestedMin != 110 || got.P80Min != 296 {
+		t.Errorf("suggestion %+v, want 110 min, P80 296 min", got)
+	}
+	if d := m.P80(1, 1, 100*time.Minute); d != 296*time.Minute {
+		t.Errorf("P80 %v, want 4h56m", d)
+	}
+
+	samples = append(samples, storage.CompletedTaskDuration{DeviceTypeID: 1, DeviceTaskTypeID: 1, Actual: 30 * time.Minute})
+	m = BuildDurationModel(samples)
+	st, _, _ = m.Lookup(1, 1)
+	if st.Samples != 6 || len(m.Factors(1, 1)) != 5 || st.P50Factor != 1.1 {
+		t.Errorf("stat %+v with %d factors, want 6 samples and 5 factors", st, len(m.Factors(1, 1)))
+	}
+	// Без плановой длительности предлагается медиана фактической.
+	if got := m.Suggest(1, 1, 0); got.SuggestedMin != st.P50Min || got.Factor != 1 {
+		t.Errorf("suggestion without nominal %+v, want p50 %d min", got, st.P50Min)
+	}
+}
@@ -0,0 +1,228 @@
+package service
+
+import (
+	"context"
+	"math"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// energyHorizon — насколько позже самого раннего слота планировщик ищет
+// дешёвое окно тарифа.
+const energyHorizon = 48 * time.Hour
+
+// Energy — тарифы электроэнергии и мощность оборудования для планировщика.
+type Energy struct {
+	schedule storage.EnergySchedule
+	power    map[int64]float64 // устройство -> кВт
+}
+
+// NewEnergy собирает мощность каждого устройства: мощность устройства
+// перекрывает мощность его типа.
+func NewEnergy(schedule storage.EnergySchedule, devices []storage.Device, types []storage.DeviceType) *Energy {
+	byType := make(map[int64]float64, len(types))
+	for _, t := range types {
+		if t.PowerKW != nil {
+			byType[t.ID] = *t.PowerKW
+		}
+	}
+	e := &Energy{schedule: schedule, power: make(map[int64]float64, len(devices))}
+	for _, d := range devices {
+		if d.PowerKW != nil {
+			e.power[d.ID] = *d.PowerKW
+		} else if p, ok := byType[d.DeviceTypeID]; ok {
+			e.power[d.ID] = p
+		}
+	}
+	return e
+}
+
+// weighted — стоимость энергии входит в цель планировщика.
+func (e *Energy) weighted() bool {
+	return e != nil && e.schedule.CostWeight > 0 && len(e.schedule.Tariffs) > 0
+}
+
+// overnight — задание может работать после конца рабочего дня: без оператора
+// оборудование допечатывает его ночью, когда тариф обычно дешевле.
+func (e *Energy) overnight(t storage.DeviceTaskRow) bool {
+	return e.weighted() && !t.NeedOperator
+}
+
+// estimate — расход (кВт·ч) и стоимость работы устройства в [start, end).
+func (e *Energy) estimate(deviceID int64, start, end time.Time) (float64, float64) {
+	if e == nil || e.power[deviceID] <= 0 || !end.After(start) {
+		return 0, 0
+	}
+	kw := e.power[deviceID]
+	var cost float64
+	covered := time.Duration(0)
+	for _, w := range e.windows(start, end) {
+		d := overlap([]interval{w.interval}, start, end)
+		covered += d
+		cost += d.Hours() * w.price
+	}
+	cost += (end.Sub(start) - covered).Hours() * e.schedule.BasePrice
+	return kw * end.Sub(start).Hours(), kw * cost
+}
+
+// penalty — надбавка к окончанию слота за стоимость его энергии.
+func (e *Energy) penalty(deviceID int64, start, end time.Time) time.Duration {
+	if !e.weighted() {
+		return 0
+	}
+	_, cost := e.estimate(deviceID, start, end)
+	return time.Duration(cost * e.schedule.CostWeight * float64(time.Minute))
+}
+
+// starts — моменты, с которых планировщик ищет слот задания длительностью dur
+// помимо earliest: начала окон тарифов и моменты, в которые задание закончится
+// к началу или концу окна.
+func (e *Energy) starts(deviceID int64, earliest time.Time, dur time.Duration) []time.Time {
+	if !e.weighted() || e.power[deviceID] <= 0 {
+		return nil
+	}
+	limit := earliest.Add(energyHorizon)
+	seen := map[time.Time]bool{}
+	var res []time.Time
+	for _, w := range e.windows(earliest, limit.Add(dur)) {
+		for _, at := range []time.Time{w.start, w.start.Add(-dur), w.end.Add(-dur)} {
+			if at.After(earliest) && at.Before(limit) && !seen[at] {
+				seen[at] = true
+				res = append(res, at)
+			}
+		}
+	}
+	sort.Slice(res, func(i, j int) bool { return res[i].Before(res[j]) })
+	return res
+}
+
+// runsOvernight — задание без оператора стоит в плане с окончанием после
+// конца рабочего дня, в который оно начинается.
+func runsOvernight(t storage.DeviceTaskRow) bool {
+	if t.NeedOperator || t.PlanStart == nil || t.PlanEnd == nil {
+		return false
+	}
+	s := *t.PlanStart
+	return t.PlanEnd.After(time.Date(s.Year(), s.Month(), s.Day(), workDayEndHour, 0, 0, 0, s.Location()))
+}
+
+type tariffWindow struct {
+	interval
+	price float64
+}
+
+// windows — окна тарифов, пересекающие [from, to), в абсолютном времени.
+func (e *Energy) windows(from, to time.Time) []tariffWindow {
+	var res []tariffWindow
+	// Окно, перешедшее через полночь, начинается накануне.
+	day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()).AddDate(0, 0, -1)
+	for ; day.Before(to); day = day.AddDate(0, 0, 1) {
+		for _, t := range e.schedule.Tariffs {
+			start, end := day.Add(t.Start), day.Add(t.End)
+			if t.End <= t.Start {
+				end = end.AddDate(0, 0, 1)
+			}
+			if intersects(start, end, from, to) {
+				res = append(res, tariffWindow{interval: interval{start: start, end: end}, price: t.Price})
+			}
+		}
+	}
+	return res
+}
+
+// TaskEnergy — оценка энергии задания в плане. Энергия прогона делится
+// поровну между его заданиями.
+type TaskEnergy struct {
+	TaskID   int64     `json:"task_id"`
+	DeviceID int64     `json:"device_id"`
+	BatchID  int64     `json:"batch_id,omitempty"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	KWh      float64   `json:"kwh"`
+	Cost     float64   `json:"cost"`
+}
+
+// EnergyReport — энергия и её стоимость по текущему плану workspace.
+type EnergyReport struct {
+	Tasks []TaskEnergy `json:"tasks"`
+	KWh   float64      `json:"kwh"`
+	Cost  float64      `json:"cost"`
+}
+
+// EnergyEstimate оценивает расход и стоимость энергии заданий плана, ещё не
+// завершённых и не отменённых.
+func (p *Planner) EnergyEstimate(ctx context.Context, workspaceID int64) (EnergyReport, error) {
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return EnergyReport{}, err
+	}
+	energy, err := p.energy(ctx, workspaceID, nil)
+	if err != nil {
+		return EnergyReport{}, err
+	}
+	return buildEnergyReport(energy, tasks), nil
+}
+
+// buildEnergyReport — отчёт EnergyEstimate по уже загруженным данным.
+func buildEnergyReport(energy *Energy, tasks []storage.DeviceTaskRow) EnergyReport {
+	var planned []storage.DeviceTaskRow
+	members := map[int64]int{}
+	for _, t := range tasks {
+		if t.Status != storage.TaskStatusPending && t.Status != storage.TaskStatusInProgress {
+			continue
+		}
+		if t.PlanStart == nil || t.PlanEnd == nil || t.DeviceID <= 0 {
+			continue
+		}
+		planned = append(planned, t)
+		if t.BatchID > 0 {
+			members[t.BatchID]++
+		}
+	}
+	res := EnergyReport{Tasks: make([]TaskEnergy, 0, len(planned))}
+	for _, t := range planned {
+		kwh, cost := energy.estimate(t.DeviceID, *t.PlanStart, *t.PlanEnd)
+		if n := members[t.BatchID]; t.BatchID > 0 && n > 1 {
+			kwh, cost = kwh/float64(n), cost/float64(n)
+		}
+		res.Tasks = append(res.Tasks, TaskEnergy{
+			TaskID:   t.ID,
+			DeviceID: t.DeviceID,
+			BatchID:  t.BatchID,
+			Start:    *t.PlanStart,
+			End:      *t.PlanEnd,
+			KWh:      roundHundredths(kwh),
+			Cost:     roundHundredths(cost),
+		})
+		res.KWh += kwh
+		res.Cost += cost
+	}
+	res.KWh, res.Cost = roundHundredths(res.KWh), roundHundredths(res.Cost)
+	return res
+}
+
+// roundHundredths округляет кВт·ч и стоимость до сотых.
+func roundHundredths(v float64) float64 {
+	return math.Round(v*100) / 100
+}
+
+// energy — тарифы workspace и мощность его оборудования; devices — уже
+// загруженное оборудование, nil — загрузить.
+func (p *Planner) energy(ctx context.Context, workspaceID int64, devices []storage.Device) (*Energy, error) {
+	schedule, err := p.repos.GetEnergySchedule(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	if devices == nil {
+		if devices, err = p.repos.ListDevices(ctx, workspaceID); err != nil {
+			return nil, err
+		}
+	}
+	types, err := p.repos.ListDeviceTypes(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return NewEnergy(schedule, devices, types), nil
+}
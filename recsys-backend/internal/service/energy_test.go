@@ -0,0 +1,113 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// testEnergy — ночной тариф с 22:00 до 06:00 по 1 за кВт·ч, днём 5.
+// Оборудование 1 потребляет 2 кВт, оборудование 2 — 3 кВт по своему типу,
+// у оборудования 3 мощность не задана.
+func testEnergy(weight float64) *Energy {
+	own, byType := 2.0, 3.0
+	schedule := storage.EnergySchedule{
+		BasePrice:  5,
+		CostWeight: weight,
+		Tariffs:    []storage.EnergyTariff{{Start: 22 * time.Hour, End: 6 * time.Hour, Price: 1}},
+	}
+	devices := []storage.Device{
+		{ID: 1, DeviceTypeID: 1, PowerKW: &own},
+		{ID: 2, DeviceTypeID: 1},
+		{ID: 3, DeviceTypeID: 2},
+	}
+	return NewEnergy(schedule, devices, []storage.DeviceType{{ID: 1, PowerKW: &byType}})
+}
+
+func TestEnergyEstimate(t *testing.T) {
+	e := testEnergy(0)
+	cases := []struct {
+		name       string
+		deviceID   int64
+		start, end time.Time
+		kwh, cost  float64
+	}{
+		{"day", 1, mar(3, 9), mar(3, 10), 2, 10},
+		{"into the night", 1, mar(3, 21), mar(3, 23), 4, 12},
+		{"across midnight", 1, mar(3, 23), mar(4, 1), 4, 4},
+		{"power of the type", 2, mar(3, 9), mar(3, 10), 3, 15},
+		{"no power", 3, mar(3, 9), mar(3, 10), 0, 0},
+		{"empty slot", 1, mar(3, 10), mar(3, 10), 0, 0},
+	}
+	for _, c := range cases {
+		if kwh, cost := e.estimate(c.deviceID, c.start, c.end); kwh != c.kwh || cost != c.cost {
+			t.Errorf("%s: estimate = (%v, %v), want (%v, %v)", c.name, kwh, cost, c.kwh, c.cost)
+		}
+	}
+}
+
+// Надбавка растёт со стоимостью слота и появляется только при весе стоимости.
+func TestEnergyPenalty(t *testing.T) {
+	if got := testEnergy(1).penalty(1, mar(3, 21), mar(3, 23)); got != 12*time.Minute {
+		t.Errorf("penalty = %v, want 12m", got)
+	}
+	if got := testEnergy(0).penalty(1, mar(3, 21), mar(3, 23)); got != 0 {
+		t.Errorf("without weight: penalty = %v, want 0", got)
+	}
+}
+
+// Кандидаты — начало ночного окна, момент, с которого задание закончится к
+// нему, и момент, с которого оно закончится к концу окна, в пределах горизонта.
+func TestEnergyStarts(t *testing.T) {
+	got := testEnergy(1).starts(1, mar(3, 20), time.Hour)
+	want := []time.Time{mar(3, 21), mar(3, 22), mar(4, 5), mar(4, 21), mar(4, 22), mar(5, 5)}
+	if !slices.EqualFunc(got, want, time.Time.Equal) {
+		t.Errorf("starts = %v, want %v", got, want)
+	}
+	if got := testEnergy(0).starts(1, mar(3, 20), time.Hour); got != nil {
+		t.Errorf("without weight: starts = %v, want none", got)
+	}
+	if got := testEnergy(1).starts(3, mar(3, 20), time.Hour); got != nil {
+		t.Errorf("without power: starts = %v, want none", got)
+	}
+}
+
+// Ночью работает только задание без оператора и только при весе стоимости.
+func TestEnergyOvernight(t *testing.T) {
+	printing := storage.DeviceTaskRow{PlanStart: slotAt(mar(3, 20)), PlanEnd: slotAt(mar(4, 2))}
+	if !testEnergy(1).overnight(printing) || testEnergy(0).overnight(printing) {
+		t.Error("overnight should follow the cost weight")
+	}
+	if !runsOvernight(printing) {
+		t.Error("task ending after the work day should run overnight")
+	}
+	staffed := printing
+	staffed.NeedOperator = true
+	if testEnergy(1).overnight(staffed) || runsOvernight(staffed) {
+		t.Error("task with an operator should not run overnight")
+	}
+	printing.PlanEnd = slotAt(mar(3, 22))
+	if runsOvernight(printing) {
+		t.Error("task ending with the work day should not run overnight")
+	}
+}
+
+// Энергия прогона делится поровну между его заданиями.
+func TestBuildEnergyReportSplitsBatch(t *testing.T) {
+	tasks := []storage.DeviceTaskRow{
+		printTask(1, mar(3, 9), mar(3, 11)),
+		printTask(2, mar(3, 9), mar(3, 11)),
+		printTask(3, mar(3, 11), mar(3, 12)),
+	}
+	tasks[0].BatchID, tasks[1].BatchID = 1, 1
+	tasks[2].Status = storage.TaskStatusDone
+	res := buildEnergyReport(testEnergy(0), tasks)
+	if len(res.Tasks) != 2 || res.Tasks[0].KWh != 2 || res.Tasks[0].Cost != 10 {
+		t.Errorf("tasks %+v, want two halves of 4 kWh and 20", res.Tasks)
+	}
+	if res.KWh != 4 || res.Cost != 20 {
+		t.Errorf("total (%v, %v), want (4, 20)", res.KWh, res.Cost)
+	}
+}
@@ -0,0 +1,192 @@
+package service
+
+import (
+	"slices"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Правила рабочего времени, из-за которых задание встало позже.
+const (
+	LabourRuleMaxDay  = "max_day"  // дневной предел работы оператора
+	LabourRuleMaxWeek = "max_week" // недельный предел
+	LabourRuleBreak   = "break"    // обязательный перерыв
+	LabourRuleMinRest = "min_rest" // отдых между сменами
+)
+
+// LabourDelay — задание, которое правила рабочего времени его оператора
+// поставили позже, чем позволяли оборудование и занятость.
+type LabourDelay struct {
+	TaskID     int64    `json:"task_id"`
+	OperatorID int64    `json:"operator_id"`
+	Rules      []string `json:"rules"`
+	DelayMin   int      `json:"delay_min"`
+}
+
+// labourLedger — работа операторов над заданиями в плане: проверка правил
+// рабочего времени и загрузка для выравнивания.
+type labourLedger struct {
+	rules    storage.LabourRules
+	work     map[int64][]interval // оператор -> задания
+	personal map[int64][]interval // оператор -> личные поручения (user_task)
+}
+
+func newLabourLedger(rules storage.LabourRules, personal []storage.UserTaskBusy) *labourLedger {
+	l := &labourLedger{rules: rules, work: map[int64][]interval{}, personal: map[int64][]interval{}}
+	for _, b := range personal {
+		l.personal[b.OperatorID] = append(l.personal[b.OperatorID], interval{start: b.Start, end: b.End})
+	}
+	return l
+}
+
+// balancing — планировщик выбирает оператора с учётом загрузки.
+func (l *labourLedger) balancing() bool {
+	return l.rules.FairnessWeight > 0
+}
+
+// penalty — надбавка к окончанию слота оператора за уже назначенную ему
+// работу (задания и личные поручения) в периоде, содержащем at.
+func (l *labourLedger) penalty(operatorID int64, at time.Time) time.Duration {
+	if operatorID <= 0 || !l.balancing() {
+		return 0
+	}
+	from := time.Date(at.Year(), at.Month(), at.Day(), 0, 0, 0, 0, at.Location())
+	to := from.AddDate(0, 0, 1)
+	if l.rules.FairnessPeriod == storage.FairnessPeriodWeek {
+		from = weekStart(from)
+		to = from.AddDate(0, 0, 7)
+	}
+	load := l.worked(operatorID, from, to)
+	return time.Duration(load.Hours() * l.rules.FairnessWeight * float64(time.Minute))
+}
+
+// weekStart — полночь понедельника недели day.
+func weekStart(day time.Time) time.Time {
+	return day.AddDate(0, 0, -(int(day.Weekday())+6)%7)
+}
+
+// check проверяет слот оператора. Если правило нарушено, возвращает его и
+// момент, с которого стоит искать слот снова; нулевой момент — слот такой
+// длины оператору не поставить никогда.
+func (l *labourLedger) check(operatorID int64, start, end time.Time) (time.Time, string) {
+	if operatorID <= 0 || !l.rules.Enabled() {
+		return time.Time{}, ""
+	}
+	dur := end.Sub(start)
+	day := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, start.Location())
+
+	if l.rules.Break > 0 {
+		breakStart := day.Add(l.rules.BreakStart)
+		breakEnd := breakStart.Add(l.rules.Break)
+		if intersects(start, end, breakStart, breakEnd) {
+			dayStart := day.Add(workDayStartHour * time.Hour)
+			dayEnd := day.Add(workDayEndHour * time.Hour)
+			if dur > breakStart.Sub(dayStart) && dur > dayEnd.Sub(breakEnd) {
+				return time.Time{}, LabourRuleBreak
+			}
+			return breakEnd, LabourRuleBreak
+		}
+	}
+	if l.rules.MaxDay > 0 {
+		if dur > l.rules.MaxDay {
+			return time.Time{}, LabourRuleMaxDay
+		}
+		if l.worked(operatorID, day, day.AddDate(0, 0, 1))+dur > l.rules.MaxDay {
+			return nextWorkdayStart(start), LabourRuleMaxDay
+		}
+	}
+	if l.rules.MaxWeek > 0 {
+		if dur > l.rules.MaxWeek {
+			return time.Time{}, LabourRuleMaxWeek
+		}
+		week := weekStart(day)
+		if l.worked(operatorID, week, week.AddDate(0, 0, 7))+dur > l.rules.MaxWeek {
+			return week.AddDate(0, 0, 7).Add(workDayStartHour * time.Hour), LabourRuleMaxWeek
+		}
+	}
+	if l.rules.MinRest > 0 {
+		// Отдых считается от работы соседних дней; работа в тот же день — одна смена.
+		for _, iv := range l.work[operatorID] {
+			if iv.end.After(day) || !iv.end.Add(l.rules.MinRest).After(start) {
+				continue
+			}
+			return iv.end.Add(l.rules.MinRest), LabourRuleMinRest
+		}
+		next := day.AddDate(0, 0, 1)
+		for _, iv := range l.work[operatorID] {
+			if !iv.start.Before(next) && end.Add(l.rules.MinRest).After(iv.start) {
+				return nextWorkdayStart(start), LabourRuleMinRest
+			}
+		}
+	}
+	return time.Time{}, ""
+}
+
+// worked — работа оператора над заданиями и личными поручениями в
+// интервале [from, to).
+func (l *labourLedger) worked(operatorID int64, from, to time.Time) time.Duration {
+	return overlap(l.work[operatorID], from, to) + overlap(l.personal[operatorID], from, to)
+}
+
+// overlap — суммарное пересечение интервалов с [from, to).
+func overlap(busy []interval, from, to time.Time) time.Duration {
+	var total time.Duration
+	for _, iv := range busy {
+		s, e := iv.start, iv.end
+		if s.Before(from) {
+			s = from
+		}
+		if e.After(to) {
+			e = to
+		}
+		if e.After(s) {
+			total += e.Sub(s)
+		}
+	}
+	return total
+}
+
+// findLabourSlot — слот findChangeoverSlot, который правила рабочего времени
+// позволяют оператору. Возвращает и нарушенные по пути правила с задержкой
+// относительно слота без них.
+func (l *labourLedger) findLabourSlot(
+	operatorID int64,
+	earliest time.Time,
+	dur time.Duration,
+	deviceBusy []interval,
+	operatorBusy []interval,
+	deadline *time.Time,
+	material int64,
+	changeovers Changeovers,
+	cooldown time.Duration,
+	overnight bool,
+) (time.Time, time.Time, time.Duration, []string, time.Duration, bool) {
+	var rules []string
+	var free time.Time
+	limit := earliest.Add(maxScheduleAhead)
+	if deadline != nil && deadline.Before(limit) {
+		limit = *deadline
+	}
+	from := earliest
+	for {
+		start, end, change, ok := findChangeoverSlot(from, dur, deviceBusy, operatorBusy, &limit, material, changeovers, cooldown, overnight)
+		if !ok {
+			return time.Time{}, time.Time{}, 0, rules, 0, false
+		}
+		if free.IsZero() {
+			free = start
+		}
+		retry, rule := l.check(operatorID, start, end)
+		if rule == "" {
+			return start, end, change, rules, start.Sub(free), true
+		}
+		if !slices.Contains(rules, rule) {
+			rules = append(rules, rule)
+		}
+		if retry.IsZero() {
+			return time.Time{}, time.Time{}, 0, rules, 0, false
+		}
+		from = retry
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// ledger — журнал с правилами rules и уже назначенной оператору работой.
+func ledger(rules storage.LabourRules, work ...interval) *labourLedger {
+	l := newLabourLedger(rules, nil)
+	l.work[testOperator] = work
+	return l
+}
+
+func expectRule(t *testing.T, l *labourLedger, start, end, wantRetry time.Time, wantRule string) {
+	t.Helper()
+	retry, rule := l.check(testOperator, start, end)
+	if rule != wantRule || !retry.Equal(wantRetry) {
+		t.Errorf("check(%v, %v) = (%v, %q), want (%v, %q)", start, end, retry, rule, wantRetry, wantRule)
+	}
+}
+
+func TestLabourCheckWithoutRules(t *testing.T) {
+	expectRule(t, ledger(storage.LabourRules{}), mar(3, 9), mar(3, 20), time.Time{}, "")
+
+	// Задание без оператора правилам не подчиняется.
+	l := ledger(storage.LabourRules{MaxDay: time.Hour})
+	if retry, rule := l.check(0, mar(3, 9), mar(3, 20)); rule != "" || !retry.IsZero() {
+		t.Errorf("got (%v, %q) for a task without operator", retry, rule)
+	}
+}
+
+func TestLabourCheckBreak(t *testing.T) {
+	l := ledger(storage.LabourRules{BreakStart: 13 * time.Hour, Break: time.Hour})
+	expectRule(t, l, mar(3, 12), mar(3, 14), mar(3, 14), LabourRuleBreak)
+	// Слот длиннее обеих половин дня не встаёт никогда.
+	expectRule(t, l, mar(3, 9), mar(3, 18), time.Time{}, LabourRuleBreak)
+}
+
+func TestLabourCheckMaxDay(t *testing.T) {
+	rules := storage.LabourRules{MaxDay: 8 * time.Hour}
+	expectRule(t, ledger(rules), mar(3, 9), mar(3, 18), time.Time{}, LabourRuleMaxDay)
+	expectRule(t, ledger(rules, interval{start: mar(3, 9), end: mar(3, 15)}), mar(3, 15), mar(3, 18), mar(4, 9), LabourRuleMaxDay)
+
+	// Личные поручения оператора входят в дневной предел.
+	l := newLabourLedger(rules, []storage.UserTaskBusy{{OperatorID: testOperator, Start: mar(3, 9), End: mar(3, 15)}})
+	expectRule(t, l, mar(3, 15), mar(3, 18), mar(4, 9), LabourRuleMaxDay)
+}
+
+func TestLabourCheckMaxWeek(t *testing.T) {
+	l := ledger(storage.LabourRules{MaxWeek: 20 * time.Hour},
+		interval{start: mar(3, 9), end: mar(3, 19)},
+		interval{start: mar(4, 9), end: mar(4, 17)},
+	)
+	// Предел исчерпан в среду — следующий слот только в понедельник.
+	expectRule(t, l, mar(5, 9), mar(5, 12), mar(10, 9), LabourRuleMaxWeek)
+}
+
+func TestLabourCheckMinRest(t *testing.T) {
+	expectRule(t, ledger(storage.LabourRules{MinRest: 11 * time.Hour}, interval{start: mar(3, 20), end: mar(3, 23)}),
+		mar(4, 9), mar(4, 12), mar(4, 10), LabourRuleMinRest)
+	expectRule(t, ledger(storage.LabourRules{MinRest: 11 * time.Hour}, interval{start: mar(3, 18), end: mar(3, 21)}),
+		mar(4, 9), mar(4, 12), time.Time{}, "")
+	// Отдыха не хватает и перед следующей сменой.
+	expectRule(t, ledger(storage.LabourRules{MinRest: 12 * time.Hour}, interval{start: mar(4, 9), end: mar(4, 12)}),
+		mar(3, 20), mar(3, 22), mar(4, 9), LabourRuleMinRest)
+}
+
+// fairnessInput — задание на оборудовании 1, которое может выполнить свой
+// оператор 5 или оператор 7; у оператора 5 уже есть два часа работы 3 марта.
+func fairnessInput(now time.Time, rules storage.LabourRules) PlanInput {
+	return PlanInput{
+		Now:     now,
+		Devices: []storage.Device{{ID: 1, DeviceTypeID: 1}, {ID: 2, DeviceTypeID: 2}},
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, OperatorID: 5, NeedOperator: true, Duration: 2 * time.Hour, Status: storage.TaskStatusPending},
+		},
+		Fixed: []storage.DeviceTaskRow{
+			{ID: 9, DeviceID: 2, OperatorID: 5, NeedOperator: true, Status: storage.TaskStatusDone, ActualStart: slotAt(mar(3, 6)), ActualEnd: slotAt(mar(3, 8))},
+		},
+		Competencies: NewCompetencies([]storage.OperatorCompetency{
+			{OperatorID: 5, DeviceTypeID: 1, Level: storage.CompetencyQualified},
+			{OperatorID: 7, DeviceTypeID: 1, Level: storage.CompetencyQualified},
+		}),
+		Labour: rules,
+	}
+}
+
+func plannedOperator(t *testing.T, out PlanOutput) int64 {
+	t.Helper()
+	if len(out.Slots) != 1 {
+		t.Fatalf("slots %+v, unscheduled %v", out.Slots, out.Unscheduled)
+	}
+	return out.Slots[0].OperatorID
+}
+
+// Без веса выравнивания задание остаётся за своим оператором, с весом —
+// переходит к менее загруженному в периоде допущенному оператору.
+func TestPlanTasksFairnessDay(t *testing.T) {
+	if got := plannedOperator(t, PlanTasks(fairnessInput(mar(3, 9), storage.LabourRules{}))); got != 5 {
+		t.Errorf("without fairness: operator %d, want own operator 5", got)
+	}
+	rules := storage.LabourRules{FairnessWeight: 10, FairnessPeriod: storage.FairnessPeriodDay}
+	if got := plannedOperator(t, PlanTasks(fairnessInput(mar(3, 9), rules))); got != 7 {
+		t.Errorf("with fairness: operator %d, want less loaded operator 7", got)
+	}
+	// Работа прошлого дня в дневной период не входит.
+	if got := plannedOperator(t, PlanTasks(fairnessInput(mar(4, 9), rules))); got != 5 {
+		t.Errorf("next day: operator %d, want own operator 5", got)
+	}
+}
+
+// В недельный период входит работа с понедельника.
+func TestPlanTasksFairnessWeek(t *testing.T) {
+	rules := storage.LabourRules{FairnessWeight: 10, FairnessPeriod: storage.FairnessPeriodWeek}
+	if got := plannedOperator(t, PlanTasks(fairnessInput(mar(4, 9), rules))); got != 7 {
+		t.Errorf("same week: operator %d, want less loaded operator 7", got)
+	}
+	if got := plannedOperator(t, PlanTasks(fairnessInput(mar(10, 9), rules))); got != 5 {
+		t.Errorf("next week: operator %d, want own operator 5", got)
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// MaterialSupply — запас материала для планирования: остаток на складе и
+// ближайшая ожидаемая поставка.
+type MaterialSupply struct {
+	OnHand     float64
+	RestockAt  *time.Time // nil — поставка не ожидается
+	RestockQty float64
+}
+
+// MaterialShortage — задание, которому не хватает материала на складе.
+type MaterialShortage struct {
+	TaskID     int64   `json:"task_id"`
+	MaterialID int64   `json:"material_id"`
+	Needed     float64 `json:"needed"`
+	Available  float64 `json:"available"` // свободный остаток после заданий, поставленных раньше
+	// RestockAt — задание ждёт поставки и стоит в плане не раньше неё;
+	// nil — материала нет и до известной поставки, задание снято с плана.
+	RestockAt *time.Time `json:"restock_at,omitempty"`
+}
+
+// stockLedger ведёт расход материалов по мере постановки заданий в план.
+// Материалы, которых нет на складе, не ограничивают план.
+type stockLedger struct {
+	supply map[int64]MaterialSupply
+	used   map[int64]float64
+}
+
+func newStockLedger(supply map[int64]MaterialSupply) *stockLedger {
+	return &stockLedger{supply: supply, used: map[int64]float64{}}
+}
+
+// reserve учитывает расход задания, уже стоящего в плане.
+func (l *stockLedger) reserve(t storage.DeviceTaskRow) {
+	if _, ok := l.supply[t.MaterialID]; ok && t.MaterialQty > 0 {
+		l.used[t.MaterialID] += t.MaterialQty
+	}
+}
+
+// check проверяет, хватит ли материала заданиям tasks. Возвращает момент, не
+// раньше которого их можно ставить (после поставки, если текущего остатка не
+// хватает), нехватки по заданиям и false, если материала нет и с поставкой.
+func (l *stockLedger) check(tasks []storage.DeviceTaskRow, now time.Time) (time.Time, []MaterialShortage, bool) {
+	release := now
+	var short []MaterialShortage
+	ok := true
+	need := map[int64]float64{}
+	for _, t := range tasks {
+		s, tracked := l.supply[t.MaterialID]
+		if !tracked || t.MaterialQty <= 0 {
+			continue
+		}
+		available := s.OnHand - l.used[t.MaterialID] - need[t.MaterialID]
+		need[t.MaterialID] += t.MaterialQty
+		if t.MaterialQty <= available {
+			continue
+		}
+		sh := MaterialShortage{TaskID: t.ID, MaterialID: t.MaterialID, Needed: t.MaterialQty, Available: max(available, 0)}
+		if s.RestockAt != nil && t.MaterialQty <= available+s.RestockQty {
+			sh.RestockAt = s.RestockAt
+			if s.RestockAt.After(release) {
+				release = *s.RestockAt
+			}
+		} else {
+			ok = false
+		}
+		short = append(short, sh)
+	}
+	return release, short, ok
+}
+
+// materialSupply — склад материалов workspace для планировщика.
+func (p *Planner) materialSupply(ctx context.Context, workspaceID int64) (map[int64]MaterialSupply, error) {
+	items, err := p.repos.ListMaterialStock(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]MaterialSupply, len(items))
+	for _, s := range items {
+		res[s.MaterialID] = MaterialSupply{OnHand: s.OnHand, RestockAt: s.RestockAt, RestockQty: s.RestockQty}
+	}
+	return res, nil
+}
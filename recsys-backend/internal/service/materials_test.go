@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+
+	"recsys-backend/internal/storage"
+)
+
+// stockTask — задание с расходом qty материала material.
+func stockTask(id, material int64, qty float64) storage.DeviceTaskRow {
+	return storage.DeviceTaskRow{ID: id, MaterialID: material, MaterialQty: qty}
+}
+
+// Хватает остатка — задание ставится сейчас; материал не со склада и задание
+// без расхода план не ограничивают.
+func TestStockLedgerEnough(t *testing.T) {
+	l := newStockLedger(map[int64]MaterialSupply{1: {OnHand: 10}})
+	l.reserve(stockTask(1, 1, 4))
+	release, short, ok := l.check([]storage.DeviceTaskRow{stockTask(2, 1, 6), stockTask(3, 2, 100), stockTask(4, 1, 0)}, mar(3, 9))
+	if !ok || len(short) != 0 || !release.Equal(mar(3, 9)) {
+		t.Errorf("got (%v, %+v, %v), want now without shortages", release, short, ok)
+	}
+}
+
+// Не хватает остатка после уже поставленных заданий — задание ждёт поставки,
+// если её хватает, и снимается с плана, если нет.
+func TestStockLedgerShortage(t *testing.T) {
+	restock := mar(4, 9)
+	supply := map[int64]MaterialSupply{1: {OnHand: 10, RestockAt: &restock, RestockQty: 5}}
+
+	l := newStockLedger(supply)
+	l.reserve(stockTask(1, 1, 8))
+	release, short, ok := l.check([]storage.DeviceTaskRow{stockTask(2, 1, 6)}, mar(3, 9))
+	if !ok || !release.Equal(restock) {
+		t.Errorf("got release %v ok %v, want restock %v", release, ok, restock)
+	}
+	if len(short) != 1 || short[0].TaskID != 2 || short[0].Needed != 6 || short[0].Available != 2 || short[0].RestockAt == nil {
+		t.Errorf("shortage %+v, want task 2 needing 6 with 2 available until restock", short)
+	}
+
+	// Прогон считается целиком: второму заданию не хватает и с поставкой.
+	l = newStockLedger(supply)
+	release, short, ok = l.check([]storage.DeviceTaskRow{stockTask(2, 1, 8), stockTask(3, 1, 8)}, mar(3, 9))
+	if ok || len(short) != 1 || short[0].TaskID != 3 || short[0].Available != 2 || short[0].RestockAt != nil {
+		t.Errorf("got (%v, %+v, %v), want task 3 short without restock", release, short, ok)
+	}
+
+	// Без ожидаемой поставки нехватка снимает задание с плана.
+	l = newStockLedger(map[int64]MaterialSupply{1: {OnHand: 1}})
+	if _, short, ok := l.check([]storage.DeviceTaskRow{stockTask(2, 1, 3)}, mar(3, 9)); ok || len(short) != 1 || short[0].RestockAt != nil {
+		t.Errorf("got (%+v, %v), want shortage without restock", short, ok)
+	}
+}
@@ -0,0 +1,455 @@
+package service
+
+import (
+	"context"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Распределения коэффициента «факт / план» при анализе рисков плана.
+const (
+	DistributionLearned   = "learned"   // бутстрэп по истории выполнения, без истории — lognormal
+	DistributionLognormal = "lognormal" // логнормальный коэффициент с медианой 1 и заданным разбросом
+)
+
+const (
+	defaultRiskRuns   = 500
+	maxRiskRuns       = 10000
+	defaultRiskSpread = 0.2
+	// bottleneckShare — ресурс считается узким местом, если ожидание из-за
+	// него не меньше этой доли от максимального по ресурсам того же вида.
+	bottleneckShare = 0.5
+)
+
+type RiskRequest struct {
+	Runs         int     `json:"runs"`
+	Distribution string  `json:"distribution"`
+	Spread       float64 `json:"spread"` // коэффициент вариации для lognormal
+	Seed         int64   `json:"seed"`   // 0 — случайный
+}
+
+type TaskRisk struct {
+	TaskID              int64      `json:"task_id"`
+	Name                string     `json:"name"`
+	DeviceID            int64      `json:"device_id"`
+	OperatorID          int64      `json:"operator_id"`
+	PlanStart           time.Time  `json:"plan_start"`
+	PlanEnd             time.Time  `json:"plan_end"`
+	Deadline            *time.Time `json:"deadline"`
+	ExpectedEnd         time.Time  `json:"expected_end"`
+	OnTimeProbability   float64    `json:"on_time_probability"` // 1, если дедлайна нет
+	ExpectedLatenessMin float64    `json:"expected_lateness_min"`
+	UnplaceableRuns     int        `json:"unplaceable_runs"` // прогоны, где слот не нашёлся за горизонт
+}
+
+type ResourceRisk struct {
+	ID                int64   `json:"id"`
+	Tasks             int     `json:"tasks"`
+	ExpectedWaitMin   float64 `json:"expected_wait_min"` // суммарное ожидание заданий из-за ресурса за прогон
+	ExpectedLateTasks float64 `json:"expected_late_tasks"`
+	Bottleneck        bool    `json:"bottleneck"`
+}
+
+type RiskResult struct {
+	Runs                 int            `json:"runs"`
+	Distribution         string         `json:"distribution"`
+	Spread               float64        `json:"spread"`
+	Seed                 int64          `json:"seed"`
+	AllOnTimeProbability float64        `json:"all_on_time_probability"`
+	ExpectedLateTasks    float64        `json:"expected_late_tasks"`
+	Tasks                []TaskRisk     `json:"tasks"`
+	Devices              []ResourceRisk `json:"devices"`
+	Operators            []ResourceRisk `json:"operators"`
+}
+
+type taskRiskAcc struct {
+	onTime     int
+	lateness   time.Duration
+	endOffset  time.Duration
+	placed     int
+	unplaced   int
+	deviceWait time.Duration
+	opWait     time.Duration
+}
+
+// AnalyzeRisk прогоняет текущий план Runs раз со случайными длительностями.
+// Задания идут в порядке планового старта, каждое ставится на своё
+// оборудование и к своему оператору в ближайший слот не раньше планового
+// старта по тем же правилам, что и в Recompute: с переналадкой, правилами
+// рабочего времени, компетенцией оператора и стоимостью энергии. Задания
+// одного прогона печатаются вместе, с одной случайной длительностью.
+// Выполняемое задание идёт с фактического начала и заканчивается не раньше
+// текущего момента: случайна только оставшаяся часть.
+func (p *Planner) AnalyzeRisk(ctx context.Context, workspaceID int64, req RiskRequest) (RiskResult, error) {
+	if req.Runs <= 0 {
+		req.Runs = defaultRiskRuns
+	}
+	if req.Runs > maxRiskRuns {
+		req.Runs = maxRiskRuns
+	}
+	if req.Distribution == "" {
+		req.Distribution = DistributionLearned
+	}
+	if req.Spread <= 0 {
+		req.Spread = defaultRiskSpread
+	}
+	if req.Seed == 0 {
+		req.Seed = time.Now().UnixNano()
+	}
+
+	all, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID, storage.TaskStatusPending, storage.TaskStatusInProgress)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	busy, err := p.repos.ListOperatorBusy(ctx, workspaceID)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	now := time.Now()
+	downtime, err := p.repos.ListDeviceDowntime(ctx, workspaceID, now)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	changeoverItems, err := p.repos.ListMaterialChangeovers(ctx, workspaceID)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	changeovers := NewChangeovers(changeoverItems)
+	cooldowns, err := p.cooldowns(ctx, workspaceID, devices)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	competencies, err := p.repos.ListOperatorCompetencies(ctx, workspaceID)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	labour, err := p.repos.GetLabourRules(ctx, workspaceID)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	energy, err := p.energy(ctx, workspaceID, devices)
+	if err != nil {
+		return RiskResult{}, err
+	}
+	var model DurationModel
+	if req.Distribution == DistributionLearned {
+		if model, err = p.durations.Model(ctx, workspaceID); err != nil {
+			return RiskResult{}, err
+		}
+	}
+	return analyzeRisk(riskInput{
+		now:          now,
+		tasks:        all,
+		operatorBusy: busy,
+		downtime:     downtime,
+		devices:      devices,
+		changeovers:  changeovers,
+		cooldowns:    cooldowns,
+		competencies: NewCompetencies(competencies),
+		labour:       labour,
+		energy:       energy,
+		model:        model,
+	}, req), nil
+}
+
+type riskInput struct {
+	now          time.Time
+	tasks        []storage.DeviceTaskRow // ожидающие и выполняемые задания
+	operatorBusy []storage.UserTaskBusy
+	downtime     []storage.DeviceDowntime
+	devices      []storage.Device
+	changeovers  Changeovers
+	cooldowns    *Cooldowns
+	competencies Competencies
+	labour       storage.LabourRules
+	energy       *Energy
+	model        DurationModel // история длительностей для learned
+}
+
+// analyzeRisk прогоняет план in.tasks req.Runs раз; параметры req уже
+// приведены к значениям по умолчанию.
+func analyzeRisk(in riskInput, req RiskRequest) RiskResult {
+	deviceType := make(map[int64]int64, len(in.devices))
+	for _, d := range in.devices {
+		deviceType[d.ID] = d.DeviceTypeID
+	}
+
+	var tasks []storage.DeviceTaskRow
+	for _, t := range in.tasks {
+		if t.PlanStart != nil && t.PlanEnd != nil && t.DeviceID > 0 {
+			tasks = append(tasks, t)
+		}
+	}
+	sort.Slice(tasks, func(i, j int) bool {
+		a, b := riskStart(tasks[i]), riskStart(tasks[j])
+		if !a.Equal(b) {
+			return a.Before(b)
+		}
+		return tasks[i].ID < tasks[j].ID
+	})
+
+	// Прогон занимает оборудование один раз, на время самого долгого задания,
+	// и остывает после него по самому долгому остыванию.
+	batchNominal := map[int64]time.Duration{}
+	batchCooldown := map[int64]time.Duration{}
+	for _, t := range tasks {
+		if t.BatchID > 0 {
+			batchNominal[t.BatchID] = max(batchNominal[t.BatchID], t.SetupTime+t.Duration+t.UnloadTime)
+			batchCooldown[t.BatchID] = max(batchCooldown[t.BatchID], in.cooldowns.After(t.DeviceID, t))
+		}
+	}
+
+	fixedOperatorBusy := map[int64][]interval{}
+	for _, b := range in.operatorBusy {
+		fixedOperatorBusy[b.OperatorID] = append(fixedOperatorBusy[b.OperatorID], interval{start: b.Start, end: b.End})
+	}
+	fixedDeviceBusy := map[int64][]interval{}
+	for _, d := range in.downtime {
+		fixedDeviceBusy[d.DeviceID] = append(fixedDeviceBusy[d.DeviceID], interval{start: d.Start, end: d.End})
+	}
+	// План прогоняется с самого начала — заправленный материал действует до
+	// первого задания на оборудовании.
+	for _, d := range in.devices {
+		if d.LoadedMaterialID > 0 {
+			fixedDeviceBusy[d.ID] = append(fixedDeviceBusy[d.ID], loadedMarker(d.LoadedMaterialID, time.Time{}))
+		}
+	}
+
+	sigma := math.Sqrt(math.Log(1 + req.Spread*req.Spread))
+	rng := rand.New(rand.NewPCG(uint64(req.Seed), uint64(req.Seed>>1)))
+	sampleFactor := func(t storage.DeviceTaskRow) float64 {
+		if req.Distribution == DistributionLearned {
+			if factors := in.model.Factors(deviceType[t.DeviceID], t.DeviceTaskTypeID); len(factors) > 0 {
+				return factors[rng.IntN(len(factors))]
+			}
+		}
+		return math.Exp(rng.NormFloat64() * sigma)
+	}
+
+	acc := make([]taskRiskAcc, len(tasks))
+	allOnTime := 0
+	for run := 0; run < req.Runs; run++ {
+		deviceBusy := make(map[int64][]interval, len(fixedDeviceBusy))
+		for id, ivs := range fixedDeviceBusy {
+			deviceBusy[id] = append([]interval(nil), ivs...)
+		}
+		operatorBusy := make(map[int64][]interval, len(fixedOperatorBusy))
+		for id, ivs := range fixedOperatorBusy {
+			operatorBusy[id] = append([]interval(nil), ivs...)
+		}
+
+		labour := newLabourLedger(in.labour, in.operatorBusy)
+		runOnTime := true
+		jobEnds := map[int64]map[int]time.Time{}
+		batchSlots := map[int64]interval{}
+		for i, t := range tasks {
+			a := &acc[i]
+			// Операция маршрута ждёт предыдущую и пролёживание после неё.
+			from := *t.PlanStart
+			if prev, ok := jobEnds[t.JobID][t.JobSeq-1]; t.JobID > 0 && ok {
+				if ready := prev.Add(t.TransferLag); ready.After(from) {
+					from = ready
+				}
+			}
+
+			var start, end time.Time
+			if iv, ok := batchSlots[t.BatchID]; t.BatchID > 0 && ok {
+				// Задание печатается в уже поставленном прогоне.
+				start, end = iv.start, iv.end
+			} else {
+				nominal := t.SetupTime + t.Duration + t.UnloadTime
+				cooldown := in.cooldowns.After(t.DeviceID, t)
+				if t.BatchID > 0 {
+					nominal, cooldown = batchNominal[t.BatchID], batchCooldown[t.BatchID]
+				}
+				nominal = in.competencies.scale(t, t.OperatorID, deviceType[t.DeviceID], nominal)
+				dur := time.Duration(float64(nominal) * sampleFactor(t)).Round(time.Minute)
+
+				if running(t) {
+					// Задание уже идёт: прошедшее время входит в выборку, а
+					// закончиться раньше текущего момента оно не может.
+					start, end = *t.ActualStart, t.ActualStart.Add(dur)
+					if end.Before(in.now) {
+						end = in.now
+					}
+				} else {
+					var opBusy []interval
+					if t.NeedOperator {
+						opBusy = operatorBusy[t.OperatorID]
+					}
+					var ok bool
+					start, end, ok = riskSlot(in, labour, t, from, dur, deviceBusy[t.DeviceID], opBusy, cooldown)
+					if !ok {
+						a.unplaced++
+						if t.Deadline != nil {
+							runOnTime = false
+						}
+						continue
+					}
+
+					// Ожидание относим к ресурсу, который сдвинул бы старт и в одиночку.
+					if base, _, ok := findNextAvailableSlot(from, dur, nil, nil, nil); ok {
+						if devStart, _, ok := findNextAvailableSlot(from, dur, deviceBusy[t.DeviceID], nil, nil); ok {
+							a.deviceWait += devStart.Sub(base)
+						}
+						if t.NeedOperator {
+							if opStart, _, ok := findNextAvailableSlot(from, dur, nil, opBusy, nil); ok {
+								a.opWait += opStart.Sub(base)
+							}
+						}
+					}
+				}
+
+				deviceBusy[t.DeviceID] = append(deviceBusy[t.DeviceID], interval{start: start, end: end.Add(cooldown), material: t.MaterialID})
+				if t.NeedOperator {
+					operatorBusy[t.OperatorID] = append(operatorBusy[t.OperatorID], interval{start: start, end: end})
+					if t.OperatorID > 0 {
+						labour.work[t.OperatorID] = append(labour.work[t.OperatorID], interval{start: start, end: end})
+					}
+				}
+				if t.BatchID > 0 {
+					batchSlots[t.BatchID] = interval{start: start, end: end}
+				}
+			}
+			if t.JobID > 0 {
+				setJobEnd(jobEnds, t.JobID, t.JobSeq, end)
+			}
+
+			a.placed++
+			a.endOffset += end.Sub(*t.PlanEnd)
+			if t.Deadline == nil || !end.After(*t.Deadline) {
+				a.onTime++
+				continue
+			}
+			runOnTime = false
+			a.lateness += end.Sub(*t.Deadline)
+		}
+		if runOnTime {
+			allOnTime++
+		}
+	}
+
+	runs := float64(req.Runs)
+	res := RiskResult{
+		Runs:                 req.Runs,
+		Distribution:         req.Distribution,
+		Spread:               req.Spread,
+		Seed:                 req.Seed,
+		AllOnTimeProbability: float64(allOnTime) / runs,
+		Tasks:                make([]TaskRisk, 0, len(tasks)),
+	}
+	devAgg := map[int64]*ResourceRisk{}
+	opAgg := map[int64]*ResourceRisk{}
+	for i, t := range tasks {
+		a := acc[i]
+		onTime := float64(a.onTime) / runs
+		if t.Deadline == nil {
+			onTime = float64(a.placed) / runs
+		}
+		tr := TaskRisk{
+			TaskID:              t.ID,
+			Name:                t.Name,
+			DeviceID:            t.DeviceID,
+			OperatorID:          t.OperatorID,
+			PlanStart:           *t.PlanStart,
+			PlanEnd:             *t.PlanEnd,
+			Deadline:            t.Deadline,
+			ExpectedEnd:         *t.PlanEnd,
+			OnTimeProbability:   onTime,
+			ExpectedLatenessMin: a.lateness.Minutes() / runs,
+			UnplaceableRuns:     a.unplaced,
+		}
+		if a.placed > 0 {
+			tr.ExpectedEnd = t.PlanEnd.Add(a.endOffset / time.Duration(a.placed)).Round(time.Minute)
+		}
+		res.Tasks = append(res.Tasks, tr)
+
+		lateTasks := 1 - onTime
+		res.ExpectedLateTasks += lateTasks
+		addResourceRisk(devAgg, t.DeviceID, a.deviceWait, lateTasks, runs)
+		if t.NeedOperator && t.OperatorID > 0 {
+			addResourceRisk(opAgg, t.OperatorID, a.opWait, lateTasks, runs)
+		}
+	}
+	res.Devices = flagBottlenecks(devAgg)
+	res.Operators = flagBottlenecks(opAgg)
+	return res
+}
+
+// riskSlot — слот задания в прогоне на его оборудовании и у его оператора,
+// который правила рабочего времени позволяют оператору. Как в bestSlot, слот
+// ищется и от окон тарифов, и из найденных берётся самый ранний по окончанию
+// с надбавкой за стоимость энергии.
+func riskSlot(in riskInput, labour *labourLedger, t storage.DeviceTaskRow, from time.Time, dur time.Duration, deviceBusy, opBusy []interval, cooldown time.Duration) (time.Time, time.Time, bool) {
+	var worker int64
+	if t.NeedOperator {
+		worker = t.OperatorID
+	}
+	overnight := runsOvernight(t) || in.energy.overnight(t)
+	var bestStart, bestEnd, bestScore time.Time
+	found := false
+	for _, at := range append([]time.Time{from}, in.energy.starts(t.DeviceID, from, dur)...) {
+		start, end, _, _, _, ok := labour.findLabourSlot(worker, at, dur, deviceBusy, opBusy, nil, t.MaterialID, in.changeovers, cooldown, overnight)
+		if !ok {
+			continue
+		}
+		score := end.Add(in.energy.penalty(t.DeviceID, start, end))
+		if !found || score.Before(bestScore) {
+			bestStart, bestEnd, bestScore, found = start, end, score, true
+		}
+	}
+	return bestStart, bestEnd, found
+}
+
+// running — задание выполняется и его фактическое начало известно.
+func running(t storage.DeviceTaskRow) bool {
+	return t.Status == storage.TaskStatusInProgress && t.ActualStart != nil
+}
+
+// riskStart — начало задания при прогоне плана: фактическое у выполняемого,
+// иначе плановое.
+func riskStart(t storage.DeviceTaskRow) time.Time {
+	if running(t) {
+		return *t.ActualStart
+	}
+	return *t.PlanStart
+}
+
+func addResourceRisk(agg map[int64]*ResourceRisk, id int64, wait time.Duration, lateTasks float64, runs float64) {
+	r, ok := agg[id]
+	if !ok {
+		r = &ResourceRisk{ID: id}
+		agg[id] = r
+	}
+	r.Tasks++
+	r.ExpectedWaitMin += wait.Minutes() / runs
+	r.ExpectedLateTasks += lateTasks
+}
+
+// flagBottlenecks сортирует ресурсы по ожиданию и помечает узкие места.
+func flagBottlenecks(agg map[int64]*ResourceRisk) []ResourceRisk {
+	res := make([]ResourceRisk, 0, len(agg))
+	var maxWait float64
+	for _, r := range agg {
+		res = append(res, *r)
+		maxWait = math.Max(maxWait, r.ExpectedWaitMin)
+	}
+	sort.Slice(res, func(i, j int) bool {
+		if res[i].ExpectedWaitMin != res[j].ExpectedWaitMin {
+			return res[i].ExpectedWaitMin > res[j].ExpectedWaitMin
+		}
+		return res[i].ID < res[j].ID
+	})
+	for i := range res {
+		res[i].Bottleneck = maxWait > 0 && res[i].ExpectedWaitMin >= maxWait*bottleneckShare
+	}
+	return res
+}
@@ -0,0 +1,150 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// mar — час дня в марте 2025 года; 3 марта — понедельник.
+func mar(day, hour int) time.Time {
+	return time.Date(2025, 3, day, hour, 0, 0, 0, time.UTC)
+}
+
+// slotAt — указатель на момент для полей плана.
+func slotAt(at time.Time) *time.Time {
+	return &at
+}
+
+// riskTasks — два задания на оборудовании 1 с 9 до 11 и с 10 до 12: второе
+// в плане наложено на первое и в прогоне ждёт его.
+func riskTasks(firstDeadline, secondDeadline time.Time) []storage.DeviceTaskRow {
+	return []storage.DeviceTaskRow{
+		{ID: 1, DeviceID: 1, Duration: 2 * time.Hour, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 11)), Deadline: &firstDeadline},
+		{ID: 2, DeviceID: 1, Duration: 2 * time.Hour, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 10)), PlanEnd: slotAt(mar(3, 12)), Deadline: &secondDeadline},
+	}
+}
+
+// Почти без разброса длительности равны плановым: второе задание стабильно
+// опаздывает на час, а ожидание относится к оборудованию.
+func TestAnalyzeRiskLateTask(t *testing.T) {
+	res := analyzeRisk(riskInput{now: mar(3, 8), tasks: riskTasks(mar(3, 11), mar(3, 12))},
+		RiskRequest{Runs: 20, Distribution: DistributionLognormal, Spread: 1e-9, Seed: 1})
+
+	if len(res.Tasks) != 2 {
+		t.Fatalf("tasks %+v", res.Tasks)
+	}
+	first, second := res.Tasks[0], res.Tasks[1]
+	if first.OnTimeProbability != 1 || first.ExpectedLatenessMin != 0 {
+		t.Errorf("first task %+v, want on time", first)
+	}
+	if second.OnTimeProbability != 0 || second.ExpectedLatenessMin != 60 || !second.ExpectedEnd.Equal(mar(3, 13)) {
+		t.Errorf("second task %+v, want late by an hour", second)
+	}
+	if res.AllOnTimeProbability != 0 || res.ExpectedLateTasks != 1 {
+		t.Errorf("all on time %v, late tasks %v", res.AllOnTimeProbability, res.ExpectedLateTasks)
+	}
+	if len(res.Devices) != 1 || res.Devices[0].ExpectedWaitMin != 60 || !res.Devices[0].Bottleneck {
+		t.Errorf("devices %+v, want device 1 bottleneck with 60 min wait", res.Devices)
+	}
+}
+
+// learned берёт коэффициенты из истории: задания вдвое длиннее плана.
+func TestAnalyzeRiskLearned(t *testing.T) {
+	var samples []storage.CompletedTaskDuration
+	for i := range 3 {
+		samples = append(samples, storage.CompletedTaskDuration{TaskID: int64(i), Nominal: time.Hour, Actual: 2 * time.Hour})
+	}
+	tasks := riskTasks(mar(3, 13), mar(3, 17))[:1]
+	res := analyzeRisk(riskInput{now: mar(3, 8), tasks: tasks, model: BuildDurationModel(samples)},
+		RiskRequest{Runs: 10, Distribution: DistributionLearned, Seed: 1})
+
+	if got := res.Tasks[0]; !got.ExpectedEnd.Equal(mar(3, 13)) || got.OnTimeProbability != 1 {
+		t.Errorf("task %+v, want end at 13:00 on time", got)
+	}
+}
+
+// Выполняемое задание считается с фактического начала и не заканчивается
+// раньше текущего момента.
+func TestAnalyzeRiskRunningTask(t *testing.T) {
+	task := riskTasks(mar(3, 13), mar(3, 13))[0]
+	task.Status, task.ActualStart = storage.TaskStatusInProgress, slotAt(mar(3, 8))
+	res := analyzeRisk(riskInput{now: mar(3, 12), tasks: []storage.DeviceTaskRow{task}},
+		RiskRequest{Runs: 10, Distribution: DistributionLognormal, Spread: 1e-9, Seed: 1})
+
+	if got := res.Tasks[0]; !got.ExpectedEnd.Equal(mar(3, 12)) || got.OnTimeProbability != 1 {
+		t.Errorf("task %+v, want end at now", got)
+	}
+}
+
+// riskEnd — ожидаемое окончание задания id в прогоне почти без разброса.
+func riskEnd(t *testing.T, in riskInput, id int64) time.Time {
+	t.Helper()
+	res := analyzeRisk(in, RiskRequest{Runs: 5, Distribution: DistributionLognormal, Spread: 1e-9, Seed: 1})
+	for _, r := range res.Tasks {
+		if r.TaskID == id {
+			if r.UnplaceableRuns > 0 {
+				t.Fatalf("task %d unplaceable in %d runs", id, r.UnplaceableRuns)
+			}
+			return r.ExpectedEnd
+		}
+	}
+	t.Fatalf("task %d not in the result", id)
+	return time.Time{}
+}
+
+// Прогон соблюдает правила рабочего времени: второе задание оператора не
+// помещается в дневной предел и переносится на следующий день.
+func TestAnalyzeRiskLabourRules(t *testing.T) {
+	tasks := []storage.DeviceTaskRow{
+		{ID: 1, DeviceID: 1, OperatorID: testOperator, NeedOperator: true, Duration: 3 * time.Hour, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 12))},
+		{ID: 2, DeviceID: 2, OperatorID: testOperator, NeedOperator: true, Duration: 2 * time.Hour, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 12)), PlanEnd: slotAt(mar(3, 14))},
+	}
+	in := riskInput{now: mar(3, 8), tasks: tasks}
+	if got := riskEnd(t, in, 2); !got.Equal(mar(3, 14)) {
+		t.Errorf("without rules: end %v, want 14:00", got)
+	}
+	in.labour = storage.LabourRules{MaxDay: 4 * time.Hour}
+	if got := riskEnd(t, in, 2); !got.Equal(mar(4, 11)) {
+		t.Errorf("with a 4h day: end %v, want next day 11:00", got)
+	}
+}
+
+// Наладка и снятие в прогоне длятся столько, сколько у оператора задания.
+func TestAnalyzeRiskCompetencyScale(t *testing.T) {
+	slow := 2.0
+	in := riskInput{
+		now:     mar(3, 8),
+		devices: []storage.Device{{ID: 1, DeviceTypeID: 1}},
+		tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, OperatorID: 5, NeedOperator: true, SetupTime: time.Hour, Duration: time.Hour, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 11))},
+		},
+		competencies: NewCompetencies([]storage.OperatorCompetency{
+			{OperatorID: 5, DeviceTypeID: 1, Level: storage.CompetencyTrainee, SetupFactor: &slow},
+		}),
+	}
+	if got := riskEnd(t, in, 1); !got.Equal(mar(3, 12)) {
+		t.Errorf("end %v, want 12:00 with a doubled setup", got)
+	}
+}
+
+// С весом стоимости энергии задание без оператора в прогоне, как и в
+// планировщике, уходит в ночное окно, если там дешевле.
+func TestAnalyzeRiskEnergy(t *testing.T) {
+	in := riskInput{
+		now: mar(3, 8),
+		tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, Duration: 12 * time.Hour, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 21))},
+		},
+		energy: testEnergy(0),
+	}
+	if got := riskEnd(t, in, 1); !got.Equal(mar(3, 21)) {
+		t.Errorf("without weight: end %v, want 21:00", got)
+	}
+	// С 18:00 до 06:00: восемь часов из двенадцати по ночному тарифу.
+	in.energy = testEnergy(100)
+	if got := riskEnd(t, in, 1); !got.Equal(mar(4, 6)) {
+		t.Errorf("with weight: end %v, want 06:00 after a night run", got)
+	}
+}
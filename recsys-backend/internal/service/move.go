@@ -0,0 +1,404 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+
+	"recsys-backend/internal/storage"
+)
+
+var (
+	ErrInvalidMove    = errors.New("invalid move")
+	ErrTaskNotMovable = errors.New("only pending tasks can be moved")
+)
+
+type MoveRequest struct {
+	TaskID     int64     `json:"-"`
+	Start      time.Time `json:"start"`
+	DeviceID   int64     `json:"device_id,omitempty"`   // новое оборудование, 0 — прежнее
+	OperatorID int64     `json:"operator_id,omitempty"` // новый оператор, 0 — прежний
+	Push       bool      `json:"push,omitempty"`        // сдвинуть мешающие задания вправо вместо отказа
+	DryRun     bool      `json:"dry_run,omitempty"`     // только проверить перенос, ничего не сохранять
+}
+
+type MoveResult struct {
+	DryRun bool `json:"dry_run"`
+	// Applied — перенос сохранён; false — отклонён из-за конфликтов или dry_run.
+	Applied        bool           `json:"applied"`
+	Moved          []TaskMove     `json:"moved"`  // задание и остальные задания его прогона
+	Pushed         []TaskMove     `json:"pushed"` // задания, сдвинутые вправо при push
+	UnscheduledIDs []int64        `json:"unscheduled_ids"`
+	Conflicts      []PlanConflict `json:"conflicts"`
+	// OperatorTasks — поручения операторам на наладку и снятие, приведённые к
+	// новому плану; пусто, если перенос не сохранён.
+	OperatorTasks OperatorTasksSync `json:"operator_tasks"`
+}
+
+// Move переносит ожидающее задание (вместе с его прогоном) на новый старт и,
+// при необходимости, на другое оборудование или к другому оператору.
+// Новый слот проверяется на занятость оборудования и оператора, простои,
+// рабочие часы, правила рабочего времени оператора, жёсткий дедлайн и порядок
+// операций маршрута. При конфликтах перенос отклоняется; с push мешающие
+// ожидающие задания сдвигаются вправо локальной починкой плана. Перенесённое
+// задание закрепляется: пересчёт плана и починка после сбоев его не двигают.
+// Проверка и сохранение идут в одной транзакции под блокировкой заданий
+// workspace, поэтому параллельный перенос не займёт тот же слот.
+func (p *Planner) Move(ctx context.Context, req MoveRequest) (MoveResult, error) {
+	now := time.Now()
+	if req.Start.IsZero() {
+		return MoveResult{}, fmt.Errorf("%w: start is required", ErrInvalidMove)
+	}
+	if req.Start.Before(now) {
+		return MoveResult{}, fmt.Errorf("%w: start must not be in the past", ErrInvalidMove)
+	}
+	task, err := p.repos.GetDeviceTask(ctx, req.TaskID)
+	if err != nil {
+		return MoveResult{}, err
+	}
+	var res MoveResult
+	err = p.inTx(ctx, func(tp *Planner) error {
+		var err error
+		res, err = tp.move(ctx, task.WorkspaceID, req, now)
+		return err
+	})
+	if err != nil {
+		return MoveResult{}, err
+	}
+	return res, nil
+}
+
+// move проверяет и сохраняет перенос внутри транзакции Move.
+func (p *Planner) move(ctx context.Context, workspaceID int64, req MoveRequest, now time.Time) (MoveResult, error) {
+	tasks, err := p.repos.LockDeviceTasksForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return MoveResult{}, err
+	}
+	var t storage.DeviceTaskRow
+	for _, row := range tasks {
+		if row.ID == req.TaskID {
+			t = row
+		}
+	}
+	if t.ID == 0 {
+		// Задание удалено или перешло в другой workspace до блокировки.
+		return MoveResult{}, pgx.ErrNoRows
+	}
+	if t.Status != storage.TaskStatusPending {
+		return MoveResult{}, fmt.Errorf("%w: task is %s", ErrTaskNotMovable, t.Status)
+	}
+	// Прогон переносится целиком: задания на одной платформе печатаются вместе.
+	group := []storage.DeviceTaskRow{t}
+	if t.BatchID > 0 {
+		for _, row := range tasks {
+			if row.BatchID == t.BatchID && row.ID != t.ID && row.Status == storage.TaskStatusPending {
+				group = append(group, row)
+			}
+		}
+		sort.Slice(group, func(i, j int) bool { return group[i].ID < group[j].ID })
+	}
+
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return MoveResult{}, err
+	}
+	deviceType := make(map[int64]int64, len(devices))
+	for _, d := range devices {
+		deviceType[d.ID] = d.DeviceTypeID
+	}
+	deviceID := t.DeviceID
+	if req.DeviceID > 0 {
+		deviceID = req.DeviceID
+	}
+	if _, ok := deviceType[deviceID]; !ok {
+		return MoveResult{}, fmt.Errorf("%w: device %d is not in the workspace", ErrInvalidMove, deviceID)
+	}
+	if deviceID != t.DeviceID {
+		if err := p.checkMoveDevice(ctx, workspaceID, group, deviceID, deviceType, devices); err != nil {
+			return MoveResult{}, err
+		}
+	}
+
+	competencies, err := p.repos.ListOperatorCompetencies(ctx, workspaceID)
+	if err != nil {
+		return MoveResult{}, err
+	}
+	comps := NewCompetencies(competencies)
+	operatorID := t.OperatorID
+	if req.OperatorID > 0 {
+		if !t.NeedOperator {
+			return MoveResult{}, fmt.Errorf("%w: task does not need an operator", ErrInvalidMove)
+		}
+		operators, err := p.repos.ListOperators(ctx, workspaceID)
+		if err != nil {
+			return MoveResult{}, err
+		}
+		found := false
+		for _, o := range operators {
+			found = found || o.ID == req.OperatorID
+		}
+		if !found {
+			return MoveResult{}, fmt.Errorf("%w: operator %d is not in the workspace", ErrInvalidMove, req.OperatorID)
+		}
+		operatorID = req.OperatorID
+	}
+	if t.NeedOperator {
+		if operatorID <= 0 {
+			return MoveResult{}, fmt.Errorf("%w: task has no operator", ErrInvalidMove)
+		}
+		for _, g := range group {
+			if !comps.qualified(operatorID, deviceType[deviceID], g.MinLevel) {
+				return MoveResult{}, fmt.Errorf("%w: operator %d is not qualified for task %d", ErrInvalidMove, operatorID, g.ID)
+			}
+		}
+	}
+
+	changeoverItems, err := p.repos.ListMaterialChangeovers(ctx, workspaceID)
+	if err != nil {
+		return MoveResult{}, err
+	}
+	changeovers := NewChangeovers(changeoverItems)
+	b, err := p.bookings(ctx, workspaceID, tasks, now)
+	if err != nil {
+		return MoveResult{}, err
+	}
+
+	// Задание в плане сохраняет длину слота без переналадки, если не меняются
+	// оператор и тип оборудования; иначе длительность считается заново: от них
+	// зависит множитель наладки. Переналадка считается от материала на
+	// оборудовании к новому старту.
+	var dur time.Duration
+	if t.PlanStart != nil && t.PlanEnd != nil && operatorID == t.OperatorID && deviceType[deviceID] == deviceType[t.DeviceID] {
+		lead := t.ID
+		if t.BatchID > 0 {
+			lead = t.BatchID
+		}
+		dur = max(t.PlanEnd.Sub(*t.PlanStart)-planChangeovers(devices, tasks, changeovers)[lead], 0)
+	} else {
+		for _, g := range group {
+			dur = max(dur, comps.scale(g, operatorID, deviceType[deviceID], g.SetupTime+g.Duration+g.UnloadTime))
+		}
+	}
+	change := changeovers.Between(b.materialAt(devices, group, deviceID, req.Start, now), t.MaterialID)
+	start, end := req.Start, req.Start.Add(change+dur)
+
+	res := MoveResult{DryRun: req.DryRun, Pushed: []TaskMove{}, UnscheduledIDs: []int64{}, Conflicts: []PlanConflict{}}
+	for _, g := range group {
+		m := TaskMove{
+			TaskID:         g.ID,
+			Name:           g.Name,
+			DeviceID:       deviceID,
+			OperatorID:     g.OperatorID,
+			OldStart:       g.PlanStart,
+			OldEnd:         g.PlanEnd,
+			NewStart:       start,
+			NewEnd:         end,
+			DeadlineMissed: g.Deadline != nil && end.After(*g.Deadline),
+		}
+		if g.NeedOperator {
+			m.OperatorID = operatorID
+		}
+		if g.PlanStart != nil {
+			m.ShiftMin = int(start.Sub(*g.PlanStart).Minutes())
+		}
+		res.Moved = append(res.Moved, m)
+	}
+
+	conflicts := b.slotConflicts(group, deviceID, operatorID, start, end)
+	if !req.Push {
+		res.Conflicts = append(res.Conflicts, conflicts...)
+	} else {
+		byID := make(map[int64]storage.DeviceTaskRow, len(tasks))
+		for _, row := range tasks {
+			byID[row.ID] = row
+		}
+		for _, c := range conflicts {
+			if !pushable(c, byID, t) {
+				res.Conflicts = append(res.Conflicts, c)
+			}
+		}
+		if len(res.Conflicts) == 0 && len(conflicts) > 0 {
+			busy, err := p.repos.ListOperatorBusy(ctx, workspaceID)
+			if err != nil {
+				return MoveResult{}, err
+			}
+			pools, err := p.repos.ListDevicePools(ctx, workspaceID)
+			if err != nil {
+				return MoveResult{}, err
+			}
+			caps, err := p.capabilities(ctx, workspaceID, devices)
+			if err != nil {
+				return MoveResult{}, err
+			}
+			pushed, unscheduled := repairPlan(repairInput{
+				now:          now,
+				tasks:        movedPlan(tasks, group, deviceID, operatorID, start, end),
+				operatorBusy: busy,
+				downtime:     b.downtime,
+				devices:      devices,
+				changeovers:  changeovers,
+				competencies: comps,
+				cooldowns:    b.cooldowns,
+				labour:       b.labour,
+				pools:        PoolMembers(pools),
+				capabilities: caps,
+				redo:         map[int64]bool{},
+				affected:     map[int64]bool{},
+			})
+			res.Pushed = append(res.Pushed, pushed...)
+			res.UnscheduledIDs = append(res.UnscheduledIDs, unscheduled...)
+		}
+	}
+	if len(res.Conflicts) > 0 || req.DryRun {
+		return res, nil
+	}
+
+	for _, g := range group {
+		if deviceID != g.DeviceID {
+			if err := p.repos.AssignDeviceTaskDevice(ctx, g.ID, deviceID); err != nil {
+				return MoveResult{}, err
+			}
+		}
+		if g.NeedOperator && operatorID != g.OperatorID {
+			if err := p.repos.AssignDeviceTaskOperator(ctx, g.ID, operatorID); err != nil {
+				return MoveResult{}, err
+			}
+		}
+		if err := p.repos.UpdateDeviceTaskPlan(ctx, g.ID, start, end); err != nil {
+			return MoveResult{}, err
+		}
+		if err := p.repos.SetDeviceTaskPinned(ctx, g.ID, true); err != nil {
+			return MoveResult{}, err
+		}
+	}
+	for _, m := range res.Pushed {
+		if m.OldDeviceID > 0 {
+			if err := p.repos.AssignDeviceTaskDevice(ctx, m.TaskID, m.DeviceID); err != nil {
+				return MoveResult{}, err
+			}
+		}
+		if err := p.repos.UpdateDeviceTaskPlan(ctx, m.TaskID, m.NewStart, m.NewEnd); err != nil {
+			return MoveResult{}, err
+		}
+	}
+	for _, id := range res.UnscheduledIDs {
+		if err := p.repos.ClearDeviceTaskPlan(ctx, id); err != nil {
+			return MoveResult{}, err
+		}
+	}
+	changeover := make(map[int64]time.Duration, len(group))
+	for _, g := range group {
+		changeover[g.ID] = change
+	}
+	if res.OperatorTasks, err = p.syncOperatorTasks(ctx, workspaceID, changeover); err != nil {
+		return MoveResult{}, err
+	}
+	res.Applied = true
+	return res, nil
+}
+
+// checkMoveDevice проверяет, что задания group можно перенести на
+// оборудование deviceID: оно из пула задания, а без пула — того же типа, что
+// целевой тип или прежнее оборудование, и удовлетворяет требованиям заданий.
+func (p *Planner) checkMoveDevice(ctx context.Context, workspaceID int64, group []storage.DeviceTaskRow, deviceID int64, deviceType map[int64]int64, devices []storage.Device) error {
+	t := group[0]
+	switch {
+	case t.DevicePoolID > 0:
+		pools, err := p.repos.ListDevicePools(ctx, workspaceID)
+		if err != nil {
+			return err
+		}
+		member := false
+		for _, id := range PoolMembers(pools)[t.DevicePoolID] {
+			member = member || id == deviceID
+		}
+		if !member {
+			return fmt.Errorf("%w: device %d is not in the task pool", ErrInvalidMove, deviceID)
+		}
+	case t.TargetTypeID > 0:
+		if deviceType[deviceID] != t.TargetTypeID {
+			return fmt.Errorf("%w: device %d is not of the task target type", ErrInvalidMove, deviceID)
+		}
+	case t.DeviceID > 0:
+		if deviceType[deviceID] != deviceType[t.DeviceID] {
+			return fmt.Errorf("%w: device %d is not of the same type as the task device", ErrInvalidMove, deviceID)
+		}
+	}
+	caps, err := p.capabilities(ctx, workspaceID, devices)
+	if err != nil {
+		return err
+	}
+	for _, g := range group {
+		if !caps.Satisfies(g.ID, deviceID) {
+			return fmt.Errorf("%w: device %d does not meet requirements of task %d", ErrInvalidMove, deviceID, g.ID)
+		}
+	}
+	return nil
+}
+
+// materialAt — материал на оборудовании deviceID к моменту at без заданий
+// group и их прогона: материал последнего задания по плану, закончившегося к
+// at, а без него — заправленный сейчас.
+func (b bookings) materialAt(devices []storage.Device, group []storage.DeviceTaskRow, deviceID int64, at, now time.Time) int64 {
+	var busy []interval
+	for _, d := range devices {
+		if d.ID == deviceID {
+			busy = append(busy, loadedMarker(d.LoadedMaterialID, now))
+		}
+	}
+	lead := group[0]
+	own := make(map[int64]bool, len(group))
+	for _, g := range group {
+		own[g.ID] = true
+	}
+	for _, o := range b.tasks {
+		if o.DeviceID != deviceID || own[o.ID] || (lead.BatchID > 0 && o.BatchID == lead.BatchID) {
+			continue
+		}
+		busy = append(busy, interval{start: *o.PlanStart, end: *o.PlanEnd, material: o.MaterialID})
+	}
+	return loadedMaterial(busy, at)
+}
+
+// movedPlan — план tasks, в котором задания group стоят в слоте [start, end)
+// на оборудовании deviceID с оператором operatorID и закреплены: остальные
+// задания их обходят.
+func movedPlan(tasks, group []storage.DeviceTaskRow, deviceID, operatorID int64, start, end time.Time) []storage.DeviceTaskRow {
+	moved := make(map[int64]bool, len(group))
+	for _, g := range group {
+		moved[g.ID] = true
+	}
+	res := make([]storage.DeviceTaskRow, len(tasks))
+	for i, row := range tasks {
+		if moved[row.ID] {
+			row.DeviceID = deviceID
+			if row.NeedOperator {
+				row.OperatorID = operatorID
+			}
+			row.PlanStart, row.PlanEnd = &start, &end
+			row.Pinned = true
+		}
+		res[i] = row
+	}
+	return res
+}
+
+// pushable — конфликт снимается сдвигом вправо: мешает ожидающее незакреплённое
+// задание, а по маршруту — только следующая операция задания t.
+func pushable(c PlanConflict, tasks map[int64]storage.DeviceTaskRow, t storage.DeviceTaskRow) bool {
+	o, ok := tasks[c.OtherTaskID]
+	if !ok || o.Status != storage.TaskStatusPending || o.Pinned {
+		return false
+	}
+	switch c.Kind {
+	case ConflictDeviceBusy, ConflictOperatorBusy:
+		return true
+	case ConflictRouteOrder:
+		return o.JobSeq > t.JobSeq
+	}
+	return false
+}
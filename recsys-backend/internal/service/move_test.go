@@ -0,0 +1,95 @@
+package service
+
+import (
+	"testing"
+
+	"recsys-backend/internal/storage"
+)
+
+// Конфликт снимается сдвигом, только если мешает ожидающее незакреплённое
+// задание, а по маршруту — следующая операция.
+func TestPushable(t *testing.T) {
+	task := storage.DeviceTaskRow{ID: 1, JobID: 30, JobSeq: 2}
+	others := map[int64]storage.DeviceTaskRow{
+		2: {ID: 2, Status: storage.TaskStatusPending},
+		3: {ID: 3, Status: storage.TaskStatusPending, Pinned: true},
+		4: {ID: 4, Status: storage.TaskStatusInProgress},
+		5: {ID: 5, Status: storage.TaskStatusPending, JobID: 30, JobSeq: 1},
+		6: {ID: 6, Status: storage.TaskStatusPending, JobID: 30, JobSeq: 3},
+	}
+	cases := []struct {
+		name string
+		c    PlanConflict
+		want bool
+	}{
+		{"pending task", PlanConflict{Kind: ConflictDeviceBusy, OtherTaskID: 2}, true},
+		{"operator busy", PlanConflict{Kind: ConflictOperatorBusy, OtherTaskID: 2}, true},
+		{"pinned task", PlanConflict{Kind: ConflictDeviceBusy, OtherTaskID: 3}, false},
+		{"running task", PlanConflict{Kind: ConflictDeviceBusy, OtherTaskID: 4}, false},
+		{"previous operation", PlanConflict{Kind: ConflictRouteOrder, OtherTaskID: 5}, false},
+		{"next operation", PlanConflict{Kind: ConflictRouteOrder, OtherTaskID: 6}, true},
+		{"personal task", PlanConflict{Kind: ConflictOperatorBusy, UserTaskID: 40}, false},
+		{"downtime", PlanConflict{Kind: ConflictDowntime, DowntimeID: 50}, false},
+	}
+	for _, c := range cases {
+		if got := pushable(c.c, others, task); got != c.want {
+			t.Errorf("%s: pushable = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+// Перенос на занятое место без push отклоняется: слот конфликтует с
+// заданием, которое там стоит.
+func TestMoveRejectsConflicts(t *testing.T) {
+	tasks := []storage.DeviceTaskRow{
+		plannedTask(1, 1, mar(3, 9), mar(3, 11)),
+		plannedTask(2, 1, mar(3, 12), mar(3, 14)),
+	}
+	b := bookings{tasks: tasks}
+	got := b.slotConflicts(tasks[:1], 1, testOperator, mar(3, 13), mar(3, 15))
+	kinds := map[string]bool{}
+	for _, c := range got {
+		if c.OtherTaskID != 2 {
+			t.Errorf("conflict %+v, want with task 2", c)
+		}
+		kinds[c.Kind] = true
+	}
+	if !kinds[ConflictDeviceBusy] || !kinds[ConflictOperatorBusy] {
+		t.Errorf("got %+v, want device and operator conflicts with task 2", got)
+	}
+}
+
+// С push перенесённое задание закрепляется на новом месте, мешающее
+// сдвигается вправо, а закреплённое остаётся на месте и сдвинутое его обходит.
+func TestMovePushesAroundPinned(t *testing.T) {
+	tasks := []storage.DeviceTaskRow{
+		plannedTask(1, 1, mar(3, 9), mar(3, 11)),
+		plannedTask(2, 1, mar(3, 12), mar(3, 14)),
+		plannedTask(3, 1, mar(3, 14), mar(3, 16)),
+	}
+	tasks[2].Pinned = true
+
+	plan := movedPlan(tasks, tasks[:1], 1, testOperator, mar(3, 12), mar(3, 14))
+	if !plan[0].Pinned || !plan[0].PlanStart.Equal(mar(3, 12)) || tasks[0].Pinned {
+		t.Fatalf("moved task %+v, want pinned at 12:00 without touching the input", plan[0])
+	}
+	moves, unscheduled := repairPlan(repairInput{
+		now:      mar(3, 9),
+		tasks:    plan,
+		redo:     map[int64]bool{},
+		affected: map[int64]bool{},
+	})
+	if len(unscheduled) != 0 {
+		t.Fatalf("unscheduled %v", unscheduled)
+	}
+	got := movesByTask(moves)
+	if _, ok := got[1]; ok {
+		t.Errorf("moved task shifted: %+v", got[1])
+	}
+	if _, ok := got[3]; ok {
+		t.Errorf("pinned task shifted: %+v", got[3])
+	}
+	if m, ok := got[2]; !ok || !m.NewStart.Equal(mar(3, 16)) {
+		t.Errorf("pushed task %+v, want start %v", m, mar(3, 16))
+	}
+}
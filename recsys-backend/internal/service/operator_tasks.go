@@ -0,0 +1,242 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// OperatorTasksSync — изменения поручений операторам (user_task) наладки и
+// снятия после изменения плана.
+type OperatorTasksSync struct {
+	Created int `json:"created"`
+	Updated int `json:"updated"`
+	Removed int `json:"removed"`
+}
+
+// handling — наладка и снятие задания с множителем его оператора на типе
+// оборудования, как их считает планировщик.
+func (c Competencies) handling(t storage.DeviceTaskRow, deviceTypeID int64) (time.Duration, time.Duration) {
+	comp, ok := c[t.OperatorID][deviceTypeID]
+	if !ok || comp.SetupFactor == nil {
+		return t.SetupTime, t.UnloadTime
+	}
+	f := *comp.SetupFactor
+	return time.Duration(float64(t.SetupTime) * f), time.Duration(float64(t.UnloadTime) * f)
+}
+
+// planUserTasks — поручения оператору задания по его слоту: наладка в начале
+// слота вместе с переходом на материал (changeover) и снятие в конце.
+// Задание прогона ведёт первое задание прогона.
+func planUserTasks(t storage.DeviceTaskRow, deviceTypeID int64, comps Competencies, changeover time.Duration) []storage.UserTask {
+	if !operatorHandled(t) {
+		return nil
+	}
+	setup, unload := comps.handling(t, deviceTypeID)
+	setup += changeover
+	taskID, priority := t.ID, int(t.PriorityID)
+	item := func(kind, name string, start, end time.Time) storage.UserTask {
+		return storage.UserTask{
+			Name:         name + ": " + t.Name,
+			StartTime:    &start,
+			EndTime:      &end,
+			Priority:     &priority,
+			WorkspaceID:  t.WorkspaceID,
+			DeviceTaskID: &taskID,
+			OperatorID:   t.OperatorID,
+			Kind:         kind,
+		}
+	}
+	var res []storage.UserTask
+	if setup > 0 {
+		res = append(res, item(storage.UserTaskSetup, "Наладка", *t.PlanStart, minTime(t.PlanStart.Add(setup), *t.PlanEnd)))
+	}
+	if unload > 0 {
+		res = append(res, item(storage.UserTaskUnload, "Снятие", maxTime(t.PlanEnd.Add(-unload), *t.PlanStart), *t.PlanEnd))
+	}
+	return res
+}
+
+// operatorHandled — у задания есть поручения оператору: оно ждёт выполнения
+// или выполняется по плану, требует оператора и не входит в чужой прогон.
+func operatorHandled(t storage.DeviceTaskRow) bool {
+	if t.Status != storage.TaskStatusPending && t.Status != storage.TaskStatusInProgress {
+		return false
+	}
+	if t.PlanStart == nil || t.PlanEnd == nil {
+		return false
+	}
+	return t.NeedOperator && t.OperatorID > 0 && (t.BatchID == 0 || t.BatchID == t.ID)
+}
+
+type userTaskKey struct {
+	taskID int64
+	kind   string
+}
+
+// diffPlanUserTasks сверяет поручения планировщика с планом: у каждого
+// задания в плане они пересчитываются по его текущему слоту и оператору,
+// changeover — переход на материал в начале слота (задание -> длительность).
+// Недостающие поручения создаются, лишние удаляются. Поручения завершённых
+// заданий не меняются, созданные вручную — не рассматриваются.
+func diffPlanUserTasks(
+	existing []storage.UserTask,
+	tasks []storage.DeviceTaskRow,
+	changeover map[int64]time.Duration,
+	deviceType map[int64]int64,
+	comps Competencies,
+) (create, update []storage.UserTask, remove []int64) {
+	own := map[userTaskKey]storage.UserTask{}
+	for _, u := range existing {
+		if u.Kind != "" && u.DeviceTaskID != nil {
+			own[userTaskKey{*u.DeviceTaskID, u.Kind}] = u
+		}
+	}
+	for _, t := range tasks {
+		if t.Status == storage.TaskStatusDone {
+			continue
+		}
+		wanted := map[string]bool{}
+		for _, u := range planUserTasks(t, deviceType[t.DeviceID], comps, changeover[t.ID]) {
+			wanted[u.Kind] = true
+			cur, ok := own[userTaskKey{t.ID, u.Kind}]
+			switch {
+			case !ok:
+				create = append(create, u)
+			case !sameUserTask(cur, u):
+				u.ID = cur.ID
+				update = append(update, u)
+			}
+		}
+		for _, kind := range []string{storage.UserTaskSetup, storage.UserTaskUnload} {
+			if cur, ok := own[userTaskKey{t.ID, kind}]; ok && !wanted[kind] {
+				remove = append(remove, cur.ID)
+			}
+		}
+	}
+	return create, update, remove
+}
+
+func sameUserTask(a, b storage.UserTask) bool {
+	return a.Name == b.Name &&
+		a.OperatorID == b.OperatorID &&
+		equalTimes(a.StartTime, b.StartTime) &&
+		equalTimes(a.EndTime, b.EndTime) &&
+		(a.Priority == nil) == (b.Priority == nil) &&
+		(a.Priority == nil || *a.Priority == *b.Priority)
+}
+
+func equalTimes(a, b *time.Time) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return a.Equal(*b)
+}
+
+// syncOperatorTasks приводит поручения наладки и снятия в соответствие с
+// планом workspace. changeover — переход на материал в начале слота,
+// рассчитанный при постановке заданий; у остальных заданий он берётся из смен
+// материала по плану.
+func (p *Planner) syncOperatorTasks(ctx context.Context, workspaceID int64, changeover map[int64]time.Duration) (OperatorTasksSync, error) {
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return OperatorTasksSync{}, err
+	}
+	existing, err := p.repos.ListUserTasks(ctx, workspaceID)
+	if err != nil {
+		return OperatorTasksSync{}, err
+	}
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return OperatorTasksSync{}, err
+	}
+	competencies, err := p.repos.ListOperatorCompetencies(ctx, workspaceID)
+	if err != nil {
+		return OperatorTasksSync{}, err
+	}
+	changeoverItems, err := p.repos.ListMaterialChangeovers(ctx, workspaceID)
+	if err != nil {
+		return OperatorTasksSync{}, err
+	}
+	taskChangeover := planChangeovers(devices, tasks, NewChangeovers(changeoverItems))
+	for id, d := range changeover {
+		taskChangeover[id] = d
+	}
+	deviceType := make(map[int64]int64, len(devices))
+	for _, d := range devices {
+		deviceType[d.ID] = d.DeviceTypeID
+	}
+
+	create, update, remove := diffPlanUserTasks(existing, tasks, taskChangeover, deviceType, NewCompetencies(competencies))
+	if len(create)+len(update)+len(remove) > 0 {
+		if err := p.repos.ApplyPlanUserTasks(ctx, create, update, remove); err != nil {
+			return OperatorTasksSync{}, err
+		}
+	}
+	return OperatorTasksSync{Created: len(create), Updated: len(update), Removed: len(remove)}, nil
+}
+
+// SetTaskStatus переводит задание в новый статус и в той же транзакции
+// приводит поручения операторам к плану: задание, которое больше не ждёт
+// выполнения и не выполняется, теряет поручения наладки и снятия.
+func (p *Planner) SetTaskStatus(ctx context.Context, id int64, status storage.TaskStatus, at time.Time) (OperatorTasksSync, error) {
+	var res OperatorTasksSync
+	err := p.inTx(ctx, func(tp *Planner) error {
+		task, err := tp.repos.GetDeviceTask(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := tp.repos.SetDeviceTaskStatus(ctx, id, status, at); err != nil {
+			return err
+		}
+		res, err = tp.syncOperatorTasks(ctx, task.WorkspaceID, nil)
+		return err
+	})
+	if err != nil {
+		return OperatorTasksSync{}, err
+	}
+	return res, nil
+}
+
+// UpdateTask перезаписывает задание и в той же транзакции пересчитывает
+// поручения операторам под его слот, оператора и статус. Если задание
+// перешло в другой workspace, поручения в прежнем удаляются.
+func (p *Planner) UpdateTask(ctx context.Context, t storage.DeviceTask) (OperatorTasksSync, error) {
+	var res OperatorTasksSync
+	err := p.inTx(ctx, func(tp *Planner) error {
+		current, err := tp.repos.GetDeviceTask(ctx, t.ID)
+		if err != nil {
+			return err
+		}
+		if err := tp.repos.UpdateDeviceTask(ctx, t); err != nil {
+			return err
+		}
+		var removed []int64
+		if current.WorkspaceID != t.WorkspaceID {
+			existing, err := tp.repos.ListUserTasks(ctx, current.WorkspaceID)
+			if err != nil {
+				return err
+			}
+			for _, u := range existing {
+				if u.Kind != "" && u.DeviceTaskID != nil && *u.DeviceTaskID == t.ID {
+					removed = append(removed, u.ID)
+				}
+			}
+			if len(removed) > 0 {
+				if err := tp.repos.ApplyPlanUserTasks(ctx, nil, nil, removed); err != nil {
+					return err
+				}
+			}
+		}
+		if res, err = tp.syncOperatorTasks(ctx, t.WorkspaceID, nil); err != nil {
+			return err
+		}
+		res.Removed += len(removed)
+		return nil
+	})
+	if err != nil {
+		return OperatorTasksSync{}, err
+	}
+	return res, nil
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// hourOf — указатель на час h 3 марта 2025 года.
+func hourOf(h int) *time.Time {
+	t := time.Date(2025, 3, 3, h, 0, 0, 0, time.UTC)
+	return &t
+}
+
+// handledTask — задание оператора 5 в плане с 10 до 12: наладка 30 минут,
+// снятие 15 минут.
+func handledTask(status storage.TaskStatus) storage.DeviceTaskRow {
+	return storage.DeviceTaskRow{
+		ID:           1,
+		Name:         "Корпус",
+		DeviceID:     1,
+		OperatorID:   5,
+		NeedOperator: true,
+		Status:       status,
+		SetupTime:    30 * time.Minute,
+		UnloadTime:   15 * time.Minute,
+		PlanStart:    hourOf(10),
+		PlanEnd:      hourOf(12),
+	}
+}
+
+// existingUserTask — поручение по заданию 1; пустой kind — созданное вручную.
+func existingUserTask(id int64, kind string, start, end time.Time) storage.UserTask {
+	taskID, priority := int64(1), 0
+	name := "Звонок"
+	switch kind {
+	case storage.UserTaskSetup:
+		name = "Наладка: Корпус"
+	case storage.UserTaskUnload:
+		name = "Снятие: Корпус"
+	}
+	return storage.UserTask{ID: id, Name: name, StartTime: &start, EndTime: &end, Priority: &priority, DeviceTaskID: &taskID, OperatorID: 5, Kind: kind}
+}
+
+func TestPlanUserTasks(t *testing.T) {
+	got := planUserTasks(handledTask(storage.TaskStatusPending), 0, nil, 10*time.Minute)
+	if len(got) != 2 {
+		t.Fatalf("got %+v, want setup and unload", got)
+	}
+	setup, unload := got[0], got[1]
+	if setup.Kind != storage.UserTaskSetup || !setup.StartTime.Equal(*hourOf(10)) || !setup.EndTime.Equal(hourOf(10).Add(40*time.Minute)) {
+		t.Errorf("setup %+v, want 10:00-10:40 with the changeover", setup)
+	}
+	if unload.Kind != storage.UserTaskUnload || !unload.StartTime.Equal(hourOf(12).Add(-15*time.Minute)) || !unload.EndTime.Equal(*hourOf(12)) {
+		t.Errorf("unload %+v, want 11:45-12:00", unload)
+	}
+
+	// Задание прогона ведёт первое задание прогона.
+	member := handledTask(storage.TaskStatusPending)
+	member.BatchID = 9
+	if got := planUserTasks(member, 0, nil, 0); len(got) != 0 {
+		t.Errorf("batch member: got %+v, want none", got)
+	}
+}
+
+func TestDiffPlanUserTasks(t *testing.T) {
+	setup := existingUserTask(20, storage.UserTaskSetup, *hourOf(10), hourOf(10).Add(30*time.Minute))
+	unload := existingUserTask(21, storage.UserTaskUnload, hourOf(12).Add(-15*time.Minute), *hourOf(12))
+	manual := existingUserTask(22, "", *hourOf(10), *hourOf(11))
+	moved := handledTask(storage.TaskStatusPending)
+	moved.PlanStart, moved.PlanEnd = hourOf(13), hourOf(15)
+
+	cases := []struct {
+		name       string
+		task       storage.DeviceTaskRow
+		existing   []storage.UserTask
+		wantCreate []string
+		wantUpdate []int64
+		wantRemove []int64
+	}{
+		{"create", handledTask(storage.TaskStatusPending), []storage.UserTask{manual}, []string{storage.UserTaskSetup, storage.UserTaskUnload}, nil, nil},
+		{"unchanged", handledTask(storage.TaskStatusInProgress), []storage.UserTask{setup, unload, manual}, nil, nil, nil},
+		{"update", moved, []storage.UserTask{setup, unload, manual}, nil, []int64{20, 21}, nil},
+		{"remove cancelled", handledTask(storage.TaskStatusCancelled), []storage.UserTask{setup, unload, manual}, nil, nil, []int64{20, 21}},
+		{"remove unplanned", storage.DeviceTaskRow{ID: 1, OperatorID: 5, NeedOperator: true, Status: storage.TaskStatusPending}, []storage.UserTask{setup, manual}, nil, nil, []int64{20}},
+		{"done untouched", handledTask(storage.TaskStatusDone), []storage.UserTask{setup, manual}, nil, nil, nil},
+	}
+	for _, c := range cases {
+		create, update, remove := diffPlanUserTasks(c.existing, []storage.DeviceTaskRow{c.task}, nil, nil, nil)
+		var createKinds []string
+		for _, u := range create {
+			createKinds = append(createKinds, u.Kind)
+		}
+		var updateIDs []int64
+		for _, u := range update {
+			updateIDs = append(updateIDs, u.ID)
+		}
+		if !slices.Equal(createKinds, c.wantCreate) || !slices.Equal(updateIDs, c.wantUpdate) || !slices.Equal(remove, c.wantRemove) {
+			t.Errorf("%s: create %v, update %v, remove %v; want %v, %v, %v",
+				c.name, createKinds, updateIDs, remove, c.wantCreate, c.wantUpdate, c.wantRemove)
+		}
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// OrderProgress — сводка заказа по его заданиям.
+type OrderProgress struct {
+	// Status — сводный статус: cancelled — отменены все задания, done —
+	// завершены все неотменённые, failed — есть задание со сбоем, in_progress —
+	// задание выполняется или часть уже завершена, on_hold — есть отложенное,
+	// иначе pending (и у заказа без заданий).
+	Status    storage.TaskStatus `json:"status"`
+	Tasks     int                `json:"tasks"`     // неотменённые задания
+	Done      int                `json:"done"`      // из них завершённые
+	Unplanned int                `json:"unplanned"` // незавершённые задания без плана
+	// PlannedCompletion — самое позднее окончание заданий: фактическое у
+	// завершённых, плановое у остальных; nil — не все задания в плане.
+	PlannedCompletion *time.Time `json:"planned_completion"`
+	// Late — заказ по плану завершается позже срока.
+	Late bool `json:"late"`
+}
+
+// CustomerOrderView — заказ, его задания и сводка по ним.
+type CustomerOrderView struct {
+	Order    storage.CustomerOrder
+	Progress OrderProgress
+	Tasks    []storage.DeviceTaskRow
+}
+
+// RollupOrder сводит статус и плановое завершение заказа по его заданиям.
+func RollupOrder(o storage.CustomerOrder, tasks []storage.DeviceTaskRow) OrderProgress {
+	res := OrderProgress{Status: storage.TaskStatusPending}
+	counts := map[storage.TaskStatus]int{}
+	var completion *time.Time
+	for _, t := range tasks {
+		counts[t.Status]++
+		if t.Status == storage.TaskStatusCancelled {
+			continue
+		}
+		res.Tasks++
+		end := t.PlanEnd
+		if t.Status == storage.TaskStatusDone {
+			res.Done++
+			end = operationEnd(t)
+		}
+		if end == nil {
+			res.Unplanned++
+			continue
+		}
+		if completion == nil || end.After(*completion) {
+			completion = end
+		}
+	}
+	switch {
+	case len(tasks) > 0 && res.Tasks == 0:
+		res.Status = storage.TaskStatusCancelled
+	case res.Tasks > 0 && res.Done == res.Tasks:
+		res.Status = storage.TaskStatusDone
+	case counts[storage.TaskStatusFailed] > 0:
+		res.Status = storage.TaskStatusFailed
+	case counts[storage.TaskStatusInProgress] > 0 || res.Done > 0:
+		res.Status = storage.TaskStatusInProgress
+	case counts[storage.TaskStatusOnHold] > 0:
+		res.Status = storage.TaskStatusOnHold
+	}
+	if res.Unplanned == 0 && completion != nil {
+		res.PlannedCompletion = completion
+		res.Late = o.DueDate != nil && completion.After(*o.DueDate)
+	}
+	return res
+}
+
+// CustomerOrders — заказы workspace со сводкой по их заданиям.
+func (p *Planner) CustomerOrders(ctx context.Context, workspaceID int64) ([]CustomerOrderView, error) {
+	orders, err := p.repos.ListCustomerOrders(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	byOrder := map[int64][]storage.DeviceTaskRow{}
+	for _, t := range tasks {
+		if t.OrderID > 0 {
+			byOrder[t.OrderID] = append(byOrder[t.OrderID], t)
+		}
+	}
+	res := make([]CustomerOrderView, 0, len(orders))
+	for _, o := range orders {
+		res = append(res, CustomerOrderView{Order: o, Progress: RollupOrder(o, byOrder[o.ID]), Tasks: byOrder[o.ID]})
+	}
+	return res, nil
+}
+
+// CustomerOrder — заказ со сводкой по его заданиям.
+func (p *Planner) CustomerOrder(ctx context.Context, id int64) (CustomerOrderView, error) {
+	o, err := p.repos.GetCustomerOrder(ctx, id)
+	if err != nil {
+		return CustomerOrderView{}, err
+	}
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, o.WorkspaceID)
+	if err != nil {
+		return CustomerOrderView{}, err
+	}
+	var own []storage.DeviceTaskRow
+	for _, t := range tasks {
+		if t.OrderID == id {
+			own = append(own, t)
+		}
+	}
+	return CustomerOrderView{Order: o, Progress: RollupOrder(o, own), Tasks: own}, nil
+}
+
+// OrderIndex — заказы по ID.
+func OrderIndex(orders []storage.CustomerOrder) map[int64]storage.CustomerOrder {
+	res := make(map[int64]storage.CustomerOrder, len(orders))
+	for _, o := range orders {
+		res[o.ID] = o
+	}
+	return res
+}
+
+// unitRank — очерёдность единицы планирования. Задания заказа идут под общим
+// сроком — самым ранним из срока заказа и дедлайнов его планируемых заданий —
+// и приоритетом заказа, а между собой — подряд, по своим дедлайнам. Со
+// старением приоритета единицы идут сначала по эффективному приоритету.
+// Единицы, поднятые ради жёстких дедлайнов, идут раньше всех.
+type unitRank struct {
+	promoted   bool // поднята ради жёсткого дедлайна
+	due        time.Time
+	priority   int64 // эффективный приоритет
+	base       int64 // приоритет до старения
+	boost      int   // на сколько уровней поднялся приоритет
+	byPriority bool  // приоритет стареет и сравнивается раньше срока
+	order      int64
+	deadline   time.Time
+}
+
+func (r unitRank) before(o unitRank) bool {
+	if r.promoted != o.promoted {
+		return r.promoted
+	}
+	if r.byPriority && r.priority != o.priority {
+		return r.priority < o.priority
+	}
+	if !r.due.Equal(o.due) {
+		return r.due.Before(o.due)
+	}
+	if r.priority != o.priority {
+		return r.priority < o.priority
+	}
+	if r.order != o.order {
+		return r.order < o.order
+	}
+	return r.deadline.Before(o.deadline)
+}
+
+// together — единицы можно переставить между собой ради меньшей переналадки:
+// срок в тот же день, тот же заказ, а со старением — и тот же приоритет.
+func (r unitRank) together(o unitRank) bool {
+	return r.promoted == o.promoted && deadlineDay(r.due).Equal(deadlineDay(o.due)) && r.order == o.order &&
+		(!r.byPriority || r.priority == o.priority)
+}
+
+// orderRanks — общий срок каждого заказа, задания которого планируются, и
+// старение приоритета на момент now.
+type orderRanks struct {
+	orders map[int64]storage.CustomerOrder
+	due    map[int64]time.Time
+	aging  *Aging
+	now    time.Time
+}
+
+func newOrderRanks(orders map[int64]storage.CustomerOrder, tasks []storage.DeviceTaskRow, aging *Aging, now, farFuture time.Time) orderRanks {
+	r := orderRanks{orders: orders, due: map[int64]time.Time{}, aging: aging, now: now}
+	for _, t := range tasks {
+		o, ok := orders[t.OrderID]
+		if !ok {
+			continue
+		}
+		due, seen := r.due[o.ID]
+		if !seen {
+			due = coalesceDeadline(o.DueDate, farFuture)
+		}
+		if d := coalesceDeadline(t.Deadline, farFuture); d.Before(due) {
+			due = d
+		}
+		r.due[o.ID] = due
+	}
+	return r
+}
+
+// rank — очерёдность единицы; единица вне заказа идёт по своему дедлайну и
+// приоритету первого задания. Единица с заданиями разных заказов идёт с
+// заказом, срок которого раньше, но не позже своего дедлайна. Приоритет
+// единицы со сроком стареет по этому сроку.
+func (r orderRanks) rank(u planUnit, farFuture time.Time) unitRank {
+	deadline := coalesceDeadline(unitDeadline(u.tasks), farFuture)
+	res := unitRank{due: deadline, priority: u.tasks[0].PriorityID, deadline: deadline}
+	var orderDue time.Time
+	for _, t := range u.tasks {
+		o, ok := r.orders[t.OrderID]
+		if !ok {
+			continue
+		}
+		if due := r.due[o.ID]; res.order == 0 || due.Before(orderDue) {
+			orderDue, res.priority, res.order = due, o.PriorityID, o.ID
+		}
+	}
+	if res.order != 0 && orderDue.Before(res.due) {
+		res.due = orderDue
+	}
+	res.base = res.priority
+	if r.aging != nil {
+		res.byPriority = true
+		if res.due.Before(farFuture) {
+			res.priority, res.boost = r.aging.effective(res.base, res.due, unitWork(u), r.now)
+		}
+	}
+	return res
+}
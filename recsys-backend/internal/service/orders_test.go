@@ -0,0 +1,149 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// orderTask — задание заказа в статусе status с планом до end; нулевой end —
+// без плана.
+func orderTask(status storage.TaskStatus, end time.Time) storage.DeviceTaskRow {
+	t := storage.DeviceTaskRow{Status: status}
+	if !end.IsZero() {
+		t.PlanStart, t.PlanEnd = slotAt(end.Add(-time.Hour)), slotAt(end)
+	}
+	return t
+}
+
+func TestRollupOrder(t *testing.T) {
+	done := orderTask(storage.TaskStatusDone, mar(3, 12))
+	done.ActualEnd = slotAt(mar(3, 11))
+	cases := []struct {
+		name       string
+		tasks      []storage.DeviceTaskRow
+		status     storage.TaskStatus
+		tasksN     int
+		doneN      int
+		unplanned  int
+		completion time.Time // нулевое — нет планового завершения
+	}{
+		{"no tasks", nil, storage.TaskStatusPending, 0, 0, 0, time.Time{}},
+		{"all cancelled", []storage.DeviceTaskRow{
+			orderTask(storage.TaskStatusCancelled, mar(3, 10)),
+			orderTask(storage.TaskStatusCancelled, time.Time{}),
+		}, storage.TaskStatusCancelled, 0, 0, 0, time.Time{}},
+		{"all done", []storage.DeviceTaskRow{
+			done,
+			orderTask(storage.TaskStatusCancelled, mar(3, 18)),
+		}, storage.TaskStatusDone, 1, 1, 0, mar(3, 11)},
+		{"failed present", []storage.DeviceTaskRow{
+			done,
+			orderTask(storage.TaskStatusFailed, mar(3, 14)),
+			orderTask(storage.TaskStatusInProgress, mar(3, 15)),
+		}, storage.TaskStatusFailed, 3, 1, 0, mar(3, 15)},
+		{"partially done", []storage.DeviceTaskRow{
+			done,
+			orderTask(storage.TaskStatusPending, mar(3, 16)),
+		}, storage.TaskStatusInProgress, 2, 1, 0, mar(3, 16)},
+		{"on hold", []storage.DeviceTaskRow{
+			orderTask(storage.TaskStatusOnHold, mar(3, 10)),
+			orderTask(storage.TaskStatusPending, mar(3, 12)),
+		}, storage.TaskStatusOnHold, 2, 0, 0, mar(3, 12)},
+		{"unplanned hides completion", []storage.DeviceTaskRow{
+			orderTask(storage.TaskStatusPending, mar(3, 12)),
+			orderTask(storage.TaskStatusPending, time.Time{}),
+		}, storage.TaskStatusPending, 2, 0, 1, time.Time{}},
+	}
+	for _, c := range cases {
+		got := RollupOrder(storage.CustomerOrder{ID: 1}, c.tasks)
+		if got.Status != c.status || got.Tasks != c.tasksN || got.Done != c.doneN || got.Unplanned != c.unplanned {
+			t.Errorf("%s: got %+v, want status %s, tasks %d, done %d, unplanned %d", c.name, got, c.status, c.tasksN, c.doneN, c.unplanned)
+		}
+		switch {
+		case c.completion.IsZero() && got.PlannedCompletion != nil:
+			t.Errorf("%s: planned completion %v, want none", c.name, got.PlannedCompletion)
+		case !c.completion.IsZero() && (got.PlannedCompletion == nil || !got.PlannedCompletion.Equal(c.completion)):
+			t.Errorf("%s: planned completion %v, want %v", c.name, got.PlannedCompletion, c.completion)
+		}
+	}
+}
+
+// Заказ опаздывает, если плановое завершение позже срока; без срока или без
+// полного плана опоздания нет.
+func TestRollupOrderLate(t *testing.T) {
+	tasks := []storage.DeviceTaskRow{orderTask(storage.TaskStatusPending, mar(3, 14))}
+	cases := []struct {
+		name  string
+		due   *time.Time
+		tasks []storage.DeviceTaskRow
+		late  bool
+	}{
+		{"after due date", slotAt(mar(3, 13)), tasks, true},
+		{"exactly at due date", slotAt(mar(3, 14)), tasks, false},
+		{"no due date", nil, tasks, false},
+		{"unplanned task", slotAt(mar(3, 13)), append([]storage.DeviceTaskRow{orderTask(storage.TaskStatusPending, time.Time{})}, tasks...), false},
+	}
+	for _, c := range cases {
+		if got := RollupOrder(storage.CustomerOrder{ID: 1, DueDate: c.due}, c.tasks); got.Late != c.late {
+			t.Errorf("%s: late %v, want %v", c.name, got.Late, c.late)
+		}
+	}
+}
+
+// Задания заказа идут подряд под сроком заказа — раньше задания вне заказа
+// с более поздним дедлайном, хотя своих дедлайнов у них нет.
+func TestPlanTasksOrderTogether(t *testing.T) {
+	now := mar(3, 9)
+	due, deadline := mar(3, 14), mar(3, 18)
+	out := PlanTasks(PlanInput{
+		Now: now,
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, OrderID: 7, Duration: 2 * time.Hour, Status: storage.TaskStatusPending},
+			{ID: 2, DeviceID: 1, Duration: 2 * time.Hour, Deadline: &deadline, Status: storage.TaskStatusPending},
+			{ID: 3, DeviceID: 1, OrderID: 7, Duration: 2 * time.Hour, Status: storage.TaskStatusPending},
+		},
+		Orders: OrderIndex([]storage.CustomerOrder{{ID: 7, DueDate: &due}}),
+	})
+	if len(out.Unscheduled) != 0 {
+		t.Fatalf("unscheduled %v", out.Unscheduled)
+	}
+	start := slotStarts(out)
+	if !start[1].Equal(mar(3, 9)) || !start[3].Equal(mar(3, 11)) {
+		t.Errorf("order tasks start at %v and %v, want 09:00 and 11:00", start[1], start[3])
+	}
+	if !start[2].Equal(mar(3, 13)) {
+		t.Errorf("task outside the order starts at %v, want 13:00", start[2])
+	}
+}
+
+// Срок заказа — самый ранний из его срока и дедлайнов его заданий; единица с
+// заданиями двух заказов идёт с тем, чей срок раньше.
+func TestOrderRanks(t *testing.T) {
+	farFuture := mar(31, 0)
+	due7, due8, early := mar(5, 18), mar(4, 18), mar(4, 12)
+	orders := OrderIndex([]storage.CustomerOrder{
+		{ID: 7, DueDate: &due7, PriorityID: 2},
+		{ID: 8, DueDate: &due8, PriorityID: 3},
+	})
+	tasks := []storage.DeviceTaskRow{
+		{ID: 1, OrderID: 7, Deadline: &early, PriorityID: 1},
+		{ID: 2, OrderID: 7, PriorityID: 1},
+		{ID: 3, OrderID: 8, PriorityID: 1},
+	}
+	r := newOrderRanks(orders, tasks, nil, mar(3, 9), farFuture)
+
+	second := r.rank(planUnit{tasks: tasks[1:2]}, farFuture)
+	if !second.due.Equal(early) || second.order != 7 || second.priority != 2 {
+		t.Errorf("order 7 task rank %+v, want due %v of the order, its priority 2", second, early)
+	}
+	mixed := r.rank(planUnit{tasks: tasks[1:3]}, farFuture)
+	if mixed.order != 7 || !mixed.due.Equal(early) {
+		t.Errorf("mixed unit rank %+v, want order 7 with the earlier due", mixed)
+	}
+	third := r.rank(planUnit{tasks: tasks[2:3]}, farFuture)
+	if !second.before(third) || !second.together(r.rank(planUnit{tasks: tasks[0:1]}, farFuture)) || second.together(third) {
+		t.Errorf("order 7 %+v should go before order 8 %+v and together only with its own tasks", second, third)
+	}
+}
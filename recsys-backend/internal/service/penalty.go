@@ -0,0 +1,103 @@
+package service
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// PenaltyRates — штраф за час опоздания заданий по приоритетам.
+type PenaltyRates map[int64]float64
+
+func NewPenaltyRates(priorities []storage.Priority) PenaltyRates {
+	res := make(PenaltyRates, len(priorities))
+	for _, p := range priorities {
+		res[p.ID] = p.PenaltyRate
+	}
+	return res
+}
+
+// Rate — штраф за час опоздания задания: его ставка, а без неё — ставка его приоритета.
+func (r PenaltyRates) Rate(t storage.DeviceTaskRow) float64 {
+	if t.PenaltyRate != nil {
+		return *t.PenaltyRate
+	}
+	return r[t.PriorityID]
+}
+
+// Penalty — штраф задания за опоздание late.
+func (r PenaltyRates) Penalty(t storage.DeviceTaskRow, late time.Duration) float64 {
+	return late.Hours() * r.Rate(t)
+}
+
+// Lateness — опоздание задания по плану относительно его дедлайна.
+type Lateness struct {
+	TaskID       int64     `json:"task_id"`
+	Name         string    `json:"name"`
+	DeadlineType string    `json:"deadline_type"` // hard | soft
+	Deadline     time.Time `json:"deadline"`
+	PlanEnd      time.Time `json:"plan_end"`
+	LateMin      int       `json:"late_min"`
+	PenaltyRate  float64   `json:"penalty_rate"` // штраф за час: задания или его приоритета
+	Penalty      float64   `json:"penalty"`
+}
+
+// LatenessReport — опоздания плана и их суммарный штраф.
+type LatenessReport struct {
+	Penalty  float64    `json:"penalty"`
+	HardLate int        `json:"hard_late"` // опоздания по жёстким дедлайнам
+	SoftLate int        `json:"soft_late"` // опоздания по мягким дедлайнам
+	Tasks    []Lateness `json:"tasks"`
+}
+
+// PlanLateness — опоздания ожидающих и выполняемых заданий по их плану, от
+// самого долгого. Штраф — часы опоздания, умноженные на ставку задания, а без
+// неё — на ставку его приоритета. Задания без плана не учитываются.
+func PlanLateness(tasks []storage.DeviceTaskRow, rates PenaltyRates) LatenessReport {
+	res := LatenessReport{Tasks: []Lateness{}}
+	for _, t := range tasks {
+		if t.Status != storage.TaskStatusPending && t.Status != storage.TaskStatusInProgress {
+			continue
+		}
+		if t.Deadline == nil || t.PlanEnd == nil || !t.PlanEnd.After(*t.Deadline) {
+			continue
+		}
+		late := t.PlanEnd.Sub(*t.Deadline)
+		item := Lateness{
+			TaskID:       t.ID,
+			Name:         t.Name,
+			DeadlineType: storage.DeadlineHard,
+			Deadline:     *t.Deadline,
+			PlanEnd:      *t.PlanEnd,
+			LateMin:      int(late.Minutes()),
+			PenaltyRate:  rates.Rate(t),
+			Penalty:      roundHundredths(rates.Penalty(t, late)),
+		}
+		if t.DeadlineType == storage.DeadlineSoft {
+			item.DeadlineType = storage.DeadlineSoft
+			res.SoftLate++
+		} else {
+			res.HardLate++
+		}
+		res.Penalty += item.Penalty
+		res.Tasks = append(res.Tasks, item)
+	}
+	res.Penalty = roundHundredths(res.Penalty)
+	sort.SliceStable(res.Tasks, func(i, j int) bool { return res.Tasks[i].LateMin > res.Tasks[j].LateMin })
+	return res
+}
+
+// Lateness — опоздания текущего плана workspace и их штраф.
+func (p *Planner) Lateness(ctx context.Context, workspaceID int64) (LatenessReport, error) {
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID, storage.TaskStatusPending, storage.TaskStatusInProgress)
+	if err != nil {
+		return LatenessReport{}, err
+	}
+	priorities, err := p.repos.ListPriorities(ctx)
+	if err != nil {
+		return LatenessReport{}, err
+	}
+	return PlanLateness(tasks, NewPenaltyRates(priorities)), nil
+}
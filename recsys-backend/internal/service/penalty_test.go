@@ -0,0 +1,94 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// testRates — час опоздания высокого приоритета стоит 50, низкого — 10.
+func testRates() PenaltyRates {
+	return NewPenaltyRates([]storage.Priority{{ID: 1, PenaltyRate: 50}, {ID: 2, PenaltyRate: 10}})
+}
+
+// lateTask — задание приоритета priority с дедлайном в 12:00 и планом до end.
+func lateTask(id, priority int64, status storage.TaskStatus, end time.Time) storage.DeviceTaskRow {
+	return storage.DeviceTaskRow{ID: id, PriorityID: priority, Status: status, Deadline: slotAt(mar(3, 12)), PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(end)}
+}
+
+// Ставка задания, даже нулевая, важнее ставки его приоритета.
+func TestPenaltyRatesRate(t *testing.T) {
+	r := testRates()
+	task := storage.DeviceTaskRow{PriorityID: 1}
+	if got := r.Rate(task); got != 50 {
+		t.Errorf("priority rate %v, want 50", got)
+	}
+	for _, rate := range []float64{80, 0} {
+		task.PenaltyRate = &rate
+		if got := r.Rate(task); got != rate {
+			t.Errorf("task rate %v, want %v", got, rate)
+		}
+	}
+	if got := r.Rate(storage.DeviceTaskRow{PriorityID: 9}); got != 0 {
+		t.Errorf("unknown priority rate %v, want 0", got)
+	}
+	if got := r.Penalty(storage.DeviceTaskRow{PriorityID: 1}, 90*time.Minute); got != 75 {
+		t.Errorf("penalty %v, want 75 for 1.5h", got)
+	}
+}
+
+// В отчёт входят опоздавшие ожидающие и выполняемые задания, от самого
+// долгого опоздания; жёсткие и мягкие дедлайны считаются отдельно.
+func TestPlanLateness(t *testing.T) {
+	own := 30.0
+	soft := lateTask(2, 1, storage.TaskStatusInProgress, mar(3, 13))
+	soft.DeadlineType, soft.PenaltyRate = storage.DeadlineSoft, &own
+	noPlan := lateTask(9, 1, storage.TaskStatusPending, mar(3, 14))
+	noPlan.PlanStart, noPlan.PlanEnd = nil, nil
+	noDeadline := lateTask(10, 1, storage.TaskStatusPending, mar(3, 14))
+	noDeadline.Deadline = nil
+	tasks := []storage.DeviceTaskRow{
+		lateTask(1, 1, storage.TaskStatusPending, mar(3, 14)),
+		soft,
+		lateTask(3, 2, storage.TaskStatusPending, mar(3, 12)),
+		lateTask(4, 1, storage.TaskStatusOnHold, mar(3, 15)),
+		lateTask(5, 1, storage.TaskStatusFailed, mar(3, 15)),
+		lateTask(6, 1, storage.TaskStatusDone, mar(3, 15)),
+		lateTask(7, 1, storage.TaskStatusCancelled, mar(3, 15)),
+		noPlan,
+		noDeadline,
+	}
+	res := PlanLateness(tasks, testRates())
+	if len(res.Tasks) != 2 || res.Tasks[0].TaskID != 1 || res.Tasks[1].TaskID != 2 {
+		t.Fatalf("tasks %+v, want 1 and 2", res.Tasks)
+	}
+	if got := res.Tasks[0]; got.LateMin != 120 || got.PenaltyRate != 50 || got.Penalty != 100 || got.DeadlineType != storage.DeadlineHard {
+		t.Errorf("hard late task %+v", got)
+	}
+	if got := res.Tasks[1]; got.LateMin != 60 || got.PenaltyRate != 30 || got.Penalty != 30 || got.DeadlineType != storage.DeadlineSoft {
+		t.Errorf("soft late task %+v", got)
+	}
+	if res.HardLate != 1 || res.SoftLate != 1 || res.Penalty != 130 {
+		t.Errorf("report hard %d, soft %d, penalty %v, want 1, 1 and 130", res.HardLate, res.SoftLate, res.Penalty)
+	}
+}
+
+// Штраф задания и итог округляются до сотых.
+func TestPlanLatenessRounding(t *testing.T) {
+	end := mar(3, 12).Add(20 * time.Minute)
+	tasks := []storage.DeviceTaskRow{
+		lateTask(1, 2, storage.TaskStatusPending, end),
+		lateTask(2, 2, storage.TaskStatusPending, end),
+		lateTask(3, 2, storage.TaskStatusPending, end),
+	}
+	res := PlanLateness(tasks, testRates())
+	for _, l := range res.Tasks {
+		if l.Penalty != 3.33 {
+			t.Errorf("task %d penalty %v, want 3.33", l.TaskID, l.Penalty)
+		}
+	}
+	if res.Penalty != 9.99 || res.HardLate != 3 {
+		t.Errorf("report penalty %v, hard %d, want 9.99 and 3", res.Penalty, res.HardLate)
+	}
+}
@@ -9,20 +9,51 @@ import (
 )
 
 type Planner struct {
-	repos *storage.Repos
+	repos     *storage.Repos
+	durations *DurationStats
 }
 
 func NewPlanner(repos *storage.Repos) *Planner {
-	return &Planner{repos: repos}
+	return &Planner{repos: repos, durations: NewDurationStats(repos)}
 }
 
+// inTx выполняет fn в одной транзакции: планировщик tp читает и пишет через
+// неё. Ошибка fn откатывает все изменения.
+func (p *Planner) inTx(ctx context.Context, fn func(tp *Planner) error) error {
+	return p.repos.InTx(ctx, func(tx *storage.Repos) error {
+		return fn(&Planner{repos: tx, durations: p.durations})
+	})
+}
+
+// Режимы оценки длительности задания при планировании.
+const (
+	DurationModeNominal = "nominal" // введённые вручную наладка + печать + снятие
+	DurationModeP80     = "p80"     // плановая, умноженная на P80-коэффициент из истории
+)
+
 type RecomputeRequest struct {
-	WorkspaceID int64 `json:"workspace_id"`
+	WorkspaceID  int64  `json:"workspace_id"`
+	DurationMode string `json:"duration_mode,omitempty"` // nominal (по умолчанию) | p80
 }
 
 type RecomputeResult struct {
 	Updated        int     `json:"updated"`
 	UnscheduledIDs []int64 `json:"unscheduled_ids"`
+	ChangeoverMin  int     `json:"changeover_min"` // суммарная переналадка между материалами в новом плане
+	// MaterialSwaps — смены материала в оборудовании, которые операторы выполняют по новому плану.
+	MaterialSwaps []MaterialSwap `json:"material_swaps"`
+	// MaterialShortages — задания, которым не хватает материала на складе:
+	// ждущие поставки и снятые с плана.
+	MaterialShortages []MaterialShortage `json:"material_shortages"`
+	// LabourDelays — задания, поставленные позже из-за правил рабочего времени операторов.
+	LabourDelays []LabourDelay `json:"labour_delays"`
+	// EnergyKWh и EnergyCost — расход и стоимость энергии перепланированных заданий.
+	EnergyKWh  float64 `json:"energy_kwh"`
+	EnergyCost float64 `json:"energy_cost"`
+	// OperatorTasks — поручения операторам на наладку и снятие, приведённые к новому плану.
+	OperatorTasks OperatorTasksSync `json:"operator_tasks"`
+	// Lateness — опоздания нового плана по дедлайнам и их суммарный штраф.
+	Lateness LatenessReport `json:"lateness"`
 }
 
 const (
@@ -34,16 +65,36 @@ const (
 )
 
 type interval struct {
-	start time.Time
-	end   time.Time
+	start    time.Time
+	end      time.Time
+	material int64 // материал задания на оборудовании; 0 — не задание или материал не указан
 }
 
-func (p *Planner) Recompute(ctx context.Context, workspaceID int64) (RecomputeResult, error) {
-	tasks, err := p.repos.ListTasksForPlanning(ctx, workspaceID)
+// Recompute пересчитывает план workspace. План читается, считается и
+// сохраняется вместе с поручениями операторам в одной транзакции под
+// блокировкой заданий workspace: параллельная починка или перенос не
+// перемешаются с записью нового плана.
+func (p *Planner) Recompute(ctx context.Context, req RecomputeRequest) (RecomputeResult, error) {
+	var res RecomputeResult
+	err := p.inTx(ctx, func(tp *Planner) error {
+		var err error
+		res, err = tp.recompute(ctx, req)
+		return err
+	})
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	return res, nil
+}
+
+// recompute пересчитывает и сохраняет план внутри транзакции Recompute.
+func (p *Planner) recompute(ctx context.Context, req RecomputeRequest) (RecomputeResult, error) {
+	workspaceID := req.WorkspaceID
+	allTasks, err := p.repos.LockDeviceTasksForWorkspace(ctx, workspaceID)
 	if err != nil {
 		return RecomputeResult{}, err
 	}
-	allTasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID)
+	tasks, err := p.repos.ListTasksForPlanning(ctx, workspaceID)
 	if err != nil {
 		return RecomputeResult{}, err
 	}
@@ -51,82 +102,932 @@ func (p *Planner) Recompute(ctx context.Context, workspaceID int64) (RecomputeRe
 	if err != nil {
 		return RecomputeResult{}, err
 	}
+	now := time.Now()
+	downtime, err := p.repos.ListDeviceDowntime(ctx, workspaceID, now)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	plateCapacity, err := p.plateCapacity(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	changeovers, err := p.repos.ListMaterialChangeovers(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	stock, err := p.materialSupply(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	caps, err := p.capabilities(ctx, workspaceID, devices)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	pools, err := p.repos.ListDevicePools(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	competencies, err := p.repos.ListOperatorCompetencies(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	labour, err := p.repos.GetLabourRules(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	energy, err := p.energy(ctx, workspaceID, devices)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	orders, err := p.repos.ListCustomerOrders(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	aging, err := p.aging(ctx, workspaceID)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	priorities, err := p.repos.ListPriorities(ctx)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	taskDuration, err := p.durationFunc(ctx, workspaceID, req.DurationMode)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
+	cooldowns, err := p.cooldowns(ctx, workspaceID, devices)
+	if err != nil {
+		return RecomputeResult{}, err
+	}
 
 	plannedIDs := make(map[int64]struct{}, len(tasks))
 	for _, t := range tasks {
 		plannedIDs[t.ID] = struct{}{}
 	}
+	var fixed []storage.DeviceTaskRow
+	for _, t := range allTasks {
+		if _, ok := plannedIDs[t.ID]; ok {
+			continue
+		}
+		// Отменённые задания не занимают оборудование и не задают готовность маршрута.
+		if t.Status == storage.TaskStatusCancelled {
+			continue
+		}
+		fixed = append(fixed, t)
+	}
+
+	out := PlanTasks(PlanInput{
+		Now:           now,
+		Tasks:         tasks,
+		Fixed:         fixed,
+		OperatorBusy:  busy,
+		Downtime:      downtime,
+		Devices:       devices,
+		PlateCapacity: plateCapacity,
+		Changeovers:   NewChangeovers(changeovers),
+		Stock:         stock,
+		Capabilities:  caps,
+		Pools:         PoolMembers(pools),
+		Competencies:  NewCompetencies(competencies),
+		Labour:        labour,
+		Energy:        energy,
+		Orders:        OrderIndex(orders),
+		Aging:         aging,
+		Cooldowns:     cooldowns,
+		Duration:      taskDuration,
+	})
+	deviceOf := make(map[int64]int64, len(tasks))
+	operatorOf := make(map[int64]int64, len(tasks))
+	batchOf := make(map[int64]int64, len(tasks))
+	for _, t := range tasks {
+		deviceOf[t.ID] = t.DeviceID
+		operatorOf[t.ID] = t.OperatorID
+		batchOf[t.ID] = t.BatchID
+	}
+	for _, s := range out.Slots {
+		if s.DeviceID != deviceOf[s.TaskID] {
+			if err := p.repos.AssignDeviceTaskDevice(ctx, s.TaskID, s.DeviceID); err != nil {
+				return RecomputeResult{}, err
+			}
+		}
+		if s.OperatorID > 0 && s.OperatorID != operatorOf[s.TaskID] {
+			if err := p.repos.AssignDeviceTaskOperator(ctx, s.TaskID, s.OperatorID); err != nil {
+				return RecomputeResult{}, err
+			}
+		}
+		if s.BatchID != batchOf[s.TaskID] {
+			if err := p.repos.SetDeviceTaskBatch(ctx, s.TaskID, s.BatchID); err != nil {
+				return RecomputeResult{}, err
+			}
+		}
+		if err := p.repos.UpdateDeviceTaskPlan(ctx, s.TaskID, s.Start, s.End); err != nil {
+			return RecomputeResult{}, err
+		}
+	}
+	// Незапланированное задание снимается с плана и выходит из прежнего
+	// прогона: устаревший слот не держит оборудование, оператора и резерв
+	// материала на складе.
+	for _, id := range out.Unscheduled {
+		if batchOf[id] != 0 {
+			if err := p.repos.SetDeviceTaskBatch(ctx, id, 0); err != nil {
+				return RecomputeResult{}, err
+			}
+		}
+		if err := p.repos.ClearDeviceTaskPlan(ctx, id); err != nil {
+			return RecomputeResult{}, err
+		}
+	}
+
+	res := RecomputeResult{
+		Updated:           len(out.Slots),
+		UnscheduledIDs:    out.Unscheduled,
+		MaterialShortages: out.Shortages,
+		LabourDelays:      []LabourDelay{},
+	}
+	if res.MaterialShortages == nil {
+		res.MaterialShortages = []MaterialShortage{}
+	}
+	for _, s := range out.Slots {
+		res.ChangeoverMin += s.ChangeoverMin
+		res.EnergyKWh += s.EnergyKWh
+		res.EnergyCost += s.EnergyCost
+		if len(s.LabourRules) > 0 {
+			res.LabourDelays = append(res.LabourDelays, LabourDelay{
+				TaskID:     s.TaskID,
+				OperatorID: s.OperatorID,
+				Rules:      s.LabourRules,
+				DelayMin:   s.LabourDelayMin,
+			})
+		}
+	}
+	res.EnergyKWh, res.EnergyCost = roundHundredths(res.EnergyKWh), roundHundredths(res.EnergyCost)
+	planned := applySlots(append(fixed, tasks...), out)
+	res.MaterialSwaps = MaterialSwaps(devices, planned)
+	res.Lateness = PlanLateness(planned, NewPenaltyRates(priorities))
+
+	changeover := make(map[int64]time.Duration, len(out.Slots))
+	for _, s := range out.Slots {
+		changeover[s.TaskID] = time.Duration(s.ChangeoverMin) * time.Minute
+	}
+	if res.OperatorTasks, err = p.syncOperatorTasks(ctx, workspaceID, changeover); err != nil {
+		return RecomputeResult{}, err
+	}
+	return res, nil
+}
+
+// PlanInput — всё, что нужно алгоритму планирования, без обращения к БД.
+type PlanInput struct {
+	Now   time.Time
+	Tasks []storage.DeviceTaskRow // задания к планированию
+	// Fixed — задания, которые остаются на своих местах в плане. Оборудование и
+	// оператора занимают ожидающие и выполняемые задания по плану и завершённые
+	// по факту; остальные задают только готовность операций маршрута.
+	Fixed        []storage.DeviceTaskRow
+	OperatorBusy []storage.UserTaskBusy
+	Downtime     []storage.DeviceDowntime
+	// Devices — оборудование workspace: операцию маршрута можно перенести на
+	// любое оборудование того же типа, а задание с материалом — на оборудование,
+	// в которое этот материал уже заправлен. nil — задания остаются на своём.
+	Devices []storage.Device
+	// PlateCapacity — вместимость платформы по типам оборудования; задания с
+	// местом на платформе объединяются в прогоны. nil — без прогонов.
+	PlateCapacity map[int64]int
+	// Changeovers — переналадка между материалами заданий на оборудовании.
+	Changeovers Changeovers
+	// Stock — склад материалов: задание ставится, только если его расход
+	// покрывается остатком или ближайшей поставкой. nil — склад не учитывается.
+	Stock map[int64]MaterialSupply
+	// Capabilities — характеристики оборудования и требования заданий: задание
+	// ставится только на оборудование, удовлетворяющее всем требованиям. nil —
+	// требования не учитываются.
+	Capabilities *Capabilities
+	// Pools — состав пулов оборудования: задание на пул ставится на любое его
+	// оборудование.
+	Pools map[int64][]int64
+	// Competencies — компетенции операторов: множитель наладки и снятия и
+	// уровень для заданий с требованием к нему. nil — задания остаются за
+	// своими операторами без поправки длительности.
+	Competencies Competencies
+	// Labour — правила рабочего времени операторов; нулевое значение — без правил.
+	Labour storage.LabourRules
+	// Energy — тарифы электроэнергии и мощность оборудования: слоты получают
+	// оценку энергии, а с весом стоимости она входит в цель планировщика.
+	// nil — энергия не учитывается.
+	Energy *Energy
+	// Orders — заказы клиентов: задания заказа ставятся подряд, под общим
+	// сроком и приоритетом заказа. nil — задания идут каждое по своему дедлайну.
+	Orders map[int64]storage.CustomerOrder
+	// Aging — старение приоритета: единицы идут по эффективному приоритету,
+	// затем по сроку. nil — по сроку, затем по приоритету.
+	Aging *Aging
+	// Cooldowns — остывание оборудования после заданий: оборудование занято,
+	// оператор свободен. nil — без остывания.
+	Cooldowns *Cooldowns
+	// Duration оценивает полную длительность задания; nil — наладка + печать + снятие.
+	Duration func(storage.DeviceTaskRow) time.Duration
+}
+
+type PlannedSlot struct {
+	TaskID   int64     `json:"task_id"`
+	DeviceID int64     `json:"device_id"`
+	Start    time.Time `json:"start"`
+	End      time.Time `json:"end"`
+	BatchID  int64     `json:"batch_id,omitempty"` // прогон (ID первого задания), 0 — задание печатается отдельно
+	// OperatorID — оператор слота; 0 — задание без оператора.
+	OperatorID int64 `json:"operator_id,omitempty"`
+	// ChangeoverMin — переналадка на материал задания в начале слота.
+	ChangeoverMin int `json:"changeover_min,omitempty"`
+	// LabourRules — правила рабочего времени оператора, сдвинувшие слот на
+	// LabourDelayMin минут позже.
+	LabourRules    []string `json:"labour_rules,omitempty"`
+	LabourDelayMin int      `json:"labour_delay_min,omitempty"`
+	// EnergyKWh и EnergyCost — расход и стоимость энергии слота по тарифам.
+	EnergyKWh  float64 `json:"energy_kwh,omitempty"`
+	EnergyCost float64 `json:"energy_cost,omitempty"`
+}
+
+type PlanOutput struct {
+	Slots       []PlannedSlot
+	Unscheduled []int64
+	Shortages   []MaterialShortage
+}
+
+// PlanTasks — эвристика earliest-slot: единицы планирования (задания, маршруты
+// и прогоны) в порядке срочности ставятся в ближайшее окно, свободное на
+// оборудовании и у оператора. Если жёсткие дедлайны сорваны, план строится
+// заново с этими заданиями в начале очереди, пока их становится меньше.
+func PlanTasks(in PlanInput) PlanOutput {
+	out := planTasks(in, nil)
+	missed := missedHardDeadlines(in.Tasks, out.Unscheduled)
+	promoted := map[int64]bool{}
+	for round := 0; round < hardDeadlineRounds && len(missed) > 0; round++ {
+		grown := false
+		for _, id := range missed {
+			if !promoted[id] {
+				promoted[id], grown = true, true
+			}
+		}
+		if !grown {
+			break
+		}
+		next := planTasks(in, promoted)
+		nextMissed := missedHardDeadlines(in.Tasks, next.Unscheduled)
+		if len(nextMissed) >= len(missed) {
+			break
+		}
+		out, missed = next, nextMissed
+	}
+	return out
+}
+
+// hardDeadlineRounds — сколько раз план строится заново ради жёстких дедлайнов.
+const hardDeadlineRounds = 3
+
+// missedHardDeadlines — задания с жёстким дедлайном, не вставшие в план.
+func missedHardDeadlines(tasks []storage.DeviceTaskRow, unscheduled []int64) []int64 {
+	hard := make(map[int64]bool, len(tasks))
+	for _, t := range tasks {
+		if t.Deadline != nil && t.DeadlineType != storage.DeadlineSoft {
+			hard[t.ID] = true
+		}
+	}
+	var res []int64
+	for _, id := range unscheduled {
+		if hard[id] {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+// planTasks — один проход планировщика; единицы с заданиями из promoted идут
+// в начале очереди.
+func planTasks(in PlanInput, promoted map[int64]bool) PlanOutput {
+	taskDuration := in.Duration
+	if taskDuration == nil {
+		taskDuration = func(t storage.DeviceTaskRow) time.Duration {
+			return t.SetupTime + t.Duration + t.UnloadTime
+		}
+	}
 
 	operatorBusy := map[int64][]interval{}
-	for _, b := range busy {
+	for _, b := range in.OperatorBusy {
 		operatorBusy[b.OperatorID] = append(operatorBusy[b.OperatorID], interval{start: b.Start, end: b.End})
 	}
 
 	deviceBusy := map[int64][]interval{}
-	for _, t := range allTasks {
-		if t.PlanStart == nil || t.PlanEnd == nil {
-			continue
+	for _, d := range in.Downtime {
+		deviceBusy[d.DeviceID] = append(deviceBusy[d.DeviceID], interval{start: d.Start, end: d.End})
+	}
+	// jobEnds — окончание операций маршрутов: задание -> шаг -> конец. Нулевое
+	// время — операция известна, но не в плане.
+	jobEnds := map[int64]map[int]time.Time{}
+	stock := newStockLedger(in.Stock)
+	labour := newLabourLedger(in.Labour, in.OperatorBusy)
+	for _, t := range in.Fixed {
+		if t.JobID > 0 {
+			var end time.Time
+			if e := operationEnd(t); e != nil {
+				end = *e
+			}
+			setJobEnd(jobEnds, t.JobID, t.JobSeq, end)
 		}
-		if _, ok := plannedIDs[t.ID]; ok {
+		slot, ok := fixedSlot(t)
+		if !ok {
 			continue
 		}
+		if t.Status == storage.TaskStatusPending || t.Status == storage.TaskStatusInProgress {
+			stock.reserve(t)
+		}
 		if t.DeviceID > 0 {
-			deviceBusy[t.DeviceID] = append(deviceBusy[t.DeviceID], interval{start: *t.PlanStart, end: *t.PlanEnd})
+			// Оборудование занято заданием до конца остывания после него.
+			end := slot.end.Add(in.Cooldowns.After(t.DeviceID, t))
+			deviceBusy[t.DeviceID] = append(deviceBusy[t.DeviceID], interval{start: slot.start, end: end, material: t.MaterialID})
 		}
 		if t.NeedOperator && t.OperatorID > 0 {
-			operatorBusy[t.OperatorID] = append(
-				operatorBusy[t.OperatorID],
-				interval{start: *t.PlanStart, end: *t.PlanEnd},
-			)
+			operatorBusy[t.OperatorID] = append(operatorBusy[t.OperatorID], slot)
+			labour.work[t.OperatorID] = append(labour.work[t.OperatorID], slot)
+		}
+	}
+
+	alternatives := deviceAlternatives(in.Devices)
+	pools := activePools(in.Pools, in.Devices)
+	deviceType := make(map[int64]int64, len(in.Devices))
+	for _, d := range in.Devices {
+		deviceType[d.ID] = d.DeviceTypeID
+		if d.LoadedMaterialID > 0 {
+			deviceBusy[d.ID] = append(deviceBusy[d.ID], loadedMarker(d.LoadedMaterialID, in.Now))
 		}
 	}
 
+	// Единица планирования — отдельное задание, все планируемые операции
+	// маршрута или прогон нескольких заданий на одной платформе.
+	var units []planUnit
+	var batchable []storage.DeviceTaskRow
+	jobUnit := map[int64]int{}
+	for _, t := range in.Tasks {
+		if t.JobID <= 0 {
+			if canBatch(t, in.PlateCapacity[deviceType[t.DeviceID]]) {
+				batchable = append(batchable, t)
+			} else {
+				units = append(units, planUnit{tasks: []storage.DeviceTaskRow{t}})
+			}
+			continue
+		}
+		i, ok := jobUnit[t.JobID]
+		if !ok {
+			i = len(units)
+			jobUnit[t.JobID] = i
+			units = append(units, planUnit{})
+		}
+		units[i].tasks = append(units[i].tasks, t)
+	}
+	for _, u := range units {
+		sort.Slice(u.tasks, func(i, j int) bool { return u.tasks[i].JobSeq < u.tasks[j].JobSeq })
+	}
 	// farFuture is computed once so the sort comparator is deterministic.
-	farFuture := time.Now().Add(maxScheduleAhead)
-	sort.Slice(tasks, func(i, j int) bool {
-		di := coalesceDeadline(tasks[i].Deadline, farFuture)
-		dj := coalesceDeadline(tasks[j].Deadline, farFuture)
+	farFuture := in.Now.Add(maxScheduleAhead)
+	for _, b := range formBatches(batchable, deviceType, in.PlateCapacity, farFuture) {
+		units = append(units, planUnit{tasks: b, batch: len(b) > 1})
+	}
+	orders := newOrderRanks(in.Orders, in.Tasks, in.Aging, in.Now, farFuture)
+	for i := range units {
+		units[i].rank = orders.rank(units[i], farFuture)
+		for _, t := range units[i].tasks {
+			if promoted[t.ID] {
+				units[i].rank.promoted = true
+			}
+		}
+	}
+	sort.SliceStable(units, func(i, j int) bool { return units[i].rank.before(units[j].rank) })
+
+	// bestSlot — самый ранний по окончанию слот среди оборудования candidates
+	// и допущенных операторов; при равном окончании — на оборудовании с уже
+	// заправленным материалом задания, затем со своим оператором задания.
+	// При выравнивании загрузки окончание сравнивается с надбавкой за уже
+	// назначенную оператору работу, а с весом стоимости энергии — и с надбавкой
+	// за неё: слот ищется и от окон тарифов, чтобы долгое задание попало в
+	// дешёвое окно, если успевает к дедлайну. Оборудование из matching
+	// подходит, только если материал менять не нужно. После слота оборудование
+	// должно успеть остыть от заданий tasks.
+	bestSlot := func(t storage.DeviceTaskRow, tasks []storage.DeviceTaskRow, candidates, matching []int64, earliest time.Time, total time.Duration, deadline *time.Time) (PlannedSlot, bool) {
+		var best PlannedSlot
+		var bestScore time.Time
+		found, bestLoaded := false, false
+		try := func(deviceID int64, matchOnly bool) {
+			for _, operatorID := range in.Competencies.operators(t, deviceType[deviceID], labour.balancing()) {
+				var worker int64
+				if t.NeedOperator {
+					worker = operatorID
+				}
+				dur := in.Competencies.scale(t, operatorID, deviceType[deviceID], total)
+				cooldown := in.Cooldowns.After(deviceID, tasks...)
+				for _, from := range append([]time.Time{earliest}, in.Energy.starts(deviceID, earliest, dur)...) {
+					start, end, change, rules, delay, ok := labour.findLabourSlot(
+						worker,
+						from,
+						dur,
+						deviceBusy[deviceID],
+						operatorBusy[operatorID],
+						deadline,
+						t.MaterialID,
+						in.Changeovers,
+						cooldown,
+						in.Energy.overnight(t),
+					)
+					if !ok {
+						continue
+					}
+					loaded := t.MaterialID != 0 && loadedMaterial(deviceBusy[deviceID], start) == t.MaterialID
+					if matchOnly && !loaded {
+						continue
+					}
+					score := end.Add(labour.penalty(worker, start) + in.Energy.penalty(deviceID, start, end))
+					if !found || score.Before(bestScore) || (score.Equal(bestScore) && loaded && !bestLoaded) {
+						bestScore = score
+						best = PlannedSlot{TaskID: t.ID, DeviceID: deviceID, Start: start, End: end, ChangeoverMin: int(change.Minutes())}
+						if t.NeedOperator {
+							best.OperatorID = operatorID
+						}
+						if delay > 0 {
+							best.LabourRules, best.LabourDelayMin = rules, int(delay.Minutes())
+						}
+						kwh, cost := in.Energy.estimate(deviceID, start, end)
+						best.EnergyKWh, best.EnergyCost = roundHundredths(kwh), roundHundredths(cost)
+						found, bestLoaded = true, loaded
+					}
+				}
+			}
+		}
+		for _, deviceID := range candidates {
+			try(deviceID, false)
+		}
+		for _, deviceID := range matching {
+			try(deviceID, true)
+		}
+		return best, found
+	}
+
+	// fitSlot — bestSlot с окончанием к самому раннему дедлайну заданий tasks;
+	// если так слот не находится, мягкие дедлайны сдвигаются и слот ищется к
+	// самому раннему жёсткому.
+	fitSlot := func(t storage.DeviceTaskRow, tasks []storage.DeviceTaskRow, candidates, matching []int64, earliest time.Time, total time.Duration) (PlannedSlot, bool) {
+		deadline := unitDeadline(tasks)
+		slot, ok := bestSlot(t, tasks, candidates, matching, earliest, total, deadline)
+		if hard := hardDeadline(tasks); !ok && !equalTimes(deadline, hard) {
+			slot, ok = bestSlot(t, tasks, candidates, matching, earliest, total, hard)
+		}
+		return slot, ok
+	}
+
+	// placeAfter ставит единицу не раньше release целиком или не ставит вовсе,
+	// возвращая занятость оборудования и операторов в прежнее состояние.
+	// Операции маршрута ставятся по порядку: каждая не раньше окончания
+	// предыдущей плюс пролёживание, на оборудовании того же типа, где она
+	// закончится раньше.
+	placeAfter := func(u planUnit, release time.Time) ([]PlannedSlot, bool) {
+		devMark := map[int64]int{}
+		opMark := map[int64]int{}
+		workMark := map[int64]int{}
+		reserve := func(t storage.DeviceTaskRow, deviceID int64, start, end time.Time, cooldown time.Duration) {
+			if _, ok := devMark[deviceID]; !ok {
+				devMark[deviceID] = len(deviceBusy[deviceID])
+			}
+			deviceBusy[deviceID] = append(deviceBusy[deviceID], interval{start: start, end: end.Add(cooldown), material: t.MaterialID})
+			if t.NeedOperator {
+				if _, ok := opMark[t.OperatorID]; !ok {
+					opMark[t.OperatorID] = len(operatorBusy[t.OperatorID])
+				}
+				operatorBusy[t.OperatorID] = append(operatorBusy[t.OperatorID], interval{start: start, end: end})
+				if _, ok := workMark[t.OperatorID]; !ok {
+					workMark[t.OperatorID] = len(labour.work[t.OperatorID])
+				}
+				labour.work[t.OperatorID] = append(labour.work[t.OperatorID], interval{start: start, end: end})
+			}
+		}
+		rollback := func() {
+			for id, n := range devMark {
+				deviceBusy[id] = deviceBusy[id][:n]
+			}
+			for id, n := range opMark {
+				operatorBusy[id] = operatorBusy[id][:n]
+			}
+			for id, n := range workMark {
+				labour.work[id] = labour.work[id][:n]
+			}
+		}
+
+		if u.batch {
+			// Прогон длится столько, сколько самое долгое задание на платформе,
+			// и должен закончиться к самому раннему дедлайну.
+			lead := u.tasks[0]
+			var total time.Duration
+			for _, t := range u.tasks {
+				total = max(total, taskDuration(t))
+			}
+			candidates := append([]int64{lead.DeviceID}, alternatives[lead.DeviceID]...)
+			if lead.DevicePoolID > 0 {
+				candidates = pools[lead.DevicePoolID]
+			}
+			candidates = in.Capabilities.eligible(u.tasks, candidates)
+			slot, ok := fitSlot(lead, u.tasks, candidates, nil, release, total)
+			if !ok {
+				return nil, false
+			}
+			lead.OperatorID = slot.OperatorID
+			reserve(lead, slot.DeviceID, slot.Start, slot.End, in.Cooldowns.After(slot.DeviceID, u.tasks...))
+			slots := make([]PlannedSlot, 0, len(u.tasks))
+			for _, t := range u.tasks {
+				slots = append(slots, PlannedSlot{
+					TaskID:     t.ID,
+					DeviceID:   slot.DeviceID,
+					Start:      slot.Start,
+					End:        slot.End,
+					BatchID:    lead.ID,
+					OperatorID: slot.OperatorID,
+				})
+			}
+			// Переналадка, задержка по правилам труда и энергия одни на прогон —
+			// они записываются первому заданию.
+			slots[0].ChangeoverMin = slot.ChangeoverMin
+			slots[0].LabourRules, slots[0].LabourDelayMin = slot.LabourRules, slot.LabourDelayMin
+			slots[0].EnergyKWh, slots[0].EnergyCost = slot.EnergyKWh, slot.EnergyCost
+			return slots, true
+		}
+
+		var slots []PlannedSlot
+		for _, t := range u.tasks {
+			// Оператора задания без своего выбирает планировщик, если задан
+			// уровень или выравнивается загрузка.
+			if t.DeviceID <= 0 || (t.NeedOperator && t.OperatorID <= 0 && t.MinLevel == "" && !labour.balancing()) {
+				rollback()
+				return nil, false
+			}
+			earliest := release
+			if prev, ok := jobEnds[t.JobID][t.JobSeq-1]; t.JobID > 0 && ok {
+				if prev.IsZero() {
+					// Предыдущая операция не в плане — эту ставить не от чего.
+					rollback()
+					return nil, false
+				}
+				if ready := prev.Add(t.TransferLag); ready.After(earliest) {
+					earliest = ready
+				}
+			}
+
+			candidates := []int64{t.DeviceID}
+			var matching []int64
+			switch {
+			case t.DevicePoolID > 0:
+				candidates = pools[t.DevicePoolID]
+			case t.JobID > 0 || t.TargetTypeID > 0:
+				candidates = append(candidates, alternatives[t.DeviceID]...)
+			case !in.Capabilities.Satisfies(t.ID, t.DeviceID):
+				// Своё оборудование не подходит по характеристикам.
+				candidates = alternatives[t.DeviceID]
+			case t.MaterialID > 0:
+				matching = alternatives[t.DeviceID]
+			}
+			one := []storage.DeviceTaskRow{t}
+			candidates = in.Capabilities.eligible(one, candidates)
+			matching = in.Capabilities.eligible(one, matching)
+			best, ok := fitSlot(t, one, candidates, matching, earliest, taskDuration(t))
+			if !ok {
+				rollback()
+				return nil, false
+			}
+			t.OperatorID = best.OperatorID
+			reserve(t, best.DeviceID, best.Start, best.End, in.Cooldowns.After(best.DeviceID, t))
+			if t.JobID > 0 {
+				setJobEnd(jobEnds, t.JobID, t.JobSeq, best.End)
+			}
+			slots = append(slots, best)
+		}
+		return slots, true
+	}
+
+	// place ставит единицу с учётом склада: если текущего остатка не хватает,
+	// единица ждёт поставки. Возвращает и нехватки материала — у поставленной
+	// единицы это задания, ждущие поставки, у снятой — причина отказа.
+	place := func(u planUnit) ([]PlannedSlot, []MaterialShortage, bool) {
+		release, short, ok := stock.check(u.tasks, in.Now)
+		if !ok {
+			return nil, short, false
+		}
+		slots, ok := placeAfter(u, release)
+		if !ok {
+			return nil, nil, false
+		}
+		for _, t := range u.tasks {
+			stock.reserve(t)
+		}
+		return slots, short, true
+	}
+
+	// nextUnit берёт самую срочную единицу, а если задана матрица переналадки —
+	// из единиц того же заказа (и эффективного приоритета) со сроком в тот же
+	// день ту, что требует меньше переналадки после последнего задания на
+	// своём оборудовании.
+	nextUnit := func() planUnit {
+		pick := 0
+		if len(in.Changeovers) > 0 {
+			best := unitChangeover(units[0], deviceBusy, in.Changeovers)
+			for i := 1; i < len(units) && units[i].rank.together(units[0].rank); i++ {
+				if c := unitChangeover(units[i], deviceBusy, in.Changeovers); c < best {
+					pick, best = i, c
+				}
+			}
+		}
+		u := units[pick]
+		units = append(units[:pick], units[pick+1:]...)
+		return u
+	}
+
+	var out PlanOutput
+	// refused — последняя нехватка материала, из-за которой задание не встало.
+	refused := map[int64]MaterialShortage{}
+	try := func(u planUnit) bool {
+		slots, short, ok := place(u)
+		if ok {
+			out.Slots = append(out.Slots, slots...)
+			out.Shortages = append(out.Shortages, short...)
+			for _, t := range u.tasks {
+				delete(refused, t.ID)
+			}
+			return true
+		}
+		for _, sh := range short {
+			refused[sh.TaskID] = sh
+		}
+		return false
+	}
+	unscheduled := func(t storage.DeviceTaskRow) {
+		out.Unscheduled = append(out.Unscheduled, t.ID)
+		if sh, ok := refused[t.ID]; ok {
+			out.Shortages = append(out.Shortages, sh)
+		}
+	}
+	for len(units) > 0 {
+		u := nextUnit()
+		if u.batch {
+			// Прогон не успевает к дедлайну — самое срочное задание ставится
+			// отдельно, остальные пробуют встать прогоном снова.
+			for rest := u.tasks; len(rest) > 0; rest = rest[1:] {
+				if try(planUnit{tasks: rest, batch: len(rest) > 1}) {
+					break
+				}
+				if len(rest) == 1 {
+					unscheduled(rest[0])
+					break
+				}
+				if !try(planUnit{tasks: rest[:1]}) {
+					unscheduled(rest[0])
+				}
+			}
+			continue
+		}
+		if try(u) {
+			continue
+		}
+		for _, t := range u.tasks {
+			unscheduled(t)
+			if t.JobID > 0 {
+				setJobEnd(jobEnds, t.JobID, t.JobSeq, time.Time{})
+			}
+		}
+	}
+	return out
+}
+
+// unitChangeover — переналадка под первое задание единицы после последнего
+// задания, стоящего на его оборудовании.
+func unitChangeover(u planUnit, deviceBusy map[int64][]interval, changeovers Changeovers) time.Duration {
+	t := u.tasks[0]
+	busy := deviceBusy[t.DeviceID]
+	var last time.Time
+	for _, iv := range busy {
+		if iv.end.After(last) {
+			last = iv.end
+		}
+	}
+	return changeovers.Between(loadedMaterial(busy, last), t.MaterialID)
+}
+
+// deadlineDay — календарный день срока.
+func deadlineDay(d time.Time) time.Time {
+	return time.Date(d.Year(), d.Month(), d.Day(), 0, 0, 0, 0, d.Location())
+}
+
+// planUnit — задания, которые ставятся в план вместе.
+type planUnit struct {
+	tasks []storage.DeviceTaskRow
+	batch bool     // прогон на одной платформе: общий слот, наладка и снятие
+	rank  unitRank // очерёдность в плане
+}
+
+// canBatch — задание можно объединить с другими на платформе вместимостью capacity.
+func canBatch(t storage.DeviceTaskRow, capacity int) bool {
+	return t.PlateSize > 0 && t.PlateSize <= capacity && t.DeviceID > 0 &&
+		(!t.NeedOperator || t.OperatorID > 0 || t.MinLevel != "")
+}
+
+type batchKey struct {
+	deviceType int64
+	material   int64
+	operator   int64 // 0 — оператор не нужен
+	pool       int64 // 0 — задание не на пул
+	level      storage.CompetencyLevel
+}
+
+// formBatches раскладывает задания по прогонам first-fit в порядке дедлайна:
+// в прогоне задания одного типа оборудования, одного материала и оператора,
+// суммарно не больше вместимости платформы.
+func formBatches(tasks []storage.DeviceTaskRow, deviceType map[int64]int64, capacity map[int64]int, farFuture time.Time) [][]storage.DeviceTaskRow {
+	sorted := append([]storage.DeviceTaskRow(nil), tasks...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		di := coalesceDeadline(sorted[i].Deadline, farFuture)
+		dj := coalesceDeadline(sorted[j].Deadline, farFuture)
 		if !di.Equal(dj) {
 			return di.Before(dj)
 		}
-		return tasks[i].PriorityID < tasks[j].PriorityID
+		return sorted[i].PriorityID < sorted[j].PriorityID
 	})
 
-	startAnchor := time.Now()
-	updated := 0
-	var unscheduled []int64
+	var batches [][]storage.DeviceTaskRow
+	var used []int
+	open := map[batchKey][]int{}
+	for _, t := range sorted {
+		key := batchKey{deviceType: deviceType[t.DeviceID], material: t.MaterialID, pool: t.DevicePoolID, level: t.MinLevel}
+		if t.NeedOperator {
+			key.operator = t.OperatorID
+		}
+		limit := capacity[key.deviceType]
+		placed := false
+		for _, i := range open[key] {
+			if used[i]+t.PlateSize <= limit {
+				batches[i] = append(batches[i], t)
+				used[i] += t.PlateSize
+				placed = true
+				break
+			}
+		}
+		if !placed {
+			open[key] = append(open[key], len(batches))
+			batches = append(batches, []storage.DeviceTaskRow{t})
+			used = append(used, t.PlateSize)
+		}
+	}
+	return batches
+}
 
-	for _, t := range tasks {
-		if t.DeviceID <= 0 || (t.NeedOperator && t.OperatorID <= 0) {
-			unscheduled = append(unscheduled, t.ID)
+// deviceAlternatives — для каждого оборудования остальное оборудование того же
+// типа, включённое в систему рекомендаций.
+func deviceAlternatives(devices []storage.Device) map[int64][]int64 {
+	byType := map[int64][]int64{}
+	for _, d := range devices {
+		if d.AddInRecSystem != nil && !*d.AddInRecSystem {
 			continue
 		}
+		byType[d.DeviceTypeID] = append(byType[d.DeviceTypeID], d.ID)
+	}
+	res := make(map[int64][]int64, len(devices))
+	for _, d := range devices {
+		for _, id := range byType[d.DeviceTypeID] {
+			if id != d.ID {
+				res[d.ID] = append(res[d.ID], id)
+			}
+		}
+	}
+	return res
+}
 
-		total := t.SetupTime + t.Duration + t.UnloadTime
+// PoolMembers — состав пулов по ID пула.
+func PoolMembers(pools []storage.DevicePool) map[int64][]int64 {
+	res := make(map[int64][]int64, len(pools))
+	for _, p := range pools {
+		res[p.ID] = p.DeviceIDs
+	}
+	return res
+}
 
-		start, end, ok := findNextAvailableSlot(
-			startAnchor,
-			total,
-			deviceBusy[t.DeviceID],
-			operatorBusy[t.OperatorID],
-			t.Deadline,
-		)
-		if !ok {
-			unscheduled = append(unscheduled, t.ID)
-			continue
+// activePools — состав пулов без оборудования, исключённого из планирования.
+func activePools(pools map[int64][]int64, devices []storage.Device) map[int64][]int64 {
+	excluded := map[int64]bool{}
+	for _, d := range devices {
+		if d.AddInRecSystem != nil && !*d.AddInRecSystem {
+			excluded[d.ID] = true
+		}
+	}
+	res := make(map[int64][]int64, len(pools))
+	for id, members := range pools {
+		for _, m := range members {
+			if !excluded[m] {
+				res[id] = append(res[id], m)
+			}
+		}
+	}
+	return res
+}
+
+// unitDeadline — самый ранний дедлайн заданий, мягкий или жёсткий; nil —
+// дедлайнов нет.
+func unitDeadline(u []storage.DeviceTaskRow) *time.Time {
+	var res *time.Time
+	for _, t := range u {
+		if t.Deadline != nil && (res == nil || t.Deadline.Before(*res)) {
+			res = t.Deadline
 		}
+	}
+	return res
+}
 
-		if err := p.repos.UpdateDeviceTaskPlan(ctx, t.ID, start, end); err != nil {
-			return RecomputeResult{}, err
+// hardDeadline — самый ранний жёсткий дедлайн заданий; nil — жёстких нет.
+func hardDeadline(u []storage.DeviceTaskRow) *time.Time {
+	var res *time.Time
+	for _, t := range u {
+		if t.Deadline != nil && t.DeadlineType != storage.DeadlineSoft && (res == nil || t.Deadline.Before(*res)) {
+			res = t.Deadline
 		}
-		deviceBusy[t.DeviceID] = append(deviceBusy[t.DeviceID], interval{start: start, end: end})
-		if t.NeedOperator {
-			operatorBusy[t.OperatorID] = append(operatorBusy[t.OperatorID], interval{start: start, end: end})
+	}
+	return res
+}
+
+// operationEnd — окончание операции, которая уже не планируется: фактическое
+// для завершённой, иначе плановое.
+func operationEnd(t storage.DeviceTaskRow) *time.Time {
+	if t.Status == storage.TaskStatusDone && t.ActualEnd != nil {
+		return t.ActualEnd
+	}
+	return t.PlanEnd
+}
+
+// fixedSlot — интервал, которым закреплённое задание занимает оборудование и
+// оператора: ожидающее или выполняемое — плановый слот, завершённое —
+// фактический. Отложенные, бракованные и отменённые задания никого не
+// занимают.
+func fixedSlot(t storage.DeviceTaskRow) (interval, bool) {
+	switch t.Status {
+	case storage.TaskStatusPending, storage.TaskStatusInProgress:
+		if t.PlanStart != nil && t.PlanEnd != nil {
+			return interval{start: *t.PlanStart, end: *t.PlanEnd}, true
+		}
+	case storage.TaskStatusDone:
+		if t.ActualStart != nil && t.ActualEnd != nil {
+			return interval{start: *t.ActualStart, end: *t.ActualEnd}, true
 		}
-		updated++
 	}
+	return interval{}, false
+}
 
-	return RecomputeResult{Updated: updated, UnscheduledIDs: unscheduled}, nil
+func setJobEnd(jobEnds map[int64]map[int]time.Time, jobID int64, seq int, end time.Time) {
+	if jobEnds[jobID] == nil {
+		jobEnds[jobID] = map[int]time.Time{}
+	}
+	jobEnds[jobID][seq] = end
+}
+
+// plateCapacity — вместимость платформы по типам оборудования workspace.
+func (p *Planner) plateCapacity(ctx context.Context, workspaceID int64) (map[int64]int, error) {
+	types, err := p.repos.ListDeviceTypes(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	res := make(map[int64]int, len(types))
+	for _, t := range types {
+		if t.PlateCapacity > 0 {
+			res[t.ID] = t.PlateCapacity
+		}
+	}
+	return res, nil
+}
+
+// durationFunc возвращает оценку полной длительности задания для выбранного режима.
+func (p *Planner) durationFunc(ctx context.Context, workspaceID int64, mode string) (func(storage.DeviceTaskRow) time.Duration, error) {
+	nominal := func(t storage.DeviceTaskRow) time.Duration {
+		return t.SetupTime + t.Duration + t.UnloadTime
+	}
+	if mode != DurationModeP80 {
+		return nominal, nil
+	}
+	model, err := p.durations.Model(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	deviceType := make(map[int64]int64, len(devices))
+	for _, d := range devices {
+		deviceType[d.ID] = d.DeviceTypeID
+	}
+	return func(t storage.DeviceTaskRow) time.Duration {
+		return model.P80(deviceType[t.DeviceID], t.DeviceTaskTypeID, nominal(t))
+	}, nil
 }
 
 func coalesceDeadline(t *time.Time, fallback time.Time) time.Time {
@@ -147,6 +1048,29 @@ func findNextAvailableSlot(
 	operatorBusy []interval,
 	deadline *time.Time,
 ) (time.Time, time.Time, bool) {
+	slotStart, slotEnd, _, ok := findChangeoverSlot(start, dur, deviceBusy, operatorBusy, deadline, 0, nil, 0, false)
+	return slotStart, slotEnd, ok
+}
+
+// findChangeoverSlot — findNextAvailableSlot для задания из материала material:
+// слот начинается с переналадки после предыдущего задания на оборудовании, а
+// после слота должно хватать времени на переналадку под следующее. Возвращает
+// и длительность переналадки в начале слота. После слота оборудование
+// остывает cooldown: это время не должно пересекаться с его занятостью, а
+// переналадка под следующее задание начинается после остывания. Слот overnight
+// только начинается в рабочее время, а закончиться может и после конца
+// рабочего дня.
+func findChangeoverSlot(
+	start time.Time,
+	dur time.Duration,
+	deviceBusy []interval,
+	operatorBusy []interval,
+	deadline *time.Time,
+	material int64,
+	changeovers Changeovers,
+	cooldown time.Duration,
+	overnight bool,
+) (time.Time, time.Time, time.Duration, bool) {
 	cur := alignToWorkday(start)
 
 	maxDate := start.Add(maxScheduleAhead)
@@ -154,20 +1078,30 @@ func findNextAvailableSlot(
 		maxDate = *deadline
 	}
 
-	busy := append([]interval{}, deviceBusy...)
-	busy = append(busy, operatorBusy...)
+	// Интервалы нулевой длины (отметки заправленного материала) не занимают ресурс.
+	var busy []interval
+	for _, iv := range append(append([]interval{}, deviceBusy...), operatorBusy...) {
+		if iv.end.After(iv.start) {
+			busy = append(busy, iv)
+		}
+	}
 	sort.Slice(busy, func(i, j int) bool {
 		return busy[i].start.Before(busy[j].start)
 	})
+	withChangeover := material != 0 && len(changeovers) > 0
 
 	for {
 		if cur.After(maxDate) {
-			return time.Time{}, time.Time{}, false
+			return time.Time{}, time.Time{}, 0, false
 		}
 		cur = alignToWorkday(cur)
+		var change time.Duration
+		if withChangeover {
+			change = changeovers.Between(loadedMaterial(deviceBusy, cur), material)
+		}
 		dayEnd := time.Date(cur.Year(), cur.Month(), cur.Day(), workDayEndHour, 0, 0, 0, cur.Location())
-		end := cur.Add(dur)
-		if end.After(dayEnd) {
+		end := cur.Add(change + dur)
+		if end.After(dayEnd) && !overnight {
 			cur = nextWorkdayStart(cur)
 			continue
 		}
@@ -182,11 +1116,55 @@ func findNextAvailableSlot(
 		if conflict {
 			continue
 		}
+		if cooldown > 0 {
+			// Оборудование остывает после слота; оператор в это время свободен.
+			for _, iv := range deviceBusy {
+				if iv.end.After(iv.start) && intersects(end, end.Add(cooldown), iv.start, iv.end) {
+					cur = iv.end
+					conflict = true
+					break
+				}
+			}
+			if conflict {
+				continue
+			}
+		}
+		if withChangeover {
+			// Следующее задание на оборудовании уже рассчитано на свой материал.
+			if next, ok := nextMaterial(deviceBusy, end); ok && end.Add(cooldown+changeovers.Between(material, next.material)).After(next.start) {
+				cur = next.end
+				continue
+			}
+		}
 		if end.After(maxDate) {
-			return time.Time{}, time.Time{}, false
+			return time.Time{}, time.Time{}, 0, false
+		}
+		return cur, end, change, true
+	}
+}
+
+// AlignToWorkday возвращает ближайший момент рабочего времени не раньше t.
+func AlignToWorkday(t time.Time) time.Time {
+	return alignToWorkday(t)
+}
+
+// WorkingTime — продолжительность рабочих часов в интервале [from, to).
+func WorkingTime(from, to time.Time) time.Duration {
+	var total time.Duration
+	for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()); day.Before(to); day = day.AddDate(0, 0, 1) {
+		dayStart := day.Add(workDayStartHour * time.Hour)
+		dayEnd := day.Add(workDayEndHour * time.Hour)
+		if dayStart.Before(from) {
+			dayStart = from
+		}
+		if dayEnd.After(to) {
+			dayEnd = to
+		}
+		if dayEnd.After(dayStart) {
+			total += dayEnd.Sub(dayStart)
 		}
-		return cur, end, true
 	}
+	return total
 }
 
 func alignToWorkday(t time.Time) time.Time {
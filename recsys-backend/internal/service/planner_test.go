@@ -0,0 +1,232 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Мягкий срок раньше, но задание к нему не успевает, если первым встанет
+// задание с жёстким сроком. Жёсткий срок соблюдается, мягкий сдвигается.
+func TestPlanTasksSlipsSoftDeadlineForHard(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	soft, hard := now.Add(3*time.Hour), now.Add(5*time.Hour)
+	out := PlanTasks(PlanInput{
+		Now: now,
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, Duration: 4 * time.Hour, Deadline: &soft, DeadlineType: storage.DeadlineSoft, Status: storage.TaskStatusPending},
+			{ID: 2, DeviceID: 1, Duration: 4 * time.Hour, Deadline: &hard, DeadlineType: storage.DeadlineHard, Status: storage.TaskStatusPending},
+		},
+	})
+
+	if len(out.Unscheduled) != 0 || len(out.Slots) != 2 {
+		t.Fatalf("slots %+v, unscheduled %v", out.Slots, out.Unscheduled)
+	}
+	start := map[int64]time.Time{}
+	for _, s := range out.Slots {
+		start[s.TaskID] = s.Start
+	}
+	if !start[2].Equal(now) {
+		t.Errorf("hard task starts at %v, want %v", start[2], now)
+	}
+	if want := now.Add(4 * time.Hour); !start[1].Equal(want) {
+		t.Errorf("soft task starts at %v, want %v", start[1], want)
+	}
+}
+
+// Жёсткий срок, к которому не успеть из-за занятого оборудования, задание с
+// плана снимает: без занятости двухчасовое задание к нему успело бы.
+func TestPlanTasksUnmeetableHardDeadline(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	deadline, busyUntil := now.Add(3*time.Hour), now.Add(2*time.Hour)
+	out := PlanTasks(PlanInput{
+		Now: now,
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, Duration: 2 * time.Hour, Deadline: &deadline, DeadlineType: storage.DeadlineHard, Status: storage.TaskStatusPending},
+		},
+		Fixed: []storage.DeviceTaskRow{
+			{ID: 9, DeviceID: 1, Status: storage.TaskStatusInProgress, PlanStart: &now, PlanEnd: &busyUntil},
+		},
+	})
+	if len(out.Slots) != 0 || !slices.Equal(out.Unscheduled, []int64{1}) {
+		t.Errorf("slots %+v, unscheduled %v, want task 1 unscheduled", out.Slots, out.Unscheduled)
+	}
+}
+
+// slotStarts — старт каждого поставленного задания.
+func slotStarts(out PlanOutput) map[int64]time.Time {
+	res := make(map[int64]time.Time, len(out.Slots))
+	for _, s := range out.Slots {
+		res[s.TaskID] = s.Start
+	}
+	return res
+}
+
+// Операция маршрута ставится после предыдущей и её пролёживания, даже если
+// в очереди стоит раньше неё.
+func TestPlanTasksJobPrecedence(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	out := PlanTasks(PlanInput{
+		Now: now,
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 2, DeviceID: 2, JobID: 30, JobSeq: 2, TransferLag: time.Hour, Duration: time.Hour, Status: storage.TaskStatusPending},
+			{ID: 1, DeviceID: 1, JobID: 30, JobSeq: 1, Duration: 2 * time.Hour, Status: storage.TaskStatusPending},
+		},
+	})
+	start := slotStarts(out)
+	if len(out.Unscheduled) != 0 || !start[1].Equal(now) {
+		t.Fatalf("slots %+v, unscheduled %v", out.Slots, out.Unscheduled)
+	}
+	if want := now.Add(3 * time.Hour); !start[2].Equal(want) {
+		t.Errorf("second operation starts at %v, want %v", start[2], want)
+	}
+}
+
+// Готовность выполненной операции считается от её фактического окончания,
+// а операция после снятой с плана не ставится.
+func TestPlanTasksJobAfterDoneAndUnscheduled(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	doneAt, plannedEnd, deadline := now.Add(time.Hour), now.Add(3*time.Hour), now.Add(time.Hour)
+	out := PlanTasks(PlanInput{
+		Now: now,
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 2, DeviceID: 2, JobID: 30, JobSeq: 2, TransferLag: 30 * time.Minute, Duration: time.Hour, Status: storage.TaskStatusPending},
+			{ID: 4, DeviceID: 1, JobID: 31, JobSeq: 1, Duration: 2 * time.Hour, Deadline: &deadline, Status: storage.TaskStatusPending},
+			{ID: 5, DeviceID: 2, JobID: 31, JobSeq: 2, Duration: time.Hour, Status: storage.TaskStatusPending},
+		},
+		Fixed: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, JobID: 30, JobSeq: 1, Status: storage.TaskStatusDone, PlanEnd: &plannedEnd, ActualEnd: &doneAt},
+		},
+	})
+	if want := doneAt.Add(30 * time.Minute); !slotStarts(out)[2].Equal(want) {
+		t.Errorf("operation after done starts at %v, want %v", slotStarts(out)[2], want)
+	}
+	if !slices.Equal(out.Unscheduled, []int64{4, 5}) {
+		t.Errorf("unscheduled %v, want the job with a missed deadline", out.Unscheduled)
+	}
+}
+
+func TestCanBatch(t *testing.T) {
+	cases := []struct {
+		name string
+		task storage.DeviceTaskRow
+		want bool
+	}{
+		{"fits", storage.DeviceTaskRow{DeviceID: 1, PlateSize: 40}, true},
+		{"no plate size", storage.DeviceTaskRow{DeviceID: 1}, false},
+		{"larger than plate", storage.DeviceTaskRow{DeviceID: 1, PlateSize: 120}, false},
+		{"no device", storage.DeviceTaskRow{PlateSize: 40}, false},
+		{"operator unknown", storage.DeviceTaskRow{DeviceID: 1, PlateSize: 40, NeedOperator: true}, false},
+		{"operator by level", storage.DeviceTaskRow{DeviceID: 1, PlateSize: 40, NeedOperator: true, MinLevel: storage.CompetencyQualified}, true},
+	}
+	for _, c := range cases {
+		if got := canBatch(c.task, 100); got != c.want {
+			t.Errorf("%s: canBatch = %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+// Прогоны собираются first-fit в порядке дедлайна; задания другого материала
+// или оператора в чужой прогон не попадают.
+func TestFormBatches(t *testing.T) {
+	day := time.Date(2025, 3, 3, 0, 0, 0, 0, time.UTC)
+	early, late := day.Add(12*time.Hour), day.Add(36*time.Hour)
+	tasks := []storage.DeviceTaskRow{
+		{ID: 1, DeviceID: 1, PlateSize: 60, MaterialID: 5, Deadline: &late},
+		{ID: 2, DeviceID: 1, PlateSize: 50, MaterialID: 5, Deadline: &early},
+		{ID: 3, DeviceID: 2, PlateSize: 40, MaterialID: 5},
+		{ID: 4, DeviceID: 1, PlateSize: 30, MaterialID: 6},
+		{ID: 5, DeviceID: 1, PlateSize: 30, MaterialID: 5, NeedOperator: true, OperatorID: 7},
+	}
+	deviceType := map[int64]int64{1: 10, 2: 10}
+	batches := formBatches(tasks, deviceType, map[int64]int{10: 100}, day.AddDate(1, 0, 0))
+
+	var got [][]int64
+	for _, b := range batches {
+		var ids []int64
+		for _, t := range b {
+			ids = append(ids, t.ID)
+		}
+		got = append(got, ids)
+	}
+	want := [][]int64{{2, 3}, {1}, {4}, {5}}
+	if !slices.EqualFunc(got, want, slices.Equal[[]int64]) {
+		t.Errorf("batches %v, want %v", got, want)
+	}
+}
+
+// Слот отложенного задания занимает новое задание, а завершённое задание
+// занимает оборудование только по факту выполнения.
+func TestPlanTasksFixedStatuses(t *testing.T) {
+	now := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	planEnd, doneStart, doneEnd := now.Add(3*time.Hour), now.Add(-time.Hour), now.Add(time.Hour)
+	out := PlanTasks(PlanInput{
+		Now: now,
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, Duration: 2 * time.Hour, Status: storage.TaskStatusPending},
+			{ID: 2, DeviceID: 2, Duration: time.Hour, Status: storage.TaskStatusPending},
+		},
+		Fixed: []storage.DeviceTaskRow{
+			{ID: 8, DeviceID: 1, Status: storage.TaskStatusOnHold, PlanStart: &now, PlanEnd: &planEnd},
+			{ID: 9, DeviceID: 1, Status: storage.TaskStatusFailed, PlanStart: &now, PlanEnd: &planEnd},
+			{ID: 10, DeviceID: 2, Status: storage.TaskStatusDone, PlanStart: &now, PlanEnd: &planEnd, ActualStart: &doneStart, ActualEnd: &doneEnd},
+		},
+	})
+	start := slotStarts(out)
+	if !start[1].Equal(now) {
+		t.Errorf("task in the on_hold slot starts at %v, want %v", start[1], now)
+	}
+	if !start[2].Equal(doneEnd) {
+		t.Errorf("task after the done one starts at %v, want its actual end %v", start[2], doneEnd)
+	}
+}
+
+// busyUntil — выполняемое на оборудовании deviceID задание с 09:00 до end.
+func busyUntil(id, deviceID int64, end time.Time) storage.DeviceTaskRow {
+	return storage.DeviceTaskRow{ID: id, DeviceID: deviceID, Status: storage.TaskStatusInProgress, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(end)}
+}
+
+// Задание на пул встаёт только на оборудование пула, включённое в
+// планирование, даже если другое оборудование того же типа свободно раньше.
+func TestPlanTasksPoolTarget(t *testing.T) {
+	off := false
+	out := PlanTasks(PlanInput{
+		Now: mar(3, 9),
+		Devices: []storage.Device{
+			{ID: 1, DeviceTypeID: 1}, {ID: 2, DeviceTypeID: 1}, {ID: 3, DeviceTypeID: 1},
+			{ID: 4, DeviceTypeID: 1, AddInRecSystem: &off},
+		},
+		Pools: map[int64][]int64{10: {2, 3, 4}},
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 2, DevicePoolID: 10, Duration: time.Hour, Status: storage.TaskStatusPending},
+		},
+		Fixed: []storage.DeviceTaskRow{busyUntil(8, 2, mar(3, 15)), busyUntil(9, 3, mar(3, 12))},
+	})
+	if len(out.Slots) != 1 {
+		t.Fatalf("slots %+v, unscheduled %v", out.Slots, out.Unscheduled)
+	}
+	if s := out.Slots[0]; s.DeviceID != 3 || !s.Start.Equal(mar(3, 12)) {
+		t.Errorf("slot on device %d at %v, want pool member 3 at 12:00", s.DeviceID, s.Start)
+	}
+}
+
+// Задание на тип встаёт на оборудование этого типа, которое освободится
+// раньше, а не на своё.
+func TestPlanTasksTypeTarget(t *testing.T) {
+	out := PlanTasks(PlanInput{
+		Now:     mar(3, 9),
+		Devices: []storage.Device{{ID: 1, DeviceTypeID: 1}, {ID: 2, DeviceTypeID: 1}, {ID: 3, DeviceTypeID: 2}},
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, TargetTypeID: 1, Duration: time.Hour, Status: storage.TaskStatusPending},
+		},
+		Fixed: []storage.DeviceTaskRow{busyUntil(8, 1, mar(3, 14)), busyUntil(9, 2, mar(3, 11))},
+	})
+	if len(out.Slots) != 1 {
+		t.Fatalf("slots %+v, unscheduled %v", out.Slots, out.Unscheduled)
+	}
+	if s := out.Slots[0]; s.DeviceID != 2 || !s.Start.Equal(mar(3, 11)) {
+		t.Errorf("slot on device %d at %v, want device 2 of the type at 11:00", s.DeviceID, s.Start)
+	}
+}
@@ -0,0 +1,589 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Виды сбоев для частичного перепланирования.
+const (
+	DisruptionDeviceDown = "device_down" // оборудование недоступно в интервале [from, until)
+	DisruptionTaskFailed = "task_failed" // задание ушло в брак и выполняется заново
+)
+
+var (
+	ErrInvalidDisruption = errors.New("invalid disruption")
+	ErrDisruptionTarget  = errors.New("device or task not found in workspace")
+)
+
+type DisruptionRequest struct {
+	WorkspaceID int64      `json:"workspace_id"`
+	Type        string     `json:"type"` // device_down | task_failed
+	DeviceID    int64      `json:"device_id,omitempty"`
+	TaskID      int64      `json:"task_id,omitempty"`
+	From        *time.Time `json:"from,omitempty"`  // начало простоя, по умолчанию — сейчас
+	Until       *time.Time `json:"until,omitempty"` // конец простоя, обязателен для device_down
+	Reason      string     `json:"reason,omitempty"`
+	DryRun      bool       `json:"dry_run,omitempty"` // только рассчитать сдвиги, ничего не сохранять
+}
+
+type TaskMove struct {
+	TaskID         int64      `json:"task_id"`
+	Name           string     `json:"name"`
+	DeviceID       int64      `json:"device_id"`
+	OldDeviceID    int64      `json:"old_device_id,omitempty"` // прежнее оборудование, если задание переставлено на другое
+	OperatorID     int64      `json:"operator_id"`
+	OldStart       *time.Time `json:"old_start"` // nil — задания не было в плане
+	OldEnd         *time.Time `json:"old_end"`
+	NewStart       time.Time  `json:"new_start"`
+	NewEnd         time.Time  `json:"new_end"`
+	ShiftMin       int        `json:"shift_min"` // сдвиг старта относительно старого плана
+	DeadlineMissed bool       `json:"deadline_missed"`
+}
+
+type RepairResult struct {
+	DryRun         bool       `json:"dry_run"`
+	DowntimeID     int64      `json:"downtime_id,omitempty"`
+	Moved          []TaskMove `json:"moved"`
+	InterruptedIDs []int64    `json:"interrupted_ids"` // прерванные задания, возвращены в очередь на переделку
+	UnscheduledIDs []int64    `json:"unscheduled_ids"`
+	// OperatorTasks — поручения операторам на наладку и снятие, приведённые к
+	// починенному плану; пусто при dry_run.
+	OperatorTasks OperatorTasksSync `json:"operator_tasks"`
+}
+
+// Repair локально чинит план после сбоя: заново ставятся только задания,
+// задетые сбоем, и те, которые они вытеснили; остальной план не меняется.
+// План читается, чинится и сохраняется в одной транзакции под блокировкой
+// заданий workspace: параллельный пересчёт или перенос не перезапишется
+// устаревшим планом.
+func (p *Planner) Repair(ctx context.Context, req DisruptionRequest) (RepairResult, error) {
+	now := time.Now()
+	from := now
+	if req.From != nil {
+		from = *req.From
+	}
+	switch req.Type {
+	case DisruptionDeviceDown:
+		if req.DeviceID <= 0 || req.Until == nil {
+			return RepairResult{}, fmt.Errorf("%w: device_id and until are required", ErrInvalidDisruption)
+		}
+		if !req.Until.After(from) {
+			return RepairResult{}, fmt.Errorf("%w: until must be after from", ErrInvalidDisruption)
+		}
+	case DisruptionTaskFailed:
+		if req.TaskID <= 0 {
+			return RepairResult{}, fmt.Errorf("%w: task_id is required", ErrInvalidDisruption)
+		}
+	default:
+		return RepairResult{}, fmt.Errorf("%w: type must be %s or %s", ErrInvalidDisruption, DisruptionDeviceDown, DisruptionTaskFailed)
+	}
+
+	var res RepairResult
+	err := p.inTx(ctx, func(tp *Planner) error {
+		var err error
+		res, err = tp.repair(ctx, req, now, from)
+		return err
+	})
+	if err != nil {
+		return RepairResult{}, err
+	}
+	return res, nil
+}
+
+// repair чинит и сохраняет план внутри транзакции Repair.
+func (p *Planner) repair(ctx context.Context, req DisruptionRequest, now, from time.Time) (RepairResult, error) {
+	workspaceID := req.WorkspaceID
+	tasks, err := p.repos.LockDeviceTasksForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	busy, err := p.repos.ListOperatorBusy(ctx, workspaceID)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	downtime, err := p.repos.ListDeviceDowntime(ctx, workspaceID, now)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	changeovers, err := p.repos.ListMaterialChangeovers(ctx, workspaceID)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	competencies, err := p.repos.ListOperatorCompetencies(ctx, workspaceID)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	cooldowns, err := p.cooldowns(ctx, workspaceID, devices)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	labour, err := p.repos.GetLabourRules(ctx, workspaceID)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	pools, err := p.repos.ListDevicePools(ctx, workspaceID)
+	if err != nil {
+		return RepairResult{}, err
+	}
+	caps, err := p.capabilities(ctx, workspaceID, devices)
+	if err != nil {
+		return RepairResult{}, err
+	}
+
+	res := RepairResult{DryRun: req.DryRun}
+	in := repairInput{
+		now:          now,
+		tasks:        tasks,
+		operatorBusy: busy,
+		downtime:     downtime,
+		devices:      devices,
+		changeovers:  NewChangeovers(changeovers),
+		competencies: NewCompetencies(competencies),
+		cooldowns:    cooldowns,
+		labour:       labour,
+		pools:        PoolMembers(pools),
+		capabilities: caps,
+		redo:         map[int64]bool{},
+		interrupted:  map[int64]time.Time{},
+		affected:     map[int64]bool{},
+	}
+	var newDowntime storage.DeviceDowntime
+	switch req.Type {
+	case DisruptionDeviceDown:
+		found := false
+		for _, d := range devices {
+			found = found || d.ID == req.DeviceID
+		}
+		if !found {
+			return RepairResult{}, ErrDisruptionTarget
+		}
+		newDowntime = storage.DeviceDowntime{
+			DeviceID:    req.DeviceID,
+			Start:       from,
+			End:         *req.Until,
+			Reason:      req.Reason,
+			WorkspaceID: workspaceID,
+		}
+		in.downtime = append(in.downtime, newDowntime)
+		for _, t := range tasks {
+			if t.DeviceID != req.DeviceID || t.PlanStart == nil || t.PlanEnd == nil {
+				continue
+			}
+			switch t.Status {
+			case storage.TaskStatusPending:
+				if intersects(*t.PlanStart, *t.PlanEnd, from, *req.Until) {
+					in.affected[t.ID] = true
+				}
+			case storage.TaskStatusInProgress:
+				// Поломка до окончания выполняемого задания прерывает его.
+				if from.Before(runningEnd(t, now)) {
+					in.redo[t.ID] = true
+					in.interrupted[t.ID] = from
+					res.InterruptedIDs = append(res.InterruptedIDs, t.ID)
+				}
+			}
+		}
+	case DisruptionTaskFailed:
+		var target *storage.DeviceTaskRow
+		for i := range tasks {
+			if tasks[i].ID == req.TaskID {
+				target = &tasks[i]
+			}
+		}
+		if target == nil {
+			return RepairResult{}, ErrDisruptionTarget
+		}
+		if !target.Status.CanTransitionTo(storage.TaskStatusFailed) {
+			return RepairResult{}, fmt.Errorf("%w: %s -> %s", storage.ErrInvalidStatusTransition, target.Status, storage.TaskStatusFailed)
+		}
+		in.redo[target.ID] = true
+	}
+
+	res.Moved, res.UnscheduledIDs = repairPlan(in)
+	if req.DryRun {
+		return res, nil
+	}
+
+	if req.Type == DisruptionDeviceDown {
+		if res.DowntimeID, err = p.repos.CreateDeviceDowntime(ctx, newDowntime); err != nil {
+			return RepairResult{}, err
+		}
+	}
+	for _, t := range tasks {
+		if !in.redo[t.ID] {
+			continue
+		}
+		if t.Status != storage.TaskStatusFailed {
+			if err := p.repos.SetDeviceTaskStatus(ctx, t.ID, storage.TaskStatusFailed, now); err != nil {
+				return RepairResult{}, err
+			}
+		}
+		if err := p.repos.SetDeviceTaskStatus(ctx, t.ID, storage.TaskStatusPending, now); err != nil {
+			return RepairResult{}, err
+		}
+		if t.BatchID > 0 {
+			if err := p.repos.SetDeviceTaskBatch(ctx, t.ID, 0); err != nil {
+				return RepairResult{}, err
+			}
+		}
+	}
+	for _, m := range res.Moved {
+		if m.OldDeviceID > 0 {
+			if err := p.repos.AssignDeviceTaskDevice(ctx, m.TaskID, m.DeviceID); err != nil {
+				return RepairResult{}, err
+			}
+		}
+		if err := p.repos.UpdateDeviceTaskPlan(ctx, m.TaskID, m.NewStart, m.NewEnd); err != nil {
+			return RepairResult{}, err
+		}
+	}
+	for _, id := range res.UnscheduledIDs {
+		if err := p.repos.ClearDeviceTaskPlan(ctx, id); err != nil {
+			return RepairResult{}, err
+		}
+	}
+	// Переход на материал сдвинутых заданий берётся из смен материала по плану.
+	if res.OperatorTasks, err = p.syncOperatorTasks(ctx, workspaceID, nil); err != nil {
+		return RepairResult{}, err
+	}
+	return res, nil
+}
+
+type repairInput struct {
+	now          time.Time
+	tasks        []storage.DeviceTaskRow
+	operatorBusy []storage.UserTaskBusy
+	downtime     []storage.DeviceDowntime
+	devices      []storage.Device // тип оборудования и заправленный материал
+	changeovers  Changeovers
+	competencies Competencies
+	cooldowns    *Cooldowns
+	labour       storage.LabourRules // правила рабочего времени операторов
+	pools        map[int64][]int64   // состав пулов оборудования
+	capabilities *Capabilities       // nil — требований к оборудованию нет
+	redo         map[int64]bool      // задания, выполняемые заново
+	interrupted  map[int64]time.Time // прерванные поломкой задания -> её начало
+	affected     map[int64]bool      // запланированные задания, чей слот задет сбоем
+}
+
+type repairItem struct {
+	task   storage.DeviceTaskRow
+	key    time.Time // не раньше этого момента задание может встать
+	dur    time.Duration
+	forced bool // слот обязательно пересчитывается
+}
+
+// repairPlan сдвигает вправо задетые сбоем задания. Задания обходятся в
+// порядке планового старта; незадетое задание остаётся на месте, если его
+// слот не пересекается с уже расставленными и не начинается раньше готовности
+// предыдущей операции маршрута, иначе встаёт в ближайший свободный слот не
+// раньше прежнего старта — на своём оборудовании или на другом из
+// repairDevices, если там закончится раньше. Закреплённые задания не сдвигаются, если их не
+// задел сбой. Задания одного прогона сдвигаются вместе; задание,
+// выполняемое заново, печатается отдельно. После задания оборудование
+// остывает, оператор в это время свободен. Сдвинутый слот начинается с
+// переналадки на материал задания, как в PlanTasks; задание, выполняемое
+// заново, длится с множителем наладки своего оператора. Прерванное будущей
+// поломкой задание выполняется до неё и ставится заново не раньше её начала.
+// Слот оператора, как и в PlanTasks, подчиняется правилам рабочего времени:
+// незадетое задание, которое после сдвигов их нарушает, тоже сдвигается.
+func repairPlan(in repairInput) ([]TaskMove, []int64) {
+	deviceBusy := map[int64][]interval{}
+	for _, d := range in.downtime {
+		deviceBusy[d.DeviceID] = append(deviceBusy[d.DeviceID], interval{start: d.Start, end: d.End})
+	}
+	deviceType := make(map[int64]int64, len(in.devices))
+	for _, d := range in.devices {
+		deviceType[d.ID] = d.DeviceTypeID
+		if d.LoadedMaterialID > 0 {
+			deviceBusy[d.ID] = append(deviceBusy[d.ID], loadedMarker(d.LoadedMaterialID, in.now))
+		}
+	}
+	// Слот в плане уже включает переналадку; при сдвиге она считается заново.
+	planned := planChangeovers(in.devices, in.tasks, in.changeovers)
+	operatorBusy := map[int64][]interval{}
+	for _, b := range in.operatorBusy {
+		operatorBusy[b.OperatorID] = append(operatorBusy[b.OperatorID], interval{start: b.Start, end: b.End})
+	}
+	labour := newLabourLedger(in.labour, in.operatorBusy)
+
+	// Прогон остывает по самому долгому остыванию своих заданий.
+	batchCooldown := map[int64]time.Duration{}
+	for _, t := range in.tasks {
+		if t.BatchID > 0 && !in.redo[t.ID] {
+			batchCooldown[t.BatchID] = max(batchCooldown[t.BatchID], in.cooldowns.After(t.DeviceID, t))
+		}
+	}
+	cooldown := func(t storage.DeviceTaskRow) time.Duration {
+		if t.BatchID > 0 && !in.redo[t.ID] {
+			return batchCooldown[t.BatchID]
+		}
+		return in.cooldowns.After(t.DeviceID, t)
+	}
+
+	jobEnds := map[int64]map[int]time.Time{}
+	var items []repairItem
+	for _, t := range in.tasks {
+		if t.JobID > 0 && !in.redo[t.ID] {
+			switch t.Status {
+			case storage.TaskStatusDone:
+				if end := operationEnd(t); end != nil {
+					setJobEnd(jobEnds, t.JobID, t.JobSeq, *end)
+				}
+			case storage.TaskStatusInProgress:
+				setJobEnd(jobEnds, t.JobID, t.JobSeq, runningEnd(t, in.now))
+			}
+		}
+		switch {
+		case in.redo[t.ID]:
+			key := in.now
+			if at, ok := in.interrupted[t.ID]; ok && at.After(in.now) {
+				// До поломки задание продолжает выполняться на оборудовании.
+				start := in.now
+				switch {
+				case t.ActualStart != nil:
+					start = *t.ActualStart
+				case t.PlanStart != nil:
+					start = *t.PlanStart
+				}
+				reserve(deviceBusy, operatorBusy, labour, t, start, at, 0)
+				key = at
+			}
+			dur := in.competencies.scale(t, t.OperatorID, deviceType[t.DeviceID], t.SetupTime+t.Duration+t.UnloadTime)
+			items = append(items, repairItem{task: t, key: key, dur: dur, forced: true})
+		case t.Status == storage.TaskStatusInProgress && t.PlanStart != nil && t.PlanEnd != nil:
+			start := *t.PlanStart
+			if t.ActualStart != nil {
+				start = *t.ActualStart
+			}
+			iv := interval{start: start, end: runningEnd(t, in.now)}
+			deviceBusy[t.DeviceID] = append(deviceBusy[t.DeviceID], interval{start: iv.start, end: iv.end.Add(cooldown(t)), material: t.MaterialID})
+			if t.NeedOperator {
+				operatorBusy[t.OperatorID] = append(operatorBusy[t.OperatorID], iv)
+				labour.work[t.OperatorID] = append(labour.work[t.OperatorID], iv)
+			}
+		case t.Status == storage.TaskStatusPending && t.Pinned && !in.affected[t.ID] && t.PlanStart != nil && t.PlanEnd != nil:
+			// Закреплённое вручную задание остаётся на месте, остальные его обходят.
+			reserve(deviceBusy, operatorBusy, labour, t, *t.PlanStart, *t.PlanEnd, cooldown(t))
+			if t.JobID > 0 {
+				setJobEnd(jobEnds, t.JobID, t.JobSeq, *t.PlanEnd)
+			}
+		case t.Status == storage.TaskStatusPending && t.PlanStart != nil && t.PlanEnd != nil:
+			lead := t.ID
+			if t.BatchID > 0 {
+				lead = t.BatchID
+			}
+			dur := max(t.PlanEnd.Sub(*t.PlanStart)-planned[lead], 0)
+			items = append(items, repairItem{task: t, key: *t.PlanStart, dur: dur, forced: in.affected[t.ID]})
+		}
+	}
+	sort.Slice(items, func(i, j int) bool {
+		if !items[i].key.Equal(items[j].key) {
+			return items[i].key.Before(items[j].key)
+		}
+		if items[i].forced != items[j].forced {
+			return items[i].forced
+		}
+		return items[i].task.ID < items[j].task.ID
+	})
+
+	var (
+		moves       []TaskMove
+		unscheduled []int64
+	)
+	addMove := func(t storage.DeviceTaskRow, deviceID int64, start, end time.Time) {
+		if deviceID == t.DeviceID && t.PlanStart != nil && t.PlanEnd != nil && start.Equal(*t.PlanStart) && end.Equal(*t.PlanEnd) {
+			return
+		}
+		m := TaskMove{
+			TaskID:         t.ID,
+			Name:           t.Name,
+			DeviceID:       deviceID,
+			OperatorID:     t.OperatorID,
+			OldStart:       t.PlanStart,
+			OldEnd:         t.PlanEnd,
+			NewStart:       start,
+			NewEnd:         end,
+			DeadlineMissed: t.Deadline != nil && end.After(*t.Deadline),
+		}
+		if t.PlanStart != nil {
+			m.ShiftMin = int(start.Sub(*t.PlanStart).Minutes())
+		}
+		if deviceID != t.DeviceID {
+			m.OldDeviceID = t.DeviceID
+		}
+		moves = append(moves, m)
+	}
+	alternatives := deviceAlternatives(in.devices)
+	pools := activePools(in.pools, in.devices)
+	// На чужом оборудовании задание встаёт только в промежутки его плана,
+	// не вытесняя незадетые задания.
+	plannedOn := map[int64][]interval{}
+	for _, it := range items {
+		if t := it.task; !in.redo[t.ID] {
+			plannedOn[t.DeviceID] = append(plannedOn[t.DeviceID], interval{start: *t.PlanStart, end: t.PlanEnd.Add(cooldown(t)), material: t.MaterialID})
+		}
+	}
+	batchSlots := map[int64]interval{}
+	for _, it := range items {
+		t := it.task
+		if t.DeviceID <= 0 || (t.NeedOperator && t.OperatorID <= 0) {
+			unscheduled = append(unscheduled, t.ID)
+			continue
+		}
+		if iv, ok := batchSlots[t.BatchID]; t.BatchID > 0 && !in.redo[t.ID] && ok {
+			// Задание печатается в уже расставленном прогоне.
+			addMove(t, t.DeviceID, iv.start, iv.end)
+			continue
+		}
+		var (
+			opBusy []interval
+			worker int64
+		)
+		if t.NeedOperator {
+			opBusy, worker = operatorBusy[t.OperatorID], t.OperatorID
+		}
+
+		// Операция маршрута не раньше окончания предыдущей плюс пролёживание.
+		var ready time.Time
+		if prev, ok := jobEnds[t.JobID][t.JobSeq-1]; t.JobID > 0 && ok {
+			ready = prev.Add(t.TransferLag)
+		}
+
+		cd := cooldown(t)
+		if !it.forced && !t.PlanStart.Before(ready) &&
+			!overlapsAny(deviceBusy[t.DeviceID], *t.PlanStart, t.PlanEnd.Add(cd)) && !overlapsAny(opBusy, *t.PlanStart, *t.PlanEnd) &&
+			labourAllows(labour, worker, *t.PlanStart, *t.PlanEnd) {
+			reserve(deviceBusy, operatorBusy, labour, t, *t.PlanStart, *t.PlanEnd, cd)
+			if t.JobID > 0 {
+				setJobEnd(jobEnds, t.JobID, t.JobSeq, *t.PlanEnd)
+			}
+			if t.BatchID > 0 {
+				batchSlots[t.BatchID] = interval{start: *t.PlanStart, end: *t.PlanEnd}
+			}
+			continue
+		}
+
+		earliest := it.key
+		if earliest.Before(in.now) {
+			earliest = in.now
+		}
+		if ready.After(earliest) {
+			earliest = ready
+		}
+		// Задание, которое по плану допечатывается ночью, может работать ночью и после сдвига.
+		overnight := runsOvernight(t)
+		// Сдвигу вправо предпочитается другое подходящее оборудование, где
+		// задание закончится раньше; при равном окончании — своё.
+		devices := repairDevices(t, in.redo[t.ID], alternatives, pools, in.capabilities)
+		var (
+			deviceID   int64
+			start, end time.Time
+			ok         bool
+		)
+		for _, deadline := range []*time.Time{t.Deadline, nil} {
+			for _, id := range devices {
+				if id != t.DeviceID && t.NeedOperator && !in.competencies.qualified(t.OperatorID, deviceType[id], t.MinLevel) {
+					continue
+				}
+				dur, devCooldown, busy := it.dur, cd, deviceBusy[id]
+				if id != t.DeviceID {
+					devCooldown = in.cooldowns.After(id, t)
+					busy = append(append([]interval(nil), busy...), plannedOn[id]...)
+					if in.redo[t.ID] {
+						dur = in.competencies.scale(t, t.OperatorID, deviceType[id], t.SetupTime+t.Duration+t.UnloadTime)
+					}
+				}
+				s, e, _, _, _, found := labour.findLabourSlot(worker, earliest, dur, busy, opBusy, deadline, t.MaterialID, in.changeovers, devCooldown, overnight)
+				if found && (!ok || e.Before(end)) {
+					deviceID, start, end, ok = id, s, e, true
+				}
+			}
+			if ok || deadline == nil {
+				// Задание уже в плане: лучше поставить его с опозданием, чем снять.
+				break
+			}
+		}
+		if !ok {
+			unscheduled = append(unscheduled, t.ID)
+			continue
+		}
+		if deviceID != t.DeviceID {
+			cd = in.cooldowns.After(deviceID, t)
+		}
+		placed := t
+		placed.DeviceID = deviceID
+		reserve(deviceBusy, operatorBusy, labour, placed, start, end, cd)
+		if t.JobID > 0 {
+			setJobEnd(jobEnds, t.JobID, t.JobSeq, end)
+		}
+		if t.BatchID > 0 && !in.redo[t.ID] {
+			batchSlots[t.BatchID] = interval{start: start, end: end}
+		}
+		addMove(t, deviceID, start, end)
+	}
+	return moves, unscheduled
+}
+
+// repairDevices — оборудование, на которое можно поставить задание при
+// починке: своё, затем включённое в планирование оборудование пула задания,
+// а без пула — того же типа, удовлетворяющее требованиям задания. Задание
+// прогона остаётся на оборудовании прогона.
+func repairDevices(t storage.DeviceTaskRow, redo bool, alternatives, pools map[int64][]int64, caps *Capabilities) []int64 {
+	if t.BatchID > 0 && !redo {
+		return []int64{t.DeviceID}
+	}
+	others := alternatives[t.DeviceID]
+	if t.DevicePoolID > 0 {
+		others = nil
+		for _, id := range pools[t.DevicePoolID] {
+			if id != t.DeviceID {
+				others = append(others, id)
+			}
+		}
+	}
+	return append([]int64{t.DeviceID}, caps.eligible([]storage.DeviceTaskRow{t}, others)...)
+}
+
+// reserve занимает оборудование слотом и остыванием cooldown после него, а
+// оператора — только слотом, который идёт и в его рабочее время.
+func reserve(deviceBusy, operatorBusy map[int64][]interval, labour *labourLedger, t storage.DeviceTaskRow, start, end time.Time, cooldown time.Duration) {
+	deviceBusy[t.DeviceID] = append(deviceBusy[t.DeviceID], interval{start: start, end: end.Add(cooldown), material: t.MaterialID})
+	if t.NeedOperator {
+		operatorBusy[t.OperatorID] = append(operatorBusy[t.OperatorID], interval{start: start, end: end})
+		labour.work[t.OperatorID] = append(labour.work[t.OperatorID], interval{start: start, end: end})
+	}
+}
+
+// labourAllows — слот оператора не нарушает правил рабочего времени.
+func labourAllows(labour *labourLedger, operatorID int64, start, end time.Time) bool {
+	_, rule := labour.check(operatorID, start, end)
+	return rule == ""
+}
+
+func overlapsAny(busy []interval, start, end time.Time) bool {
+	for _, b := range busy {
+		if intersects(start, end, b.start, b.end) {
+			return true
+		}
+	}
+	return false
+}
+
+// runningEnd — ожидаемое окончание выполняемого задания: плановое, но не раньше now.
+func runningEnd(t storage.DeviceTaskRow, now time.Time) time.Time {
+	if t.PlanEnd == nil || t.PlanEnd.Before(now) {
+		return now
+	}
+	return *t.PlanEnd
+}
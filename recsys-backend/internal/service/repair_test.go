@@ -0,0 +1,175 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+const testOperator = 7
+
+// plannedTask — ожидающее задание оператора testOperator в плане.
+func plannedTask(id, deviceID int64, start, end time.Time) storage.DeviceTaskRow {
+	return storage.DeviceTaskRow{
+		ID:           id,
+		DeviceID:     deviceID,
+		OperatorID:   testOperator,
+		NeedOperator: true,
+		Status:       storage.TaskStatusPending,
+		PlanStart:    slotAt(start),
+		PlanEnd:      slotAt(end),
+	}
+}
+
+// movesByTask — новые слоты сдвинутых заданий.
+func movesByTask(moves []TaskMove) map[int64]TaskMove {
+	res := make(map[int64]TaskMove, len(moves))
+	for _, m := range moves {
+		res[m.TaskID] = m
+	}
+	return res
+}
+
+// printTask — ожидающее задание без оператора на оборудовании 1.
+func printTask(id int64, start, end time.Time) storage.DeviceTaskRow {
+	return storage.DeviceTaskRow{ID: id, DeviceID: 1, Status: storage.TaskStatusPending, PlanStart: slotAt(start), PlanEnd: slotAt(end)}
+}
+
+// Задетое простоем задание сдвигается вправо и вытесняет прогон, который
+// сдвигается целиком и обходит закреплённое задание.
+func TestRepairPlanShiftsRight(t *testing.T) {
+	tasks := []storage.DeviceTaskRow{
+		printTask(1, mar(3, 9), mar(3, 11)),
+		printTask(2, mar(3, 12), mar(3, 14)),
+		printTask(3, mar(3, 12), mar(3, 14)),
+		printTask(4, mar(3, 14), mar(3, 15)),
+	}
+	tasks[1].BatchID, tasks[2].BatchID = 2, 2
+	tasks[3].Pinned = true
+	moves, unscheduled := repairPlan(repairInput{
+		now:      mar(3, 9),
+		tasks:    tasks,
+		downtime: []storage.DeviceDowntime{{DeviceID: 1, Start: mar(3, 9), End: mar(3, 11)}},
+		redo:     map[int64]bool{},
+		affected: map[int64]bool{1: true},
+	})
+	if len(unscheduled) != 0 {
+		t.Fatalf("unscheduled %v", unscheduled)
+	}
+	got := movesByTask(moves)
+	want := map[int64]time.Time{1: mar(3, 11), 2: mar(3, 15), 3: mar(3, 15)}
+	if len(got) != len(want) {
+		t.Errorf("moved %+v, want tasks 1, 2 and 3", moves)
+	}
+	for id, start := range want {
+		if m := got[id]; !m.NewStart.Equal(start) || m.ShiftMin <= 0 {
+			t.Errorf("task %d: got %+v, want start %v", id, m, start)
+		}
+	}
+}
+
+// Поломка в будущем прерывает выполняемое задание: до неё оно занимает
+// оборудование и оператора, а заново ставится только после простоя.
+func TestRepairPlanFutureBreakdown(t *testing.T) {
+	running := plannedTask(1, 1, mar(3, 9), mar(3, 13))
+	running.Status, running.ActualStart, running.Duration = storage.TaskStatusInProgress, slotAt(mar(3, 9)), 4*time.Hour
+	other := plannedTask(2, 2, mar(3, 10), mar(3, 11))
+	moves, unscheduled := repairPlan(repairInput{
+		now:         mar(3, 9),
+		tasks:       []storage.DeviceTaskRow{running, other},
+		downtime:    []storage.DeviceDowntime{{DeviceID: 1, Start: mar(3, 11), End: mar(3, 12)}},
+		redo:        map[int64]bool{1: true},
+		interrupted: map[int64]time.Time{1: mar(3, 11)},
+		affected:    map[int64]bool{},
+	})
+	if len(unscheduled) != 0 {
+		t.Fatalf("unscheduled %v", unscheduled)
+	}
+	got := movesByTask(moves)
+	if m := got[1]; !m.NewStart.Equal(mar(3, 12)) || !m.NewEnd.Equal(mar(3, 16)) {
+		t.Errorf("redo %+v, want 12:00-16:00", m)
+	}
+	// Оператор работает на прерванном задании до 11:00.
+	if m := got[2]; !m.NewStart.Equal(mar(3, 11)) {
+		t.Errorf("operator task %+v, want start 11:00", m)
+	}
+}
+
+// Сдвинутое сбоем задание вытесняет следующее задание оператора, и дневной
+// предел переносит его на следующий день, а не в конец текущего.
+func TestRepairPlanRespectsLabourRules(t *testing.T) {
+	in := repairInput{
+		now: mar(3, 9),
+		tasks: []storage.DeviceTaskRow{
+			plannedTask(1, 1, mar(3, 9), mar(3, 11)),
+			plannedTask(2, 2, mar(3, 12), mar(3, 14)),
+		},
+		downtime: []storage.DeviceDowntime{{DeviceID: 1, Start: mar(3, 9), End: mar(3, 12)}},
+		labour:   storage.LabourRules{MaxDay: 3 * time.Hour},
+		redo:     map[int64]bool{},
+		affected: map[int64]bool{1: true},
+	}
+	moves, unscheduled := repairPlan(in)
+	if len(unscheduled) != 0 {
+		t.Fatalf("unscheduled %v", unscheduled)
+	}
+	got := movesByTask(moves)
+	if m := got[1]; !m.NewStart.Equal(mar(3, 12)) {
+		t.Errorf("task 1 starts at %v, want %v", m.NewStart, mar(3, 12))
+	}
+	if m := got[2]; !m.NewStart.Equal(mar(4, 9)) {
+		t.Errorf("task 2 starts at %v, want %v", m.NewStart, mar(4, 9))
+	}
+}
+
+// Задетое простоем задание переходит на другое оборудование того же типа,
+// если там закончится раньше, а при равном окончании остаётся на своём.
+func TestRepairPlanAlternativeDevice(t *testing.T) {
+	devices := []storage.Device{{ID: 1, DeviceTypeID: 1}, {ID: 2, DeviceTypeID: 1}, {ID: 3, DeviceTypeID: 2}}
+	repair := func(downUntil time.Time) []TaskMove {
+		moves, unscheduled := repairPlan(repairInput{
+			now:      mar(3, 9),
+			tasks:    []storage.DeviceTaskRow{printTask(1, mar(3, 9), mar(3, 11)), {ID: 2, DeviceID: 2, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 10))}},
+			downtime: []storage.DeviceDowntime{{DeviceID: 1, Start: mar(3, 9), End: downUntil}},
+			devices:  devices,
+			redo:     map[int64]bool{},
+			affected: map[int64]bool{1: true},
+		})
+		if len(unscheduled) != 0 {
+			t.Fatalf("unscheduled %v", unscheduled)
+		}
+		return moves
+	}
+
+	moves := repair(mar(3, 13))
+	if len(moves) != 1 {
+		t.Fatalf("moves %+v, want only task 1", moves)
+	}
+	if m := moves[0]; m.TaskID != 1 || m.DeviceID != 2 || m.OldDeviceID != 1 || !m.NewStart.Equal(mar(3, 10)) || m.ShiftMin != 60 {
+		t.Errorf("move %+v, want task 1 on device 2 at 10:00", m)
+	}
+
+	moves = repair(mar(3, 10))
+	if len(moves) != 1 || moves[0].DeviceID != 1 || moves[0].OldDeviceID != 0 || !moves[0].NewStart.Equal(mar(3, 10)) {
+		t.Errorf("moves %+v, want task 1 on its own device at 10:00", moves)
+	}
+}
+
+// Задание на пул переходит только на оборудование пула, даже другого типа.
+func TestRepairPlanPoolDevice(t *testing.T) {
+	task := printTask(1, mar(3, 9), mar(3, 11))
+	task.DevicePoolID = 10
+	moves, _ := repairPlan(repairInput{
+		now:      mar(3, 9),
+		tasks:    []storage.DeviceTaskRow{task},
+		downtime: []storage.DeviceDowntime{{DeviceID: 1, Start: mar(3, 9), End: mar(3, 13)}, {DeviceID: 3, Start: mar(3, 9), End: mar(3, 12)}},
+		devices:  []storage.Device{{ID: 1, DeviceTypeID: 1}, {ID: 2, DeviceTypeID: 1}, {ID: 3, DeviceTypeID: 2}},
+		pools:    map[int64][]int64{10: {1, 3}},
+		redo:     map[int64]bool{},
+		affected: map[int64]bool{1: true},
+	})
+	if len(moves) != 1 || moves[0].DeviceID != 3 || !moves[0].NewStart.Equal(mar(3, 12)) {
+		t.Errorf("moves %+v, want task 1 on pool device 3 at 12:00", moves)
+	}
+}
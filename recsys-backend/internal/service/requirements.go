@@ -0,0 +1,240 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+
+	"recsys-backend/internal/storage"
+)
+
+// ErrInvalidRequirement — требование или значение не подходит к виду характеристики.
+var ErrInvalidRequirement = errors.New("invalid requirement")
+
+// Capabilities — значения характеристик оборудования и требования заданий к нему.
+type Capabilities struct {
+	values map[int64]map[int64]storage.CharacteristicValue // устройство -> характеристика -> значение
+	reqs   map[int64][]storage.TaskRequirement             // задание -> требования
+}
+
+// NewCapabilities собирает значения характеристик каждого устройства: значение
+// устройства перекрывает значение его типа.
+func NewCapabilities(devices []storage.Device, values []storage.CharacteristicValue, reqs []storage.TaskRequirement) *Capabilities {
+	byType := map[int64][]storage.CharacteristicValue{}
+	byDevice := map[int64][]storage.CharacteristicValue{}
+	for _, v := range values {
+		if v.DeviceID > 0 {
+			byDevice[v.DeviceID] = append(byDevice[v.DeviceID], v)
+		} else {
+			byType[v.DeviceTypeID] = append(byType[v.DeviceTypeID], v)
+		}
+	}
+	c := &Capabilities{
+		values: make(map[int64]map[int64]storage.CharacteristicValue, len(devices)),
+		reqs:   map[int64][]storage.TaskRequirement{},
+	}
+	for _, d := range devices {
+		own := map[int64]storage.CharacteristicValue{}
+		for _, v := range byType[d.DeviceTypeID] {
+			own[v.CharacteristicID] = v
+		}
+		for _, v := range byDevice[d.ID] {
+			own[v.CharacteristicID] = v
+		}
+		c.values[d.ID] = own
+	}
+	for _, q := range reqs {
+		c.reqs[q.DeviceTaskID] = append(c.reqs[q.DeviceTaskID], q)
+	}
+	return c
+}
+
+// Unmet — требования задания, которым устройство не удовлетворяет.
+func (c *Capabilities) Unmet(taskID, deviceID int64) []storage.TaskRequirement {
+	if c == nil {
+		return nil
+	}
+	var res []storage.TaskRequirement
+	for _, q := range c.reqs[taskID] {
+		v, ok := c.values[deviceID][q.CharacteristicID]
+		if !ok || !requirementMet(q, v) {
+			res = append(res, q)
+		}
+	}
+	return res
+}
+
+// Satisfies — устройство удовлетворяет всем требованиям задания. Без
+// требований подходит любое.
+func (c *Capabilities) Satisfies(taskID, deviceID int64) bool {
+	return len(c.Unmet(taskID, deviceID)) == 0
+}
+
+// eligible — устройства из devices, удовлетворяющие требованиям всех заданий.
+func (c *Capabilities) eligible(tasks []storage.DeviceTaskRow, devices []int64) []int64 {
+	if c == nil {
+		return devices
+	}
+	var res []int64
+	for _, id := range devices {
+		ok := true
+		for _, t := range tasks {
+			if !c.Satisfies(t.ID, id) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			res = append(res, id)
+		}
+	}
+	return res
+}
+
+func requirementMet(q storage.TaskRequirement, v storage.CharacteristicValue) bool {
+	switch q.Op {
+	case storage.RequirementGTE:
+		return v.Num != nil && q.Num != nil && *v.Num >= *q.Num
+	case storage.RequirementLTE:
+		return v.Num != nil && q.Num != nil && *v.Num <= *q.Num
+	case storage.RequirementEq:
+		if q.Bool != nil {
+			return v.Bool != nil && *v.Bool == *q.Bool
+		}
+		return v.Num != nil && q.Num != nil && *v.Num == *q.Num
+	case storage.RequirementIn:
+		for _, o := range v.Options {
+			if slices.Contains(q.Options, o) {
+				return true
+			}
+		}
+		return false
+	case storage.RequirementCovers:
+		if v.Num == nil || v.NumMax == nil || q.Num == nil {
+			return false
+		}
+		hi := *q.Num
+		if q.NumMax != nil {
+			hi = *q.NumMax
+		}
+		return *v.Num <= *q.Num && hi <= *v.NumMax
+	}
+	return false
+}
+
+// ValidateCharacteristicValue проверяет, что значение задано полями своего вида.
+func ValidateCharacteristicValue(c storage.Characteristic, v storage.CharacteristicValue) error {
+	switch c.Kind {
+	case storage.CharacteristicNumber:
+		if v.Num == nil {
+			return fmt.Errorf("%w: number value requires num", ErrInvalidRequirement)
+		}
+	case storage.CharacteristicRange:
+		if v.Num == nil || v.NumMax == nil || *v.NumMax < *v.Num {
+			return fmt.Errorf("%w: range value requires num <= num_max", ErrInvalidRequirement)
+		}
+	case storage.CharacteristicBool:
+		if v.Bool == nil {
+			return fmt.Errorf("%w: bool value requires bool", ErrInvalidRequirement)
+		}
+	case storage.CharacteristicEnum:
+		if len(v.Options) == 0 {
+			return fmt.Errorf("%w: enum value requires options", ErrInvalidRequirement)
+		}
+		if err := checkOptions(c, v.Options); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ValidateRequirement проверяет, что операция подходит к виду характеристики.
+func ValidateRequirement(c storage.Characteristic, q storage.TaskRequirement) error {
+	switch {
+	case c.Kind == storage.CharacteristicNumber &&
+		(q.Op == storage.RequirementGTE || q.Op == storage.RequirementLTE || q.Op == storage.RequirementEq):
+		if q.Num == nil {
+			return fmt.Errorf("%w: %s requires num", ErrInvalidRequirement, q.Op)
+		}
+	case c.Kind == storage.CharacteristicRange && q.Op == storage.RequirementCovers:
+		if q.Num == nil || (q.NumMax != nil && *q.NumMax < *q.Num) {
+			return fmt.Errorf("%w: covers requires num <= num_max", ErrInvalidRequirement)
+		}
+	case c.Kind == storage.CharacteristicBool && q.Op == storage.RequirementEq:
+		if q.Bool == nil {
+			return fmt.Errorf("%w: eq on bool requires bool", ErrInvalidRequirement)
+		}
+	case c.Kind == storage.CharacteristicEnum && q.Op == storage.RequirementIn:
+		if len(q.Options) == 0 {
+			return fmt.Errorf("%w: in requires options", ErrInvalidRequirement)
+		}
+		return checkOptions(c, q.Options)
+	default:
+		return fmt.Errorf("%w: op %q is not allowed for %s characteristic", ErrInvalidRequirement, q.Op, c.Kind)
+	}
+	return nil
+}
+
+func checkOptions(c storage.Characteristic, options []string) error {
+	if len(c.Options) == 0 {
+		return nil
+	}
+	for _, o := range options {
+		if !slices.Contains(c.Options, o) {
+			return fmt.Errorf("%w: %q is not an option of %s", ErrInvalidRequirement, o, c.Key)
+		}
+	}
+	return nil
+}
+
+// DeviceEligibility — подходит ли устройство заданию по характеристикам.
+type DeviceEligibility struct {
+	DeviceID     int64   `json:"device_id"`
+	Name         string  `json:"name"`
+	DeviceTypeID int64   `json:"device_type_id"`
+	Eligible     bool    `json:"eligible"`
+	Unmet        []int64 `json:"unmet_requirement_ids"` // невыполненные требования
+}
+
+// EligibleDevices — оборудование workspace задания: сначала подходящее по
+// требованиям, затем остальное с перечнем невыполненных требований.
+func (p *Planner) EligibleDevices(ctx context.Context, taskID int64) ([]DeviceEligibility, error) {
+	task, err := p.repos.GetDeviceTask(ctx, taskID)
+	if err != nil {
+		return nil, err
+	}
+	devices, err := p.repos.ListDevices(ctx, task.WorkspaceID)
+	if err != nil {
+		return nil, err
+	}
+	caps, err := p.capabilities(ctx, task.WorkspaceID, devices)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]DeviceEligibility, 0, len(devices))
+	for _, d := range devices {
+		e := DeviceEligibility{DeviceID: d.ID, Name: d.Name, DeviceTypeID: d.DeviceTypeID, Unmet: []int64{}}
+		for _, q := range caps.Unmet(taskID, d.ID) {
+			e.Unmet = append(e.Unmet, q.ID)
+		}
+		e.Eligible = len(e.Unmet) == 0
+		res = append(res, e)
+	}
+	sort.SliceStable(res, func(i, j int) bool { return res[i].Eligible && !res[j].Eligible })
+	return res, nil
+}
+
+// capabilities — характеристики оборудования и требования заданий workspace.
+func (p *Planner) capabilities(ctx context.Context, workspaceID int64, devices []storage.Device) (*Capabilities, error) {
+	values, err := p.repos.ListCharacteristicValues(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	reqs, err := p.repos.ListTaskRequirementsForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return NewCapabilities(devices, values, reqs), nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+
+	"recsys-backend/internal/storage"
+)
+
+func TestRequirementMet(t *testing.T) {
+	num := func(v float64) *float64 { return &v }
+	flag := func(v bool) *bool { return &v }
+	check := func(q storage.TaskRequirement, v storage.CharacteristicValue, want bool) {
+		t.Helper()
+		if got := requirementMet(q, v); got != want {
+			t.Errorf("requirementMet(%s) = %v, want %v", q.Op, got, want)
+		}
+	}
+
+	// Числовые пороги; характеристика без значения требованию не отвечает.
+	minTemp := storage.TaskRequirement{Op: storage.RequirementGTE, Num: num(200)}
+	check(minTemp, storage.CharacteristicValue{Num: num(250)}, true)
+	check(minTemp, storage.CharacteristicValue{Num: num(150)}, false)
+	check(minTemp, storage.CharacteristicValue{}, false)
+	check(storage.TaskRequirement{Op: storage.RequirementLTE, Num: num(0.4)}, storage.CharacteristicValue{Num: num(0.4)}, true)
+
+	// Равенство — для флага и для числа.
+	enclosed := storage.TaskRequirement{Op: storage.RequirementEq, Bool: flag(true)}
+	check(enclosed, storage.CharacteristicValue{Bool: flag(true)}, true)
+	check(enclosed, storage.CharacteristicValue{Bool: flag(false)}, false)
+	check(storage.TaskRequirement{Op: storage.RequirementEq, Num: num(2)}, storage.CharacteristicValue{Num: num(2)}, true)
+
+	// Хотя бы один из допустимых вариантов.
+	check(storage.TaskRequirement{Op: storage.RequirementIn, Options: []string{"PETG", "ABS"}}, storage.CharacteristicValue{Options: []string{"PLA", "ABS"}}, true)
+	check(storage.TaskRequirement{Op: storage.RequirementIn, Options: []string{"PETG"}}, storage.CharacteristicValue{Options: []string{"PLA"}}, false)
+
+	// Диапазон характеристики покрывает точку или весь диапазон требования.
+	nozzle := storage.CharacteristicValue{Num: num(180), NumMax: num(260)}
+	check(storage.TaskRequirement{Op: storage.RequirementCovers, Num: num(220)}, nozzle, true)
+	check(storage.TaskRequirement{Op: storage.RequirementCovers, Num: num(200), NumMax: num(280)}, nozzle, false)
+	check(storage.TaskRequirement{Op: storage.RequirementCovers, Num: num(220)}, storage.CharacteristicValue{Num: num(180)}, false)
+
+	check(storage.TaskRequirement{Op: "like", Num: num(1)}, storage.CharacteristicValue{Num: num(1)}, false)
+}
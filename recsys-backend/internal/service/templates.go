@@ -0,0 +1,155 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// TemplateRun — результат создания заданий по шаблонам.
+type TemplateRun struct {
+	Until   time.Time `json:"until"`       // горизонт: повторения до этого момента включительно
+	Created []int64   `json:"created_ids"` // созданные задания
+	// Skipped — активные шаблоны, для типа оборудования которых нет
+	// оборудования в системе рекомендаций.
+	Skipped []int64 `json:"skipped_template_ids"`
+}
+
+// TemplateOccurrences — моменты повторения шаблона в (from, to]. Повторение
+// «раз в месяц» с 31-го числа в коротком месяце приходится на его последний день.
+func TemplateOccurrences(t storage.TaskTemplate, from, to time.Time) []time.Time {
+	n := max(t.Interval, 1)
+	var res []time.Time
+	for k := 0; ; k++ {
+		var at time.Time
+		switch t.Recurrence {
+		case storage.RecurrenceWeekly:
+			at = t.StartAt.AddDate(0, 0, 7*n*k)
+		case storage.RecurrenceMonthly:
+			at = addMonths(t.StartAt, n*k)
+		default:
+			at = t.StartAt.AddDate(0, 0, n*k)
+		}
+		if at.After(to) || (t.EndAt != nil && at.After(*t.EndAt)) {
+			return res
+		}
+		if at.After(from) {
+			res = append(res, at)
+		}
+	}
+}
+
+// addMonths сдвигает t на months месяцев, не переходя через конец месяца.
+func addMonths(t time.Time, months int) time.Time {
+	first := time.Date(t.Year(), t.Month()+time.Month(months), 1, t.Hour(), t.Minute(), t.Second(), t.Nanosecond(), t.Location())
+	day := min(t.Day(), first.AddDate(0, 1, -1).Day())
+	return first.AddDate(0, 0, day-1)
+}
+
+// GenerateTemplateTasks создаёт задания по активным шаблонам workspace для
+// повторений в (now, now+horizon], которые ещё не созданы. Срок задания —
+// момент повторения; задание адресовано типу оборудования шаблона.
+func (p *Planner) GenerateTemplateTasks(ctx context.Context, workspaceID int64, now time.Time, horizon time.Duration) (TemplateRun, error) {
+	res := TemplateRun{Until: now.Add(horizon), Created: []int64{}, Skipped: []int64{}}
+	templates, err := p.repos.ListTaskTemplates(ctx, workspaceID)
+	if err != nil {
+		return res, err
+	}
+	existing, err := p.repos.ListTemplateOccurrences(ctx, workspaceID)
+	if err != nil {
+		return res, err
+	}
+	devices, err := p.repos.ListDevices(ctx, workspaceID)
+	if err != nil {
+		return res, err
+	}
+
+	var tasks []storage.DeviceTask
+	for _, t := range templates {
+		if !t.Active {
+			continue
+		}
+		due := TemplateOccurrences(t, now, res.Until)
+		if len(due) == 0 {
+			continue
+		}
+		deviceID, ok := templateDevice(devices, t.DeviceTypeID)
+		if !ok {
+			res.Skipped = append(res.Skipped, t.ID)
+			continue
+		}
+		created := make(map[time.Time]bool, len(existing[t.ID]))
+		for _, at := range existing[t.ID] {
+			created[at.UTC()] = true
+		}
+		for _, at := range due {
+			if created[at.UTC()] {
+				continue
+			}
+			tasks = append(tasks, templateTask(t, at, deviceID))
+		}
+	}
+	if len(tasks) == 0 {
+		return res, nil
+	}
+	ids, err := p.repos.CreateTemplateTasks(ctx, tasks)
+	if err != nil {
+		return res, err
+	}
+	res.Created = ids
+	return res, nil
+}
+
+// ApplyTemplate переносит параметры шаблона в его ожидающие задания с
+// повторением позже now. Правило повторения уже созданные задания не
+// затрагивает. Возвращает число изменённых заданий.
+func (p *Planner) ApplyTemplate(ctx context.Context, t storage.TaskTemplate, now time.Time) (int64, error) {
+	devices, err := p.repos.ListDevices(ctx, t.WorkspaceID)
+	if err != nil {
+		return 0, err
+	}
+	deviceID, ok := templateDevice(devices, t.DeviceTypeID)
+	if !ok {
+		return 0, fmt.Errorf("no device of type %d", t.DeviceTypeID)
+	}
+	return p.repos.UpdateFutureTemplateTasks(ctx, t, now, deviceID)
+}
+
+// templateTask — задание повторения at шаблона t на оборудовании deviceID.
+func templateTask(t storage.TaskTemplate, at time.Time, deviceID int64) storage.DeviceTask {
+	addInRecSystem := true
+	return storage.DeviceTask{
+		Name:             fmt.Sprintf("%s — %s", t.Name, at.Format("2006-01-02")),
+		Deadline:         &at,
+		Duration:         t.Duration,
+		SetupTime:        t.SetupTime,
+		UnloadTime:       t.UnloadTime,
+		NeedOperator:     t.NeedOperator,
+		DocNum:           t.DocNum,
+		Status:           storage.TaskStatusPending,
+		AddInRecSystem:   &addInRecSystem,
+		DeviceTaskTypeID: t.DeviceTaskTypeID,
+		WorkspaceID:      t.WorkspaceID,
+		OperatorID:       t.OperatorID,
+		DeviceID:         deviceID,
+		PriorityID:       t.PriorityID,
+		MaterialID:       t.MaterialID,
+		MaterialQty:      t.MaterialQty,
+		TargetTypeID:     t.DeviceTypeID,
+		TemplateID:       t.ID,
+		Occurrence:       &at,
+	}
+}
+
+// templateDevice — первое оборудование типа, включённое в рекомендации: на нём
+// задание стоит до пересчёта плана.
+func templateDevice(devices []storage.Device, deviceTypeID int64) (int64, bool) {
+	for _, d := range devices {
+		if d.DeviceTypeID == deviceTypeID && (d.AddInRecSystem == nil || *d.AddInRecSystem) {
+			return d.ID, true
+		}
+	}
+	return 0, false
+}
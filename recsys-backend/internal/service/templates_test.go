@@ -0,0 +1,63 @@
+package service
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// occurrenceDates — даты повторений шаблона в (from, to] в виде YYYY-MM-DD.
+func occurrenceDates(tpl storage.TaskTemplate, from, to string) []string {
+	var res []string
+	for _, at := range TemplateOccurrences(tpl, at10(from), at10(to)) {
+		res = append(res, at.Format(time.DateOnly))
+	}
+	return res
+}
+
+// at10 — 10:00 UTC дня s.
+func at10(s string) time.Time {
+	d, _ := time.Parse(time.DateOnly, s)
+	return d.Add(10 * time.Hour)
+}
+
+func TestTemplateOccurrencesEveryNDays(t *testing.T) {
+	tpl := storage.TaskTemplate{Recurrence: storage.RecurrenceEveryNDays, Interval: 2, StartAt: at10("2025-01-01")}
+	got := occurrenceDates(tpl, "2025-01-01", "2025-01-07")
+	if want := []string{"2025-01-03", "2025-01-05", "2025-01-07"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestTemplateOccurrencesStopAtEnd(t *testing.T) {
+	end := at10("2025-01-20")
+	tpl := storage.TaskTemplate{Recurrence: storage.RecurrenceWeekly, StartAt: at10("2025-01-01"), EndAt: &end}
+	got := occurrenceDates(tpl, "2024-12-31", "2025-02-28")
+	if want := []string{"2025-01-01", "2025-01-08", "2025-01-15"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+// С 31-го числа ежемесячное повторение в коротком месяце приходится на его
+// последний день и возвращается на 31-е, где оно есть.
+func TestTemplateOccurrencesMonthEnd(t *testing.T) {
+	tpl := storage.TaskTemplate{Recurrence: storage.RecurrenceMonthly, StartAt: at10("2025-01-31")}
+	got := occurrenceDates(tpl, "2025-01-31", "2025-05-31")
+	if want := []string{"2025-02-28", "2025-03-31", "2025-04-30", "2025-05-31"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	tpl.StartAt = at10("2024-01-31")
+	if got := occurrenceDates(tpl, "2024-02-01", "2024-03-01"); !slices.Equal(got, []string{"2024-02-29"}) {
+		t.Errorf("leap year: got %v", got)
+	}
+}
+
+func TestTemplateOccurrencesEmpty(t *testing.T) {
+	tpl := storage.TaskTemplate{Recurrence: storage.RecurrenceMonthly, Interval: 3, StartAt: at10("2025-01-15")}
+	if got := occurrenceDates(tpl, "2025-01-15", "2025-04-14"); len(got) != 0 {
+		t.Errorf("got %v, want none", got)
+	}
+}
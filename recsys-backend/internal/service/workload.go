@@ -0,0 +1,128 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// WorkloadDay — загрузка оператора за календарный день.
+type WorkloadDay struct {
+	Date        string `json:"date"` // ГГГГ-ММ-ДД
+	TaskMin     int    `json:"task_min"`
+	UserTaskMin int    `json:"user_task_min"`
+}
+
+// OperatorWorkload — загрузка оператора за период: задания с его участием
+// (need_operator) и личные поручения.
+type OperatorWorkload struct {
+	OperatorID  int64         `json:"operator_id"`
+	FullName    string        `json:"full_name"`
+	Tasks       int           `json:"tasks"`
+	TaskMin     int           `json:"task_min"`
+	UserTaskMin int           `json:"user_task_min"`
+	TotalMin    int           `json:"total_min"`
+	Days        []WorkloadDay `json:"days"`
+}
+
+// WorkloadReport — загрузка операторов workspace за [from, to).
+type WorkloadReport struct {
+	From      time.Time          `json:"from"`
+	To        time.Time          `json:"to"`
+	Operators []OperatorWorkload `json:"operators"`
+	MeanMin   int                `json:"mean_min"`   // средняя загрузка оператора
+	SpreadMin int                `json:"spread_min"` // разница самой большой и самой малой загрузки
+}
+
+// OperatorWorkload считает загрузку операторов по плану, а у завершённых
+// заданий — по факту. Отменённые задания не учитываются.
+func (p *Planner) OperatorWorkload(ctx context.Context, workspaceID int64, from, to time.Time) (WorkloadReport, error) {
+	operators, err := p.repos.ListOperators(ctx, workspaceID)
+	if err != nil {
+		return WorkloadReport{}, err
+	}
+	tasks, err := p.repos.ListDeviceTasksForWorkspace(ctx, workspaceID)
+	if err != nil {
+		return WorkloadReport{}, err
+	}
+	busy, err := p.repos.ListOperatorBusy(ctx, workspaceID)
+	if err != nil {
+		return WorkloadReport{}, err
+	}
+	return buildWorkloadReport(operators, tasks, busy, from, to), nil
+}
+
+// buildWorkloadReport — отчёт OperatorWorkload по уже загруженным данным.
+func buildWorkloadReport(operators []storage.Operator, tasks []storage.DeviceTaskRow, busy []storage.UserTaskBusy, from, to time.Time) WorkloadReport {
+	work := map[int64][]interval{}
+	counted := map[int64]int{}
+	for _, t := range tasks {
+		if !t.NeedOperator || t.OperatorID <= 0 || t.Status == storage.TaskStatusCancelled {
+			continue
+		}
+		start, end := t.PlanStart, t.PlanEnd
+		if t.ActualStart != nil && t.ActualEnd != nil {
+			start, end = t.ActualStart, t.ActualEnd
+		}
+		if start == nil || end == nil || !intersects(*start, *end, from, to) {
+			continue
+		}
+		work[t.OperatorID] = append(work[t.OperatorID], interval{start: *start, end: *end})
+		counted[t.OperatorID]++
+	}
+	personal := map[int64][]interval{}
+	for _, b := range busy {
+		personal[b.OperatorID] = append(personal[b.OperatorID], interval{start: b.Start, end: b.End})
+	}
+
+	res := WorkloadReport{From: from, To: to, Operators: make([]OperatorWorkload, 0, len(operators))}
+	for _, o := range operators {
+		w := OperatorWorkload{
+			OperatorID:  o.ID,
+			FullName:    o.FullName,
+			Tasks:       counted[o.ID],
+			TaskMin:     int(overlap(work[o.ID], from, to).Minutes()),
+			UserTaskMin: int(overlap(personal[o.ID], from, to).Minutes()),
+			Days:        []WorkloadDay{},
+		}
+		w.TotalMin = w.TaskMin + w.UserTaskMin
+		for day := time.Date(from.Year(), from.Month(), from.Day(), 0, 0, 0, 0, from.Location()); day.Before(to); day = day.AddDate(0, 0, 1) {
+			dayFrom, dayTo := maxTime(day, from), minTime(day.AddDate(0, 0, 1), to)
+			d := WorkloadDay{
+				Date:        day.Format("2006-01-02"),
+				TaskMin:     int(overlap(work[o.ID], dayFrom, dayTo).Minutes()),
+				UserTaskMin: int(overlap(personal[o.ID], dayFrom, dayTo).Minutes()),
+			}
+			if d.TaskMin > 0 || d.UserTaskMin > 0 {
+				w.Days = append(w.Days, d)
+			}
+		}
+		res.Operators = append(res.Operators, w)
+	}
+	if len(res.Operators) > 0 {
+		total := 0
+		highest, lowest := res.Operators[0].TotalMin, res.Operators[0].TotalMin
+		for _, w := range res.Operators {
+			total += w.TotalMin
+			highest, lowest = max(highest, w.TotalMin), min(lowest, w.TotalMin)
+		}
+		res.MeanMin = total / len(res.Operators)
+		res.SpreadMin = highest - lowest
+	}
+	return res
+}
+
+func maxTime(a, b time.Time) time.Time {
+	if a.After(b) {
+		return a
+	}
+	return b
+}
+
+func minTime(a, b time.Time) time.Time {
+	if a.Before(b) {
+		return a
+	}
+	return b
+}
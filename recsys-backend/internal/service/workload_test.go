@@ -0,0 +1,52 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// Загрузка считается по плану, у выполненных заданий — по факту, делится по
+// дням через полночь; отменённые задания и задания без оператора не входят.
+func TestBuildWorkloadReport(t *testing.T) {
+	operators := []storage.Operator{{ID: 5, FullName: "Иванов"}, {ID: 7, FullName: "Петров"}}
+	tasks := []storage.DeviceTaskRow{
+		{ID: 1, OperatorID: 5, NeedOperator: true, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 22)), PlanEnd: slotAt(mar(4, 2))},
+		{ID: 2, OperatorID: 5, NeedOperator: true, Status: storage.TaskStatusDone, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 10)), ActualStart: slotAt(mar(3, 10)), ActualEnd: slotAt(mar(3, 12))},
+		{ID: 3, OperatorID: 7, NeedOperator: true, Status: storage.TaskStatusCancelled, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 12))},
+		{ID: 4, OperatorID: 7, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(3, 9)), PlanEnd: slotAt(mar(3, 12))},
+		// Вне периода.
+		{ID: 5, OperatorID: 7, NeedOperator: true, Status: storage.TaskStatusPending, PlanStart: slotAt(mar(5, 9)), PlanEnd: slotAt(mar(5, 12))},
+	}
+	busy := []storage.UserTaskBusy{{OperatorID: 7, Start: mar(4, 9), End: mar(4, 10)}}
+
+	got := buildWorkloadReport(operators, tasks, busy, mar(3, 0), mar(5, 0))
+	want := []OperatorWorkload{
+		{OperatorID: 5, FullName: "Иванов", Tasks: 2, TaskMin: 360, TotalMin: 360, Days: []WorkloadDay{
+			{Date: "2025-03-03", TaskMin: 240},
+			{Date: "2025-03-04", TaskMin: 120},
+		}},
+		{OperatorID: 7, FullName: "Петров", UserTaskMin: 60, TotalMin: 60, Days: []WorkloadDay{
+			{Date: "2025-03-04", UserTaskMin: 60},
+		}},
+	}
+	if !reflect.DeepEqual(got.Operators, want) {
+		t.Errorf("operators\n%+v\nwant\n%+v", got.Operators, want)
+	}
+	if got.MeanMin != 210 || got.SpreadMin != 300 {
+		t.Errorf("mean %d, spread %d, want 210 and 300", got.MeanMin, got.SpreadMin)
+	}
+}
+
+// Без операторов средняя загрузка и разброс нулевые.
+func TestBuildWorkloadReportEmpty(t *testing.T) {
+	got := buildWorkloadReport(nil, nil, nil, mar(3, 0), mar(4, 0))
+	if len(got.Operators) != 0 || got.MeanMin != 0 || got.SpreadMin != 0 {
+		t.Errorf("report %+v", got)
+	}
+	if !got.From.Equal(mar(3, 0)) || !got.To.Equal(time.Date(2025, 3, 4, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("bounds %v – %v", got.From, got.To)
+	}
+}
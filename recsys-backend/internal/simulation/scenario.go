@@ -0,0 +1,196 @@
+package simulation
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math/rand/v2"
+	"os"
+	"time"
+
+	"recsys-backend/internal/service"
+	"recsys-backend/internal/storage"
+)
+
+// Scenario — исходное состояние цеха для прогона симуляции: снимок рабочего
+// пространства (GET /api/workspaces/{id}/snapshot) или сгенерированный сценарий.
+type Scenario struct {
+	Start        time.Time                       `json:"start"`
+	Devices      []storage.Device                `json:"devices"`
+	DeviceTypes  []storage.DeviceType            `json:"device_types"`
+	TaskTypes    []storage.DeviceTaskType        `json:"task_types"`
+	Operators    []storage.Operator              `json:"operators"`
+	Tasks        []storage.DeviceTaskRow         `json:"tasks"`
+	OperatorBusy []storage.UserTaskBusy          `json:"operator_busy"`
+	History      []storage.CompletedTaskDuration `json:"history"`
+	Changeovers  []storage.MaterialChangeover    `json:"changeovers"`
+	Pools        []storage.DevicePool            `json:"device_pools"`
+	Competencies []storage.OperatorCompetency    `json:"competencies"`
+	Labour       storage.LabourRules             `json:"labour_rules"`
+	Energy       storage.EnergySchedule          `json:"energy"`
+	Orders       []storage.CustomerOrder         `json:"orders"`
+	Aging        storage.PriorityAging           `json:"priority_aging"`
+	Priorities   []storage.Priority              `json:"priorities"`
+}
+
+// LoadScenario читает сценарий из JSON-файла.
+func LoadScenario(path string) (Scenario, error) {
+	var sc Scenario
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return sc, err
+	}
+	if err := json.Unmarshal(data, &sc); err != nil {
+		return sc, fmt.Errorf("parse scenario %s: %w", path, err)
+	}
+	if sc.Start.IsZero() {
+		sc.Start = time.Now()
+	}
+	return sc, nil
+}
+
+// Snapshot снимает текущее состояние рабочего пространства: оборудование и его
+// типы, типы заданий, операторов, ожидающие и выполняемые задания, занятость операторов, историю
+// фактических длительностей, матрицу переналадки, пулы оборудования,
+// компетенции операторов, правила их рабочего времени, тарифы электроэнергии,
+// заказы клиентов и старение приоритета.
+func Snapshot(ctx context.Context, repos *storage.Repos, workspaceID int64) (Scenario, error) {
+	sc := Scenario{Start: time.Now()}
+	var err error
+	if sc.Devices, err = repos.ListDevices(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.DeviceTypes, err = repos.ListDeviceTypes(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.TaskTypes, err = repos.ListDeviceTaskTypes(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Operators, err = repos.ListOperators(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Tasks, err = repos.ListDeviceTasksForWorkspace(ctx, workspaceID, storage.TaskStatusPending, storage.TaskStatusInProgress); err != nil {
+		return sc, err
+	}
+	if sc.OperatorBusy, err = repos.ListOperatorBusy(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.History, err = repos.ListCompletedTaskDurations(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Changeovers, err = repos.ListMaterialChangeovers(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Pools, err = repos.ListDevicePools(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Competencies, err = repos.ListOperatorCompetencies(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Labour, err = repos.GetLabourRules(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Energy, err = repos.GetEnergySchedule(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Orders, err = repos.ListCustomerOrders(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Aging, err = repos.GetPriorityAging(ctx, workspaceID); err != nil {
+		return sc, err
+	}
+	if sc.Priorities, err = repos.ListPriorities(ctx); err != nil {
+		return sc, err
+	}
+	return sc, nil
+}
+
+// GenerateConfig — параметры синтетического сценария.
+type GenerateConfig struct {
+	Start       time.Time
+	Devices     int
+	DeviceTypes int
+	Operators   int
+	Backlog     int // заданий в очереди на момент старта
+}
+
+// GenerateScenario строит сценарий со случайным парком оборудования и очередью
+// заданий. Задания получают один из трёх приоритетов со штрафом за час опоздания.
+func GenerateScenario(cfg GenerateConfig, rng *rand.Rand) Scenario {
+	if cfg.Start.IsZero() {
+		cfg.Start = time.Now()
+	}
+	if cfg.DeviceTypes <= 0 {
+		cfg.DeviceTypes = 1
+	}
+	sc := Scenario{Start: cfg.Start, Priorities: generatedPriorities}
+	for i := 1; i <= cfg.Devices; i++ {
+		sc.Devices = append(sc.Devices, storage.Device{
+			ID:           int64(i),
+			Name:         fmt.Sprintf("Принтер %d", i),
+			DeviceTypeID: int64((i-1)%cfg.DeviceTypes + 1),
+		})
+	}
+	for i := 1; i <= cfg.Operators; i++ {
+		sc.Operators = append(sc.Operators, storage.Operator{
+			ID:       int64(i),
+			FullName: fmt.Sprintf("Оператор %d", i),
+		})
+	}
+	gen := NewTaskGenerator(sc)
+	for i := 0; i < cfg.Backlog; i++ {
+		sc.Tasks = append(sc.Tasks, gen.Next(rng, cfg.Start))
+	}
+	return sc
+}
+
+// generatedPriorities — приоритеты сгенерированных заданий: чем меньше ID, тем
+// срочнее задание и дороже его опоздание.
+var generatedPriorities = []storage.Priority{
+	{ID: 1, Name: "Высокий", PenaltyRate: 50},
+	{ID: 2, Name: "Средний", PenaltyRate: 20},
+	{ID: 3, Name: "Низкий", PenaltyRate: 5},
+}
+
+// TaskGenerator порождает новые задания (заказы) на оборудовании сценария.
+type TaskGenerator struct {
+	devices   []storage.Device
+	operators []storage.Operator
+	nextID    int64
+}
+
+func NewTaskGenerator(sc Scenario) *TaskGenerator {
+	g := &TaskGenerator{devices: sc.Devices, operators: sc.Operators, nextID: 1}
+	for _, t := range sc.Tasks {
+		if t.ID >= g.nextID {
+			g.nextID = t.ID + 1
+		}
+	}
+	return g
+}
+
+// Next создаёт задание, поступившее в момент now: печать 0.5–6 ч, дедлайн через
+// 1–5 рабочих дней, оператор нужен в 70% случаев.
+func (g *TaskGenerator) Next(rng *rand.Rand, now time.Time) storage.DeviceTaskRow {
+	t := storage.DeviceTaskRow{
+		ID:               g.nextID,
+		Name:             fmt.Sprintf("Заказ %d", g.nextID),
+		Duration:         time.Duration(30+rng.IntN(331)) * time.Minute,
+		SetupTime:        time.Duration(10+rng.IntN(31)) * time.Minute,
+		UnloadTime:       time.Duration(5+rng.IntN(16)) * time.Minute,
+		NeedOperator:     rng.Float64() < 0.7,
+		Status:           storage.TaskStatusPending,
+		PriorityID:       int64(1 + rng.IntN(3)),
+		DeviceTaskTypeID: 1,
+	}
+	g.nextID++
+	deadline := service.AlignToWorkday(now).AddDate(0, 0, 1+rng.IntN(5))
+	t.Deadline = &deadline
+	if len(g.devices) > 0 {
+		t.DeviceID = g.devices[rng.IntN(len(g.devices))].ID
+	}
+	if len(g.operators) > 0 {
+		t.OperatorID = g.operators[rng.IntN(len(g.operators))].ID
+	}
+	return t
+}
@@ -0,0 +1,750 @@
+// Package simulation — дискретно-событийная модель цеха для офлайн-сравнения
+// политик планирования. Работает целиком в памяти и вызывает тот же
+// service.PlanTasks, что и живая система при пересчёте плана.
+package simulation
+
+import (
+	"container/heap"
+	"errors"
+	"fmt"
+	"math"
+	"math/rand/v2"
+	"sort"
+	"time"
+
+	"recsys-backend/internal/service"
+	"recsys-backend/internal/storage"
+)
+
+// Политики пересчёта плана.
+const (
+	ReplanOnEvent = "event" // после каждого сбоя, брака и нового заказа
+	ReplanDaily   = "daily" // раз в сутки, в начале рабочего дня
+)
+
+type Config struct {
+	Horizon        time.Duration // длительность моделируемого периода
+	Seed           uint64
+	DurationSpread float64       // коэффициент вариации фактической длительности, если нет истории
+	FailureRate    float64       // вероятность брака задания при завершении
+	MTBF           time.Duration // средняя наработка оборудования на отказ; 0 — без поломок
+	MTTR           time.Duration // среднее время ремонта
+	OrdersPerDay   float64       // интенсивность поступления новых заказов
+	DurationMode   string        // service.DurationModeNominal | service.DurationModeP80
+	Replan         string        // ReplanOnEvent | ReplanDaily
+}
+
+type DeviceKPI struct {
+	DeviceID    int64   `json:"device_id"`
+	Name        string  `json:"name"`
+	BusyMin     int     `json:"busy_min"`
+	DownMin     int     `json:"down_min"`
+	Breakdowns  int     `json:"breakdowns"`
+	Utilization float64 `json:"utilization"` // занятость / рабочее время периода
+}
+
+type Result struct {
+	Start           time.Time   `json:"start"`
+	End             time.Time   `json:"end"`
+	DurationMode    string      `json:"duration_mode"`
+	Replan          string      `json:"replan"`
+	Completed       int         `json:"completed"`
+	Unfinished      int         `json:"unfinished"`
+	Failures        int         `json:"failures"`
+	Breakdowns      int         `json:"breakdowns"`
+	Arrivals        int         `json:"arrivals"`
+	Replans         int         `json:"replans"`
+	OnTimeRate      float64     `json:"on_time_rate"` // завершённые в срок / (завершённые + просроченные незавершённые) с дедлайном
+	LateTasks       int         `json:"late_tasks"`
+	MeanLatenessMin float64     `json:"mean_lateness_min"`
+	LatePenalty     float64     `json:"late_penalty"`      // штраф за опоздания по ставкам заданий и приоритетов
+	AvgFlowTimeMin  float64     `json:"avg_flow_time_min"` // от поступления до завершения
+	MakespanMin     float64     `json:"makespan_min"`      // от начала периода до последнего завершения
+	Utilization     float64     `json:"utilization"`
+	Devices         []DeviceKPI `json:"devices"`
+}
+
+var ErrInvalidConfig = errors.New("invalid simulation config")
+
+type eventKind int
+
+const (
+	evDispatch eventKind = iota
+	evFinish
+	evBreakdown
+	evRepair
+	evArrival
+	evReplan
+)
+
+type event struct {
+	at   time.Time
+	kind eventKind
+	id   int64 // задание или оборудование
+	gen  int   // поколение запуска задания: завершение прерванного запуска игнорируется
+	seq  int
+}
+
+type eventQueue []event
+
+func (q eventQueue) Len() int { return len(q) }
+func (q eventQueue) Less(i, j int) bool {
+	if !q[i].at.Equal(q[j].at) {
+		return q[i].at.Before(q[j].at)
+	}
+	return q[i].seq < q[j].seq
+}
+func (q eventQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *eventQueue) Push(x any)   { *q = append(*q, x.(event)) }
+func (q *eventQueue) Pop() any {
+	old := *q
+	ev := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return ev
+}
+
+type simTask struct {
+	row      storage.DeviceTaskRow
+	release  time.Time
+	started  time.Time
+	expected time.Duration // оценка планировщика для запущенного задания
+	doneAt   time.Time
+	gen      int
+	plate    []*simTask // задания, запущенные в одном прогоне с этим
+}
+
+type simDevice struct {
+	info       storage.Device
+	running    int64 // ID выполняемого задания, 0 — простаивает
+	material   int64 // заправленный материал: исходный или последнего запущенного задания
+	down       bool
+	downSince  time.Time
+	repairAt   time.Time
+	coolUntil  time.Time // остывает после последнего запуска до этого момента
+	busy       time.Duration
+	downTime   time.Duration
+	breakdowns int
+}
+
+type simulator struct {
+	cfg   Config
+	rng   *rand.Rand
+	now   time.Time
+	end   time.Time
+	queue eventQueue
+	seq   int
+
+	tasks     map[int64]*simTask
+	taskOrder []int64
+	jobOps    map[int64]map[int]int64 // маршрут -> шаг -> задание
+	devices   map[int64]*simDevice
+	deviceIDs []int64
+	inventory []storage.Device
+	capacity  map[int64]int // тип оборудования -> вместимость платформы
+	changes   service.Changeovers
+	pools     map[int64][]int64 // пул -> оборудование
+	skills    service.Competencies
+	labour    storage.LabourRules
+	energy    *service.Energy
+	orders    map[int64]storage.CustomerOrder
+	aging     *service.Aging
+	penalties service.PenaltyRates
+	cooldowns *service.Cooldowns
+	operator  map[int64]int64 // оператор -> выполняемое задание
+	userBusy  []storage.UserTaskBusy
+	scheduled map[int64]bool // моменты уже запланированных evDispatch (UnixNano)
+
+	generator  *TaskGenerator
+	model      service.DurationModel
+	deviceType map[int64]int64
+	sigma      float64
+
+	res Result
+}
+
+// Run прогоняет сценарий на горизонте cfg.Horizon и возвращает KPI.
+func Run(sc Scenario, cfg Config) (Result, error) {
+	if cfg.Horizon <= 0 {
+		return Result{}, fmt.Errorf("%w: horizon must be positive", ErrInvalidConfig)
+	}
+	if cfg.FailureRate < 0 || cfg.FailureRate >= 1 {
+		return Result{}, fmt.Errorf("%w: failure rate must be in [0, 1)", ErrInvalidConfig)
+	}
+	if cfg.DurationSpread < 0 || cfg.OrdersPerDay < 0 || cfg.MTBF < 0 || cfg.MTTR < 0 {
+		return Result{}, fmt.Errorf("%w: negative parameter", ErrInvalidConfig)
+	}
+	if cfg.MTBF > 0 && cfg.MTTR <= 0 {
+		return Result{}, fmt.Errorf("%w: mttr must be positive when mtbf is set", ErrInvalidConfig)
+	}
+	if cfg.DurationMode == "" {
+		cfg.DurationMode = service.DurationModeNominal
+	}
+	if cfg.DurationMode != service.DurationModeNominal && cfg.DurationMode != service.DurationModeP80 {
+		return Result{}, fmt.Errorf("%w: unknown duration mode %q", ErrInvalidConfig, cfg.DurationMode)
+	}
+	if cfg.Replan == "" {
+		cfg.Replan = ReplanOnEvent
+	}
+	if cfg.Replan != ReplanOnEvent && cfg.Replan != ReplanDaily {
+		return Result{}, fmt.Errorf("%w: unknown replan policy %q", ErrInvalidConfig, cfg.Replan)
+	}
+
+	s := &simulator{
+		cfg:        cfg,
+		rng:        rand.New(rand.NewPCG(cfg.Seed, cfg.Seed>>1)),
+		now:        sc.Start,
+		end:        sc.Start.Add(cfg.Horizon),
+		tasks:      map[int64]*simTask{},
+		jobOps:     map[int64]map[int]int64{},
+		devices:    map[int64]*simDevice{},
+		inventory:  sc.Devices,
+		capacity:   map[int64]int{},
+		changes:    service.NewChangeovers(sc.Changeovers),
+		pools:      service.PoolMembers(sc.Pools),
+		skills:     service.NewCompetencies(sc.Competencies),
+		labour:     sc.Labour,
+		energy:     service.NewEnergy(sc.Energy, sc.Devices, sc.DeviceTypes),
+		orders:     service.OrderIndex(sc.Orders),
+		aging:      service.NewAging(sc.Aging, sc.Priorities),
+		penalties:  service.NewPenaltyRates(sc.Priorities),
+		cooldowns:  service.NewCooldowns(sc.Devices, sc.DeviceTypes, sc.TaskTypes),
+		operator:   map[int64]int64{},
+		userBusy:   sc.OperatorBusy,
+		scheduled:  map[int64]bool{},
+		generator:  NewTaskGenerator(sc),
+		model:      service.BuildDurationModel(sc.History),
+		deviceType: map[int64]int64{},
+		sigma:      math.Sqrt(math.Log(1 + cfg.DurationSpread*cfg.DurationSpread)),
+	}
+	s.res = Result{Start: s.now, End: s.end, DurationMode: cfg.DurationMode, Replan: cfg.Replan}
+	s.load(sc)
+	s.replan()
+	s.dispatch()
+	for s.queue.Len() > 0 {
+		ev := heap.Pop(&s.queue).(event)
+		if ev.at.After(s.end) {
+			break
+		}
+		s.now = ev.at
+		s.handle(ev)
+	}
+	s.now = s.end
+	return s.collect(), nil
+}
+
+func (s *simulator) load(sc Scenario) {
+	for _, d := range sc.Devices {
+		s.devices[d.ID] = &simDevice{info: d, material: d.LoadedMaterialID}
+		s.deviceIDs = append(s.deviceIDs, d.ID)
+		s.deviceType[d.ID] = d.DeviceTypeID
+		if s.cfg.MTBF > 0 {
+			s.push(event{at: s.now.Add(s.exp(s.cfg.MTBF)), kind: evBreakdown, id: d.ID})
+		}
+	}
+	sort.Slice(s.deviceIDs, func(i, j int) bool { return s.deviceIDs[i] < s.deviceIDs[j] })
+	for _, dt := range sc.DeviceTypes {
+		if dt.PlateCapacity > 0 {
+			s.capacity[dt.ID] = dt.PlateCapacity
+		}
+	}
+
+	for _, row := range sc.Tasks {
+		if row.Status != storage.TaskStatusPending && row.Status != storage.TaskStatusInProgress {
+			continue
+		}
+		t := &simTask{row: row, release: s.now}
+		s.addTask(t)
+		d := s.devices[row.DeviceID]
+		if row.Status != storage.TaskStatusInProgress || d == nil || d.running != 0 {
+			t.row.Status = storage.TaskStatusPending
+			continue
+		}
+		// Уже запущенное задание продолжается с учётом прошедшего времени.
+		started := s.now
+		if row.ActualStart != nil && row.ActualStart.Before(s.now) {
+			started = *row.ActualStart
+		}
+		t.started = started
+		t.gen++
+		t.expected = s.estimate(t.row)
+		d.running = row.ID
+		d.material = row.MaterialID
+		if row.NeedOperator {
+			s.operator[row.OperatorID] = row.ID
+		}
+		finish := started.Add(s.sampleDuration(t.row))
+		if finish.Before(s.now) {
+			finish = s.now
+		}
+		s.push(event{at: finish, kind: evFinish, id: row.ID, gen: t.gen})
+	}
+
+	if s.cfg.OrdersPerDay > 0 {
+		s.push(event{at: s.now.Add(s.exp(s.arrivalGap())), kind: evArrival})
+	}
+	if s.cfg.Replan == ReplanDaily {
+		s.push(event{at: nextWorkdayStart(s.now), kind: evReplan})
+	}
+}
+
+func (s *simulator) handle(ev event) {
+	switch ev.kind {
+	case evDispatch:
+		delete(s.scheduled, ev.at.UnixNano())
+	case evFinish:
+		if s.complete(ev.id, ev.gen) {
+			s.disrupted()
+		}
+	case evBreakdown:
+		s.breakDown(ev.id)
+		s.disrupted()
+	case evRepair:
+		s.repair(ev.id)
+		s.disrupted()
+	case evArrival:
+		s.addTask(&simTask{row: s.generator.Next(s.rng, s.now), release: s.now})
+		s.res.Arrivals++
+		s.push(event{at: s.now.Add(s.exp(s.arrivalGap())), kind: evArrival})
+		s.disrupted()
+	case evReplan:
+		s.replan()
+		s.push(event{at: nextWorkdayStart(s.now), kind: evReplan})
+	}
+	s.dispatch()
+}
+
+// disrupted пересчитывает план, если политика реагирует на события.
+func (s *simulator) disrupted() {
+	if s.cfg.Replan == ReplanOnEvent {
+		s.replan()
+	}
+}
+
+// replan передаёт планировщику ожидающие задания; запущенные задания и
+// оборудование в ремонте остаются занятыми.
+func (s *simulator) replan() {
+	in := service.PlanInput{
+		Now:           s.now,
+		OperatorBusy:  s.userBusy,
+		PlateCapacity: s.capacity,
+		Changeovers:   s.changes,
+		Pools:         s.pools,
+		Competencies:  s.skills,
+		Labour:        s.labour,
+		Energy:        s.energy,
+		Orders:        s.orders,
+		Aging:         s.aging,
+		Duration:      s.estimate,
+	}
+	// Планировщик видит материал, заправленный в оборудование к этому моменту.
+	for _, info := range s.inventory {
+		if d := s.devices[info.ID]; d != nil {
+			info.LoadedMaterialID = d.material
+		}
+		in.Devices = append(in.Devices, info)
+	}
+	for _, id := range s.taskOrder {
+		t := s.tasks[id]
+		switch t.row.Status {
+		case storage.TaskStatusDone:
+			// Завершённые операции маршрута задают готовность следующих.
+			if t.row.JobID > 0 {
+				in.Fixed = append(in.Fixed, t.row)
+			}
+		case storage.TaskStatusPending:
+			// Закреплённое вручную задание остаётся на своём месте в плане.
+			if t.row.Pinned && t.row.PlanStart != nil && t.row.PlanEnd != nil {
+				in.Fixed = append(in.Fixed, t.row)
+				continue
+			}
+			in.Tasks = append(in.Tasks, t.row)
+		case storage.TaskStatusInProgress:
+			row := t.row
+			start, end := t.started, t.started.Add(t.expected)
+			if end.Before(s.now) {
+				end = s.now
+			}
+			row.PlanStart, row.PlanEnd = &start, &end
+			in.Fixed = append(in.Fixed, row)
+		}
+	}
+	for _, id := range s.deviceIDs {
+		d := s.devices[id]
+		if d.down {
+			in.Downtime = append(in.Downtime, storage.DeviceDowntime{DeviceID: id, Start: s.now, End: d.repairAt})
+		}
+		// Остывающее оборудование планировщик видит занятым до конца остывания.
+		if d.coolUntil.After(s.now) {
+			in.Downtime = append(in.Downtime, storage.DeviceDowntime{DeviceID: id, Start: s.now, End: d.coolUntil})
+		}
+	}
+	in.Cooldowns = s.cooldowns
+
+	out := service.PlanTasks(in)
+	for _, slot := range out.Slots {
+		t := s.tasks[slot.TaskID]
+		start, end := slot.Start, slot.End
+		t.row.PlanStart, t.row.PlanEnd = &start, &end
+		t.row.DeviceID = slot.DeviceID
+		t.row.BatchID = slot.BatchID
+		if slot.OperatorID > 0 {
+			t.row.OperatorID = slot.OperatorID
+		}
+	}
+	for _, id := range out.Unscheduled {
+		t := s.tasks[id]
+		t.row.PlanStart, t.row.PlanEnd = nil, nil
+		t.row.BatchID = 0
+	}
+	s.res.Replans++
+}
+
+// dispatch запускает на свободном и остывшем оборудовании очередное по плану
+// задание, если наступило его плановое время, идёт рабочая смена и свободен
+// оператор.
+func (s *simulator) dispatch() {
+	for _, id := range s.deviceIDs {
+		d := s.devices[id]
+		if d.down || d.running != 0 || d.coolUntil.After(s.now) {
+			continue
+		}
+		t := s.nextTask(id)
+		if t == nil {
+			continue
+		}
+		if t.row.PlanStart.After(s.now) {
+			s.scheduleDispatch(*t.row.PlanStart)
+			continue
+		}
+		if ready, ok := s.predecessorReady(t); !ok {
+			continue
+		} else if ready.After(s.now) {
+			s.scheduleDispatch(ready)
+			continue
+		}
+		if aligned := service.AlignToWorkday(s.now); aligned.After(s.now) {
+			s.scheduleDispatch(aligned)
+			continue
+		}
+		if t.row.NeedOperator && !s.operatorFree(t.row.OperatorID) {
+			continue
+		}
+		s.start(t, d)
+	}
+}
+
+func (s *simulator) nextTask(deviceID int64) *simTask {
+	var next *simTask
+	for _, id := range s.taskOrder {
+		t := s.tasks[id]
+		if t.row.Status != storage.TaskStatusPending || t.row.DeviceID != deviceID || t.row.PlanStart == nil {
+			continue
+		}
+		if next == nil || t.row.PlanStart.Before(*next.row.PlanStart) {
+			next = t
+		}
+	}
+	return next
+}
+
+func (s *simulator) operatorFree(operatorID int64) bool {
+	if s.operator[operatorID] != 0 {
+		return false
+	}
+	for _, b := range s.userBusy {
+		if b.OperatorID == operatorID && !s.now.Before(b.Start) && s.now.Before(b.End) {
+			s.scheduleDispatch(b.End)
+			return false
+		}
+	}
+	return true
+}
+
+// start запускает задание, а с ним — остальные задания его прогона,
+// стоящие в плане на этом же оборудовании.
+func (s *simulator) start(t *simTask, d *simDevice) {
+	t.plate = nil
+	if t.row.BatchID > 0 {
+		for _, id := range s.taskOrder {
+			m := s.tasks[id]
+			if m != t && m.row.BatchID == t.row.BatchID && m.row.Status == storage.TaskStatusPending &&
+				m.row.DeviceID == d.info.ID && m.row.PlanStart != nil {
+				t.plate = append(t.plate, m)
+			}
+		}
+	}
+	// Переналадка на материал задания выполняется перед печатью.
+	change := s.changes.Between(d.material, t.row.MaterialID)
+	if t.row.MaterialID != 0 {
+		d.material = t.row.MaterialID
+	}
+	dur := s.sampleDuration(t.row)
+	t.expected = s.estimate(t.row)
+	for _, m := range t.plate {
+		m.row.Status = storage.TaskStatusInProgress
+		m.started = s.now
+		m.gen++
+		dur = max(dur, s.sampleDuration(m.row))
+		t.expected = max(t.expected, s.estimate(m.row))
+	}
+	t.expected += change
+	for _, m := range t.plate {
+		m.expected = t.expected
+	}
+	t.row.Status = storage.TaskStatusInProgress
+	t.started = s.now
+	t.gen++
+	d.running = t.row.ID
+	if t.row.NeedOperator {
+		s.operator[t.row.OperatorID] = t.row.ID
+	}
+	s.push(event{at: s.now.Add(change + dur), kind: evFinish, id: t.row.ID, gen: t.gen})
+}
+
+// complete завершает запуск задания и его прогона, после чего оборудование
+// остывает; true — хотя бы одно задание ушло в брак и вернулось в очередь.
+func (s *simulator) complete(taskID int64, gen int) bool {
+	t := s.tasks[taskID]
+	if t == nil || t.gen != gen || t.row.Status != storage.TaskStatusInProgress {
+		return false
+	}
+	s.release(t)
+	if d := s.devices[t.row.DeviceID]; d != nil {
+		rows := []storage.DeviceTaskRow{t.row}
+		for _, m := range t.plate {
+			rows = append(rows, m.row)
+		}
+		if cooldown := s.cooldowns.After(d.info.ID, rows...); cooldown > 0 {
+			d.coolUntil = s.now.Add(cooldown)
+			s.scheduleDispatch(d.coolUntil)
+		}
+	}
+	failed := false
+	for _, m := range append([]*simTask{t}, t.plate...) {
+		if s.rng.Float64() < s.cfg.FailureRate {
+			s.res.Failures++
+			s.requeue(m)
+			failed = true
+			continue
+		}
+		m.row.Status = storage.TaskStatusDone
+		m.doneAt = s.now
+		doneAt := s.now
+		m.row.ActualEnd = &doneAt
+	}
+	t.plate = nil
+	return failed
+}
+
+func (s *simulator) breakDown(deviceID int64) {
+	d := s.devices[deviceID]
+	d.down = true
+	d.downSince = s.now
+	d.repairAt = s.now.Add(s.exp(s.cfg.MTTR))
+	d.breakdowns++
+	s.res.Breakdowns++
+	if d.running != 0 {
+		t := s.tasks[d.running]
+		s.release(t)
+		for _, m := range append([]*simTask{t}, t.plate...) {
+			s.requeue(m)
+		}
+		t.plate = nil
+	}
+	s.push(event{at: d.repairAt, kind: evRepair, id: deviceID})
+}
+
+func (s *simulator) repair(deviceID int64) {
+	d := s.devices[deviceID]
+	d.down = false
+	d.downTime += s.now.Sub(d.downSince)
+	s.push(event{at: s.now.Add(s.exp(s.cfg.MTBF)), kind: evBreakdown, id: deviceID})
+}
+
+// release освобождает оборудование и оператора и учитывает занятость.
+func (s *simulator) release(t *simTask) {
+	if d := s.devices[t.row.DeviceID]; d != nil && d.running == t.row.ID {
+		d.busy += s.now.Sub(s.busySince(t))
+		d.running = 0
+	}
+	if s.operator[t.row.OperatorID] == t.row.ID {
+		delete(s.operator, t.row.OperatorID)
+	}
+}
+
+// requeue возвращает задание в ожидание без места в плане — его переставит
+// ближайший пересчёт.
+func (s *simulator) requeue(t *simTask) {
+	t.row.Status = storage.TaskStatusPending
+	t.row.PlanStart, t.row.PlanEnd = nil, nil
+	t.row.BatchID = 0
+	t.gen++
+}
+
+func (s *simulator) collect() Result {
+	res := s.res
+	capacity := service.WorkingTime(res.Start, res.End)
+	var (
+		withDeadline, onTime int
+		lateness, flow       time.Duration
+		lastDone             = res.Start
+	)
+	for _, id := range s.taskOrder {
+		t := s.tasks[id]
+		if t.row.Status != storage.TaskStatusDone {
+			res.Unfinished++
+			if t.row.Deadline != nil && t.row.Deadline.Before(res.End) {
+				withDeadline++
+				res.LateTasks++
+				lateness += res.End.Sub(*t.row.Deadline)
+				res.LatePenalty += s.penalties.Penalty(t.row, res.End.Sub(*t.row.Deadline))
+			}
+			continue
+		}
+		res.Completed++
+		flow += t.doneAt.Sub(t.release)
+		if t.doneAt.After(lastDone) {
+			lastDone = t.doneAt
+		}
+		if t.row.Deadline == nil {
+			continue
+		}
+		withDeadline++
+		if t.doneAt.After(*t.row.Deadline) {
+			res.LateTasks++
+			lateness += t.doneAt.Sub(*t.row.Deadline)
+			res.LatePenalty += s.penalties.Penalty(t.row, t.doneAt.Sub(*t.row.Deadline))
+		} else {
+			onTime++
+		}
+	}
+	if withDeadline > 0 {
+		res.OnTimeRate = round3(float64(onTime) / float64(withDeadline))
+	}
+	if res.LateTasks > 0 {
+		res.MeanLatenessMin = round3(lateness.Minutes() / float64(res.LateTasks))
+	}
+	res.LatePenalty = round3(res.LatePenalty)
+	if res.Completed > 0 {
+		res.AvgFlowTimeMin = round3(flow.Minutes() / float64(res.Completed))
+	}
+	res.MakespanMin = round3(lastDone.Sub(res.Start).Minutes())
+
+	var totalUtil float64
+	for _, id := range s.deviceIDs {
+		d := s.devices[id]
+		busy, down := d.busy, d.downTime
+		if d.running != 0 {
+			busy += res.End.Sub(s.busySince(s.tasks[d.running]))
+		}
+		if d.down {
+			down += res.End.Sub(d.downSince)
+		}
+		kpi := DeviceKPI{
+			DeviceID:   id,
+			Name:       d.info.Name,
+			BusyMin:    int(busy.Minutes()),
+			DownMin:    int(down.Minutes()),
+			Breakdowns: d.breakdowns,
+		}
+		if capacity > 0 {
+			kpi.Utilization = round3(busy.Minutes() / capacity.Minutes())
+		}
+		totalUtil += kpi.Utilization
+		res.Devices = append(res.Devices, kpi)
+	}
+	if len(res.Devices) > 0 {
+		res.Utilization = round3(totalUtil / float64(len(res.Devices)))
+	}
+	return res
+}
+
+// busySince — начало занятости оборудования в пределах моделируемого периода.
+func (s *simulator) busySince(t *simTask) time.Time {
+	if t.started.Before(s.res.Start) {
+		return s.res.Start
+	}
+	return t.started
+}
+
+func (s *simulator) addTask(t *simTask) {
+	s.tasks[t.row.ID] = t
+	s.taskOrder = append(s.taskOrder, t.row.ID)
+	if t.row.JobID > 0 {
+		if s.jobOps[t.row.JobID] == nil {
+			s.jobOps[t.row.JobID] = map[int]int64{}
+		}
+		s.jobOps[t.row.JobID][t.row.JobSeq] = t.row.ID
+	}
+}
+
+// predecessorReady — момент, с которого операцию маршрута можно начать:
+// окончание предыдущей операции плюс пролёживание. false — предыдущая ещё не
+// завершена (операция вне маршрута готова всегда).
+func (s *simulator) predecessorReady(t *simTask) (time.Time, bool) {
+	if t.row.JobID <= 0 || t.row.JobSeq <= 1 {
+		return s.now, true
+	}
+	prevID, ok := s.jobOps[t.row.JobID][t.row.JobSeq-1]
+	if !ok {
+		// Предыдущей операции нет в сценарии — считаем её выполненной.
+		return s.now, true
+	}
+	prev := s.tasks[prevID]
+	if prev.row.Status != storage.TaskStatusDone {
+		return time.Time{}, false
+	}
+	return prev.doneAt.Add(t.row.TransferLag), true
+}
+
+func (s *simulator) push(ev event) {
+	ev.seq = s.seq
+	s.seq++
+	heap.Push(&s.queue, ev)
+}
+
+func (s *simulator) scheduleDispatch(at time.Time) {
+	key := at.UnixNano()
+	if s.scheduled[key] {
+		return
+	}
+	s.scheduled[key] = true
+	s.push(event{at: at, kind: evDispatch})
+}
+
+// estimate — длительность задания, которую видит планировщик.
+func (s *simulator) estimate(t storage.DeviceTaskRow) time.Duration {
+	nominal := t.SetupTime + t.Duration + t.UnloadTime
+	if s.cfg.DurationMode == service.DurationModeP80 {
+		return s.model.P80(s.deviceType[t.DeviceID], t.DeviceTaskTypeID, nominal)
+	}
+	return nominal
+}
+
+// sampleDuration — фактическая длительность: номинал, умноженный на коэффициент
+// из истории выполнения, а без истории — на логнормальный с медианой 1.
+func (s *simulator) sampleDuration(t storage.DeviceTaskRow) time.Duration {
+	factor := math.Exp(s.rng.NormFloat64() * s.sigma)
+	if factors := s.model.Factors(s.deviceType[t.DeviceID], t.DeviceTaskTypeID); len(factors) > 0 {
+		factor = factors[s.rng.IntN(len(factors))]
+	}
+	return time.Duration(float64(t.SetupTime+t.Duration+t.UnloadTime) * factor)
+}
+
+func (s *simulator) arrivalGap() time.Duration {
+	return time.Duration(float64(24*time.Hour) / s.cfg.OrdersPerDay)
+}
+
+// exp — экспоненциально распределённый интервал со средним mean.
+func (s *simulator) exp(mean time.Duration) time.Duration {
+	return time.Duration(s.rng.ExpFloat64() * float64(mean))
+}
+
+// nextWorkdayStart — начало рабочего дня, следующего за днём t.
+func nextWorkdayStart(t time.Time) time.Time {
+	return service.AlignToWorkday(time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location()))
+}
+
+func round3(v float64) float64 {
+	return math.Round(v*1000) / 1000
+}
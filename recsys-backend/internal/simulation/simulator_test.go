@@ -0,0 +1,146 @@
+package simulation
+
+import (
+	"container/heap"
+	"math/rand/v2"
+	"reflect"
+	"testing"
+	"time"
+
+	"recsys-backend/internal/storage"
+)
+
+// monday — понедельник 3 марта 2025 года, начало рабочего дня.
+var monday = time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+// События выходят из очереди по времени, а одновременные — в порядке
+// добавления.
+func TestEventQueueOrder(t *testing.T) {
+	s := &simulator{}
+	s.push(event{at: monday.Add(2 * time.Hour), kind: evArrival})
+	s.push(event{at: monday.Add(time.Hour), kind: evFinish, id: 1})
+	s.push(event{at: monday.Add(2 * time.Hour), kind: evBreakdown, id: 2})
+	s.push(event{at: monday.Add(time.Hour), kind: evRepair, id: 3})
+
+	var got []eventKind
+	for s.queue.Len() > 0 {
+		got = append(got, heap.Pop(&s.queue).(event).kind)
+	}
+	want := []eventKind{evFinish, evRepair, evArrival, evBreakdown}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("order %v, want %v", got, want)
+	}
+}
+
+// Поломка прерывает задание: оно возвращается в очередь без плана, его
+// завершение больше не засчитывается, а ремонт возвращает оборудование и
+// учитывает простой.
+func TestBreakdownAndRepair(t *testing.T) {
+	s := &simulator{
+		cfg:       Config{MTBF: 100 * time.Hour, MTTR: 2 * time.Hour},
+		rng:       rand.New(rand.NewPCG(1, 2)),
+		now:       monday,
+		tasks:     map[int64]*simTask{},
+		devices:   map[int64]*simDevice{1: {info: storage.Device{ID: 1}}},
+		deviceIDs: []int64{1},
+		operator:  map[int64]int64{},
+		scheduled: map[int64]bool{},
+	}
+	s.res.Start = monday
+	task := &simTask{row: storage.DeviceTaskRow{
+		ID: 1, DeviceID: 1, OperatorID: 5, NeedOperator: true, Duration: 3 * time.Hour,
+		Status: storage.TaskStatusPending, PlanStart: &monday,
+	}}
+	s.addTask(task)
+	s.start(task, s.devices[1])
+	finish := heap.Pop(&s.queue).(event)
+	if finish.kind != evFinish || !finish.at.Equal(monday.Add(3*time.Hour)) {
+		t.Fatalf("finish event %+v, want at 12:00", finish)
+	}
+
+	s.now = monday.Add(time.Hour)
+	s.breakDown(1)
+	d := s.devices[1]
+	if !d.down || d.running != 0 || d.breakdowns != 1 || s.res.Breakdowns != 1 {
+		t.Fatalf("device after breakdown %+v", d)
+	}
+	if task.row.Status != storage.TaskStatusPending || task.row.PlanStart != nil {
+		t.Errorf("task after breakdown %+v, want pending without plan", task.row)
+	}
+	if s.operator[5] != 0 {
+		t.Errorf("operator still busy with task %d", s.operator[5])
+	}
+	if d.busy != time.Hour {
+		t.Errorf("busy %v, want 1h", d.busy)
+	}
+	if s.complete(1, finish.gen) {
+		t.Error("finish of the interrupted run was counted")
+	}
+
+	repair := heap.Pop(&s.queue).(event)
+	if repair.kind != evRepair || !repair.at.Equal(d.repairAt) || !d.repairAt.After(s.now) {
+		t.Fatalf("repair event %+v, repair at %v", repair, d.repairAt)
+	}
+	s.now = repair.at
+	s.repair(1)
+	if d.down || d.downTime != repair.at.Sub(monday.Add(time.Hour)) {
+		t.Errorf("device after repair %+v", d)
+	}
+	if next := heap.Pop(&s.queue).(event); next.kind != evBreakdown || !next.at.After(repair.at) {
+		t.Errorf("next event %+v, want the next breakdown", next)
+	}
+}
+
+// Без разброса длительностей, брака, поломок и новых заказов прогон
+// детерминирован: задание с ранним дедлайном идёт первым, оба опаздывают на
+// час, штраф считается по ставке задания или его приоритета.
+func TestRunDeterministicScenario(t *testing.T) {
+	first, second := monday.Add(3*time.Hour), monday.Add(5*time.Hour)
+	rate := 60.0
+	sc := Scenario{
+		Start:   monday,
+		Devices: []storage.Device{{ID: 1, Name: "Принтер 1", DeviceTypeID: 1}},
+		Tasks: []storage.DeviceTaskRow{
+			{ID: 1, DeviceID: 1, Duration: 4 * time.Hour, Deadline: &first, DeadlineType: storage.DeadlineSoft, PriorityID: 1, Status: storage.TaskStatusPending},
+			{ID: 2, DeviceID: 1, Duration: 2 * time.Hour, Deadline: &second, DeadlineType: storage.DeadlineSoft, PriorityID: 2, PenaltyRate: &rate, Status: storage.TaskStatusPending},
+		},
+		Priorities: generatedPriorities,
+	}
+	res, err := Run(sc, Config{Horizon: 24 * time.Hour, Seed: 7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Completed != 2 || res.Unfinished != 0 || res.LateTasks != 2 || res.OnTimeRate != 0 {
+		t.Fatalf("result %+v", res)
+	}
+	if res.MakespanMin != 360 {
+		t.Errorf("makespan %v min, want 360", res.MakespanMin)
+	}
+	if res.MeanLatenessMin != 60 {
+		t.Errorf("mean lateness %v min, want 60", res.MeanLatenessMin)
+	}
+	// Час опоздания по ставке высокого приоритета и час по ставке задания.
+	if res.LatePenalty != 50+60 {
+		t.Errorf("penalty %v, want 110", res.LatePenalty)
+	}
+}
+
+// Прогон с одним зерном повторяется целиком, включая случайные события.
+func TestRunSameSeedSameResult(t *testing.T) {
+	sc := GenerateScenario(GenerateConfig{Start: monday, Devices: 3, Operators: 2, Backlog: 12}, rand.New(rand.NewPCG(3, 4)))
+	cfg := Config{Horizon: 72 * time.Hour, Seed: 11, DurationSpread: 0.3, FailureRate: 0.1, MTBF: 30 * time.Hour, MTTR: 3 * time.Hour, OrdersPerDay: 4}
+	a, err := Run(sc, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	b, err := Run(sc, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(a, b) {
+		t.Errorf("runs differ:\n%+v\n%+v", a, b)
+	}
+	if a.LateTasks > 0 && a.LatePenalty <= 0 {
+		t.Errorf("late tasks %d without penalty", a.LateTasks)
+	}
+}
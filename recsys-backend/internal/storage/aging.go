@@ -0,0 +1,67 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+)
+
+// Режимы старения приоритета.
+const (
+	AgingNone     = "none"     // приоритет не стареет
+	AgingDeadline = "deadline" // по времени до дедлайна
+	AgingSlack    = "slack"    // по запасу: время до дедлайна минус оставшаяся работа
+)
+
+// PriorityAging — правило старения приоритета workspace: задание поднимается
+// на уровень приоритета за каждые Step внутри окна Window до дедлайна.
+type PriorityAging struct {
+	WorkspaceID int64         `json:"workspace_id"`
+	Mode        string        `json:"mode"` // none | deadline | slack
+	Window      time.Duration `json:"window" swaggertype:"integer"`
+	Step        time.Duration `json:"step" swaggertype:"integer"`
+}
+
+// Enabled — приоритет стареет.
+func (a PriorityAging) Enabled() bool {
+	return a.Mode != "" && a.Mode != AgingNone && a.Window > 0 && a.Step > 0
+}
+
+// GetPriorityAging возвращает правило workspace; без настроенного правила —
+// приоритет не стареет.
+func (r *Repos) GetPriorityAging(ctx context.Context, workspaceID int64) (PriorityAging, error) {
+	a := PriorityAging{WorkspaceID: workspaceID, Mode: AgingNone}
+	var window, step int
+	err := r.DB.QueryRow(ctx, `
+		SELECT prag_mode, prag_windowmin, prag_stepmin
+		FROM priority_aging
+		WHERE workspace = $1
+	`, workspaceID).Scan(&a.Mode, &window, &step)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return a, nil
+	}
+	if err != nil {
+		return a, err
+	}
+	a.Window = minutesToDuration(window)
+	a.Step = minutesToDuration(step)
+	return a, nil
+}
+
+// SetPriorityAging создаёт или заменяет правило workspace.
+func (r *Repos) SetPriorityAging(ctx context.Context, a PriorityAging) error {
+	if a.Mode == "" {
+		a.Mode = AgingNone
+	}
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO priority_aging (workspace, prag_mode, prag_windowmin, prag_stepmin)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace) DO UPDATE SET
+			prag_mode = EXCLUDED.prag_mode,
+			prag_windowmin = EXCLUDED.prag_windowmin,
+			prag_stepmin = EXCLUDED.prag_stepmin
+	`, a.WorkspaceID, a.Mode, int(a.Window.Minutes()), int(a.Step.Minutes()))
+	return err
+}
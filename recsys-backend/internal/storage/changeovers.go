@@ -0,0 +1,68 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// MaterialChangeover — время перехода оборудования с одного материала на другой.
+type MaterialChangeover struct {
+	ID             int64         `json:"id"`
+	FromMaterialID int64         `json:"from_material_id"`
+	ToMaterialID   int64         `json:"to_material_id"`
+	Duration       time.Duration `json:"duration" swaggertype:"integer"`
+	WorkspaceID    int64         `json:"workspace_id"`
+}
+
+func (r *Repos) ListMaterialChangeovers(ctx context.Context, workspaceID int64) ([]MaterialChangeover, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT mtrchg_id, mtrchg_from, mtrchg_to, mtrchg_duration, workspace
+		FROM material_changeover
+		WHERE workspace = $1
+		ORDER BY mtrchg_from, mtrchg_to
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []MaterialChangeover
+	for rows.Next() {
+		var c MaterialChangeover
+		var duration pgtype.Time
+		if err := rows.Scan(&c.ID, &c.FromMaterialID, &c.ToMaterialID, &duration, &c.WorkspaceID); err != nil {
+			return nil, err
+		}
+		c.Duration = timeToDuration(duration)
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+// CreateMaterialChangeover добавляет переход или обновляет время уже заданного.
+func (r *Repos) CreateMaterialChangeover(ctx context.Context, c MaterialChangeover) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO material_changeover (mtrchg_from, mtrchg_to, mtrchg_duration, workspace)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT (workspace, mtrchg_from, mtrchg_to) DO UPDATE SET mtrchg_duration = EXCLUDED.mtrchg_duration
+		RETURNING mtrchg_id
+	`, c.FromMaterialID, c.ToMaterialID, formatDuration(c.Duration), c.WorkspaceID).Scan(&id)
+	return id, err
+}
+
+func (r *Repos) UpdateMaterialChangeover(ctx context.Context, c MaterialChangeover) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE material_changeover
+		SET mtrchg_from = $2, mtrchg_to = $3, mtrchg_duration = $4
+		WHERE mtrchg_id = $1 AND workspace = $5
+	`, c.ID, c.FromMaterialID, c.ToMaterialID, formatDuration(c.Duration), c.WorkspaceID)
+	return err
+}
+
+func (r *Repos) DeleteMaterialChangeover(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM material_changeover WHERE mtrchg_id = $1`, id)
+	return err
+}
@@ -0,0 +1,228 @@
+package storage
+
+import (
+	"context"
+)
+
+// Виды типизированных характеристик оборудования.
+const (
+	CharacteristicNumber = "number" // число с единицей измерения
+	CharacteristicEnum   = "enum"   // одно или несколько значений из списка
+	CharacteristicBool   = "bool"   // да/нет
+	CharacteristicRange  = "range"  // диапазон чисел
+)
+
+// Операции требований задания к характеристике.
+const (
+	RequirementGTE    = "gte"    // число не меньше num
+	RequirementLTE    = "lte"    // число не больше num
+	RequirementEq     = "eq"     // число равно num или bool равно bool
+	RequirementIn     = "in"     // значения enum пересекаются с options
+	RequirementCovers = "covers" // диапазон оборудования покрывает [num, num_max]
+)
+
+// Characteristic — типизированная характеристика оборудования workspace.
+type Characteristic struct {
+	ID          int64    `json:"id"`
+	Key         string   `json:"key"`
+	Kind        string   `json:"kind"` // number | enum | bool | range
+	Unit        string   `json:"unit"`
+	Options     []string `json:"options"` // допустимые значения enum, пусто — любые
+	WorkspaceID int64    `json:"workspace_id"`
+}
+
+// CharacteristicValue — значение характеристики у типа оборудования или у
+// отдельного устройства; значение устройства перекрывает значение типа.
+type CharacteristicValue struct {
+	ID               int64    `json:"id"`
+	CharacteristicID int64    `json:"characteristic_id"`
+	DeviceTypeID     int64    `json:"device_type_id"` // 0 — значение устройства
+	DeviceID         int64    `json:"device_id"`      // 0 — значение типа
+	Num              *float64 `json:"num"`            // number; нижняя граница range
+	NumMax           *float64 `json:"num_max"`        // верхняя граница range
+	Bool             *bool    `json:"bool"`
+	Options          []string `json:"options"` // значения enum
+}
+
+// TaskRequirement — требование задания к характеристике оборудования.
+type TaskRequirement struct {
+	ID               int64    `json:"id"`
+	DeviceTaskID     int64    `json:"device_task_id"`
+	CharacteristicID int64    `json:"characteristic_id"`
+	Op               string   `json:"op"` // gte | lte | eq | in | covers
+	Num              *float64 `json:"num"`
+	NumMax           *float64 `json:"num_max"`
+	Bool             *bool    `json:"bool"`
+	Options          []string `json:"options"`
+}
+
+func (r *Repos) ListCharacteristics(ctx context.Context, workspaceID int64) ([]Characteristic, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT chrctr_id, chrctr_key, chrctr_kind, chrctr_unit, chrctr_options, workspace
+		FROM characteristic
+		WHERE workspace = $1
+		ORDER BY chrctr_key
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []Characteristic
+	for rows.Next() {
+		var c Characteristic
+		if err := rows.Scan(&c.ID, &c.Key, &c.Kind, &c.Unit, &c.Options, &c.WorkspaceID); err != nil {
+			return nil, err
+		}
+		res = append(res, c)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repos) GetCharacteristic(ctx context.Context, id int64) (Characteristic, error) {
+	var c Characteristic
+	err := r.DB.QueryRow(ctx, `
+		SELECT chrctr_id, chrctr_key, chrctr_kind, chrctr_unit, chrctr_options, workspace
+		FROM characteristic
+		WHERE chrctr_id = $1
+	`, id).Scan(&c.ID, &c.Key, &c.Kind, &c.Unit, &c.Options, &c.WorkspaceID)
+	return c, err
+}
+
+func (r *Repos) CreateCharacteristic(ctx context.Context, c Characteristic) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO characteristic (chrctr_key, chrctr_kind, chrctr_unit, chrctr_options, workspace)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING chrctr_id
+	`, c.Key, c.Kind, c.Unit, nonNilStrings(c.Options), c.WorkspaceID).Scan(&id)
+	return id, err
+}
+
+func (r *Repos) UpdateCharacteristic(ctx context.Context, c Characteristic) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE characteristic
+		SET chrctr_key = $2, chrctr_kind = $3, chrctr_unit = $4, chrctr_options = $5
+		WHERE chrctr_id = $1 AND workspace = $6
+	`, c.ID, c.Key, c.Kind, c.Unit, nonNilStrings(c.Options), c.WorkspaceID)
+	return err
+}
+
+func (r *Repos) DeleteCharacteristic(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM characteristic WHERE chrctr_id = $1`, id)
+	return err
+}
+
+// ListCharacteristicValues — значения характеристик workspace у типов оборудования и устройств.
+func (r *Repos) ListCharacteristicValues(ctx context.Context, workspaceID int64) ([]CharacteristicValue, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT v.chrval_id, v.characteristic, COALESCE(v.devices_type,0), COALESCE(v.device,0),
+			v.chrval_num, v.chrval_nummax, v.chrval_bool, v.chrval_options
+		FROM characteristic_value v
+		JOIN characteristic c ON c.chrctr_id = v.characteristic
+		WHERE c.workspace = $1
+		ORDER BY v.chrval_id
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []CharacteristicValue
+	for rows.Next() {
+		var v CharacteristicValue
+		if err := rows.Scan(&v.ID, &v.CharacteristicID, &v.DeviceTypeID, &v.DeviceID, &v.Num, &v.NumMax, &v.Bool, &v.Options); err != nil {
+			return nil, err
+		}
+		res = append(res, v)
+	}
+	return res, rows.Err()
+}
+
+// SetCharacteristicValue задаёт значение характеристики типу оборудования или
+// устройству, перезаписывая прежнее.
+func (r *Repos) SetCharacteristicValue(ctx context.Context, v CharacteristicValue) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO characteristic_value (characteristic, devices_type, device, chrval_num, chrval_nummax, chrval_bool, chrval_options)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (characteristic, (COALESCE(devices_type, 0)), (COALESCE(device, 0))) DO UPDATE
+		SET chrval_num = EXCLUDED.chrval_num,
+			chrval_nummax = EXCLUDED.chrval_nummax,
+			chrval_bool = EXCLUDED.chrval_bool,
+			chrval_options = EXCLUDED.chrval_options
+		RETURNING chrval_id
+	`, v.CharacteristicID, nullableID(v.DeviceTypeID), nullableID(v.DeviceID), v.Num, v.NumMax, v.Bool, nonNilStrings(v.Options)).Scan(&id)
+	return id, err
+}
+
+func (r *Repos) DeleteCharacteristicValue(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM characteristic_value WHERE chrval_id = $1`, id)
+	return err
+}
+
+const taskRequirementColumns = `
+	q.tskreq_id, q.device_task, q.characteristic, q.tskreq_op,
+	q.tskreq_num, q.tskreq_nummax, q.tskreq_bool, q.tskreq_options`
+
+// ListTaskRequirements — требования одного задания.
+func (r *Repos) ListTaskRequirements(ctx context.Context, taskID int64) ([]TaskRequirement, error) {
+	return r.queryTaskRequirements(ctx, `
+		SELECT `+taskRequirementColumns+`
+		FROM task_requirement q
+		WHERE q.device_task = $1
+		ORDER BY q.tskreq_id
+	`, taskID)
+}
+
+// ListTaskRequirementsForWorkspace — требования всех заданий workspace.
+func (r *Repos) ListTaskRequirementsForWorkspace(ctx context.Context, workspaceID int64) ([]TaskRequirement, error) {
+	return r.queryTaskRequirements(ctx, `
+		SELECT `+taskRequirementColumns+`
+		FROM task_requirement q
+		JOIN device_task t ON t.dvctsk_id = q.device_task
+		WHERE t.workspace = $1
+		ORDER BY q.tskreq_id
+	`, workspaceID)
+}
+
+func (r *Repos) queryTaskRequirements(ctx context.Context, sql string, arg int64) ([]TaskRequirement, error) {
+	rows, err := r.DB.Query(ctx, sql, arg)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []TaskRequirement
+	for rows.Next() {
+		var q TaskRequirement
+		if err := rows.Scan(&q.ID, &q.DeviceTaskID, &q.CharacteristicID, &q.Op, &q.Num, &q.NumMax, &q.Bool, &q.Options); err != nil {
+			return nil, err
+		}
+		res = append(res, q)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repos) CreateTaskRequirement(ctx context.Context, q TaskRequirement) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO task_requirement (device_task, characteristic, tskreq_op, tskreq_num, tskreq_nummax, tskreq_bool, tskreq_options)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		RETURNING tskreq_id
+	`, q.DeviceTaskID, q.CharacteristicID, q.Op, q.Num, q.NumMax, q.Bool, nonNilStrings(q.Options)).Scan(&id)
+	return id, err
+}
+
+func (r *Repos) DeleteTaskRequirement(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM task_requirement WHERE tskreq_id = $1`, id)
+	return err
+}
+
+// nonNilStrings — пустой массив вместо NULL для колонок TEXT[] NOT NULL.
+func nonNilStrings(s []string) []string {
+	if s == nil {
+		return []string{}
+	}
+	return s
+}
@@ -0,0 +1,44 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+)
+
+// CompetencyLevel — уровень владения оператором типом оборудования (compt_level).
+type CompetencyLevel string
+
+const (
+	CompetencyTrainee   CompetencyLevel = "trainee"   // стажёр
+	CompetencyQualified CompetencyLevel = "qualified" // допущен к самостоятельной работе
+	CompetencyExpert    CompetencyLevel = "expert"    // эксперт
+)
+
+var ErrUnknownCompetencyLevel = errors.New("unknown competency level")
+
+var competencyRank = map[CompetencyLevel]int{
+	CompetencyTrainee:   1,
+	CompetencyQualified: 2,
+	CompetencyExpert:    3,
+}
+
+func ParseCompetencyLevel(s string) (CompetencyLevel, error) {
+	l := CompetencyLevel(s)
+	if _, ok := competencyRank[l]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownCompetencyLevel, s)
+	}
+	return l, nil
+}
+
+// competencyLevelOrDefault — пустой уровень компетенции считается qualified.
+func competencyLevelOrDefault(l CompetencyLevel) CompetencyLevel {
+	if l == "" {
+		return CompetencyQualified
+	}
+	return l
+}
+
+// AtLeast — уровень не ниже min; пустой min допускает любой уровень.
+func (l CompetencyLevel) AtLeast(min CompetencyLevel) bool {
+	return min == "" || competencyRank[l] >= competencyRank[min]
+}
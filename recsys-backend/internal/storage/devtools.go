@@ -8,6 +8,21 @@ func (r *Repos) ClearAllData(ctx context.Context) error {
 		TRUNCATE TABLE
 			user_task,
 			device_task,
+			device_downtime,
+			production_job,
+			customer_order,
+			task_template,
+			material_changeover,
+			material_stock,
+			labour_rules,
+			priority_aging,
+			energy_tariff,
+			energy_schedule,
+			task_requirement,
+			characteristic_value,
+			characteristic,
+			device_pool_member,
+			device_pool,
 			operator_device,
 			competencies_operator,
 			operator,
@@ -0,0 +1,56 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// DeviceDowntime — интервал недоступности оборудования.
+type DeviceDowntime struct {
+	ID          int64     `json:"id"`
+	DeviceID    int64     `json:"device_id"`
+	Start       time.Time `json:"start"`
+	End         time.Time `json:"end"`
+	Reason      string    `json:"reason"`
+	WorkspaceID int64     `json:"workspace_id"`
+}
+
+// ListDeviceDowntime возвращает интервалы недоступности, которые ещё не закончились к моменту from.
+func (r *Repos) ListDeviceDowntime(ctx context.Context, workspaceID int64, from time.Time) ([]DeviceDowntime, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT dvcdwn_id, device, dvcdwn_starttime, dvcdwn_endtime, dvcdwn_reason, workspace
+		FROM device_downtime
+		WHERE workspace = $1
+		  AND dvcdwn_endtime > $2
+		ORDER BY dvcdwn_starttime, dvcdwn_id
+	`, workspaceID, from)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []DeviceDowntime
+	for rows.Next() {
+		var d DeviceDowntime
+		if err := rows.Scan(&d.ID, &d.DeviceID, &d.Start, &d.End, &d.Reason, &d.WorkspaceID); err != nil {
+			return nil, err
+		}
+		res = append(res, d)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repos) CreateDeviceDowntime(ctx context.Context, d DeviceDowntime) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO device_downtime (device, dvcdwn_starttime, dvcdwn_endtime, dvcdwn_reason, workspace)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING dvcdwn_id
+	`, d.DeviceID, d.Start, d.End, d.Reason, d.WorkspaceID).Scan(&id)
+	return id, err
+}
+
+func (r *Repos) DeleteDeviceDowntime(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM device_downtime WHERE dvcdwn_id = $1`, id)
+	return err
+}
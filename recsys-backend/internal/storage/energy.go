@@ -0,0 +1,95 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// EnergyTariff — цена кВт·ч в окне времени суток [Start, End). Окно с End не
+// позже Start переходит через полночь.
+type EnergyTariff struct {
+	ID    int64         `json:"id"`
+	Start time.Duration `json:"start" swaggertype:"integer"` // от полуночи
+	End   time.Duration `json:"end" swaggertype:"integer"`
+	Price float64       `json:"price"`
+}
+
+// EnergySchedule — тарифный план электроэнергии workspace.
+type EnergySchedule struct {
+	WorkspaceID int64 `json:"workspace_id"`
+	// BasePrice — цена кВт·ч вне окон тарифов.
+	BasePrice float64 `json:"base_price"`
+	// CostWeight — на сколько минут позже может закончиться задание ради
+	// экономии единицы стоимости энергии; 0 — стоимость только оценивается.
+	CostWeight float64        `json:"cost_weight"`
+	Tariffs    []EnergyTariff `json:"tariffs"`
+}
+
+// GetEnergySchedule возвращает тарифный план workspace; без плана — пустой.
+func (r *Repos) GetEnergySchedule(ctx context.Context, workspaceID int64) (EnergySchedule, error) {
+	s := EnergySchedule{WorkspaceID: workspaceID, Tariffs: []EnergyTariff{}}
+	err := r.DB.QueryRow(ctx, `
+		SELECT enrsch_baseprice, enrsch_costweight
+		FROM energy_schedule
+		WHERE workspace = $1
+	`, workspaceID).Scan(&s.BasePrice, &s.CostWeight)
+	if err != nil && !errors.Is(err, pgx.ErrNoRows) {
+		return s, err
+	}
+	rows, err := r.DB.Query(ctx, `
+		SELECT enrtrf_id, enrtrf_start, enrtrf_end, enrtrf_price
+		FROM energy_tariff
+		WHERE workspace = $1
+		ORDER BY enrtrf_start, enrtrf_id
+	`, workspaceID)
+	if err != nil {
+		return s, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var t EnergyTariff
+		var start, end pgtype.Time
+		if err := rows.Scan(&t.ID, &start, &end, &t.Price); err != nil {
+			return s, err
+		}
+		t.Start, t.End = timeToDuration(start), timeToDuration(end)
+		s.Tariffs = append(s.Tariffs, t)
+	}
+	return s, rows.Err()
+}
+
+// SetEnergySchedule создаёт или заменяет тарифный план workspace вместе с окнами.
+func (r *Repos) SetEnergySchedule(ctx context.Context, s EnergySchedule) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO energy_schedule (workspace, enrsch_baseprice, enrsch_costweight)
+		VALUES ($1, $2, $3)
+		ON CONFLICT (workspace) DO UPDATE SET
+			enrsch_baseprice = EXCLUDED.enrsch_baseprice,
+			enrsch_costweight = EXCLUDED.enrsch_costweight
+	`, s.WorkspaceID, s.BasePrice, s.CostWeight); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM energy_tariff WHERE workspace = $1`, s.WorkspaceID); err != nil {
+		return err
+	}
+	for _, t := range s.Tariffs {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO energy_tariff (workspace, enrtrf_start, enrtrf_end, enrtrf_price)
+			VALUES ($1, $2, $3, $4)
+		`, s.WorkspaceID, formatDuration(t.Start), formatDuration(t.End), t.Price); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
+	"fmt"
 	"time"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgtype"
 )
 
@@ -28,6 +30,8 @@ type DeviceState struct {
 type Priority struct {
 	ID   int64  `json:"id"`
 	Name string `json:"name"`
+	// PenaltyRate — штраф за час опоздания заданий приоритета; 0 — опоздание не штрафуется.
+	PenaltyRate float64 `json:"penalty_rate"`
 }
 
 type EquipmentCharacteristic struct {
@@ -40,7 +44,12 @@ type DeviceType struct {
 	ID                        int64  `json:"id"`
 	Name                      string `json:"name"`
 	EquipmentCharacteristicID int64  `json:"equipment_characteristic_id"`
+	PlateCapacity             int    `json:"plate_capacity"` // 0 — задания не объединяются в прогоны
 	WorkspaceID               int64  `json:"workspace_id"`
+	// PowerKW — потребляемая мощность оборудования типа, кВт; nil — не задана.
+	PowerKW *float64 `json:"power_kw"`
+	// Cooldown — остывание оборудования типа после каждого задания; 0 — без остывания.
+	Cooldown time.Duration `json:"cooldown" swaggertype:"integer"`
 }
 
 type Device struct {
@@ -51,6 +60,12 @@ type Device struct {
 	DeviceTypeID   int64  `json:"device_type_id"`
 	DeviceStateID  int64  `json:"device_state_id"`
 	WorkspaceID    int64  `json:"workspace_id"`
+	// LoadedMaterialID — заправленный материал (характеристика оборудования), 0 — неизвестен.
+	LoadedMaterialID int64 `json:"loaded_material_id"`
+	// PowerKW — потребляемая мощность, кВт; nil — как у типа оборудования.
+	PowerKW *float64 `json:"power_kw"`
+	// HourlyRate — стоимость часа работы оборудования; 0 — не учитывается.
+	HourlyRate float64 `json:"hourly_rate"`
 }
 
 type Operator struct {
@@ -59,13 +74,17 @@ type Operator struct {
 	PhoneNumber string `json:"phone_number"`
 	WorkspaceID int64  `json:"workspace_id"`
 	UserLogin   string `json:"user_login"`
+	// HourlyRate — стоимость часа работы оператора; 0 — не учитывается.
+	HourlyRate float64 `json:"hourly_rate"`
 }
 
 type OperatorCompetency struct {
-	ID           int64 `json:"id"`
-	WorkspaceID  int64 `json:"workspace_id"`
-	DeviceTypeID int64 `json:"device_type_id"`
-	OperatorID   int64 `json:"operator_id"`
+	ID           int64           `json:"id"`
+	WorkspaceID  int64           `json:"workspace_id"`
+	DeviceTypeID int64           `json:"device_type_id"`
+	OperatorID   int64           `json:"operator_id"`
+	Level        CompetencyLevel `json:"level"`
+	SetupFactor  *float64        `json:"setup_factor"` // множитель наладки и снятия, nil — 1
 }
 
 type OperatorDevice struct {
@@ -78,28 +97,60 @@ type DeviceTaskType struct {
 	ID          int64  `json:"id"`
 	Name        string `json:"name"`
 	WorkspaceID int64  `json:"workspace_id"`
+	// Cooldown — остывание оборудования после задания этого типа; 0 — без остывания.
+	Cooldown time.Duration `json:"cooldown" swaggertype:"integer"`
 }
 
+// Типы дедлайна задания.
+const (
+	DeadlineHard = "hard" // задание ставится только с окончанием к сроку
+	DeadlineSoft = "soft" // срок можно сдвинуть ради жёстких дедлайнов
+)
+
 type DeviceTask struct {
-	ID               int64         `json:"id"`
-	Name             string        `json:"name"`
-	Deadline         *time.Time    `json:"deadline"`
-	Duration         time.Duration `json:"duration"`
-	SetupTime        time.Duration `json:"setup_time"`
-	UnloadTime       time.Duration `json:"unload_time"`
-	NeedOperator     bool          `json:"need_operator"`
-	PhotoURL         string        `json:"photo_url"`
-	PlanStart        *time.Time    `json:"plan_start"`
-	PlanEnd          *time.Time    `json:"plan_end"`
-	DocNum           string        `json:"doc_num"`
-	CompletionMark   string        `json:"completion_mark"`
-	AddInRecSystem   *bool         `json:"add_in_rec_system"`
-	DeviceTaskTypeID int64         `json:"device_task_type_id"`
-	WorkspaceID      int64         `json:"workspace_id"`
-	OperatorID       int64         `json:"operator_id"`
-	DeviceID         int64         `json:"device_id"`
-	PriorityID       int64         `json:"priority_id"`
-}
+	ID               int64           `json:"id"`
+	Name             string          `json:"name"`
+	Deadline         *time.Time      `json:"deadline"`
+	Duration         time.Duration   `json:"duration"`
+	SetupTime        time.Duration   `json:"setup_time"`
+	UnloadTime       time.Duration   `json:"unload_time"`
+	NeedOperator     bool            `json:"need_operator"`
+	PhotoURL         string          `json:"photo_url"`
+	PlanStart        *time.Time      `json:"plan_start"`
+	PlanEnd          *time.Time      `json:"plan_end"`
+	DocNum           string          `json:"doc_num"`
+	Status           TaskStatus      `json:"status"`
+	ActualStart      *time.Time      `json:"actual_start"`
+	ActualEnd        *time.Time      `json:"actual_end"`
+	AddInRecSystem   *bool           `json:"add_in_rec_system"`
+	DeviceTaskTypeID int64           `json:"device_task_type_id"`
+	WorkspaceID      int64           `json:"workspace_id"`
+	OperatorID       int64           `json:"operator_id"`
+	DeviceID         int64           `json:"device_id"`
+	PriorityID       int64           `json:"priority_id"`
+	JobID            int64           `json:"job_id"`
+	JobSeq           int             `json:"job_seq"`
+	TransferLag      time.Duration   `json:"transfer_lag"`
+	MaterialID       int64           `json:"material_id"`
+	PlateSize        int             `json:"plate_size"`
+	BatchID          int64           `json:"batch_id"`
+	MaterialQty      float64         `json:"material_qty"`
+	DevicePoolID     int64           `json:"device_pool_id"` // цель — любое оборудование пула
+	TargetTypeID     int64           `json:"target_type_id"` // цель — любое оборудование типа
+	MinLevel         CompetencyLevel `json:"min_level"`      // минимальный уровень оператора, пусто — любой
+	OrderID          int64           `json:"order_id"`       // заказ клиента, 0 — вне заказа
+	TemplateID       int64           `json:"template_id"`    // шаблон, по которому создано задание, 0 — нет
+	Occurrence       *time.Time      `json:"occurrence"`     // повторение шаблона, к которому относится задание
+	DeadlineType     string          `json:"deadline_type"`  // hard | soft
+	PenaltyRate      *float64        `json:"penalty_rate"`   // штраф за час опоздания, nil — по приоритету
+	Pinned           bool            `json:"pinned"`         // перенесено вручную; меняется только переносом и закреплением
+}
+
+// Виды поручений оператору, которые планировщик ведёт по плану оборудования.
+const (
+	UserTaskSetup  = "setup"  // наладка задания
+	UserTaskUnload = "unload" // снятие задания
+)
 
 type UserTask struct {
 	ID             int64      `json:"id"`
@@ -111,6 +162,8 @@ type UserTask struct {
 	WorkspaceID    int64      `json:"workspace_id"`
 	DeviceTaskID   *int64     `json:"device_task_id"`
 	OperatorID     int64      `json:"operator_id"`
+	// Kind — setup | unload у поручений, созданных планировщиком; пусто — создано вручную.
+	Kind string `json:"kind"`
 }
 
 func (r *Repos) ListUsers(ctx context.Context) ([]User, error) {
@@ -294,7 +347,7 @@ func (r *Repos) DeleteDeviceState(ctx context.Context, id int64) error {
 }
 
 func (r *Repos) ListPriorities(ctx context.Context) ([]Priority, error) {
-	rows, err := r.DB.Query(ctx, `SELECT prts_id, prts_name FROM priorities ORDER BY prts_id`)
+	rows, err := r.DB.Query(ctx, `SELECT prts_id, prts_name, prts_penaltyrate FROM priorities ORDER BY prts_id`)
 	if err != nil {
 		return nil, err
 	}
@@ -303,7 +356,7 @@ func (r *Repos) ListPriorities(ctx context.Context) ([]Priority, error) {
 	var res []Priority
 	for rows.Next() {
 		var p Priority
-		if err := rows.Scan(&p.ID, &p.Name); err != nil {
+		if err := rows.Scan(&p.ID, &p.Name, &p.PenaltyRate); err != nil {
 			return nil, err
 		}
 		res = append(res, p)
@@ -313,19 +366,19 @@ func (r *Repos) ListPriorities(ctx context.Context) ([]Priority, error) {
 
 func (r *Repos) GetPriority(ctx context.Context, id int64) (Priority, error) {
 	var p Priority
-	err := r.DB.QueryRow(ctx, `SELECT prts_id, prts_name FROM priorities WHERE prts_id = $1`, id).
-		Scan(&p.ID, &p.Name)
+	err := r.DB.QueryRow(ctx, `SELECT prts_id, prts_name, prts_penaltyrate FROM priorities WHERE prts_id = $1`, id).
+		Scan(&p.ID, &p.Name, &p.PenaltyRate)
 	return p, err
 }
 
-func (r *Repos) CreatePriority(ctx context.Context, name string) (int64, error) {
+func (r *Repos) CreatePriority(ctx context.Context, p Priority) (int64, error) {
 	var id int64
-	err := r.DB.QueryRow(ctx, `INSERT INTO priorities (prts_name) VALUES ($1) RETURNING prts_id`, name).Scan(&id)
+	err := r.DB.QueryRow(ctx, `INSERT INTO priorities (prts_name, prts_penaltyrate) VALUES ($1, $2) RETURNING prts_id`, p.Name, p.PenaltyRate).Scan(&id)
 	return id, err
 }
 
-func (r *Repos) UpdatePriority(ctx context.Context, id int64, name string) error {
-	_, err := r.DB.Exec(ctx, `UPDATE priorities SET prts_name = $2 WHERE prts_id = $1`, id, name)
+func (r *Repos) UpdatePriority(ctx context.Context, p Priority) error {
+	_, err := r.DB.Exec(ctx, `UPDATE priorities SET prts_name = $2, prts_penaltyrate = $3 WHERE prts_id = $1`, p.ID, p.Name, p.PenaltyRate)
 	return err
 }
 
@@ -385,7 +438,7 @@ func (r *Repos) DeleteEquipmentCharacteristic(ctx context.Context, id int64) err
 
 func (r *Repos) ListDeviceTypes(ctx context.Context, workspaceID int64) ([]DeviceType, error) {
 	rows, err := r.DB.Query(ctx, `
-		SELECT dvctp_id, dvctp_name, eqpmnt_characteristics, workspace
+		SELECT dvctp_id, dvctp_name, eqpmnt_characteristics, dvctp_platecapacity, workspace, dvctp_powerkw, dvctp_cooldownmin
 		FROM devices_type
 		WHERE workspace = $1
 		ORDER BY dvctp_id
@@ -398,9 +451,11 @@ func (r *Repos) ListDeviceTypes(ctx context.Context, workspaceID int64) ([]Devic
 	var res []DeviceType
 	for rows.Next() {
 		var t DeviceType
-		if err := rows.Scan(&t.ID, &t.Name, &t.EquipmentCharacteristicID, &t.WorkspaceID); err != nil {
+		var cooldown int
+		if err := rows.Scan(&t.ID, &t.Name, &t.EquipmentCharacteristicID, &t.PlateCapacity, &t.WorkspaceID, &t.PowerKW, &cooldown); err != nil {
 			return nil, err
 		}
+		t.Cooldown = minutesToDuration(cooldown)
 		res = append(res, t)
 	}
 	return res, rows.Err()
@@ -408,30 +463,33 @@ func (r *Repos) ListDeviceTypes(ctx context.Context, workspaceID int64) ([]Devic
 
 func (r *Repos) GetDeviceType(ctx context.Context, id int64) (DeviceType, error) {
 	var t DeviceType
+	var cooldown int
 	err := r.DB.QueryRow(ctx, `
-		SELECT dvctp_id, dvctp_name, eqpmnt_characteristics, workspace
+		SELECT dvctp_id, dvctp_name, eqpmnt_characteristics, dvctp_platecapacity, workspace, dvctp_powerkw, dvctp_cooldownmin
 		FROM devices_type
 		WHERE dvctp_id = $1
-	`, id).Scan(&t.ID, &t.Name, &t.EquipmentCharacteristicID, &t.WorkspaceID)
+	`, id).Scan(&t.ID, &t.Name, &t.EquipmentCharacteristicID, &t.PlateCapacity, &t.WorkspaceID, &t.PowerKW, &cooldown)
+	t.Cooldown = minutesToDuration(cooldown)
 	return t, err
 }
 
 func (r *Repos) CreateDeviceType(ctx context.Context, t DeviceType) (int64, error) {
 	var id int64
 	err := r.DB.QueryRow(ctx, `
-		INSERT INTO devices_type (dvctp_name, eqpmnt_characteristics, workspace)
-		VALUES ($1, $2, $3)
+		INSERT INTO devices_type (dvctp_name, eqpmnt_characteristics, dvctp_platecapacity, workspace, dvctp_powerkw, dvctp_cooldownmin)
+		VALUES ($1, $2, $3, $4, $5, $6)
 		RETURNING dvctp_id
-	`, t.Name, t.EquipmentCharacteristicID, t.WorkspaceID).Scan(&id)
+	`, t.Name, t.EquipmentCharacteristicID, t.PlateCapacity, t.WorkspaceID, t.PowerKW, int(t.Cooldown.Minutes())).Scan(&id)
 	return id, err
 }
 
 func (r *Repos) UpdateDeviceType(ctx context.Context, t DeviceType) error {
 	_, err := r.DB.Exec(ctx, `
 		UPDATE devices_type
-		SET dvctp_name = $2, eqpmnt_characteristics = $3, workspace = $4
+		SET dvctp_name = $2, eqpmnt_characteristics = $3, dvctp_platecapacity = $4, workspace = $5, dvctp_powerkw = $6,
+			dvctp_cooldownmin = $7
 		WHERE dvctp_id = $1
-	`, t.ID, t.Name, t.EquipmentCharacteristicID, t.WorkspaceID)
+	`, t.ID, t.Name, t.EquipmentCharacteristicID, t.PlateCapacity, t.WorkspaceID, t.PowerKW, int(t.Cooldown.Minutes()))
 	return err
 }
 
@@ -442,7 +500,8 @@ func (r *Repos) DeleteDeviceType(ctx context.Context, id int64) error {
 
 func (r *Repos) ListDevices(ctx context.Context, workspaceID int64) ([]Device, error) {
 	rows, err := r.DB.Query(ctx, `
-		SELECT dvc_id, dvc_name, dvc_photourl, dvc_addinrecsystem, devices__type, device_state, workspace
+		SELECT dvc_id, dvc_name, dvc_photourl, dvc_addinrecsystem, devices__type, device_state, workspace,
+			COALESCE(dvc_loadedmaterial,0), dvc_powerkw, dvc_hourlyrate
 		FROM device
 		WHERE workspace = $1
 		ORDER BY dvc_id
@@ -455,7 +514,7 @@ func (r *Repos) ListDevices(ctx context.Context, workspaceID int64) ([]Device, e
 	var res []Device
 	for rows.Next() {
 		var d Device
-		if err := rows.Scan(&d.ID, &d.Name, &d.PhotoURL, &d.AddInRecSystem, &d.DeviceTypeID, &d.DeviceStateID, &d.WorkspaceID); err != nil {
+		if err := rows.Scan(&d.ID, &d.Name, &d.PhotoURL, &d.AddInRecSystem, &d.DeviceTypeID, &d.DeviceStateID, &d.WorkspaceID, &d.LoadedMaterialID, &d.PowerKW, &d.HourlyRate); err != nil {
 			return nil, err
 		}
 		res = append(res, d)
@@ -466,20 +525,21 @@ func (r *Repos) ListDevices(ctx context.Context, workspaceID int64) ([]Device, e
 func (r *Repos) GetDevice(ctx context.Context, id int64) (Device, error) {
 	var d Device
 	err := r.DB.QueryRow(ctx, `
-		SELECT dvc_id, dvc_name, dvc_photourl, dvc_addinrecsystem, devices__type, device_state, workspace
+		SELECT dvc_id, dvc_name, dvc_photourl, dvc_addinrecsystem, devices__type, device_state, workspace,
+			COALESCE(dvc_loadedmaterial,0), dvc_powerkw, dvc_hourlyrate
 		FROM device
 		WHERE dvc_id = $1
-	`, id).Scan(&d.ID, &d.Name, &d.PhotoURL, &d.AddInRecSystem, &d.DeviceTypeID, &d.DeviceStateID, &d.WorkspaceID)
+	`, id).Scan(&d.ID, &d.Name, &d.PhotoURL, &d.AddInRecSystem, &d.DeviceTypeID, &d.DeviceStateID, &d.WorkspaceID, &d.LoadedMaterialID, &d.PowerKW, &d.HourlyRate)
 	return d, err
 }
 
 func (r *Repos) CreateDevice(ctx context.Context, d Device) (int64, error) {
 	var id int64
 	err := r.DB.QueryRow(ctx, `
-		INSERT INTO device (dvc_name, dvc_photourl, dvc_addinrecsystem, devices__type, device_state, workspace)
-		VALUES ($1, $2, $3, $4, $5, $6)
+		INSERT INTO device (dvc_name, dvc_photourl, dvc_addinrecsystem, devices__type, device_state, workspace, dvc_loadedmaterial, dvc_powerkw, dvc_hourlyrate)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING dvc_id
-	`, d.Name, d.PhotoURL, d.AddInRecSystem, d.DeviceTypeID, d.DeviceStateID, d.WorkspaceID).Scan(&id)
+	`, d.Name, d.PhotoURL, d.AddInRecSystem, d.DeviceTypeID, d.DeviceStateID, d.WorkspaceID, nullableID(d.LoadedMaterialID), d.PowerKW, d.HourlyRate).Scan(&id)
 	return id, err
 }
 
@@ -491,12 +551,29 @@ func (r *Repos) UpdateDevice(ctx context.Context, d Device) error {
 			dvc_addinrecsystem = $4,
 			devices__type = $5,
 			device_state = $6,
-			workspace = $7
+			workspace = $7,
+			dvc_loadedmaterial = $8,
+			dvc_powerkw = $9,
+			dvc_hourlyrate = $10
 		WHERE dvc_id = $1
-	`, d.ID, d.Name, d.PhotoURL, d.AddInRecSystem, d.DeviceTypeID, d.DeviceStateID, d.WorkspaceID)
+	`, d.ID, d.Name, d.PhotoURL, d.AddInRecSystem, d.DeviceTypeID, d.DeviceStateID, d.WorkspaceID, nullableID(d.LoadedMaterialID), d.PowerKW, d.HourlyRate)
+	return err
+}
+
+// SetDeviceLoadedMaterial отмечает, какой материал заправлен в оборудование; 0 — неизвестен.
+func (r *Repos) SetDeviceLoadedMaterial(ctx context.Context, id int64, materialID int64) error {
+	_, err := r.DB.Exec(ctx, `UPDATE device SET dvc_loadedmaterial = $2 WHERE dvc_id = $1`, id, nullableID(materialID))
 	return err
 }
 
+// nullableID — NULL вместо нулевой ссылки.
+func nullableID(id int64) any {
+	if id <= 0 {
+		return nil
+	}
+	return id
+}
+
 func (r *Repos) DeleteDevice(ctx context.Context, id int64) error {
 	_, err := r.DB.Exec(ctx, `DELETE FROM device WHERE dvc_id = $1`, id)
 	return err
@@ -504,7 +581,7 @@ func (r *Repos) DeleteDevice(ctx context.Context, id int64) error {
 
 func (r *Repos) ListOperators(ctx context.Context, workspaceID int64) ([]Operator, error) {
 	rows, err := r.DB.Query(ctx, `
-		SELECT oprt_id, oprt_fio, oprt_phnnm, workspace, "user"
+		SELECT oprt_id, oprt_fio, oprt_phnnm, workspace, "user", oprt_hourlyrate
 		FROM operator
 		WHERE workspace = $1
 		ORDER BY oprt_id
@@ -517,7 +594,7 @@ func (r *Repos) ListOperators(ctx context.Context, workspaceID int64) ([]Operato
 	var res []Operator
 	for rows.Next() {
 		var o Operator
-		if err := rows.Scan(&o.ID, &o.FullName, &o.PhoneNumber, &o.WorkspaceID, &o.UserLogin); err != nil {
+		if err := rows.Scan(&o.ID, &o.FullName, &o.PhoneNumber, &o.WorkspaceID, &o.UserLogin, &o.HourlyRate); err != nil {
 			return nil, err
 		}
 		res = append(res, o)
@@ -528,20 +605,20 @@ func (r *Repos) ListOperators(ctx context.Context, workspaceID int64) ([]Operato
 func (r *Repos) GetOperator(ctx context.Context, id int64) (Operator, error) {
 	var o Operator
 	err := r.DB.QueryRow(ctx, `
-		SELECT oprt_id, oprt_fio, oprt_phnnm, workspace, "user"
+		SELECT oprt_id, oprt_fio, oprt_phnnm, workspace, "user", oprt_hourlyrate
 		FROM operator
 		WHERE oprt_id = $1
-	`, id).Scan(&o.ID, &o.FullName, &o.PhoneNumber, &o.WorkspaceID, &o.UserLogin)
+	`, id).Scan(&o.ID, &o.FullName, &o.PhoneNumber, &o.WorkspaceID, &o.UserLogin, &o.HourlyRate)
 	return o, err
 }
 
 func (r *Repos) CreateOperator(ctx context.Context, o Operator) (int64, error) {
 	var id int64
 	err := r.DB.QueryRow(ctx, `
-		INSERT INTO operator (oprt_fio, oprt_phnnm, workspace, "user")
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO operator (oprt_fio, oprt_phnnm, workspace, "user", oprt_hourlyrate)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING oprt_id
-	`, o.FullName, o.PhoneNumber, o.WorkspaceID, o.UserLogin).Scan(&id)
+	`, o.FullName, o.PhoneNumber, o.WorkspaceID, o.UserLogin, o.HourlyRate).Scan(&id)
 	return id, err
 }
 
@@ -551,9 +628,10 @@ func (r *Repos) UpdateOperator(ctx context.Context, o Operator) error {
 		SET oprt_fio = $2,
 			oprt_phnnm = $3,
 			workspace = $4,
-			"user" = $5
+			"user" = $5,
+			oprt_hourlyrate = $6
 		WHERE oprt_id = $1
-	`, o.ID, o.FullName, o.PhoneNumber, o.WorkspaceID, o.UserLogin)
+	`, o.ID, o.FullName, o.PhoneNumber, o.WorkspaceID, o.UserLogin, o.HourlyRate)
 	return err
 }
 
@@ -564,7 +642,7 @@ func (r *Repos) DeleteOperator(ctx context.Context, id int64) error {
 
 func (r *Repos) ListOperatorCompetencies(ctx context.Context, workspaceID int64) ([]OperatorCompetency, error) {
 	rows, err := r.DB.Query(ctx, `
-		SELECT compt_oprt_id, workspace, devices__type, operator
+		SELECT compt_oprt_id, workspace, devices__type, operator, compt_level, compt_setupfactor
 		FROM competencies_operator
 		WHERE workspace = $1
 		ORDER BY compt_oprt_id
@@ -577,7 +655,7 @@ func (r *Repos) ListOperatorCompetencies(ctx context.Context, workspaceID int64)
 	var res []OperatorCompetency
 	for rows.Next() {
 		var c OperatorCompetency
-		if err := rows.Scan(&c.ID, &c.WorkspaceID, &c.DeviceTypeID, &c.OperatorID); err != nil {
+		if err := rows.Scan(&c.ID, &c.WorkspaceID, &c.DeviceTypeID, &c.OperatorID, &c.Level, &c.SetupFactor); err != nil {
 			return nil, err
 		}
 		res = append(res, c)
@@ -588,29 +666,29 @@ func (r *Repos) ListOperatorCompetencies(ctx context.Context, workspaceID int64)
 func (r *Repos) GetOperatorCompetency(ctx context.Context, id int64) (OperatorCompetency, error) {
 	var c OperatorCompetency
 	err := r.DB.QueryRow(ctx, `
-		SELECT compt_oprt_id, workspace, devices__type, operator
+		SELECT compt_oprt_id, workspace, devices__type, operator, compt_level, compt_setupfactor
 		FROM competencies_operator
 		WHERE compt_oprt_id = $1
-	`, id).Scan(&c.ID, &c.WorkspaceID, &c.DeviceTypeID, &c.OperatorID)
+	`, id).Scan(&c.ID, &c.WorkspaceID, &c.DeviceTypeID, &c.OperatorID, &c.Level, &c.SetupFactor)
 	return c, err
 }
 
 func (r *Repos) CreateOperatorCompetency(ctx context.Context, c OperatorCompetency) (int64, error) {
 	var id int64
 	err := r.DB.QueryRow(ctx, `
-		INSERT INTO competencies_operator (workspace, devices__type, operator)
-		VALUES ($1, $2, $3)
+		INSERT INTO competencies_operator (workspace, devices__type, operator, compt_level, compt_setupfactor)
+		VALUES ($1, $2, $3, $4, $5)
 		RETURNING compt_oprt_id
-	`, c.WorkspaceID, c.DeviceTypeID, c.OperatorID).Scan(&id)
+	`, c.WorkspaceID, c.DeviceTypeID, c.OperatorID, competencyLevelOrDefault(c.Level), c.SetupFactor).Scan(&id)
 	return id, err
 }
 
 func (r *Repos) UpdateOperatorCompetency(ctx context.Context, c OperatorCompetency) error {
 	_, err := r.DB.Exec(ctx, `
 		UPDATE competencies_operator
-		SET workspace = $2, devices__type = $3, operator = $4
+		SET workspace = $2, devices__type = $3, operator = $4, compt_level = $5, compt_setupfactor = $6
 		WHERE compt_oprt_id = $1
-	`, c.ID, c.WorkspaceID, c.DeviceTypeID, c.OperatorID)
+	`, c.ID, c.WorkspaceID, c.DeviceTypeID, c.OperatorID, competencyLevelOrDefault(c.Level), c.SetupFactor)
 	return err
 }
 
@@ -678,7 +756,7 @@ func (r *Repos) DeleteOperatorDevice(ctx context.Context, id int64) error {
 
 func (r *Repos) ListDeviceTaskTypes(ctx context.Context, workspaceID int64) ([]DeviceTaskType, error) {
 	rows, err := r.DB.Query(ctx, `
-		SELECT dvctsktp_id, dvctsktp_name, workspace
+		SELECT dvctsktp_id, dvctsktp_name, workspace, dvctsktp_cooldownmin
 		FROM device_tasks_type
 		WHERE workspace = $1
 		ORDER BY dvctsktp_id
@@ -691,9 +769,11 @@ func (r *Repos) ListDeviceTaskTypes(ctx context.Context, workspaceID int64) ([]D
 	var res []DeviceTaskType
 	for rows.Next() {
 		var t DeviceTaskType
-		if err := rows.Scan(&t.ID, &t.Name, &t.WorkspaceID); err != nil {
+		var cooldown int
+		if err := rows.Scan(&t.ID, &t.Name, &t.WorkspaceID, &cooldown); err != nil {
 			return nil, err
 		}
+		t.Cooldown = minutesToDuration(cooldown)
 		res = append(res, t)
 	}
 	return res, rows.Err()
@@ -701,30 +781,32 @@ func (r *Repos) ListDeviceTaskTypes(ctx context.Context, workspaceID int64) ([]D
 
 func (r *Repos) GetDeviceTaskType(ctx context.Context, id int64) (DeviceTaskType, error) {
 	var t DeviceTaskType
+	var cooldown int
 	err := r.DB.QueryRow(ctx, `
-		SELECT dvctsktp_id, dvctsktp_name, workspace
+		SELECT dvctsktp_id, dvctsktp_name, workspace, dvctsktp_cooldownmin
 		FROM device_tasks_type
 		WHERE dvctsktp_id = $1
-	`, id).Scan(&t.ID, &t.Name, &t.WorkspaceID)
+	`, id).Scan(&t.ID, &t.Name, &t.WorkspaceID, &cooldown)
+	t.Cooldown = minutesToDuration(cooldown)
 	return t, err
 }
 
 func (r *Repos) CreateDeviceTaskType(ctx context.Context, t DeviceTaskType) (int64, error) {
 	var id int64
 	err := r.DB.QueryRow(ctx, `
-		INSERT INTO device_tasks_type (dvctsktp_name, workspace)
-		VALUES ($1, $2)
+		INSERT INTO device_tasks_type (dvctsktp_name, workspace, dvctsktp_cooldownmin)
+		VALUES ($1, $2, $3)
 		RETURNING dvctsktp_id
-	`, t.Name, t.WorkspaceID).Scan(&id)
+	`, t.Name, t.WorkspaceID, int(t.Cooldown.Minutes())).Scan(&id)
 	return id, err
 }
 
 func (r *Repos) UpdateDeviceTaskType(ctx context.Context, t DeviceTaskType) error {
 	_, err := r.DB.Exec(ctx, `
 		UPDATE device_tasks_type
-		SET dvctsktp_name = $2, workspace = $3
+		SET dvctsktp_name = $2, workspace = $3, dvctsktp_cooldownmin = $4
 		WHERE dvctsktp_id = $1
-	`, t.ID, t.Name, t.WorkspaceID)
+	`, t.ID, t.Name, t.WorkspaceID, int(t.Cooldown.Minutes()))
 	return err
 }
 
@@ -738,11 +820,18 @@ func (r *Repos) GetDeviceTask(ctx context.Context, id int64) (DeviceTask, error)
 	var duration pgtype.Time
 	var setup pgtype.Time
 	var unload pgtype.Time
+	var lag pgtype.Time
 	err := r.DB.QueryRow(ctx, `
 		SELECT dvctsk_id, dvctsk_name, dvctsk_deadline, dvctsk_duration, dvctsk_setuptime,
 			dvctsk_timetocomplite, COALESCE(dvctsk_needoperator,false), dvctsk_photourl,
-			dvctsk_planestarttime, dvctsk_planecomptime, dvctsk_docnum, dvctsk_complitionmark,
-			dvctsk_addinrecsystem, device_tasks_type, workspace, operator, device, priorities
+			dvctsk_planestarttime, dvctsk_planecomptime, dvctsk_docnum, dvctsk_status,
+			dvctsk_actualstarttime, dvctsk_actualcomptime,
+			dvctsk_addinrecsystem, device_tasks_type, workspace, COALESCE(operator,0), device, priorities,
+			COALESCE(production_job,0), COALESCE(dvctsk_jobseq,0), dvctsk_transferlag,
+			COALESCE(eqpmnt_characteristics,0), dvctsk_platesize, COALESCE(dvctsk_batch,0),
+			dvctsk_materialqty, COALESCE(device_pool,0), COALESCE(devices_type,0),
+			COALESCE(dvctsk_minlevel,''), COALESCE(customer_order,0), COALESCE(task_template,0),
+			dvctsk_occurrence, dvctsk_deadlinetype, dvctsk_penaltyrate, dvctsk_pinned
 		FROM device_task
 		WHERE dvctsk_id = $1
 	`, id).Scan(
@@ -757,13 +846,31 @@ func (r *Repos) GetDeviceTask(ctx context.Context, id int64) (DeviceTask, error)
 		&t.PlanStart,
 		&t.PlanEnd,
 		&t.DocNum,
-		&t.CompletionMark,
+		&t.Status,
+		&t.ActualStart,
+		&t.ActualEnd,
 		&t.AddInRecSystem,
 		&t.DeviceTaskTypeID,
 		&t.WorkspaceID,
 		&t.OperatorID,
 		&t.DeviceID,
 		&t.PriorityID,
+		&t.JobID,
+		&t.JobSeq,
+		&lag,
+		&t.MaterialID,
+		&t.PlateSize,
+		&t.BatchID,
+		&t.MaterialQty,
+		&t.DevicePoolID,
+		&t.TargetTypeID,
+		&t.MinLevel,
+		&t.OrderID,
+		&t.TemplateID,
+		&t.Occurrence,
+		&t.DeadlineType,
+		&t.PenaltyRate,
+		&t.Pinned,
 	)
 	if err != nil {
 		return t, err
@@ -771,12 +878,44 @@ func (r *Repos) GetDeviceTask(ctx context.Context, id int64) (DeviceTask, error)
 	t.Duration = timeToDuration(duration)
 	t.SetupTime = timeToDuration(setup)
 	t.UnloadTime = timeToDuration(unload)
+	t.TransferLag = timeToDuration(lag)
 	return t, nil
 }
 
+// CreateDeviceTask создаёт задание в статусе pending: остальные статусы
+// задание получает только переходами, пустой Status означает pending.
 func (r *Repos) CreateDeviceTask(ctx context.Context, t DeviceTask) (int64, error) {
+	return insertDeviceTask(ctx, r.DB, t)
+}
+
+// queryRower — общий для пула и транзакции метод QueryRow.
+type queryRower interface {
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
+func insertDeviceTask(ctx context.Context, q queryRower, t DeviceTask) (int64, error) {
+	if t.Status == "" {
+		t.Status = TaskStatusPending
+	}
+	if t.Status != TaskStatusPending {
+		return 0, fmt.Errorf("%w: new task must be %s, got %s", ErrInvalidStatusTransition, TaskStatusPending, t.Status)
+	}
+	if t.DeadlineType == "" {
+		t.DeadlineType = DeadlineHard
+	}
+	var job, seq, lag any
+	if t.JobID > 0 {
+		job, seq, lag = t.JobID, t.JobSeq, formatDuration(t.TransferLag)
+	}
+	var material any
+	if t.MaterialID > 0 {
+		material = t.MaterialID
+	}
+	if err := checkDeviceTarget(ctx, q, t.DeviceID, t.DevicePoolID, t.TargetTypeID); err != nil {
+		return 0, err
+	}
 	var id int64
-	err := r.DB.QueryRow(ctx, `
+	err := q.QueryRow(ctx, `
 		INSERT INTO device_task (
 			dvctsk_name,
 			dvctsk_deadline,
@@ -788,14 +927,28 @@ func (r *Repos) CreateDeviceTask(ctx context.Context, t DeviceTask) (int64, erro
 			dvctsk_docnum,
 			dvctsk_setuptime,
 			dvctsk_timetocomplite,
-			dvctsk_complitionmark,
+			dvctsk_status,
 			dvctsk_addinrecsystem,
 			device_tasks_type,
 			workspace,
 			operator,
 			device,
-			priorities
-		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17)
+			priorities,
+			production_job,
+			dvctsk_jobseq,
+			dvctsk_transferlag,
+			eqpmnt_characteristics,
+			dvctsk_platesize,
+			dvctsk_materialqty,
+			device_pool,
+			devices_type,
+			dvctsk_minlevel,
+			customer_order,
+			task_template,
+			dvctsk_occurrence,
+			dvctsk_deadlinetype,
+			dvctsk_penaltyrate
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18,$19,$20,$21,$22,$23,$24,$25,NULLIF($26,''),$27,$28,$29,$30,$31)
 		RETURNING dvctsk_id
 	`,
 		t.Name,
@@ -808,19 +961,64 @@ func (r *Repos) CreateDeviceTask(ctx context.Context, t DeviceTask) (int64, erro
 		t.DocNum,
 		formatDuration(t.SetupTime),
 		formatDuration(t.UnloadTime),
-		t.CompletionMark,
+		t.Status,
 		t.AddInRecSystem,
 		t.DeviceTaskTypeID,
 		t.WorkspaceID,
-		t.OperatorID,
+		nullableID(t.OperatorID),
 		t.DeviceID,
 		t.PriorityID,
+		job,
+		seq,
+		lag,
+		material,
+		t.PlateSize,
+		t.MaterialQty,
+		nullableID(t.DevicePoolID),
+		nullableID(t.TargetTypeID),
+		t.MinLevel,
+		nullableID(t.OrderID),
+		nullableID(t.TemplateID),
+		t.Occurrence,
+		t.DeadlineType,
+		t.PenaltyRate,
 	).Scan(&id)
 	return id, err
 }
 
+// UpdateDeviceTask перезаписывает задание целиком. Пустой Status сохраняет
+// текущий статус, иначе переход проверяется по taskStatusTransitions.
+// Фактические времена меняются только вместе со статусом.
 func (r *Repos) UpdateDeviceTask(ctx context.Context, t DeviceTask) error {
-	_, err := r.DB.Exec(ctx, `
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := lockDeviceTaskStatus(ctx, tx, t.ID)
+	if err != nil {
+		return err
+	}
+	if t.Status == "" {
+		t.Status = current.Status
+	}
+	if err := checkStatusTransition(current.Status, t.Status); err != nil {
+		return err
+	}
+	t.ActualStart, t.ActualEnd = actualTimesAfter(current.Status, t.Status, time.Now(), current.ActualStart, current.ActualEnd)
+	if t.DeadlineType == "" {
+		t.DeadlineType = DeadlineHard
+	}
+	var material any
+	if t.MaterialID > 0 {
+		material = t.MaterialID
+	}
+	if err := checkDeviceTarget(ctx, tx, t.DeviceID, t.DevicePoolID, t.TargetTypeID); err != nil {
+		return err
+	}
+
+	if _, err := tx.Exec(ctx, `
 		UPDATE device_task
 		SET dvctsk_name = $2,
 			dvctsk_deadline = $3,
@@ -832,13 +1030,24 @@ func (r *Repos) UpdateDeviceTask(ctx context.Context, t DeviceTask) error {
 			dvctsk_docnum = $9,
 			dvctsk_setuptime = $10,
 			dvctsk_timetocomplite = $11,
-			dvctsk_complitionmark = $12,
+			dvctsk_status = $12,
 			dvctsk_addinrecsystem = $13,
 			device_tasks_type = $14,
 			workspace = $15,
 			operator = $16,
 			device = $17,
-			priorities = $18
+			priorities = $18,
+			dvctsk_actualstarttime = $19,
+			dvctsk_actualcomptime = $20,
+			eqpmnt_characteristics = $21,
+			dvctsk_platesize = $22,
+			dvctsk_materialqty = $23,
+			device_pool = $24,
+			devices_type = $25,
+			dvctsk_minlevel = NULLIF($26,''),
+			customer_order = $27,
+			dvctsk_deadlinetype = $28,
+			dvctsk_penaltyrate = $29
 		WHERE dvctsk_id = $1
 	`,
 		t.ID,
@@ -852,14 +1061,121 @@ func (r *Repos) UpdateDeviceTask(ctx context.Context, t DeviceTask) error {
 		t.DocNum,
 		formatDuration(t.SetupTime),
 		formatDuration(t.UnloadTime),
-		t.CompletionMark,
+		t.Status,
 		t.AddInRecSystem,
 		t.DeviceTaskTypeID,
 		t.WorkspaceID,
-		t.OperatorID,
+		nullableID(t.OperatorID),
 		t.DeviceID,
 		t.PriorityID,
-	)
+		t.ActualStart,
+		t.ActualEnd,
+		material,
+		t.PlateSize,
+		t.MaterialQty,
+		nullableID(t.DevicePoolID),
+		nullableID(t.TargetTypeID),
+		t.MinLevel,
+		nullableID(t.OrderID),
+		t.DeadlineType,
+		t.PenaltyRate,
+	); err != nil {
+		return err
+	}
+	if err := markHeld(ctx, tx, t.ID, current, t.Status); err != nil {
+		return err
+	}
+	if err := applyTaskCompletion(ctx, tx, t.ID, current.Status, t.Status); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// SetDeviceTaskStatus переводит задание в новый статус, если переход разрешён,
+// и фиксирует фактическое начало/окончание моментом at.
+func (r *Repos) SetDeviceTaskStatus(ctx context.Context, id int64, to TaskStatus, at time.Time) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	current, err := lockDeviceTaskStatus(ctx, tx, id)
+	if err != nil {
+		return err
+	}
+	if err := checkStatusTransition(current.Status, to); err != nil {
+		return err
+	}
+	start, end := actualTimesAfter(current.Status, to, at, current.ActualStart, current.ActualEnd)
+	if _, err := tx.Exec(ctx, `
+		UPDATE device_task
+		SET dvctsk_status = $2,
+			dvctsk_actualstarttime = $3,
+			dvctsk_actualcomptime = $4
+		WHERE dvctsk_id = $1
+	`, id, to, start, end); err != nil {
+		return err
+	}
+	if err := markHeld(ctx, tx, id, current, to); err != nil {
+		return err
+	}
+	if err := applyTaskCompletion(ctx, tx, id, current.Status, to); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+// applyTaskCompletion учитывает завершение задания: в оборудовании остаётся
+// материал задания, а его расход списывается со склада.
+func applyTaskCompletion(ctx context.Context, tx pgx.Tx, id int64, from, to TaskStatus) error {
+	if to != TaskStatusDone || from == TaskStatusDone {
+		return nil
+	}
+	if _, err := tx.Exec(ctx, `
+		UPDATE device d
+		SET dvc_loadedmaterial = t.eqpmnt_characteristics
+		FROM device_task t
+		WHERE t.dvctsk_id = $1 AND d.dvc_id = t.device AND t.eqpmnt_characteristics IS NOT NULL
+	`, id); err != nil {
+		return err
+	}
+	_, err := tx.Exec(ctx, `
+		UPDATE material_stock s
+		SET mtrstk_onhand = GREATEST(s.mtrstk_onhand - t.dvctsk_materialqty, 0)
+		FROM device_task t
+		WHERE t.dvctsk_id = $1 AND s.eqpmnt_characteristics = t.eqpmnt_characteristics AND t.dvctsk_materialqty > 0
+	`, id)
+	return err
+}
+
+type deviceTaskStatusRow struct {
+	Status      TaskStatus
+	ActualStart *time.Time
+	ActualEnd   *time.Time
+	Held        bool
+}
+
+// lockDeviceTaskStatus читает статус задания с блокировкой строки до конца транзакции.
+func lockDeviceTaskStatus(ctx context.Context, tx pgx.Tx, id int64) (deviceTaskStatusRow, error) {
+	var row deviceTaskStatusRow
+	err := tx.QueryRow(ctx, `
+		SELECT dvctsk_status, dvctsk_actualstarttime, dvctsk_actualcomptime, dvctsk_held
+		FROM device_task
+		WHERE dvctsk_id = $1
+		FOR UPDATE
+	`, id).Scan(&row.Status, &row.ActualStart, &row.ActualEnd, &row.Held)
+	return row, err
+}
+
+// markHeld отмечает, что задание откладывалось после начала, или снимает
+// отметку при повторе после перехода из current в to.
+func markHeld(ctx context.Context, tx pgx.Tx, id int64, current deviceTaskStatusRow, to TaskStatus) error {
+	held := heldAfter(current.Status, to, current.ActualStart, current.Held)
+	if held == current.Held {
+		return nil
+	}
+	_, err := tx.Exec(ctx, `UPDATE device_task SET dvctsk_held = $2 WHERE dvctsk_id = $1`, id, held)
 	return err
 }
 
@@ -871,7 +1187,8 @@ func (r *Repos) DeleteDeviceTask(ctx context.Context, id int64) error {
 func (r *Repos) ListUserTasks(ctx context.Context, workspaceID int64) ([]UserTask, error) {
 	rows, err := r.DB.Query(ctx, `
 		SELECT usertsk_id, usertsk_name, usertsk_starttime, usertsk_endtime,
-			usertsk_priority, usertsk_complitionmark, workspace, device_task, operator
+			usertsk_priority, usertsk_complitionmark, workspace, device_task, operator,
+			COALESCE(usertsk_kind, '')
 		FROM user_task
 		WHERE workspace = $1
 		ORDER BY usertsk_id
@@ -885,7 +1202,7 @@ func (r *Repos) ListUserTasks(ctx context.Context, workspaceID int64) ([]UserTas
 	for rows.Next() {
 		var t UserTask
 		var deviceTaskID pgtype.Int8
-		if err := rows.Scan(&t.ID, &t.Name, &t.StartTime, &t.EndTime, &t.Priority, &t.CompletionMark, &t.WorkspaceID, &deviceTaskID, &t.OperatorID); err != nil {
+		if err := rows.Scan(&t.ID, &t.Name, &t.StartTime, &t.EndTime, &t.Priority, &t.CompletionMark, &t.WorkspaceID, &deviceTaskID, &t.OperatorID, &t.Kind); err != nil {
 			return nil, err
 		}
 		if deviceTaskID.Valid {
@@ -902,10 +1219,11 @@ func (r *Repos) GetUserTask(ctx context.Context, id int64) (UserTask, error) {
 	var deviceTaskID pgtype.Int8
 	err := r.DB.QueryRow(ctx, `
 		SELECT usertsk_id, usertsk_name, usertsk_starttime, usertsk_endtime,
-			usertsk_priority, usertsk_complitionmark, workspace, device_task, operator
+			usertsk_priority, usertsk_complitionmark, workspace, device_task, operator,
+			COALESCE(usertsk_kind, '')
 		FROM user_task
 		WHERE usertsk_id = $1
-	`, id).Scan(&t.ID, &t.Name, &t.StartTime, &t.EndTime, &t.Priority, &t.CompletionMark, &t.WorkspaceID, &deviceTaskID, &t.OperatorID)
+	`, id).Scan(&t.ID, &t.Name, &t.StartTime, &t.EndTime, &t.Priority, &t.CompletionMark, &t.WorkspaceID, &deviceTaskID, &t.OperatorID, &t.Kind)
 	if err != nil {
 		return t, err
 	}
@@ -954,3 +1272,43 @@ func (r *Repos) DeleteUserTask(ctx context.Context, id int64) error {
 	_, err := r.DB.Exec(ctx, `DELETE FROM user_task WHERE usertsk_id = $1`, id)
 	return err
 }
+
+// ApplyPlanUserTasks создаёт, обновляет и удаляет поручения планировщика
+// одной транзакцией. Отметка о выполнении при обновлении сохраняется.
+func (r *Repos) ApplyPlanUserTasks(ctx context.Context, create, update []UserTask, remove []int64) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	for _, t := range create {
+		if _, err := tx.Exec(ctx, `
+			INSERT INTO user_task (
+				usertsk_name, usertsk_starttime, usertsk_endtime, usertsk_priority,
+				usertsk_complitionmark, workspace, device_task, operator, usertsk_kind
+			) VALUES ($1, $2, $3, $4, FALSE, $5, $6, $7, $8)
+		`, t.Name, t.StartTime, t.EndTime, t.Priority, t.WorkspaceID, t.DeviceTaskID, t.OperatorID, t.Kind); err != nil {
+			return err
+		}
+	}
+	for _, t := range update {
+		if _, err := tx.Exec(ctx, `
+			UPDATE user_task
+			SET usertsk_name = $2,
+				usertsk_starttime = $3,
+				usertsk_endtime = $4,
+				usertsk_priority = $5,
+				operator = $6
+			WHERE usertsk_id = $1 AND usertsk_kind IS NOT NULL
+		`, t.ID, t.Name, t.StartTime, t.EndTime, t.Priority, t.OperatorID); err != nil {
+			return err
+		}
+	}
+	if len(remove) > 0 {
+		if _, err := tx.Exec(ctx, `DELETE FROM user_task WHERE usertsk_id = ANY($1) AND usertsk_kind IS NOT NULL`, remove); err != nil {
+			return err
+		}
+	}
+	return tx.Commit(ctx)
+}
@@ -0,0 +1,134 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// ProductionJob — производственное задание с маршрутом: упорядоченными
+// операциями на оборудовании разных типов.
+type ProductionJob struct {
+	ID          int64           `json:"id"`
+	Name        string          `json:"name"`
+	Deadline    *time.Time      `json:"deadline"`
+	DocNum      string          `json:"doc_num"`
+	PriorityID  int64           `json:"priority_id"`
+	WorkspaceID int64           `json:"workspace_id"`
+	Operations  []DeviceTaskRow `json:"operations"`
+}
+
+// CreateProductionJob создаёт задание и его операции одной транзакцией.
+// Операции нумеруются в порядке ops начиная с 1.
+func (r *Repos) CreateProductionJob(ctx context.Context, job ProductionJob, ops []DeviceTask) (int64, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO production_job (prdjob_name, prdjob_deadline, prdjob_docnum, priorities, workspace)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING prdjob_id
+	`, job.Name, job.Deadline, job.DocNum, job.PriorityID, job.WorkspaceID).Scan(&id); err != nil {
+		return 0, err
+	}
+	for i, op := range ops {
+		op.JobID = id
+		op.JobSeq = i + 1
+		if _, err := insertDeviceTask(ctx, tx, op); err != nil {
+			return 0, err
+		}
+	}
+	return id, tx.Commit(ctx)
+}
+
+func (r *Repos) ListProductionJobs(ctx context.Context, workspaceID int64) ([]ProductionJob, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT prdjob_id, prdjob_name, prdjob_deadline, prdjob_docnum, priorities, workspace
+		FROM production_job
+		WHERE workspace = $1
+		ORDER BY prdjob_id
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []ProductionJob
+	index := map[int64]int{}
+	for rows.Next() {
+		var j ProductionJob
+		if err := rows.Scan(&j.ID, &j.Name, &j.Deadline, &j.DocNum, &j.PriorityID, &j.WorkspaceID); err != nil {
+			return nil, err
+		}
+		index[j.ID] = len(res)
+		res = append(res, j)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	opRows, err := r.DB.Query(ctx, `
+		SELECT `+deviceTaskRowColumns+`
+		FROM device_task
+		WHERE workspace = $1
+		  AND production_job IS NOT NULL
+		ORDER BY production_job, dvctsk_jobseq
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	ops, err := scanDeviceTaskRows(opRows)
+	if err != nil {
+		return nil, err
+	}
+	for _, op := range ops {
+		if i, ok := index[op.JobID]; ok {
+			res[i].Operations = append(res[i].Operations, op)
+		}
+	}
+	return res, nil
+}
+
+func (r *Repos) GetProductionJob(ctx context.Context, id int64) (ProductionJob, error) {
+	var j ProductionJob
+	err := r.DB.QueryRow(ctx, `
+		SELECT prdjob_id, prdjob_name, prdjob_deadline, prdjob_docnum, priorities, workspace
+		FROM production_job
+		WHERE prdjob_id = $1
+	`, id).Scan(&j.ID, &j.Name, &j.Deadline, &j.DocNum, &j.PriorityID, &j.WorkspaceID)
+	if err != nil {
+		return j, err
+	}
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+deviceTaskRowColumns+`
+		FROM device_task
+		WHERE production_job = $1
+		ORDER BY dvctsk_jobseq
+	`, id)
+	if err != nil {
+		return j, err
+	}
+	j.Operations, err = scanDeviceTaskRows(rows)
+	return j, err
+}
+
+// DeleteProductionJob удаляет задание вместе с его операциями.
+func (r *Repos) DeleteProductionJob(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM production_job WHERE prdjob_id = $1`, id)
+	return err
+}
+
+// AssignDeviceTaskDevice переносит задание на другое оборудование.
+func (r *Repos) AssignDeviceTaskDevice(ctx context.Context, id int64, deviceID int64) error {
+	_, err := r.DB.Exec(ctx, `UPDATE device_task SET device = $2 WHERE dvctsk_id = $1`, id, deviceID)
+	return err
+}
+
+// AssignDeviceTaskOperator назначает заданию другого оператора.
+func (r *Repos) AssignDeviceTaskOperator(ctx context.Context, id int64, operatorID int64) error {
+	_, err := r.DB.Exec(ctx, `UPDATE device_task SET operator = $2 WHERE dvctsk_id = $1`, id, operatorID)
+	return err
+}
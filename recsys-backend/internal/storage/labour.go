@@ -0,0 +1,92 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Периоды учёта загрузки операторов при выравнивании.
+const (
+	FairnessPeriodDay  = "day"
+	FairnessPeriodWeek = "week"
+)
+
+// LabourRules — правила рабочего времени операторов workspace. Нулевое
+// значение правила — правило не действует.
+type LabourRules struct {
+	WorkspaceID int64         `json:"workspace_id"`
+	MaxDay      time.Duration `json:"max_day" swaggertype:"integer"`  // работа над заданиями за календарный день
+	MaxWeek     time.Duration `json:"max_week" swaggertype:"integer"` // работа над заданиями за неделю (с понедельника)
+	// BreakStart — начало ежедневного перерыва от полуночи; перерыв длится Break.
+	BreakStart time.Duration `json:"break_start" swaggertype:"integer"`
+	Break      time.Duration `json:"break" swaggertype:"integer"`
+	// MinRest — отдых между последней работой дня и первой работой следующего.
+	MinRest time.Duration `json:"min_rest" swaggertype:"integer"`
+	// FairnessWeight — на сколько минут позже может закончиться задание ради
+	// оператора, у которого на час меньше работы в периоде FairnessPeriod;
+	// 0 — загрузка не выравнивается.
+	FairnessWeight float64 `json:"fairness_weight"`
+	FairnessPeriod string  `json:"fairness_period"` // day | week
+}
+
+// Enabled — действует хотя бы одно правило.
+func (l LabourRules) Enabled() bool {
+	return l.MaxDay > 0 || l.MaxWeek > 0 || l.Break > 0 || l.MinRest > 0
+}
+
+// GetLabourRules возвращает правила workspace; без настроенных правил — пустые.
+func (r *Repos) GetLabourRules(ctx context.Context, workspaceID int64) (LabourRules, error) {
+	l := LabourRules{WorkspaceID: workspaceID, FairnessPeriod: FairnessPeriodDay}
+	var maxDay, maxWeek, breakMin, minRest int
+	var breakStart pgtype.Time
+	err := r.DB.QueryRow(ctx, `
+		SELECT lbrrl_maxdaymin, lbrrl_maxweekmin, lbrrl_breakstart, lbrrl_breakmin, lbrrl_minrestmin,
+			lbrrl_fairnessweight, lbrrl_fairnessperiod
+		FROM labour_rules
+		WHERE workspace = $1
+	`, workspaceID).Scan(&maxDay, &maxWeek, &breakStart, &breakMin, &minRest, &l.FairnessWeight, &l.FairnessPeriod)
+	if errors.Is(err, pgx.ErrNoRows) {
+		return l, nil
+	}
+	if err != nil {
+		return l, err
+	}
+	l.MaxDay = minutesToDuration(maxDay)
+	l.MaxWeek = minutesToDuration(maxWeek)
+	l.BreakStart = timeToDuration(breakStart)
+	l.Break = minutesToDuration(breakMin)
+	l.MinRest = minutesToDuration(minRest)
+	return l, nil
+}
+
+// SetLabourRules создаёт или заменяет правила workspace.
+func (r *Repos) SetLabourRules(ctx context.Context, l LabourRules) error {
+	var breakStart any
+	if l.Break > 0 {
+		breakStart = formatDuration(l.BreakStart)
+	}
+	if l.FairnessPeriod == "" {
+		l.FairnessPeriod = FairnessPeriodDay
+	}
+	_, err := r.DB.Exec(ctx, `
+		INSERT INTO labour_rules (
+			workspace, lbrrl_maxdaymin, lbrrl_maxweekmin, lbrrl_breakstart, lbrrl_breakmin, lbrrl_minrestmin,
+			lbrrl_fairnessweight, lbrrl_fairnessperiod
+		)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (workspace) DO UPDATE SET
+			lbrrl_maxdaymin = EXCLUDED.lbrrl_maxdaymin,
+			lbrrl_maxweekmin = EXCLUDED.lbrrl_maxweekmin,
+			lbrrl_breakstart = EXCLUDED.lbrrl_breakstart,
+			lbrrl_breakmin = EXCLUDED.lbrrl_breakmin,
+			lbrrl_minrestmin = EXCLUDED.lbrrl_minrestmin,
+			lbrrl_fairnessweight = EXCLUDED.lbrrl_fairnessweight,
+			lbrrl_fairnessperiod = EXCLUDED.lbrrl_fairnessperiod
+	`, l.WorkspaceID, int(l.MaxDay.Minutes()), int(l.MaxWeek.Minutes()), breakStart, int(l.Break.Minutes()), int(l.MinRest.Minutes()),
+		l.FairnessWeight, l.FairnessPeriod)
+	return err
+}
@@ -0,0 +1,93 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// MaterialStock — остаток материала (характеристики оборудования) на складе workspace.
+type MaterialStock struct {
+	ID          int64      `json:"id"`
+	MaterialID  int64      `json:"material_id"`
+	Unit        string     `json:"unit"` // г, мл, шт
+	OnHand      float64    `json:"on_hand"`
+	LowLevel    float64    `json:"low_level"`  // порог низкого остатка
+	RestockAt   *time.Time `json:"restock_at"` // ожидаемая поставка, nil — дата неизвестна
+	RestockQty  float64    `json:"restock_qty"`
+	WorkspaceID int64      `json:"workspace_id"`
+	// UnitCost — стоимость единицы материала; 0 — не учитывается.
+	UnitCost float64 `json:"unit_cost"`
+	// Reserved — расход запланированных заданий в статусах pending и in_progress.
+	Reserved float64 `json:"reserved"`
+}
+
+// Free — остаток, не зарезервированный под запланированные задания.
+func (s MaterialStock) Free() float64 {
+	return s.OnHand - s.Reserved
+}
+
+// Low — свободный остаток не выше порога.
+func (s MaterialStock) Low() bool {
+	return s.LowLevel > 0 && s.Free() <= s.LowLevel
+}
+
+func (r *Repos) ListMaterialStock(ctx context.Context, workspaceID int64) ([]MaterialStock, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT s.mtrstk_id, s.eqpmnt_characteristics, s.mtrstk_unit, s.mtrstk_onhand, s.mtrstk_lowlevel,
+			s.mtrstk_restockdate, s.mtrstk_restockqty, s.workspace, s.mtrstk_unitcost,
+			COALESCE((
+				SELECT SUM(t.dvctsk_materialqty)
+				FROM device_task t
+				WHERE t.eqpmnt_characteristics = s.eqpmnt_characteristics
+					AND t.dvctsk_status IN ($2, $3)
+					AND t.dvctsk_planestarttime IS NOT NULL
+			), 0)
+		FROM material_stock s
+		WHERE s.workspace = $1
+		ORDER BY s.mtrstk_id
+	`, workspaceID, TaskStatusPending, TaskStatusInProgress)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []MaterialStock
+	for rows.Next() {
+		var s MaterialStock
+		if err := rows.Scan(&s.ID, &s.MaterialID, &s.Unit, &s.OnHand, &s.LowLevel, &s.RestockAt, &s.RestockQty, &s.WorkspaceID, &s.UnitCost, &s.Reserved); err != nil {
+			return nil, err
+		}
+		res = append(res, s)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repos) CreateMaterialStock(ctx context.Context, s MaterialStock) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO material_stock (eqpmnt_characteristics, mtrstk_unit, mtrstk_onhand, mtrstk_lowlevel, mtrstk_restockdate, mtrstk_restockqty, workspace, mtrstk_unitcost)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING mtrstk_id
+	`, s.MaterialID, s.Unit, s.OnHand, s.LowLevel, s.RestockAt, s.RestockQty, s.WorkspaceID, s.UnitCost).Scan(&id)
+	return id, err
+}
+
+func (r *Repos) UpdateMaterialStock(ctx context.Context, s MaterialStock) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE material_stock
+		SET eqpmnt_characteristics = $2,
+			mtrstk_unit = $3,
+			mtrstk_onhand = $4,
+			mtrstk_lowlevel = $5,
+			mtrstk_restockdate = $6,
+			mtrstk_restockqty = $7,
+			mtrstk_unitcost = $9
+		WHERE mtrstk_id = $1 AND workspace = $8
+	`, s.ID, s.MaterialID, s.Unit, s.OnHand, s.LowLevel, s.RestockAt, s.RestockQty, s.WorkspaceID, s.UnitCost)
+	return err
+}
+
+func (r *Repos) DeleteMaterialStock(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM material_stock WHERE mtrstk_id = $1`, id)
+	return err
+}
@@ -0,0 +1,25 @@
+-- Свободный текст dvctsk_complitionmark заменяется статусом задания.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_status" TEXT NOT NULL DEFAULT 'pending';
+
+UPDATE "device_task"
+SET "dvctsk_status" = CASE
+  WHEN "dvctsk_complitionmark" IS NULL
+    OR lower(btrim("dvctsk_complitionmark")) IN ('', 'false', '0', 'no')
+    THEN 'pending'
+  WHEN lower(btrim("dvctsk_complitionmark")) IN ('true', '1', 'yes', 'done', 'completed', 'завершено')
+    THEN 'done'
+  WHEN lower(btrim("dvctsk_complitionmark")) IN ('in_progress', 'progress', 'в работе')
+    THEN 'in_progress'
+  WHEN lower(btrim("dvctsk_complitionmark")) IN ('cancelled', 'canceled', 'отменено')
+    THEN 'cancelled'
+  -- Прочие значения раньше молча исключали задание из планирования:
+  -- откладываем их, чтобы планировщик решил вручную.
+  ELSE 'on_hold'
+END;
+
+ALTER TABLE "device_task" DROP COLUMN "dvctsk_complitionmark";
+
+ALTER TABLE "device_task" ADD CONSTRAINT "chk_device_task__status"
+  CHECK ("dvctsk_status" IN ('pending', 'in_progress', 'on_hold', 'done', 'failed', 'cancelled'));
+
+CREATE INDEX "idx_device_task__status" ON "device_task" ("workspace", "dvctsk_status");
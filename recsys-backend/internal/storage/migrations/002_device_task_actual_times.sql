@@ -0,0 +1,6 @@
+-- Фактические времена выполнения, фиксируются при смене статуса.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_actualstarttime" TIMESTAMP;
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_actualcomptime" TIMESTAMP;
+-- Задание откладывалось после фактического начала: время между началом и
+-- окончанием включает простой, в статистику длительностей оно не попадает.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_held" BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,18 @@
+-- Интервалы недоступности оборудования (поломка, обслуживание); планировщик их обходит.
+CREATE TABLE "device_downtime" (
+  "dvcdwn_id" SERIAL PRIMARY KEY,
+  "dvcdwn_starttime" TIMESTAMP NOT NULL,
+  "dvcdwn_endtime" TIMESTAMP NOT NULL,
+  "dvcdwn_reason" TEXT NOT NULL DEFAULT '',
+  "device" INTEGER NOT NULL,
+  "workspace" INTEGER NOT NULL,
+  CONSTRAINT "chk_device_downtime__interval" CHECK ("dvcdwn_endtime" > "dvcdwn_starttime")
+);
+
+CREATE INDEX "idx_device_downtime__device" ON "device_downtime" ("device");
+
+CREATE INDEX "idx_device_downtime__workspace" ON "device_downtime" ("workspace");
+
+ALTER TABLE "device_downtime" ADD CONSTRAINT "fk_device_downtime__device" FOREIGN KEY ("device") REFERENCES "device" ("dvc_id") ON DELETE CASCADE;
+
+ALTER TABLE "device_downtime" ADD CONSTRAINT "fk_device_downtime__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
@@ -0,0 +1,34 @@
+-- Производственные задания с маршрутом: операции — это device_task с номером
+-- шага в маршруте и межоперационным пролёживанием.
+CREATE TABLE "production_job" (
+  "prdjob_id" SERIAL PRIMARY KEY,
+  "prdjob_name" TEXT NOT NULL,
+  "prdjob_deadline" TIMESTAMP,
+  "prdjob_docnum" TEXT NOT NULL DEFAULT '',
+  "priorities" INTEGER NOT NULL,
+  "workspace" INTEGER NOT NULL
+);
+
+CREATE INDEX "idx_production_job__workspace" ON "production_job" ("workspace");
+
+ALTER TABLE "production_job" ADD CONSTRAINT "fk_production_job__priorities" FOREIGN KEY ("priorities") REFERENCES "priorities" ("prts_id") ON DELETE CASCADE;
+
+ALTER TABLE "production_job" ADD CONSTRAINT "fk_production_job__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
+
+ALTER TABLE "device_task" ADD COLUMN "production_job" INTEGER;
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_jobseq" INTEGER;
+-- Минимальный интервал между окончанием предыдущей операции и началом этой.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_transferlag" TIME;
+
+CREATE INDEX "idx_device_task__production_job" ON "device_task" ("production_job");
+
+ALTER TABLE "device_task" ADD CONSTRAINT "fk_device_task__production_job" FOREIGN KEY ("production_job") REFERENCES "production_job" ("prdjob_id") ON DELETE CASCADE;
+
+ALTER TABLE "device_task" ADD CONSTRAINT "uq_device_task__job_seq" UNIQUE ("production_job", "dvctsk_jobseq");
+
+-- Оператор задания необязателен: операции без участия оператора (мойка,
+-- отверждение, печать без присмотра) создаются без него. Задание с
+-- dvctsk_needoperator требует оператора.
+ALTER TABLE "device_task" ALTER COLUMN "operator" DROP NOT NULL;
+
+ALTER TABLE "device_task" ADD CONSTRAINT "chk_device_task__operator" CHECK ("operator" IS NOT NULL OR NOT COALESCE("dvctsk_needoperator", false));
@@ -0,0 +1,20 @@
+-- Пакетирование: несколько небольших заданий печатаются на одной платформе
+-- за один прогон с общей наладкой и снятием.
+
+-- Вместимость платформы в условных единицах (например, см² стола); 0 — без пакетирования.
+ALTER TABLE "devices_type" ADD COLUMN "dvctp_platecapacity" INTEGER NOT NULL DEFAULT 0;
+
+-- Материал задания; в одном прогоне печатаются только задания из одного материала.
+ALTER TABLE "device_task" ADD COLUMN "eqpmnt_characteristics" INTEGER;
+-- Место, занимаемое заданием на платформе; 0 — задание печатается отдельно.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_platesize" INTEGER NOT NULL DEFAULT 0;
+-- Прогон, в который планировщик включил задание: ID первого задания прогона.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_batch" INTEGER;
+
+ALTER TABLE "devices_type" ADD CONSTRAINT "chk_devices_type__platecapacity" CHECK ("dvctp_platecapacity" >= 0);
+
+ALTER TABLE "device_task" ADD CONSTRAINT "chk_device_task__platesize" CHECK ("dvctsk_platesize" >= 0);
+
+CREATE INDEX "idx_device_task__eqpmnt_characteristics" ON "device_task" ("eqpmnt_characteristics");
+
+ALTER TABLE "device_task" ADD CONSTRAINT "fk_device_task__eqpmnt_characteristics" FOREIGN KEY ("eqpmnt_characteristics") REFERENCES "eqpmnt_characteristics" ("eqpchrscs_id") ON DELETE SET NULL;
@@ -0,0 +1,18 @@
+-- Матрица переналадки: сколько занимает переход оборудования с одного
+-- материала (характеристики) на другой.
+CREATE TABLE "material_changeover" (
+  "mtrchg_id" SERIAL PRIMARY KEY,
+  "mtrchg_from" INTEGER NOT NULL,
+  "mtrchg_to" INTEGER NOT NULL,
+  "mtrchg_duration" TIME NOT NULL,
+  "workspace" INTEGER NOT NULL,
+  CONSTRAINT "uq_material_changeover__pair" UNIQUE ("workspace", "mtrchg_from", "mtrchg_to")
+);
+
+CREATE INDEX "idx_material_changeover__workspace" ON "material_changeover" ("workspace");
+
+ALTER TABLE "material_changeover" ADD CONSTRAINT "fk_material_changeover__from" FOREIGN KEY ("mtrchg_from") REFERENCES "eqpmnt_characteristics" ("eqpchrscs_id") ON DELETE CASCADE;
+
+ALTER TABLE "material_changeover" ADD CONSTRAINT "fk_material_changeover__to" FOREIGN KEY ("mtrchg_to") REFERENCES "eqpmnt_characteristics" ("eqpchrscs_id") ON DELETE CASCADE;
+
+ALTER TABLE "material_changeover" ADD CONSTRAINT "fk_material_changeover__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
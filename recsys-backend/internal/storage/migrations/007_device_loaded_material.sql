@@ -0,0 +1,7 @@
+-- Материал, заправленный в оборудование сейчас (филамент, смола и т.п.).
+-- Обновляется вручную или при завершении задания; NULL — неизвестен.
+ALTER TABLE "device" ADD COLUMN "dvc_loadedmaterial" INTEGER;
+
+CREATE INDEX "idx_device__loadedmaterial" ON "device" ("dvc_loadedmaterial");
+
+ALTER TABLE "device" ADD CONSTRAINT "fk_device__loadedmaterial" FOREIGN KEY ("dvc_loadedmaterial") REFERENCES "eqpmnt_characteristics" ("eqpchrscs_id") ON DELETE SET NULL;
@@ -0,0 +1,29 @@
+-- Склад материалов и расходников: остаток по каждому материалу workspace.
+-- Резерв под запланированные задания не хранится, а считается по заданиям.
+CREATE TABLE "material_stock" (
+  "mtrstk_id" SERIAL PRIMARY KEY,
+  "eqpmnt_characteristics" INTEGER NOT NULL,
+  "mtrstk_unit" TEXT NOT NULL DEFAULT '',
+  "mtrstk_onhand" NUMERIC(12,2) NOT NULL DEFAULT 0,
+  -- Порог низкого остатка: при свободном остатке не выше него материал попадает в отчёт.
+  "mtrstk_lowlevel" NUMERIC(12,2) NOT NULL DEFAULT 0,
+  -- Ожидаемая поставка; NULL — дата неизвестна.
+  "mtrstk_restockdate" TIMESTAMP,
+  "mtrstk_restockqty" NUMERIC(12,2) NOT NULL DEFAULT 0,
+  "workspace" INTEGER NOT NULL,
+  CONSTRAINT "uq_material_stock__material" UNIQUE ("eqpmnt_characteristics"),
+  CONSTRAINT "chk_material_stock__onhand" CHECK ("mtrstk_onhand" >= 0),
+  CONSTRAINT "chk_material_stock__lowlevel" CHECK ("mtrstk_lowlevel" >= 0),
+  CONSTRAINT "chk_material_stock__restockqty" CHECK ("mtrstk_restockqty" >= 0)
+);
+
+CREATE INDEX "idx_material_stock__workspace" ON "material_stock" ("workspace");
+
+ALTER TABLE "material_stock" ADD CONSTRAINT "fk_material_stock__eqpmnt_characteristics" FOREIGN KEY ("eqpmnt_characteristics") REFERENCES "eqpmnt_characteristics" ("eqpchrscs_id") ON DELETE CASCADE;
+
+ALTER TABLE "material_stock" ADD CONSTRAINT "fk_material_stock__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
+
+-- Расход материала заданием в единицах склада; списывается при завершении.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_materialqty" NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE "device_task" ADD CONSTRAINT "chk_device_task__materialqty" CHECK ("dvctsk_materialqty" >= 0);
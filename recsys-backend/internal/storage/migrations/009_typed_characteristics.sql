@@ -0,0 +1,68 @@
+-- Типизированные характеристики оборудования: объём печати, поддерживаемые
+-- материалы, диаметр сопла и т.п. Значения задаются типу оборудования и
+-- отдельному устройству; значение устройства перекрывает значение типа.
+CREATE TABLE "characteristic" (
+  "chrctr_id" SERIAL PRIMARY KEY,
+  "chrctr_key" TEXT NOT NULL,
+  -- number — число с единицей, enum — одно или несколько значений из списка,
+  -- bool — да/нет, range — диапазон чисел.
+  "chrctr_kind" TEXT NOT NULL,
+  "chrctr_unit" TEXT NOT NULL DEFAULT '',
+  -- Допустимые значения enum; пустой список — любые.
+  "chrctr_options" TEXT[] NOT NULL DEFAULT '{}',
+  "workspace" INTEGER NOT NULL,
+  CONSTRAINT "uq_characteristic__key" UNIQUE ("workspace", "chrctr_key"),
+  CONSTRAINT "chk_characteristic__kind" CHECK ("chrctr_kind" IN ('number', 'enum', 'bool', 'range'))
+);
+
+CREATE INDEX "idx_characteristic__workspace" ON "characteristic" ("workspace");
+
+ALTER TABLE "characteristic" ADD CONSTRAINT "fk_characteristic__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
+
+CREATE TABLE "characteristic_value" (
+  "chrval_id" SERIAL PRIMARY KEY,
+  "characteristic" INTEGER NOT NULL,
+  "devices_type" INTEGER,
+  "device" INTEGER,
+  -- number — значение, range — нижняя граница.
+  "chrval_num" DOUBLE PRECISION,
+  -- range — верхняя граница.
+  "chrval_nummax" DOUBLE PRECISION,
+  "chrval_bool" BOOLEAN,
+  "chrval_options" TEXT[] NOT NULL DEFAULT '{}',
+  CONSTRAINT "chk_characteristic_value__owner" CHECK (("devices_type" IS NULL) <> ("device" IS NULL))
+);
+
+CREATE UNIQUE INDEX "uq_characteristic_value__owner" ON "characteristic_value" ("characteristic", (COALESCE("devices_type", 0)), (COALESCE("device", 0)));
+
+CREATE INDEX "idx_characteristic_value__devices_type" ON "characteristic_value" ("devices_type");
+
+CREATE INDEX "idx_characteristic_value__device" ON "characteristic_value" ("device");
+
+ALTER TABLE "characteristic_value" ADD CONSTRAINT "fk_characteristic_value__characteristic" FOREIGN KEY ("characteristic") REFERENCES "characteristic" ("chrctr_id") ON DELETE CASCADE;
+
+ALTER TABLE "characteristic_value" ADD CONSTRAINT "fk_characteristic_value__devices_type" FOREIGN KEY ("devices_type") REFERENCES "devices_type" ("dvctp_id") ON DELETE CASCADE;
+
+ALTER TABLE "characteristic_value" ADD CONSTRAINT "fk_characteristic_value__device" FOREIGN KEY ("device") REFERENCES "device" ("dvc_id") ON DELETE CASCADE;
+
+-- Требования задания к оборудованию: планировщик рассматривает только
+-- оборудование, которое удовлетворяет всем требованиям.
+CREATE TABLE "task_requirement" (
+  "tskreq_id" SERIAL PRIMARY KEY,
+  "device_task" INTEGER NOT NULL,
+  "characteristic" INTEGER NOT NULL,
+  -- gte/lte/eq — сравнение числа, in — пересечение со списком enum,
+  -- covers — диапазон оборудования покрывает [num, nummax], eq для bool.
+  "tskreq_op" TEXT NOT NULL,
+  "tskreq_num" DOUBLE PRECISION,
+  "tskreq_nummax" DOUBLE PRECISION,
+  "tskreq_bool" BOOLEAN,
+  "tskreq_options" TEXT[] NOT NULL DEFAULT '{}',
+  CONSTRAINT "chk_task_requirement__op" CHECK ("tskreq_op" IN ('gte', 'lte', 'eq', 'in', 'covers'))
+);
+
+CREATE INDEX "idx_task_requirement__device_task" ON "task_requirement" ("device_task");
+
+ALTER TABLE "task_requirement" ADD CONSTRAINT "fk_task_requirement__device_task" FOREIGN KEY ("device_task") REFERENCES "device_task" ("dvctsk_id") ON DELETE CASCADE;
+
+ALTER TABLE "task_requirement" ADD CONSTRAINT "fk_task_requirement__characteristic" FOREIGN KEY ("characteristic") REFERENCES "characteristic" ("chrctr_id") ON DELETE CASCADE;
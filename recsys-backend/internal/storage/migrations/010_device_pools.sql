@@ -0,0 +1,39 @@
+-- Пулы взаимозаменяемого оборудования: задание может быть адресовано пулу или
+-- типу оборудования, а конкретное устройство выбирает планировщик.
+CREATE TABLE "device_pool" (
+  "dvcpl_id" SERIAL PRIMARY KEY,
+  "dvcpl_name" TEXT NOT NULL,
+  "workspace" INTEGER NOT NULL,
+  CONSTRAINT "uq_device_pool__name" UNIQUE ("workspace", "dvcpl_name")
+);
+
+CREATE INDEX "idx_device_pool__workspace" ON "device_pool" ("workspace");
+
+ALTER TABLE "device_pool" ADD CONSTRAINT "fk_device_pool__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
+
+CREATE TABLE "device_pool_member" (
+  "device_pool" INTEGER NOT NULL,
+  "device" INTEGER NOT NULL,
+  PRIMARY KEY ("device_pool", "device")
+);
+
+CREATE INDEX "idx_device_pool_member__device" ON "device_pool_member" ("device");
+
+ALTER TABLE "device_pool_member" ADD CONSTRAINT "fk_device_pool_member__device_pool" FOREIGN KEY ("device_pool") REFERENCES "device_pool" ("dvcpl_id") ON DELETE CASCADE;
+
+ALTER TABLE "device_pool_member" ADD CONSTRAINT "fk_device_pool_member__device" FOREIGN KEY ("device") REFERENCES "device" ("dvc_id") ON DELETE CASCADE;
+
+-- Цель задания: пул или тип оборудования. device остаётся выбранным
+-- устройством и должно входить в пул или быть нужного типа.
+ALTER TABLE "device_task" ADD COLUMN "device_pool" INTEGER;
+ALTER TABLE "device_task" ADD COLUMN "devices_type" INTEGER;
+
+ALTER TABLE "device_task" ADD CONSTRAINT "chk_device_task__target" CHECK ("device_pool" IS NULL OR "devices_type" IS NULL);
+
+CREATE INDEX "idx_device_task__device_pool" ON "device_task" ("device_pool");
+
+CREATE INDEX "idx_device_task__devices_type" ON "device_task" ("devices_type");
+
+ALTER TABLE "device_task" ADD CONSTRAINT "fk_device_task__device_pool" FOREIGN KEY ("device_pool") REFERENCES "device_pool" ("dvcpl_id") ON DELETE SET NULL;
+
+ALTER TABLE "device_task" ADD CONSTRAINT "fk_device_task__devices_type" FOREIGN KEY ("devices_type") REFERENCES "devices_type" ("dvctp_id") ON DELETE SET NULL;
@@ -0,0 +1,12 @@
+-- Уровень владения оборудованием и множитель времени наладки и снятия
+-- изделия; NULL — множитель 1.
+ALTER TABLE "competencies_operator" ADD COLUMN "compt_level" TEXT NOT NULL DEFAULT 'qualified';
+ALTER TABLE "competencies_operator" ADD COLUMN "compt_setupfactor" NUMERIC(4,2);
+
+ALTER TABLE "competencies_operator" ADD CONSTRAINT "chk_competencies_operator__level" CHECK ("compt_level" IN ('trainee', 'qualified', 'expert'));
+ALTER TABLE "competencies_operator" ADD CONSTRAINT "chk_competencies_operator__setupfactor" CHECK ("compt_setupfactor" > 0);
+
+-- Минимальный уровень оператора для задания на типе его оборудования; NULL — любой.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_minlevel" TEXT;
+
+ALTER TABLE "device_task" ADD CONSTRAINT "chk_device_task__minlevel" CHECK ("dvctsk_minlevel" IN ('trainee', 'qualified', 'expert'));
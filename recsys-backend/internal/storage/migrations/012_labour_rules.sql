@@ -0,0 +1,18 @@
+-- Правила рабочего времени операторов workspace. Ноль или NULL — правило не действует.
+CREATE TABLE "labour_rules" (
+  "workspace" INTEGER PRIMARY KEY,
+  -- Предел работы оператора над заданиями за календарный день и неделю, минуты.
+  "lbrrl_maxdaymin" INTEGER NOT NULL DEFAULT 0,
+  "lbrrl_maxweekmin" INTEGER NOT NULL DEFAULT 0,
+  -- Обязательный ежедневный перерыв: начало и длительность.
+  "lbrrl_breakstart" TIME,
+  "lbrrl_breakmin" INTEGER NOT NULL DEFAULT 0,
+  -- Минимальный отдых между последней работой дня и первой работой следующего, минуты.
+  "lbrrl_minrestmin" INTEGER NOT NULL DEFAULT 0,
+  CONSTRAINT "chk_labour_rules__maxdaymin" CHECK ("lbrrl_maxdaymin" >= 0),
+  CONSTRAINT "chk_labour_rules__maxweekmin" CHECK ("lbrrl_maxweekmin" >= 0),
+  CONSTRAINT "chk_labour_rules__breakmin" CHECK ("lbrrl_breakmin" >= 0),
+  CONSTRAINT "chk_labour_rules__minrestmin" CHECK ("lbrrl_minrestmin" >= 0)
+);
+
+ALTER TABLE "labour_rules" ADD CONSTRAINT "fk_labour_rules__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
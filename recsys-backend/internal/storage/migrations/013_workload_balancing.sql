@@ -0,0 +1,8 @@
+-- Выравнивание загрузки операторов: вес загрузки в цели планировщика —
+-- минуты более позднего окончания задания за час уже назначенной оператору
+-- работы в периоде (день или неделя). 0 — загрузка не учитывается.
+ALTER TABLE "labour_rules" ADD COLUMN "lbrrl_fairnessweight" NUMERIC(8,2) NOT NULL DEFAULT 0;
+ALTER TABLE "labour_rules" ADD COLUMN "lbrrl_fairnessperiod" TEXT NOT NULL DEFAULT 'day';
+
+ALTER TABLE "labour_rules" ADD CONSTRAINT "chk_labour_rules__fairnessweight" CHECK ("lbrrl_fairnessweight" >= 0);
+ALTER TABLE "labour_rules" ADD CONSTRAINT "chk_labour_rules__fairnessperiod" CHECK ("lbrrl_fairnessperiod" IN ('day', 'week'));
@@ -0,0 +1,34 @@
+-- Потребляемая мощность оборудования, кВт. Значение устройства перекрывает
+-- значение его типа; NULL — не задана, энергия не учитывается.
+ALTER TABLE "devices_type" ADD COLUMN "dvctp_powerkw" NUMERIC(8,3);
+ALTER TABLE "device" ADD COLUMN "dvc_powerkw" NUMERIC(8,3);
+
+ALTER TABLE "devices_type" ADD CONSTRAINT "chk_devices_type__powerkw" CHECK ("dvctp_powerkw" >= 0);
+ALTER TABLE "device" ADD CONSTRAINT "chk_device__powerkw" CHECK ("dvc_powerkw" >= 0);
+
+-- Тарифный план электроэнергии workspace: цена кВт·ч вне окон и вес стоимости
+-- в цели планировщика — минуты более позднего окончания задания за единицу
+-- сэкономленной стоимости. 0 — стоимость только оценивается.
+CREATE TABLE "energy_schedule" (
+  "workspace" INTEGER PRIMARY KEY,
+  "enrsch_baseprice" NUMERIC(10,4) NOT NULL DEFAULT 0,
+  "enrsch_costweight" NUMERIC(8,2) NOT NULL DEFAULT 0,
+  CONSTRAINT "chk_energy_schedule__baseprice" CHECK ("enrsch_baseprice" >= 0),
+  CONSTRAINT "chk_energy_schedule__costweight" CHECK ("enrsch_costweight" >= 0)
+);
+
+ALTER TABLE "energy_schedule" ADD CONSTRAINT "fk_energy_schedule__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
+
+-- Окно тарифа по времени суток; окно с концом не позже начала переходит через полночь.
+CREATE TABLE "energy_tariff" (
+  "enrtrf_id" SERIAL PRIMARY KEY,
+  "workspace" INTEGER NOT NULL,
+  "enrtrf_start" TIME NOT NULL,
+  "enrtrf_end" TIME NOT NULL,
+  "enrtrf_price" NUMERIC(10,4) NOT NULL,
+  CONSTRAINT "chk_energy_tariff__price" CHECK ("enrtrf_price" >= 0)
+);
+
+CREATE INDEX "idx_energy_tariff__workspace" ON "energy_tariff" ("workspace");
+
+ALTER TABLE "energy_tariff" ADD CONSTRAINT "fk_energy_tariff__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
@@ -0,0 +1,10 @@
+-- Ставки для расчёта себестоимости заданий; 0 — не учитывается.
+-- Стоимость часа работы оборудования и оператора.
+ALTER TABLE "device" ADD COLUMN "dvc_hourlyrate" NUMERIC(10,2) NOT NULL DEFAULT 0;
+ALTER TABLE "operator" ADD COLUMN "oprt_hourlyrate" NUMERIC(10,2) NOT NULL DEFAULT 0;
+-- Стоимость единицы материала на складе (за г, мл, шт).
+ALTER TABLE "material_stock" ADD COLUMN "mtrstk_unitcost" NUMERIC(12,4) NOT NULL DEFAULT 0;
+
+ALTER TABLE "device" ADD CONSTRAINT "chk_device__hourlyrate" CHECK ("dvc_hourlyrate" >= 0);
+ALTER TABLE "operator" ADD CONSTRAINT "chk_operator__hourlyrate" CHECK ("oprt_hourlyrate" >= 0);
+ALTER TABLE "material_stock" ADD CONSTRAINT "chk_material_stock__unitcost" CHECK ("mtrstk_unitcost" >= 0);
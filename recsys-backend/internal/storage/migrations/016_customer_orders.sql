@@ -0,0 +1,23 @@
+-- Заказы клиентов: заказ объединяет задания на оборудовании под общим сроком
+-- и приоритетом.
+CREATE TABLE "customer_order" (
+  "cstord_id" SERIAL PRIMARY KEY,
+  "cstord_customer" TEXT NOT NULL,
+  "cstord_docnum" TEXT NOT NULL DEFAULT '',
+  "cstord_duedate" TIMESTAMP,
+  "priorities" INTEGER NOT NULL,
+  "workspace" INTEGER NOT NULL
+);
+
+CREATE INDEX "idx_customer_order__workspace" ON "customer_order" ("workspace");
+
+ALTER TABLE "customer_order" ADD CONSTRAINT "fk_customer_order__priorities" FOREIGN KEY ("priorities") REFERENCES "priorities" ("prts_id") ON DELETE CASCADE;
+
+ALTER TABLE "customer_order" ADD CONSTRAINT "fk_customer_order__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
+
+-- Задание заказа; при удалении заказа задания остаются без него.
+ALTER TABLE "device_task" ADD COLUMN "customer_order" INTEGER;
+
+CREATE INDEX "idx_device_task__customer_order" ON "device_task" ("customer_order");
+
+ALTER TABLE "device_task" ADD CONSTRAINT "fk_device_task__customer_order" FOREIGN KEY ("customer_order") REFERENCES "customer_order" ("cstord_id") ON DELETE SET NULL;
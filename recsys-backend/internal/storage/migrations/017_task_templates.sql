@@ -0,0 +1,55 @@
+-- Шаблоны повторяющихся заданий: параметры задания и правило повторения, по
+-- которому на горизонт вперёд создаются device_task.
+CREATE TABLE "task_template" (
+  "tsktpl_id" SERIAL PRIMARY KEY,
+  "tsktpl_name" TEXT NOT NULL,
+  "tsktpl_docnum" TEXT NOT NULL DEFAULT '',
+  "tsktpl_duration" TIME NOT NULL,
+  "tsktpl_setuptime" TIME NOT NULL,
+  "tsktpl_timetocomplite" TIME NOT NULL,
+  "tsktpl_needoperator" BOOLEAN NOT NULL DEFAULT false,
+  "tsktpl_materialqty" NUMERIC(12,2) NOT NULL DEFAULT 0,
+  -- Правило повторения: weekly — раз в N недель, monthly — раз в N месяцев,
+  -- every_n_days — раз в N дней, начиная с tsktpl_startat.
+  "tsktpl_recurrence" TEXT NOT NULL,
+  "tsktpl_interval" INTEGER NOT NULL DEFAULT 1,
+  "tsktpl_startat" TIMESTAMP NOT NULL,
+  "tsktpl_endat" TIMESTAMP,
+  "tsktpl_active" BOOLEAN NOT NULL DEFAULT true,
+  "device_tasks_type" INTEGER NOT NULL,
+  "devices_type" INTEGER NOT NULL,
+  "eqpmnt_characteristics" INTEGER,
+  -- Оператор необязателен для шаблонов операций без участия оператора.
+  "operator" INTEGER,
+  "priorities" INTEGER NOT NULL,
+  "workspace" INTEGER NOT NULL,
+  CONSTRAINT "chk_task_template__recurrence" CHECK ("tsktpl_recurrence" IN ('weekly', 'monthly', 'every_n_days')),
+  CONSTRAINT "chk_task_template__interval" CHECK ("tsktpl_interval" > 0),
+  CONSTRAINT "chk_task_template__materialqty" CHECK ("tsktpl_materialqty" >= 0),
+  CONSTRAINT "chk_task_template__operator" CHECK ("operator" IS NOT NULL OR NOT "tsktpl_needoperator")
+);
+
+CREATE INDEX "idx_task_template__workspace" ON "task_template" ("workspace");
+
+CREATE INDEX "idx_task_template__device_tasks_type" ON "task_template" ("device_tasks_type");
+
+ALTER TABLE "task_template" ADD CONSTRAINT "fk_task_template__device_tasks_type" FOREIGN KEY ("device_tasks_type") REFERENCES "device_tasks_type" ("dvctsktp_id") ON DELETE CASCADE;
+
+ALTER TABLE "task_template" ADD CONSTRAINT "fk_task_template__devices_type" FOREIGN KEY ("devices_type") REFERENCES "devices_type" ("dvctp_id") ON DELETE CASCADE;
+
+ALTER TABLE "task_template" ADD CONSTRAINT "fk_task_template__eqpmnt_characteristics" FOREIGN KEY ("eqpmnt_characteristics") REFERENCES "eqpmnt_characteristics" ("eqpchrscs_id") ON DELETE SET NULL;
+
+ALTER TABLE "task_template" ADD CONSTRAINT "fk_task_template__operator" FOREIGN KEY ("operator") REFERENCES "operator" ("oprt_id") ON DELETE CASCADE;
+
+ALTER TABLE "task_template" ADD CONSTRAINT "fk_task_template__priorities" FOREIGN KEY ("priorities") REFERENCES "priorities" ("prts_id") ON DELETE CASCADE;
+
+ALTER TABLE "task_template" ADD CONSTRAINT "fk_task_template__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
+
+-- Задание, созданное по шаблону, и момент повторения, к которому оно
+-- относится (срок задания). Повторение создаётся не больше одного раза.
+ALTER TABLE "device_task" ADD COLUMN "task_template" INTEGER;
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_occurrence" TIMESTAMP;
+
+ALTER TABLE "device_task" ADD CONSTRAINT "uq_device_task__template_occurrence" UNIQUE ("task_template", "dvctsk_occurrence");
+
+ALTER TABLE "device_task" ADD CONSTRAINT "fk_device_task__task_template" FOREIGN KEY ("task_template") REFERENCES "task_template" ("tsktpl_id") ON DELETE SET NULL;
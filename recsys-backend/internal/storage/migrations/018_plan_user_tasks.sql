@@ -0,0 +1,8 @@
+-- Поручения оператору, созданные планировщиком: наладка и снятие задания на
+-- оборудовании по плану. NULL — поручение создано вручную, планировщик его
+-- не трогает.
+ALTER TABLE "user_task" ADD COLUMN "usertsk_kind" TEXT;
+
+ALTER TABLE "user_task" ADD CONSTRAINT "chk_user_task__kind" CHECK ("usertsk_kind" IN ('setup', 'unload'));
+
+CREATE UNIQUE INDEX "idx_user_task__device_task_kind" ON "user_task" ("device_task", "usertsk_kind") WHERE "usertsk_kind" IS NOT NULL;
@@ -0,0 +1,15 @@
+-- Старение приоритета: по мере приближения дедлайна задание поднимается на
+-- уровень приоритета за каждые prag_stepmin минут внутри окна prag_windowmin.
+-- deadline — окно отсчитывается по времени до дедлайна, slack — по запасу
+-- (время до дедлайна минус оставшаяся работа). Без строки — приоритет не стареет.
+CREATE TABLE "priority_aging" (
+  "workspace" INTEGER PRIMARY KEY,
+  "prag_mode" TEXT NOT NULL DEFAULT 'none',
+  "prag_windowmin" INTEGER NOT NULL DEFAULT 0,
+  "prag_stepmin" INTEGER NOT NULL DEFAULT 0,
+  CONSTRAINT "chk_priority_aging__mode" CHECK ("prag_mode" IN ('none', 'deadline', 'slack')),
+  CONSTRAINT "chk_priority_aging__windowmin" CHECK ("prag_windowmin" >= 0),
+  CONSTRAINT "chk_priority_aging__stepmin" CHECK ("prag_stepmin" >= 0)
+);
+
+ALTER TABLE "priority_aging" ADD CONSTRAINT "fk_priority_aging__workspace" FOREIGN KEY ("workspace") REFERENCES "workspace" ("wrkspc_id") ON DELETE CASCADE;
@@ -0,0 +1,11 @@
+-- Тип дедлайна задания: hard — задание ставится только с окончанием к сроку,
+-- soft — срок можно сдвинуть, если это спасает жёсткие дедлайны.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_deadlinetype" TEXT NOT NULL DEFAULT 'hard';
+-- Штраф за час опоздания задания; NULL — по его приоритету.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_penaltyrate" NUMERIC(12,2);
+-- Штраф за час опоздания заданий приоритета; 0 — опоздание не штрафуется.
+ALTER TABLE "priorities" ADD COLUMN "prts_penaltyrate" NUMERIC(12,2) NOT NULL DEFAULT 0;
+
+ALTER TABLE "device_task" ADD CONSTRAINT "chk_device_task__deadlinetype" CHECK ("dvctsk_deadlinetype" IN ('hard', 'soft'));
+ALTER TABLE "device_task" ADD CONSTRAINT "chk_device_task__penaltyrate" CHECK ("dvctsk_penaltyrate" >= 0);
+ALTER TABLE "priorities" ADD CONSTRAINT "chk_priorities__penaltyrate" CHECK ("prts_penaltyrate" >= 0);
@@ -0,0 +1,9 @@
+-- Остывание оборудования после задания, мин: стол после высокотемпературной
+-- печати, отдых ванны с фотополимером. Оборудование в это время занято,
+-- оператор свободен. Действует большее из значений типа оборудования и типа
+-- задания; 0 — без остывания.
+ALTER TABLE "devices_type" ADD COLUMN "dvctp_cooldownmin" INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE "device_tasks_type" ADD COLUMN "dvctsktp_cooldownmin" INTEGER NOT NULL DEFAULT 0;
+
+ALTER TABLE "devices_type" ADD CONSTRAINT "chk_devices_type__cooldownmin" CHECK ("dvctp_cooldownmin" >= 0);
+ALTER TABLE "device_tasks_type" ADD CONSTRAINT "chk_device_tasks_type__cooldownmin" CHECK ("dvctsktp_cooldownmin" >= 0);
@@ -0,0 +1,2 @@
+-- Закреплённое задание: перенесено вручную, пересчёт плана оставляет его на месте.
+ALTER TABLE "device_task" ADD COLUMN "dvctsk_pinned" BOOLEAN NOT NULL DEFAULT false;
@@ -0,0 +1,75 @@
+package storage
+
+import (
+	"context"
+	"time"
+)
+
+// CustomerOrder — заказ клиента: задания на оборудовании с общим сроком и
+// приоритетом.
+type CustomerOrder struct {
+	ID          int64      `json:"id"`
+	Customer    string     `json:"customer"`
+	DocNum      string     `json:"doc_num"`
+	DueDate     *time.Time `json:"due_date"`
+	PriorityID  int64      `json:"priority_id"`
+	WorkspaceID int64      `json:"workspace_id"`
+}
+
+func (r *Repos) ListCustomerOrders(ctx context.Context, workspaceID int64) ([]CustomerOrder, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT cstord_id, cstord_customer, cstord_docnum, cstord_duedate, priorities, workspace
+		FROM customer_order
+		WHERE workspace = $1
+		ORDER BY cstord_duedate NULLS LAST, cstord_id
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []CustomerOrder
+	for rows.Next() {
+		var o CustomerOrder
+		if err := rows.Scan(&o.ID, &o.Customer, &o.DocNum, &o.DueDate, &o.PriorityID, &o.WorkspaceID); err != nil {
+			return nil, err
+		}
+		res = append(res, o)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repos) GetCustomerOrder(ctx context.Context, id int64) (CustomerOrder, error) {
+	var o CustomerOrder
+	err := r.DB.QueryRow(ctx, `
+		SELECT cstord_id, cstord_customer, cstord_docnum, cstord_duedate, priorities, workspace
+		FROM customer_order
+		WHERE cstord_id = $1
+	`, id).Scan(&o.ID, &o.Customer, &o.DocNum, &o.DueDate, &o.PriorityID, &o.WorkspaceID)
+	return o, err
+}
+
+func (r *Repos) CreateCustomerOrder(ctx context.Context, o CustomerOrder) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO customer_order (cstord_customer, cstord_docnum, cstord_duedate, priorities, workspace)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING cstord_id
+	`, o.Customer, o.DocNum, o.DueDate, o.PriorityID, o.WorkspaceID).Scan(&id)
+	return id, err
+}
+
+func (r *Repos) UpdateCustomerOrder(ctx context.Context, o CustomerOrder) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE customer_order
+		SET cstord_customer = $2, cstord_docnum = $3, cstord_duedate = $4, priorities = $5
+		WHERE cstord_id = $1 AND workspace = $6
+	`, o.ID, o.Customer, o.DocNum, o.DueDate, o.PriorityID, o.WorkspaceID)
+	return err
+}
+
+// DeleteCustomerOrder удаляет заказ; его задания остаются без заказа.
+func (r *Repos) DeleteCustomerOrder(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM customer_order WHERE cstord_id = $1`, id)
+	return err
+}
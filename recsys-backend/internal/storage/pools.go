@@ -0,0 +1,145 @@
+package storage
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrDeviceOutsideTarget — оборудование задания не входит в его пул или не
+// того типа, которому адресовано задание.
+var ErrDeviceOutsideTarget = errors.New("device does not match task target")
+
+// DevicePool — именованный набор взаимозаменяемого оборудования workspace.
+type DevicePool struct {
+	ID          int64   `json:"id"`
+	Name        string  `json:"name"`
+	WorkspaceID int64   `json:"workspace_id"`
+	DeviceIDs   []int64 `json:"device_ids"`
+}
+
+func (r *Repos) ListDevicePools(ctx context.Context, workspaceID int64) ([]DevicePool, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT p.dvcpl_id, p.dvcpl_name, p.workspace,
+			COALESCE(array_agg(m.device ORDER BY m.device) FILTER (WHERE m.device IS NOT NULL), '{}')
+		FROM device_pool p
+		LEFT JOIN device_pool_member m ON m.device_pool = p.dvcpl_id
+		WHERE p.workspace = $1
+		GROUP BY p.dvcpl_id
+		ORDER BY p.dvcpl_name
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []DevicePool
+	for rows.Next() {
+		var p DevicePool
+		if err := rows.Scan(&p.ID, &p.Name, &p.WorkspaceID, &p.DeviceIDs); err != nil {
+			return nil, err
+		}
+		res = append(res, p)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repos) GetDevicePool(ctx context.Context, id int64) (DevicePool, error) {
+	var p DevicePool
+	err := r.DB.QueryRow(ctx, `
+		SELECT p.dvcpl_id, p.dvcpl_name, p.workspace,
+			COALESCE(array_agg(m.device ORDER BY m.device) FILTER (WHERE m.device IS NOT NULL), '{}')
+		FROM device_pool p
+		LEFT JOIN device_pool_member m ON m.device_pool = p.dvcpl_id
+		WHERE p.dvcpl_id = $1
+		GROUP BY p.dvcpl_id
+	`, id).Scan(&p.ID, &p.Name, &p.WorkspaceID, &p.DeviceIDs)
+	return p, err
+}
+
+// CreateDevicePool создаёт пул вместе с составом.
+func (r *Repos) CreateDevicePool(ctx context.Context, p DevicePool) (int64, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback(ctx)
+
+	var id int64
+	if err := tx.QueryRow(ctx, `
+		INSERT INTO device_pool (dvcpl_name, workspace)
+		VALUES ($1, $2)
+		RETURNING dvcpl_id
+	`, p.Name, p.WorkspaceID).Scan(&id); err != nil {
+		return 0, err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO device_pool_member (device_pool, device)
+		SELECT $1, unnest($2::int[])
+	`, id, p.DeviceIDs); err != nil {
+		return 0, err
+	}
+	return id, tx.Commit(ctx)
+}
+
+// UpdateDevicePool переименовывает пул и заменяет его состав. Задания на
+// выбывшем оборудовании пересчёт плана перенесёт на оборудование пула.
+func (r *Repos) UpdateDevicePool(ctx context.Context, p DevicePool) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	tag, err := tx.Exec(ctx, `
+		UPDATE device_pool
+		SET dvcpl_name = $2
+		WHERE dvcpl_id = $1 AND workspace = $3
+	`, p.ID, p.Name, p.WorkspaceID)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return tx.Commit(ctx)
+	}
+	if _, err := tx.Exec(ctx, `DELETE FROM device_pool_member WHERE device_pool = $1`, p.ID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(ctx, `
+		INSERT INTO device_pool_member (device_pool, device)
+		SELECT $1, unnest($2::int[])
+	`, p.ID, p.DeviceIDs); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
+func (r *Repos) DeleteDevicePool(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM device_pool WHERE dvcpl_id = $1`, id)
+	return err
+}
+
+// checkDeviceTarget проверяет, что оборудование задания входит в его пул или
+// относится к его типу.
+func checkDeviceTarget(ctx context.Context, q queryRower, deviceID, poolID, deviceTypeID int64) error {
+	var ok bool
+	var err error
+	switch {
+	case poolID > 0:
+		err = q.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM device_pool_member WHERE device_pool = $1 AND device = $2)
+		`, poolID, deviceID).Scan(&ok)
+	case deviceTypeID > 0:
+		err = q.QueryRow(ctx, `
+			SELECT EXISTS (SELECT 1 FROM device WHERE dvc_id = $2 AND devices__type = $1)
+		`, deviceTypeID, deviceID).Scan(&ok)
+	default:
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrDeviceOutsideTarget
+	}
+	return nil
+}
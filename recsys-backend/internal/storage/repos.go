@@ -4,34 +4,88 @@ import (
 	"context"
 	"time"
 
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
 	"github.com/jackc/pgx/v5/pgtype"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// DBTX — общее у пула соединений и транзакции: репозитории работают поверх
+// любого из них.
+type DBTX interface {
+	Begin(ctx context.Context) (pgx.Tx, error)
+	Exec(ctx context.Context, sql string, args ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+}
+
 type Repos struct {
-	DB *pgxpool.Pool
+	DB DBTX
 }
 
 func NewRepos(db *pgxpool.Pool) *Repos {
 	return &Repos{DB: db}
 }
 
+// InTx выполняет fn в одной транзакции: репозитории tx пишут в неё, а
+// собственные транзакции методов становятся точками сохранения. Ошибка fn
+// откатывает все изменения.
+func (r *Repos) InTx(ctx context.Context, fn func(tx *Repos) error) error {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(&Repos{DB: tx}); err != nil {
+		return err
+	}
+	return tx.Commit(ctx)
+}
+
 type DeviceTaskRow struct {
-	ID               int64         `json:"id"`
-	Name             string        `json:"name"`
-	Deadline         *time.Time    `json:"deadline"`
-	Duration         time.Duration `json:"duration"`    // печать
-	SetupTime        time.Duration `json:"setup_time"`  // наладка
-	UnloadTime       time.Duration `json:"unload_time"` // снятие изделия
-	NeedOperator     bool          `json:"need_operator"`
-	PlanStart        *time.Time    `json:"plan_start"`
-	PlanEnd          *time.Time    `json:"plan_end"`
-	DocNum           string        `json:"doc_num"`
-	PriorityID       int64         `json:"priority_id"`
-	OperatorID       int64         `json:"operator_id"`
-	DeviceID         int64         `json:"device_id"`
+	ID               int64           `json:"id"`
+	Name             string          `json:"name"`
+	Deadline         *time.Time      `json:"deadline"`
+	Duration         time.Duration   `json:"duration" swaggertype:"integer"`    // печать
+	SetupTime        time.Duration   `json:"setup_time" swaggertype:"integer"`  // наладка
+	UnloadTime       time.Duration   `json:"unload_time" swaggertype:"integer"` // снятие изделия
+	NeedOperator     bool            `json:"need_operator"`
+	PlanStart        *time.Time      `json:"plan_start"`
+	PlanEnd          *time.Time      `json:"plan_end"`
+	DocNum           string          `json:"doc_num"`
+	Status           TaskStatus      `json:"status"`
+	ActualStart      *time.Time      `json:"actual_start"`
+	ActualEnd        *time.Time      `json:"actual_end"`
+	PriorityID       int64           `json:"priority_id"`
+	OperatorID       int64           `json:"operator_id"`
+	DeviceID         int64           `json:"device_id"`
+	DeviceTaskTypeID int64           `json:"device_task_type_id"`
+	WorkspaceID      int64           `json:"workspace_id"`
+	JobID            int64           `json:"job_id"`                             // 0 — задание вне маршрута
+	JobSeq           int             `json:"job_seq"`                            // номер операции в маршруте
+	TransferLag      time.Duration   `json:"transfer_lag" swaggertype:"integer"` // пролёживание после предыдущей операции
+	MaterialID       int64           `json:"material_id"`                        // характеристика-материал, 0 — не указан
+	PlateSize        int             `json:"plate_size"`                         // место на платформе, 0 — печатается отдельно
+	MaterialQty      float64         `json:"material_qty"`                       // расход материала в единицах склада
+	BatchID          int64           `json:"batch_id"`                           // прогон (ID первого задания), 0 — вне прогона
+	DevicePoolID     int64           `json:"device_pool_id"`                     // задание на любое оборудование пула, 0 — нет
+	TargetTypeID     int64           `json:"target_type_id"`                     // задание на любое оборудование типа, 0 — нет
+	MinLevel         CompetencyLevel `json:"min_level"`                          // минимальный уровень оператора, пусто — любой
+	OrderID          int64           `json:"order_id"`                           // заказ клиента, 0 — вне заказа
+	TemplateID       int64           `json:"template_id"`                        // шаблон, по которому создано задание, 0 — нет
+	DeadlineType     string          `json:"deadline_type"`                      // hard | soft
+	PenaltyRate      *float64        `json:"penalty_rate"`                       // штраф за час опоздания, nil — по приоритету
+	Pinned           bool            `json:"pinned"`                             // перенесено вручную, пересчёт не двигает
+}
+
+// CompletedTaskDuration — плановая и фактическая длительность завершённого задания.
+type CompletedTaskDuration struct {
+	TaskID           int64         `json:"task_id"`
+	DeviceTypeID     int64         `json:"device_type_id"`
 	DeviceTaskTypeID int64         `json:"device_task_type_id"`
-	WorkspaceID      int64         `json:"workspace_id"`
+	Nominal          time.Duration `json:"nominal" swaggertype:"integer"` // наладка + печать + снятие
+	Actual           time.Duration `json:"actual" swaggertype:"integer"`
 }
 
 type UserTaskBusy struct {
@@ -42,12 +96,15 @@ type UserTaskBusy struct {
 
 // Health-check
 func (r *Repos) Ping(ctx context.Context) error {
-	return r.DB.Ping(ctx)
+	if pool, ok := r.DB.(*pgxpool.Pool); ok {
+		return pool.Ping(ctx)
+	}
+	_, err := r.DB.Exec(ctx, `SELECT 1`)
+	return err
 }
 
-func (r *Repos) ListDeviceTasksForWorkspace(ctx context.Context, workspaceID int64) ([]DeviceTaskRow, error) {
-	rows, err := r.DB.Query(ctx, `
-		SELECT
+// deviceTaskRowColumns — колонки device_task в порядке полей scanDeviceTaskRows.
+const deviceTaskRowColumns = `
 			dvctsk_id,
 			dvctsk_name,
 			dvctsk_deadline,
@@ -58,18 +115,31 @@ func (r *Repos) ListDeviceTasksForWorkspace(ctx context.Context, workspaceID int
 			dvctsk_planestarttime,
 			dvctsk_planecomptime,
 			dvctsk_docnum,
+			dvctsk_status,
+			dvctsk_actualstarttime,
+			dvctsk_actualcomptime,
 			priorities,
-			operator,
+			COALESCE(operator,0),
 			device,
 			device_tasks_type,
-			workspace
-		FROM device_task
-		WHERE workspace = $1
-		ORDER BY dvctsk_id DESC
-	`, workspaceID)
-	if err != nil {
-		return nil, err
-	}
+			workspace,
+			COALESCE(production_job,0),
+			COALESCE(dvctsk_jobseq,0),
+			dvctsk_transferlag,
+			COALESCE(eqpmnt_characteristics,0),
+			dvctsk_platesize,
+			COALESCE(dvctsk_batch,0),
+			dvctsk_materialqty,
+			COALESCE(device_pool,0),
+			COALESCE(devices_type,0),
+			COALESCE(dvctsk_minlevel,''),
+			COALESCE(customer_order,0),
+			COALESCE(task_template,0),
+			dvctsk_deadlinetype,
+			dvctsk_penaltyrate,
+			dvctsk_pinned`
+
+func scanDeviceTaskRows(rows pgx.Rows) ([]DeviceTaskRow, error) {
 	defer rows.Close()
 
 	var res []DeviceTaskRow
@@ -78,6 +148,7 @@ func (r *Repos) ListDeviceTasksForWorkspace(ctx context.Context, workspaceID int
 		var duration pgtype.Time
 		var setup pgtype.Time
 		var unload pgtype.Time
+		var lag pgtype.Time
 		if err := rows.Scan(
 			&t.ID,
 			&t.Name,
@@ -89,89 +160,151 @@ func (r *Repos) ListDeviceTasksForWorkspace(ctx context.Context, workspaceID int
 			&t.PlanStart,
 			&t.PlanEnd,
 			&t.DocNum,
+			&t.Status,
+			&t.ActualStart,
+			&t.ActualEnd,
 			&t.PriorityID,
 			&t.OperatorID,
 			&t.DeviceID,
 			&t.DeviceTaskTypeID,
 			&t.WorkspaceID,
+			&t.JobID,
+			&t.JobSeq,
+			&lag,
+			&t.MaterialID,
+			&t.PlateSize,
+			&t.BatchID,
+			&t.MaterialQty,
+			&t.DevicePoolID,
+			&t.TargetTypeID,
+			&t.MinLevel,
+			&t.OrderID,
+			&t.TemplateID,
+			&t.DeadlineType,
+			&t.PenaltyRate,
+			&t.Pinned,
 		); err != nil {
 			return nil, err
 		}
 		t.Duration = timeToDuration(duration)
 		t.SetupTime = timeToDuration(setup)
 		t.UnloadTime = timeToDuration(unload)
+		t.TransferLag = timeToDuration(lag)
 		res = append(res, t)
 	}
 	return res, rows.Err()
 }
 
+// ListDeviceTasksForWorkspace возвращает задания workspace; непустой statuses
+// оставляет только задания в перечисленных статусах.
+func (r *Repos) ListDeviceTasksForWorkspace(ctx context.Context, workspaceID int64, statuses ...TaskStatus) ([]DeviceTaskRow, error) {
+	query := `
+		SELECT ` + deviceTaskRowColumns + `
+		FROM device_task
+		WHERE workspace = $1`
+	args := []any{workspaceID}
+	if len(statuses) > 0 {
+		names := make([]string, 0, len(statuses))
+		for _, st := range statuses {
+			names = append(names, string(st))
+		}
+		query += ` AND dvctsk_status = ANY($2)`
+		args = append(args, names)
+	}
+	query += ` ORDER BY dvctsk_id DESC`
+
+	rows, err := r.DB.Query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeviceTaskRows(rows)
+}
+
+// LockDeviceTasksForWorkspace возвращает задания workspace, блокируя их строки
+// до конца транзакции: параллельные изменения плана ждут её завершения.
+func (r *Repos) LockDeviceTasksForWorkspace(ctx context.Context, workspaceID int64) ([]DeviceTaskRow, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+deviceTaskRowColumns+`
+		FROM device_task
+		WHERE workspace = $1
+		ORDER BY dvctsk_id DESC
+		FOR UPDATE
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeviceTaskRows(rows)
+}
+
 func (r *Repos) ListTasksForPlanning(ctx context.Context, workspaceID int64) ([]DeviceTaskRow, error) {
 	rows, err := r.DB.Query(ctx, `
-		SELECT
-			dvctsk_id,
-			dvctsk_name,
-			dvctsk_deadline,
-			dvctsk_duration,
-			dvctsk_setuptime,
-			dvctsk_timetocomplite,
-			COALESCE(dvctsk_needoperator,false),
-			dvctsk_planestarttime,
-			dvctsk_planecomptime,
-			dvctsk_docnum,
-			priorities,
-			operator,
-			device,
-			device_tasks_type,
-			workspace
+		SELECT `+deviceTaskRowColumns+`
 		FROM device_task
 		WHERE workspace = $1
 		  AND COALESCE(dvctsk_addinrecsystem,false) = true
-		  AND (dvctsk_complitionmark IS NULL OR dvctsk_complitionmark = '' OR dvctsk_complitionmark = 'false')
+		  AND dvctsk_status = $2
+		  AND NOT dvctsk_pinned
 		ORDER BY COALESCE(dvctsk_deadline, now() + interval '365 days') ASC
-	`, workspaceID)
+	`, workspaceID, TaskStatusPending)
+	if err != nil {
+		return nil, err
+	}
+	return scanDeviceTaskRows(rows)
+}
+
+// ListCompletedTaskDurations возвращает завершённые задания workspace с
+// записанным фактическим началом и окончанием. Задания, которые откладывались
+// после начала, не входят: их фактическое время включает простой.
+func (r *Repos) ListCompletedTaskDurations(ctx context.Context, workspaceID int64) ([]CompletedTaskDuration, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT
+			t.dvctsk_id,
+			d.devices__type,
+			t.device_tasks_type,
+			t.dvctsk_duration,
+			t.dvctsk_setuptime,
+			t.dvctsk_timetocomplite,
+			t.dvctsk_actualstarttime,
+			t.dvctsk_actualcomptime
+		FROM device_task t
+		JOIN device d ON d.dvc_id = t.device
+		WHERE t.workspace = $1
+		  AND t.dvctsk_status = $2
+		  AND t.dvctsk_actualstarttime IS NOT NULL
+		  AND t.dvctsk_actualcomptime > t.dvctsk_actualstarttime
+		  AND NOT t.dvctsk_held
+		ORDER BY t.dvctsk_actualcomptime
+	`, workspaceID, TaskStatusDone)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	var res []DeviceTaskRow
+	var res []CompletedTaskDuration
 	for rows.Next() {
-		var t DeviceTaskRow
+		var c CompletedTaskDuration
 		var duration pgtype.Time
 		var setup pgtype.Time
 		var unload pgtype.Time
-		if err := rows.Scan(
-			&t.ID,
-			&t.Name,
-			&t.Deadline,
-			&duration,
-			&setup,
-			&unload,
-			&t.NeedOperator,
-			&t.PlanStart,
-			&t.PlanEnd,
-			&t.DocNum,
-			&t.PriorityID,
-			&t.OperatorID,
-			&t.DeviceID,
-			&t.DeviceTaskTypeID,
-			&t.WorkspaceID,
-		); err != nil {
+		var start, end time.Time
+		if err := rows.Scan(&c.TaskID, &c.DeviceTypeID, &c.DeviceTaskTypeID, &duration, &setup, &unload, &start, &end); err != nil {
 			return nil, err
 		}
-		t.Duration = timeToDuration(duration)
-		t.SetupTime = timeToDuration(setup)
-		t.UnloadTime = timeToDuration(unload)
-		res = append(res, t)
+		c.Nominal = timeToDuration(duration) + timeToDuration(setup) + timeToDuration(unload)
+		c.Actual = end.Sub(start)
+		res = append(res, c)
 	}
 	return res, rows.Err()
 }
 
+// ListOperatorBusy — личные поручения операторов, созданные вручную.
+// Наладка и снятие по плану уже учтены в самих заданиях.
 func (r *Repos) ListOperatorBusy(ctx context.Context, workspaceID int64) ([]UserTaskBusy, error) {
 	rows, err := r.DB.Query(ctx, `
 		SELECT operator, usertsk_starttime, usertsk_endtime
 		FROM user_task
 		WHERE workspace = $1
+		  AND usertsk_kind IS NULL
 		  AND usertsk_starttime IS NOT NULL
 		  AND usertsk_endtime IS NOT NULL
 	`, workspaceID)
@@ -200,3 +333,36 @@ func (r *Repos) UpdateDeviceTaskPlan(ctx context.Context, id int64, start time.T
 	`, id, start, end)
 	return err
 }
+
+// SetDeviceTaskPinned закрепляет задание на его месте в плане или снимает закрепление.
+func (r *Repos) SetDeviceTaskPinned(ctx context.Context, id int64, pinned bool) error {
+	tag, err := r.DB.Exec(ctx, `UPDATE device_task SET dvctsk_pinned = $2 WHERE dvctsk_id = $1`, id, pinned)
+	if err != nil {
+		return err
+	}
+	if tag.RowsAffected() == 0 {
+		return pgx.ErrNoRows
+	}
+	return nil
+}
+
+// SetDeviceTaskBatch включает задание в прогон; 0 — задание печатается отдельно.
+func (r *Repos) SetDeviceTaskBatch(ctx context.Context, id int64, batchID int64) error {
+	var batch any
+	if batchID > 0 {
+		batch = batchID
+	}
+	_, err := r.DB.Exec(ctx, `UPDATE device_task SET dvctsk_batch = $2 WHERE dvctsk_id = $1`, id, batch)
+	return err
+}
+
+// ClearDeviceTaskPlan снимает задание с плана.
+func (r *Repos) ClearDeviceTaskPlan(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE device_task
+		SET dvctsk_planestarttime = NULL,
+		    dvctsk_planecomptime  = NULL
+		WHERE dvctsk_id = $1
+	`, id)
+	return err
+}
@@ -2,8 +2,10 @@ package storage
 
 import (
 	"context"
-	_ "embed"
+	"embed"
 	"fmt"
+	"io/fs"
+	"sort"
 
 	"github.com/jackc/pgx/v5/pgxpool"
 )
@@ -11,16 +13,69 @@ import (
 //go:embed createDB.sql
 var createDBSQL string
 
+// Миграции применяются поверх createDB.sql по порядку имён файлов.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
 func EnsureSchema(ctx context.Context, db *pgxpool.Pool) error {
 	var exists bool
 	if err := db.QueryRow(ctx, `SELECT to_regclass('public."user"') IS NOT NULL`).Scan(&exists); err != nil {
 		return fmt.Errorf("check schema: %w", err)
 	}
-	if exists {
-		return nil
+	if !exists {
+		if _, err := db.Exec(ctx, createDBSQL); err != nil {
+			return fmt.Errorf("init schema: %w", err)
+		}
+	}
+	return applyMigrations(ctx, db)
+}
+
+// applyMigrations выполняет ещё не применённые файлы из migrations/, каждый в
+// отдельной транзакции, и запоминает их в schema_migrations.
+func applyMigrations(ctx context.Context, db *pgxpool.Pool) error {
+	if _, err := db.Exec(ctx, `
+		CREATE TABLE IF NOT EXISTS "schema_migrations" (
+			"version" TEXT PRIMARY KEY,
+			"applied_at" TIMESTAMP NOT NULL DEFAULT now()
+		)
+	`); err != nil {
+		return fmt.Errorf("init migrations: %w", err)
 	}
-	if _, err := db.Exec(ctx, createDBSQL); err != nil {
-		return fmt.Errorf("init schema: %w", err)
+
+	names, err := fs.Glob(migrationsFS, "migrations/*.sql")
+	if err != nil {
+		return err
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		var applied bool
+		if err := db.QueryRow(ctx, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, name).Scan(&applied); err != nil {
+			return fmt.Errorf("check migration %s: %w", name, err)
+		}
+		if applied {
+			continue
+		}
+		body, err := migrationsFS.ReadFile(name)
+		if err != nil {
+			return err
+		}
+		tx, err := db.Begin(ctx)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.Exec(ctx, string(body)); err != nil {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("apply migration %s: %w", name, err)
+		}
+		if _, err := tx.Exec(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, name); err != nil {
+			_ = tx.Rollback(ctx)
+			return fmt.Errorf("record migration %s: %w", name, err)
+		}
+		if err := tx.Commit(ctx); err != nil {
+			return fmt.Errorf("commit migration %s: %w", name, err)
+		}
 	}
 	return nil
 }
@@ -0,0 +1,102 @@
+package storage
+
+import (
+	"errors"
+	"fmt"
+	"time"
+)
+
+// TaskStatus — статус выполнения задания оборудования (dvctsk_status).
+type TaskStatus string
+
+const (
+	TaskStatusPending    TaskStatus = "pending"     // ожидает планирования/запуска
+	TaskStatusInProgress TaskStatus = "in_progress" // выполняется
+	TaskStatusOnHold     TaskStatus = "on_hold"     // отложено, не планируется
+	TaskStatusDone       TaskStatus = "done"        // завершено
+	TaskStatusFailed     TaskStatus = "failed"      // брак/сбой, можно перезапустить
+	TaskStatusCancelled  TaskStatus = "cancelled"   // отменено
+)
+
+var (
+	ErrUnknownTaskStatus       = errors.New("unknown task status")
+	ErrInvalidStatusTransition = errors.New("invalid status transition")
+)
+
+// taskStatusTransitions перечисляет разрешённые переходы. Повтор текущего
+// статуса всегда допустим и сюда не входит.
+var taskStatusTransitions = map[TaskStatus][]TaskStatus{
+	TaskStatusPending:    {TaskStatusInProgress, TaskStatusOnHold, TaskStatusCancelled},
+	TaskStatusInProgress: {TaskStatusDone, TaskStatusFailed, TaskStatusOnHold},
+	TaskStatusOnHold:     {TaskStatusPending, TaskStatusCancelled},
+	TaskStatusFailed:     {TaskStatusPending, TaskStatusCancelled},
+	TaskStatusCancelled:  {TaskStatusPending},
+	TaskStatusDone:       {},
+}
+
+func ParseTaskStatus(s string) (TaskStatus, error) {
+	st := TaskStatus(s)
+	if _, ok := taskStatusTransitions[st]; !ok {
+		return "", fmt.Errorf("%w: %q", ErrUnknownTaskStatus, s)
+	}
+	return st, nil
+}
+
+func (s TaskStatus) CanTransitionTo(to TaskStatus) bool {
+	if s == to {
+		return true
+	}
+	for _, next := range taskStatusTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
+
+func checkStatusTransition(from, to TaskStatus) error {
+	if !from.CanTransitionTo(to) {
+		return fmt.Errorf("%w: %s -> %s", ErrInvalidStatusTransition, from, to)
+	}
+	return nil
+}
+
+// actualTimesAfter возвращает фактические начало и окончание задания после
+// перехода from → to в момент at. Повтор после брака или отмены (→ pending)
+// сбрасывает оба времени. Отложенное задание возвращается в pending с исходным
+// началом, и следующий запуск его сохраняет.
+func actualTimesAfter(from, to TaskStatus, at time.Time, start, end *time.Time) (*time.Time, *time.Time) {
+	if from == to {
+		return start, end
+	}
+	switch to {
+	case TaskStatusPending:
+		if from == TaskStatusOnHold {
+			return start, nil
+		}
+		return nil, nil
+	case TaskStatusInProgress:
+		if start == nil {
+			start = &at
+		}
+		return start, nil
+	case TaskStatusDone, TaskStatusFailed:
+		return start, &at
+	}
+	return start, end
+}
+
+// heldAfter — задание откладывалось после фактического начала (start), с
+// учётом перехода from → to. Повтор после брака или отмены начинает отсчёт
+// заново.
+func heldAfter(from, to TaskStatus, start *time.Time, held bool) bool {
+	switch {
+	case from == to:
+		return held
+	case to == TaskStatusOnHold:
+		return held || start != nil
+	case to == TaskStatusPending && from != TaskStatusOnHold:
+		return false
+	}
+	return held
+}
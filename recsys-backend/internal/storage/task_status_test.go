@@ -0,0 +1,113 @@
+package storage
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestCanTransitionTo(t *testing.T) {
+	allowed := [][2]TaskStatus{
+		{TaskStatusPending, TaskStatusInProgress},
+		{TaskStatusInProgress, TaskStatusOnHold},
+		{TaskStatusOnHold, TaskStatusPending},
+		{TaskStatusFailed, TaskStatusPending},
+		{TaskStatusCancelled, TaskStatusPending},
+		{TaskStatusDone, TaskStatusDone},
+	}
+	for _, p := range allowed {
+		if !p[0].CanTransitionTo(p[1]) {
+			t.Errorf("%s -> %s must be allowed", p[0], p[1])
+		}
+		if err := checkStatusTransition(p[0], p[1]); err != nil {
+			t.Errorf("checkStatusTransition(%s, %s) = %v", p[0], p[1], err)
+		}
+	}
+
+	denied := [][2]TaskStatus{
+		{TaskStatusPending, TaskStatusDone},
+		{TaskStatusInProgress, TaskStatusPending},
+		{TaskStatusDone, TaskStatusPending},
+	}
+	for _, p := range denied {
+		if p[0].CanTransitionTo(p[1]) {
+			t.Errorf("%s -> %s must be denied", p[0], p[1])
+		}
+		if err := checkStatusTransition(p[0], p[1]); !errors.Is(err, ErrInvalidStatusTransition) {
+			t.Errorf("checkStatusTransition(%s, %s) = %v, want ErrInvalidStatusTransition", p[0], p[1], err)
+		}
+	}
+}
+
+func TestParseTaskStatus(t *testing.T) {
+	if st, err := ParseTaskStatus("on_hold"); err != nil || st != TaskStatusOnHold {
+		t.Errorf("on_hold: got %q, %v", st, err)
+	}
+	if _, err := ParseTaskStatus("paused"); !errors.Is(err, ErrUnknownTaskStatus) {
+		t.Errorf("paused: got %v, want ErrUnknownTaskStatus", err)
+	}
+}
+
+// Задание проходит запуск, паузу, возврат в очередь и повторный запуск:
+// фактическое начало остаётся первым, окончание ставит завершение.
+func TestActualTimesAfterHoldAndResume(t *testing.T) {
+	t0 := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	var start, end *time.Time
+	steps := []struct {
+		from, to TaskStatus
+		at       time.Time
+	}{
+		{TaskStatusPending, TaskStatusInProgress, t0},
+		{TaskStatusInProgress, TaskStatusOnHold, t0.Add(time.Hour)},
+		{TaskStatusOnHold, TaskStatusPending, t0.Add(2 * time.Hour)},
+		{TaskStatusPending, TaskStatusInProgress, t0.Add(3 * time.Hour)},
+		{TaskStatusInProgress, TaskStatusDone, t0.Add(5 * time.Hour)},
+	}
+	for _, s := range steps {
+		start, end = actualTimesAfter(s.from, s.to, s.at, start, end)
+		if start == nil || !start.Equal(t0) {
+			t.Fatalf("%s -> %s: start %v, want %v", s.from, s.to, start, t0)
+		}
+	}
+	if end == nil || !end.Equal(t0.Add(5*time.Hour)) {
+		t.Errorf("end %v, want %v", end, t0.Add(5*time.Hour))
+	}
+
+	if _, end = actualTimesAfter(TaskStatusDone, TaskStatusDone, t0.Add(6*time.Hour), start, end); !end.Equal(t0.Add(5 * time.Hour)) {
+		t.Errorf("same status moved end to %v", end)
+	}
+}
+
+func TestActualTimesAfterRetry(t *testing.T) {
+	t0 := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+	t1 := t0.Add(2 * time.Hour)
+	for _, from := range []TaskStatus{TaskStatusFailed, TaskStatusCancelled} {
+		if start, end := actualTimesAfter(from, TaskStatusPending, t1, &t0, &t1); start != nil || end != nil {
+			t.Errorf("%s -> pending: got (%v, %v), want both reset", from, start, end)
+		}
+	}
+	start, end := actualTimesAfter(TaskStatusInProgress, TaskStatusFailed, t1, &t0, nil)
+	if !start.Equal(t0) || end == nil || !end.Equal(t1) {
+		t.Errorf("in_progress -> failed: got (%v, %v), want (%v, %v)", start, end, t0, t1)
+	}
+}
+
+func TestHeldAfter(t *testing.T) {
+	t0 := time.Date(2025, 3, 3, 9, 0, 0, 0, time.UTC)
+
+	if heldAfter(TaskStatusPending, TaskStatusOnHold, nil, false) {
+		t.Error("hold before start must not mark the task")
+	}
+	held := heldAfter(TaskStatusInProgress, TaskStatusOnHold, &t0, false)
+	if !held {
+		t.Fatal("hold after start must mark the task")
+	}
+	held = heldAfter(TaskStatusOnHold, TaskStatusPending, &t0, held)
+	held = heldAfter(TaskStatusPending, TaskStatusInProgress, &t0, held)
+	if held = heldAfter(TaskStatusInProgress, TaskStatusDone, &t0, held); !held {
+		t.Error("mark lost on resume and completion")
+	}
+	if heldAfter(TaskStatusFailed, TaskStatusPending, &t0, true) {
+		t.Error("retry after failure must clear the mark")
+	}
+}
@@ -0,0 +1,290 @@
+package storage
+
+import (
+	"context"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgtype"
+)
+
+// Правила повторения шаблона задания.
+const (
+	RecurrenceWeekly     = "weekly"       // раз в Interval недель
+	RecurrenceMonthly    = "monthly"      // раз в Interval месяцев
+	RecurrenceEveryNDays = "every_n_days" // раз в Interval дней
+)
+
+// TaskTemplate — шаблон повторяющегося задания: параметры задания и правило
+// повторения. Повторения идут от StartAt; момент повторения становится
+// сроком созданного задания.
+type TaskTemplate struct {
+	ID               int64         `json:"id"`
+	Name             string        `json:"name"`
+	DocNum           string        `json:"doc_num"`
+	Duration         time.Duration `json:"duration" swaggertype:"integer"`
+	SetupTime        time.Duration `json:"setup_time" swaggertype:"integer"`
+	UnloadTime       time.Duration `json:"unload_time" swaggertype:"integer"`
+	NeedOperator     bool          `json:"need_operator"`
+	MaterialID       int64         `json:"material_id"` // 0 — не указан
+	MaterialQty      float64       `json:"material_qty"`
+	Recurrence       string        `json:"recurrence"`
+	Interval         int           `json:"interval"`
+	StartAt          time.Time     `json:"start_at"`
+	EndAt            *time.Time    `json:"end_at"` // nil — без окончания
+	Active           bool          `json:"active"`
+	DeviceTaskTypeID int64         `json:"device_task_type_id"`
+	DeviceTypeID     int64         `json:"device_type_id"`
+	OperatorID       int64         `json:"operator_id"`
+	PriorityID       int64         `json:"priority_id"`
+	WorkspaceID      int64         `json:"workspace_id"`
+}
+
+const taskTemplateColumns = `
+			tsktpl_id, tsktpl_name, tsktpl_docnum, tsktpl_duration, tsktpl_setuptime,
+			tsktpl_timetocomplite, tsktpl_needoperator, COALESCE(eqpmnt_characteristics,0),
+			tsktpl_materialqty, tsktpl_recurrence, tsktpl_interval, tsktpl_startat, tsktpl_endat,
+			tsktpl_active, device_tasks_type, devices_type, COALESCE(operator,0), priorities, workspace`
+
+func scanTaskTemplate(row pgx.Row) (TaskTemplate, error) {
+	var t TaskTemplate
+	var duration, setup, unload pgtype.Time
+	err := row.Scan(
+		&t.ID,
+		&t.Name,
+		&t.DocNum,
+		&duration,
+		&setup,
+		&unload,
+		&t.NeedOperator,
+		&t.MaterialID,
+		&t.MaterialQty,
+		&t.Recurrence,
+		&t.Interval,
+		&t.StartAt,
+		&t.EndAt,
+		&t.Active,
+		&t.DeviceTaskTypeID,
+		&t.DeviceTypeID,
+		&t.OperatorID,
+		&t.PriorityID,
+		&t.WorkspaceID,
+	)
+	t.Duration = timeToDuration(duration)
+	t.SetupTime = timeToDuration(setup)
+	t.UnloadTime = timeToDuration(unload)
+	return t, err
+}
+
+func (r *Repos) ListTaskTemplates(ctx context.Context, workspaceID int64) ([]TaskTemplate, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT `+taskTemplateColumns+`
+		FROM task_template
+		WHERE workspace = $1
+		ORDER BY tsktpl_name, tsktpl_id
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var res []TaskTemplate
+	for rows.Next() {
+		t, err := scanTaskTemplate(rows)
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, t)
+	}
+	return res, rows.Err()
+}
+
+func (r *Repos) GetTaskTemplate(ctx context.Context, id int64) (TaskTemplate, error) {
+	return scanTaskTemplate(r.DB.QueryRow(ctx, `
+		SELECT `+taskTemplateColumns+`
+		FROM task_template
+		WHERE tsktpl_id = $1
+	`, id))
+}
+
+func (r *Repos) CreateTaskTemplate(ctx context.Context, t TaskTemplate) (int64, error) {
+	var id int64
+	err := r.DB.QueryRow(ctx, `
+		INSERT INTO task_template (
+			tsktpl_name, tsktpl_docnum, tsktpl_duration, tsktpl_setuptime, tsktpl_timetocomplite,
+			tsktpl_needoperator, eqpmnt_characteristics, tsktpl_materialqty, tsktpl_recurrence,
+			tsktpl_interval, tsktpl_startat, tsktpl_endat, tsktpl_active, device_tasks_type,
+			devices_type, operator, priorities, workspace
+		) VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,$14,$15,$16,$17,$18)
+		RETURNING tsktpl_id
+	`,
+		t.Name,
+		t.DocNum,
+		formatDuration(t.Duration),
+		formatDuration(t.SetupTime),
+		formatDuration(t.UnloadTime),
+		t.NeedOperator,
+		nullableID(t.MaterialID),
+		t.MaterialQty,
+		t.Recurrence,
+		t.Interval,
+		t.StartAt,
+		t.EndAt,
+		t.Active,
+		t.DeviceTaskTypeID,
+		t.DeviceTypeID,
+		nullableID(t.OperatorID),
+		t.PriorityID,
+		t.WorkspaceID,
+	).Scan(&id)
+	return id, err
+}
+
+// UpdateTaskTemplate перезаписывает шаблон. Уже созданные задания не меняются,
+// см. UpdateFutureTemplateTasks.
+func (r *Repos) UpdateTaskTemplate(ctx context.Context, t TaskTemplate) error {
+	_, err := r.DB.Exec(ctx, `
+		UPDATE task_template
+		SET tsktpl_name = $2,
+			tsktpl_docnum = $3,
+			tsktpl_duration = $4,
+			tsktpl_setuptime = $5,
+			tsktpl_timetocomplite = $6,
+			tsktpl_needoperator = $7,
+			eqpmnt_characteristics = $8,
+			tsktpl_materialqty = $9,
+			tsktpl_recurrence = $10,
+			tsktpl_interval = $11,
+			tsktpl_startat = $12,
+			tsktpl_endat = $13,
+			tsktpl_active = $14,
+			device_tasks_type = $15,
+			devices_type = $16,
+			operator = $17,
+			priorities = $18
+		WHERE tsktpl_id = $1 AND workspace = $19
+	`,
+		t.ID,
+		t.Name,
+		t.DocNum,
+		formatDuration(t.Duration),
+		formatDuration(t.SetupTime),
+		formatDuration(t.UnloadTime),
+		t.NeedOperator,
+		nullableID(t.MaterialID),
+		t.MaterialQty,
+		t.Recurrence,
+		t.Interval,
+		t.StartAt,
+		t.EndAt,
+		t.Active,
+		t.DeviceTaskTypeID,
+		t.DeviceTypeID,
+		nullableID(t.OperatorID),
+		t.PriorityID,
+		t.WorkspaceID,
+	)
+	return err
+}
+
+// DeleteTaskTemplate удаляет шаблон; созданные по нему задания остаются.
+func (r *Repos) DeleteTaskTemplate(ctx context.Context, id int64) error {
+	_, err := r.DB.Exec(ctx, `DELETE FROM task_template WHERE tsktpl_id = $1`, id)
+	return err
+}
+
+// ListTemplateOccurrences возвращает уже созданные повторения шаблонов
+// workspace: шаблон -> моменты повторения.
+func (r *Repos) ListTemplateOccurrences(ctx context.Context, workspaceID int64) (map[int64][]time.Time, error) {
+	rows, err := r.DB.Query(ctx, `
+		SELECT task_template, dvctsk_occurrence
+		FROM device_task
+		WHERE workspace = $1
+		  AND task_template IS NOT NULL
+		  AND dvctsk_occurrence IS NOT NULL
+	`, workspaceID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := map[int64][]time.Time{}
+	for rows.Next() {
+		var id int64
+		var at time.Time
+		if err := rows.Scan(&id, &at); err != nil {
+			return nil, err
+		}
+		res[id] = append(res[id], at)
+	}
+	return res, rows.Err()
+}
+
+// CreateTemplateTasks создаёт задания повторений одной транзакцией.
+func (r *Repos) CreateTemplateTasks(ctx context.Context, tasks []DeviceTask) ([]int64, error) {
+	tx, err := r.DB.Begin(ctx)
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback(ctx)
+
+	ids := make([]int64, 0, len(tasks))
+	for _, t := range tasks {
+		id, err := insertDeviceTask(ctx, tx, t)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, tx.Commit(ctx)
+}
+
+// UpdateFutureTemplateTasks переносит параметры шаблона в его ожидающие
+// задания с повторением позже after. Задание остаётся на своём оборудовании,
+// если оно нужного типа, иначе переходит на deviceID. Возвращает число
+// изменённых заданий.
+func (r *Repos) UpdateFutureTemplateTasks(ctx context.Context, t TaskTemplate, after time.Time, deviceID int64) (int64, error) {
+	tag, err := r.DB.Exec(ctx, `
+		UPDATE device_task
+		SET dvctsk_name = $2 || ' — ' || to_char(dvctsk_occurrence, 'YYYY-MM-DD'),
+			dvctsk_docnum = $3,
+			dvctsk_duration = $4,
+			dvctsk_setuptime = $5,
+			dvctsk_timetocomplite = $6,
+			dvctsk_needoperator = $7,
+			eqpmnt_characteristics = $8,
+			dvctsk_materialqty = $9,
+			device_tasks_type = $10,
+			operator = $11,
+			priorities = $12,
+			device_pool = NULL,
+			devices_type = $13,
+			device = CASE
+				WHEN EXISTS (SELECT 1 FROM device d WHERE d.dvc_id = device_task.device AND d.devices__type = $13)
+				THEN device ELSE $14 END
+		WHERE task_template = $1
+		  AND dvctsk_status = $15
+		  AND dvctsk_occurrence > $16
+	`,
+		t.ID,
+		t.Name,
+		t.DocNum,
+		formatDuration(t.Duration),
+		formatDuration(t.SetupTime),
+		formatDuration(t.UnloadTime),
+		t.NeedOperator,
+		nullableID(t.MaterialID),
+		t.MaterialQty,
+		t.DeviceTaskTypeID,
+		nullableID(t.OperatorID),
+		t.PriorityID,
+		t.DeviceTypeID,
+		deviceID,
+		TaskStatusPending,
+		after,
+	)
+	if err != nil {
+		return 0, err
+	}
+	return tag.RowsAffected(), nil
+}
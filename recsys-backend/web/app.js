@@ -64,8 +64,13 @@ const taskOperatorSelect = document.getElementById('task-operator');
 const taskTypeSelect = document.getElementById('task-type');
 const taskPrioritySelect = document.getElementById('task-priority');
 const taskDeviceSelect = document.getElementById('task-device');
+const taskMaterialSelect = document.getElementById('task-material');
 const deviceTypeSelect = document.getElementById('device-type');
 const deviceStateSelect = document.getElementById('device-state');
+const deviceLoadedMaterialSelect = document.getElementById('device-loaded-material');
+const taskDevicePoolSelect = document.getElementById('task-device-pool');
+const taskOrderSelect = document.getElementById('task-order');
+const taskTargetTypeSelect = document.getElementById('task-target-type');
 const scheduleOperatorSelect = document.getElementById('schedule-operator');
 const scheduleTypeSelect = document.getElementById('schedule-type');
 
@@ -78,6 +83,8 @@ const state = {
   operators: [],
   tasks: [],
   deviceTypes: [],
+  devicePools: [],
+  customerOrders: [],
   equipmentCharacteristics: [],
   deviceStates: [],
   priorities: [],
@@ -361,7 +368,9 @@ async function loadWorkspaceData() {
     taskTypes,
     operatorDevices,
     operatorCompetencies,
-    userTasks
+    userTasks,
+    devicePools,
+    customerOrders
   ] = await Promise.all([
     fetchJSON(`${apiBase}/workspaces/${workspaceId}/devices`),
     fetchJSON(`${apiBase}/workspaces/${workspaceId}/operators`),
@@ -371,7 +380,9 @@ async function loadWorkspaceData() {
     fetchJSON(`${apiBase}/workspaces/${workspaceId}/device-task-types`),
     fetchJSON(`${apiBase}/workspaces/${workspaceId}/operator-devices`),
     fetchJSON(`${apiBase}/workspaces/${workspaceId}/operator-competencies`),
-    fetchJSON(`${apiBase}/workspaces/${workspaceId}/user-tasks`)
+    fetchJSON(`${apiBase}/workspaces/${workspaceId}/user-tasks`),
+    fetchJSON(`${apiBase}/workspaces/${workspaceId}/device-pools`),
+    fetchJSON(`${apiBase}/workspaces/${workspaceId}/customer-orders`)
   ]);
   state.devices = devices;
   state.operators = operators;
@@ -382,6 +393,8 @@ async function loadWorkspaceData() {
   state.operatorDevices = operatorDevices;
   state.operatorCompetencies = operatorCompetencies;
   state.userTasks = userTasks;
+  state.devicePools = devicePools;
+  state.customerOrders = customerOrders;
   renderAll();
   if (pendingOverlapCheck) {
     notifyAllTaskBreakOverlaps();
@@ -421,6 +434,15 @@ function getStatusLabel(status) {
   return labels[status] || status || 'Без статуса';
 }
 
+function getCompetencyLevelLabel(level) {
+  const labels = {
+    trainee: 'стажёр',
+    qualified: 'допущен',
+    expert: 'эксперт'
+  };
+  return labels[level] || level || 'допущен';
+}
+
 function getTaskTimeRange(task) {
   const startValue = task._start
     ? new Date(task._start)
@@ -565,13 +587,25 @@ function buildGantt(container, tasks, labelFormatter) {
       bar.title = `${formatTime(startValue)} – ${formatTime(endValue)} · ${getStatusLabel(
         status
       )}`;
+      if (task.job_id) {
+        bar.title += ` · маршрут #${task.job_id}, шаг ${task.job_seq}`;
+      }
+      if (task.batch_id) {
+        bar.title += ` · прогон #${task.batch_id}`;
+      }
+      if (task.pinned) {
+        bar.title += ' · закреплено';
+      }
       if (task.id) {
         bar.dataset.taskId = task.id;
         bar.classList.add('is-draggable');
       }
       if (task._userTaskId) {
         bar.dataset.userTaskId = task._userTaskId;
-        bar.classList.add('is-draggable');
+        bar.classList.toggle('is-draggable', !task.kind);
+      }
+      if (task.kind) {
+        bar.title += ` · ${task.kind === 'setup' ? 'наладка' : 'снятие'} по плану`;
       }
       track.appendChild(bar);
     }
@@ -590,6 +624,8 @@ function buildTaskPayload(task, overrides) {
     doc_num: task.doc_num,
     photo_url: task.photo_url,
     deadline: task.deadline ? new Date(task.deadline) : null,
+    deadline_type: task.deadline_type || 'hard',
+    penalty_rate: task.penalty_rate ?? null,
     operator_id: Number(task.operator_id || 0),
     device_id: Number(task.device_id || 0),
     priority_id: Number(task.priority_id || 0),
@@ -597,6 +633,13 @@ function buildTaskPayload(task, overrides) {
     duration_min: Number(task.duration_min || 0),
     setup_time_min: Number(task.setup_time_min || 0),
     unload_time_min: Number(task.unload_time_min || 0),
+    material_id: Number(task.material_id || 0),
+    plate_size: Number(task.plate_size || 0),
+    material_qty: Number(task.material_qty || 0),
+    device_pool_id: Number(task.device_pool_id || 0),
+    target_type_id: Number(task.target_type_id || 0),
+    min_level: task.min_level || '',
+    order_id: Number(task.order_id || 0),
     need_operator: Boolean(task.need_operator),
     add_in_rec_system: Boolean(task.add_in_rec_system),
     plan_start: task.plan_start ? new Date(task.plan_start) : null,
@@ -618,26 +661,77 @@ function buildUserTaskPayload(userTask, overrides) {
   };
 }
 
-function hasDeviceOverlap(taskId, deviceId, start, end) {
-  return state.tasks.some((task) => {
-    if (task.id === taskId) return false;
-    if (task.device_id !== deviceId) return false;
-    if (!task.plan_start || !task.plan_end) return false;
-    const otherStart = new Date(task.plan_start);
-    const otherEnd = new Date(task.plan_end);
-    return start < otherEnd && end > otherStart;
+const conflictLabels = {
+  device_busy: 'оборудование занято',
+  operator_busy: 'оператор занят',
+  device_unavailable: 'оборудование в простое',
+  outside_working_hours: 'вне рабочих часов',
+  deadline: 'позже дедлайна',
+  route_order: 'нарушен порядок маршрута',
+  labour_rule: 'нарушены правила рабочего времени'
+};
+
+const labourRuleLabels = {
+  max_day: 'дневной предел',
+  max_week: 'недельный предел',
+  break: 'перерыв',
+  min_rest: 'отдых между сменами'
+};
+
+function describeConflicts(conflicts) {
+  return conflicts
+    .map((conflict) => {
+      const other = conflict.other_task_id
+        ? ` (задание #${conflict.other_task_id})`
+        : conflict.user_task_id
+        ? ` (поручение #${conflict.user_task_id})`
+        : conflict.labour_rule
+        ? ` (${labourRuleLabels[conflict.labour_rule] || conflict.labour_rule})`
+        : '';
+      const label = conflictLabels[conflict.kind] || conflict.kind;
+      return `• ${label}${other}: ${formatTime(conflict.start)} – ${formatTime(conflict.end)}`;
+    })
+    .join('\n');
+}
+
+// moveTask переносит задание через API переноса; при конфликтах сервер
+// отвечает 409 со списком конфликтов, он возвращается как результат.
+async function moveTask(task, newStart, push) {
+  const headers = new Headers({ 'Content-Type': 'application/json' });
+  if (authToken) {
+    headers.set('Authorization', `Bearer ${authToken}`);
+  }
+  const response = await fetch(`${apiBase}/device-tasks/${task.id}/move`, {
+    method: 'POST',
+    headers,
+    body: JSON.stringify({ start: newStart, push: Boolean(push) })
   });
+  const text = await response.text();
+  const body = text ? JSON.parse(text) : null;
+  if (response.status === 409 && body?.conflicts) {
+    return body;
+  }
+  if (!response.ok) {
+    throw new Error(body?.error || text || 'Ошибка запроса');
+  }
+  return body;
 }
 
 async function updateTaskSchedule(task, newStart, newEnd) {
   const workspaceId = getWorkspaceId();
   if (!workspaceId) return false;
-  const payload = buildTaskPayload(task, { plan_start: newStart, plan_end: newEnd });
-  await fetchJSON(`${apiBase}/device-tasks/${task.id}?workspace_id=${workspaceId}`, {
-    method: 'PUT',
-    headers: { 'Content-Type': 'application/json' },
-    body: JSON.stringify(payload)
-  });
+  let result = await moveTask(task, newStart, false);
+  if (result.conflicts?.length) {
+    const push = confirm(
+      `Перенос конфликтует с планом:\n${describeConflicts(result.conflicts)}\n\nСдвинуть мешающие задания вправо?`
+    );
+    if (!push) return false;
+    result = await moveTask(task, newStart, true);
+    if (result.conflicts?.length) {
+      alert(`Перенос невозможен:\n${describeConflicts(result.conflicts)}`);
+      return false;
+    }
+  }
   await loadWorkspaceData();
   notifyTaskBreakOverlap(task, newStart, newEnd);
   return true;
@@ -668,7 +762,8 @@ function startGanttDrag(event) {
   let isUserTask = false;
   if (userTaskId) {
     task = state.userTasks.find((item) => item.id === Number(userTaskId));
-    if (!task?.start_time || !task?.end_time) return;
+    // Наладку и снятие по плану двигает только пересчёт плана.
+    if (!task?.start_time || !task?.end_time || task.kind) return;
     startValue = new Date(task.start_time);
     endValue = new Date(task.end_time);
     isUserTask = true;
@@ -746,12 +841,9 @@ async function endGanttDrag() {
   const newEnd = new Date(newStart.getTime() + durationMinutes * 60000);
   try {
     if (!isUserTask) {
-      if (hasDeviceOverlap(task.id, task.device_id, newStart, newEnd)) {
-        alert('Нельзя пересекать задания на одном принтере.');
+      if (!(await updateTaskSchedule(task, newStart, newEnd))) {
         bar.style.left = originalLeft;
-        return;
       }
-      await updateTaskSchedule(task, newStart, newEnd);
     } else {
       await updateUserTaskSchedule(task, newStart, newEnd);
     }
@@ -941,6 +1033,7 @@ function renderEquipment() {
           <strong>${device.name}</strong>
           <div class="muted">${deviceType?.name || 'Тип не указан'}</div>
           <div class="muted">Характеристика: ${characteristicName}</div>
+          <div class="muted">Материал: ${characteristicsById[device.loaded_material_id]?.name || 'неизвестен'}</div>
         </div>
         <div class="device-card__badges">
           <span class="badge ${stateBadgeClass}">${stateLabel}</span>
@@ -982,7 +1075,11 @@ function renderOperators() {
     .map((operator) => {
       const competencies = state.operatorCompetencies
         .filter((item) => item.operator_id === operator.id)
-        .map((item) => deviceTypesById[item.device_type_id]?.name || `#${item.device_type_id}`);
+        .map((item) => {
+          const name = deviceTypesById[item.device_type_id]?.name || `#${item.device_type_id}`;
+          const level = getCompetencyLevelLabel(item.level);
+          return item.setup_factor ? `${name} · ${level} · ×${item.setup_factor}` : `${name} · ${level}`;
+        });
       const responsibilities = state.operatorDevices
         .filter((item) => item.operator_id === operator.id)
         .map((item) => devicesById[item.device_id]?.name || `#${item.device_id}`);
@@ -1088,13 +1185,25 @@ function buildOperatorsGantt(container, tasksByOperator) {
       bar.title = `${formatTime(startValue)} – ${formatTime(endValue)} · ${getStatusLabel(
         status
       )}`;
+      if (task.job_id) {
+        bar.title += ` · маршрут #${task.job_id}, шаг ${task.job_seq}`;
+      }
+      if (task.batch_id) {
+        bar.title += ` · прогон #${task.batch_id}`;
+      }
+      if (task.pinned) {
+        bar.title += ' · закреплено';
+      }
       if (task.id) {
         bar.dataset.taskId = task.id;
         bar.classList.add('is-draggable');
       }
       if (task._userTaskId) {
         bar.dataset.userTaskId = task._userTaskId;
-        bar.classList.add('is-draggable');
+        bar.classList.toggle('is-draggable', !task.kind);
+      }
+      if (task.kind) {
+        bar.title += ` · ${task.kind === 'setup' ? 'наладка' : 'снятие'} по плану`;
       }
       track.appendChild(bar);
     });
@@ -1148,7 +1257,10 @@ function renderSelects() {
   populateSelect(taskOperatorSelect, state.operators, (o) => `${o.full_name} (#${o.id})`);
   populateSelect(taskTypeSelect, state.taskTypes, (t) => `${t.name} (#${t.id})`);
   populateSelect(taskPrioritySelect, state.priorities, (p) => `${p.name} (#${p.id})`);
-  populateSelect(taskDeviceSelect, state.devices, (d) => `${d.name} (#${d.id})`);
+  populateSelect(taskDeviceSelect, state.devices, (d) => `${d.name} (#${d.id})`, 'Выберет планировщик');
+  populateSelect(taskDevicePoolSelect, state.devicePools, (p) => `${p.name} (#${p.id})`, 'Без пула');
+  populateSelect(taskOrderSelect, state.customerOrders, (o) => `${o.customer} ${o.doc_num} (#${o.id})`, 'Без заказа');
+  populateSelect(taskTargetTypeSelect, state.deviceTypes, (t) => `${t.name} (#${t.id})`, 'Только выбранное');
   populateSelect(deviceTypeSelect, state.deviceTypes, (t) => `${t.name} (#${t.id})`);
   populateSelect(deviceStateSelect, state.deviceStates, (s) => `${s.name} (#${s.id})`);
   populateSelect(scheduleOperatorSelect, state.operators, (o) => `${o.full_name} (#${o.id})`);
@@ -1158,6 +1270,13 @@ function renderSelects() {
     (c) => `${c.name} (#${c.id})`,
     'Выберите характеристику...'
   );
+  populateSelect(taskMaterialSelect, state.equipmentCharacteristics, (c) => `${c.name} (#${c.id})`, 'Не указан');
+  populateSelect(
+    deviceLoadedMaterialSelect,
+    state.equipmentCharacteristics,
+    (c) => `${c.name} (#${c.id})`,
+    'Неизвестен'
+  );
 }
 
 function renderTasksPage() {
@@ -1250,7 +1369,11 @@ function renderReferenceTables() {
         (item) => `
         <div class="table__row">
           <div><strong>${item.name}</strong><br /><span class="muted">#${item.id}</span></div>
-          <div>${characteristicsById[item.equipment_characteristic_id]?.name || '—'}</div>
+          <div>${characteristicsById[item.equipment_characteristic_id]?.name || '—'}${
+            item.plate_capacity ? `<br /><span class="muted">Платформа: ${item.plate_capacity}</span>` : ''
+          }${item.power_kw != null ? `<br /><span class="muted">Мощность: ${item.power_kw} кВт</span>` : ''}${
+            item.cooldown_min ? `<br /><span class="muted">Остывание: ${item.cooldown_min} мин</span>` : ''
+          }</div>
           <div class="table__actions">
             <button class="button button--ghost" data-delete-device-type="${item.id}" type="button">Удалить</button>
           </div>
@@ -1275,7 +1398,9 @@ function renderReferenceTables() {
       .map(
         (item) => `
         <div class="table__row">
-          <div><strong>${item.name}</strong><br /><span class="muted">#${item.id}</span></div>
+          <div><strong>${item.name}</strong><br /><span class="muted">#${item.id}${
+            item.cooldown_min ? ` · остывание ${item.cooldown_min} мин` : ''
+          }</span></div>
           <div class="table__cell--center">${item.workspace_id || '—'}</div>
           <div class="table__actions">
             <button class="button button--ghost" data-delete-task-type="${item.id}" type="button">Удалить</button>
@@ -1380,6 +1505,8 @@ function fillTaskForm(task) {
   taskForm.elements.doc_num.value = formatDocNumber(task.doc_num || '', true);
   taskForm.elements.photo_url.value = task.photo_url || '';
   taskForm.elements.deadline.value = task.deadline ? toLocalDateTimeValue(new Date(task.deadline)) : '';
+  taskForm.elements.deadline_type.value = task.deadline_type || 'hard';
+  taskForm.elements.penalty_rate.value = task.penalty_rate ?? '';
   taskForm.elements.operator_id.value = task.operator_id || '';
   taskForm.elements.device_task_type_id.value = task.device_task_type_id || '';
   taskForm.elements.priority_id.value = task.priority_id || '';
@@ -1387,6 +1514,13 @@ function fillTaskForm(task) {
   taskForm.elements.duration_min.value = task.duration_min ?? '';
   taskForm.elements.setup_time_min.value = task.setup_time_min ?? '';
   taskForm.elements.unload_time_min.value = task.unload_time_min ?? '';
+  taskForm.elements.material_id.value = task.material_id || '';
+  taskForm.elements.plate_size.value = task.plate_size || '';
+  taskForm.elements.material_qty.value = task.material_qty || '';
+  taskForm.elements.device_pool_id.value = task.device_pool_id || '';
+  taskForm.elements.target_type_id.value = task.target_type_id || '';
+  taskForm.elements.min_level.value = task.min_level || '';
+  taskForm.elements.order_id.value = task.order_id || '';
   taskForm.elements.plan_start.value = task.plan_start
     ? toLocalDateTimeValue(new Date(task.plan_start))
     : '';
@@ -1402,6 +1536,7 @@ function fillOperatorForm(operator) {
   operatorForm.elements.full_name.value = operator.full_name || '';
   operatorForm.elements.phone_number.value = operator.phone_number || '';
   operatorForm.elements.user_login.value = operator.user_login || '';
+  operatorForm.elements.hourly_rate.value = operator.hourly_rate || '';
 }
 
 function fillDeviceForm(device) {
@@ -1412,6 +1547,9 @@ function fillDeviceForm(device) {
   deviceForm.elements.photo_url.value = device.photo_url || '';
   deviceForm.elements.device_type_id.value = device.device_type_id || '';
   deviceForm.elements.device_state_id.value = device.device_state_id || '';
+  deviceForm.elements.loaded_material_id.value = device.loaded_material_id || '';
+  deviceForm.elements.power_kw.value = device.power_kw ?? '';
+  deviceForm.elements.hourly_rate.value = device.hourly_rate || '';
   deviceForm.elements.add_in_rec_system.checked = device.add_in_rec_system !== false;
 }
 
@@ -1652,15 +1790,27 @@ async function createTask(event) {
   payload.duration_min = Number(payload.duration_min || 0);
   payload.setup_time_min = Number(payload.setup_time_min || 0);
   payload.unload_time_min = Number(payload.unload_time_min || 0);
+  payload.material_id = Number(payload.material_id || 0);
+  payload.plate_size = Number(payload.plate_size || 0);
+  payload.material_qty = Number(payload.material_qty || 0);
   payload.operator_id = Number(payload.operator_id || 0);
   payload.device_id = Number(payload.device_id || 0);
+  payload.device_pool_id = Number(payload.device_pool_id || 0);
+  payload.target_type_id = Number(payload.target_type_id || 0);
+  payload.order_id = Number(payload.order_id || 0);
   payload.priority_id = Number(payload.priority_id || 0);
   payload.device_task_type_id = Number(payload.device_task_type_id || 0);
   payload.need_operator = formData.get('need_operator') === 'on';
+  if (!payload.need_operator) payload.min_level = '';
   payload.add_in_rec_system = formData.get('add_in_rec_system') === 'on';
   payload.deadline = parseDateTimeInput(payload.deadline);
+  payload.penalty_rate = payload.penalty_rate === '' ? null : Number(payload.penalty_rate);
   payload.plan_start = parseDateTimeInput(payload.plan_start);
   payload.plan_end = parseDateTimeInput(payload.plan_end);
+  if (!payload.device_id && !payload.device_pool_id && !payload.target_type_id) {
+    alert('Укажите оборудование, пул или тип оборудования.');
+    return;
+  }
   if (payload.plan_start && payload.plan_end && payload.plan_end < payload.plan_start) {
     alert('Плановое завершение не может быть раньше начала.');
     return;
@@ -1695,6 +1845,7 @@ async function createOperator(event) {
   if (!operatorForm.reportValidity()) return;
   const formData = new FormData(operatorForm);
   const payload = Object.fromEntries(formData.entries());
+  payload.hourly_rate = Number(payload.hourly_rate || 0);
   const isEdit = operatorForm.dataset.mode === 'edit' && operatorForm.dataset.operatorId;
   const url = isEdit
     ? `${apiBase}/operators/${operatorForm.dataset.operatorId}?workspace_id=${workspaceId}`
@@ -1722,6 +1873,9 @@ async function createDevice(event) {
   const payload = Object.fromEntries(formData.entries());
   payload.device_type_id = Number(payload.device_type_id || 0);
   payload.device_state_id = Number(payload.device_state_id || 0);
+  payload.loaded_material_id = Number(payload.loaded_material_id || 0);
+  payload.power_kw = payload.power_kw === '' ? null : Number(payload.power_kw);
+  payload.hourly_rate = Number(payload.hourly_rate || 0);
   payload.add_in_rec_system = formData.get('add_in_rec_system') === 'on';
   const isEdit = deviceForm.dataset.mode === 'edit' && deviceForm.dataset.deviceId;
   const url = isEdit
@@ -1791,6 +1945,9 @@ async function createDeviceType(event) {
   const formData = new FormData(deviceTypeForm);
   const payload = Object.fromEntries(formData.entries());
   payload.equipment_characteristic_id = Number(payload.equipment_characteristic_id || 0);
+  payload.plate_capacity = Number(payload.plate_capacity || 0);
+  payload.power_kw = payload.power_kw === '' ? null : Number(payload.power_kw);
+  payload.cooldown_min = Number(payload.cooldown_min || 0);
   if (!payload.equipment_characteristic_id) {
     alert('Выберите характеристику оборудования.');
     return;
@@ -1827,6 +1984,7 @@ async function createTaskType(event) {
   if (!taskTypeForm.reportValidity()) return;
   const formData = new FormData(taskTypeForm);
   const payload = Object.fromEntries(formData.entries());
+  payload.cooldown_min = Number(payload.cooldown_min || 0);
   await fetchJSON(`${apiBase}/workspaces/${workspaceId}/device-task-types`, {
     method: 'POST',
     headers: { 'Content-Type': 'application/json' },